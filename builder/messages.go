@@ -52,11 +52,14 @@ func buildHeader(header *quickfix.Header, msgType, senderCompId, targetCompId st
 
 // --- Logon Message ---
 
+// BuildLogon adds the Prime authentication fields to a Logon body. ts and
+// seqNum must be the SendingTime and MsgSeqNum the message is sent with, as
+// Prime recomputes the signature from the received header.
 func BuildLogon(
 	body *quickfix.Body,
-	ts, apiKey, apiSecret, passphrase, targetCompId, portfolioId string,
+	ts, seqNum, apiKey, apiSecret, passphrase, targetCompId, portfolioId string,
 ) {
-	sig := utils.Sign(ts, constants.MsgTypeLogon, constants.MsgSeqNumInit, apiKey, targetCompId, passphrase, apiSecret)
+	sig := utils.Sign(ts, constants.MsgTypeLogon, seqNum, apiKey, targetCompId, passphrase, apiSecret)
 
 	setString(body, constants.TagEncryptMethod, constants.EncryptMethodNone)
 	setString(body, constants.TagHeartBtInt, constants.HeartBtInterval)
@@ -285,7 +288,7 @@ func BuildOrderStatusRequest(orderID, clOrdID, symbol, side, senderCompId, targe
 	m := quickfix.NewMessage()
 	buildHeader(&m.Header, constants.MsgTypeOrderStatusRequest, senderCompId, targetCompId)
 
-	setString(&m.Body, constants.TagOrderID, orderID)
+	setStringIfNotEmpty(&m.Body, constants.TagOrderID, orderID)
 	setStringIfNotEmpty(&m.Body, constants.TagClOrdID, clOrdID)
 	setStringIfNotEmpty(&m.Body, constants.TagSymbol, symbol)
 	setStringIfNotEmpty(&m.Body, constants.TagSide, side)
@@ -293,6 +296,77 @@ func BuildOrderStatusRequest(orderID, clOrdID, symbol, side, senderCompId, targe
 	return m
 }
 
+// --- Order Mass Status Request (AF) ---
+
+// OrderMassStatusParams contains parameters for requesting the status of
+// every open order.
+type OrderMassStatusParams struct {
+	MassStatusReqID   string // Client-selected identifier (required)
+	MassStatusReqType string // constants.MassStatusReqType* (required)
+	Account           string // Portfolio ID (conditional)
+	Symbol            string // Required for MassStatusReqTypeSecurity
+}
+
+// BuildOrderMassStatusRequest creates an Order Mass Status Request (AF)
+// message. Each open order is reported in an Execution Report (8) with
+// ExecType=I carrying the MassStatusReqID.
+//
+// Example:
+//
+//	params := OrderMassStatusParams{
+//	    MassStatusReqID: "mass-1", MassStatusReqType: constants.MassStatusReqTypeAllOrders,
+//	    Account: "portfolio-123",
+//	}
+//	msg := BuildOrderMassStatusRequest(params, senderCompId, targetCompId)
+func BuildOrderMassStatusRequest(params OrderMassStatusParams, senderCompId, targetCompId string) *quickfix.Message {
+	m := quickfix.NewMessage()
+	buildHeader(&m.Header, constants.MsgTypeOrderMassStatusRequest, senderCompId, targetCompId)
+
+	setString(&m.Body, constants.TagMassStatusReqID, params.MassStatusReqID)
+	setString(&m.Body, constants.TagMassStatusReqType, params.MassStatusReqType)
+	setStringIfNotEmpty(&m.Body, constants.TagAccount, params.Account)
+	setStringIfNotEmpty(&m.Body, constants.TagSymbol, params.Symbol)
+
+	return m
+}
+
+// --- Order Mass Cancel Request (q) ---
+
+// MassCancelParams contains parameters for cancelling many orders at once.
+type MassCancelParams struct {
+	ClOrdID               string // Client-selected request ID (required)
+	Account               string // Portfolio ID (required)
+	MassCancelRequestType string // constants.MassCancelRequestType* (required)
+	Symbol                string // Required for MassCancelRequestTypeSecurity
+	Side                  string // Only cancel this side (optional)
+}
+
+// BuildOrderMassCancelRequest creates an Order Mass Cancel Request (q)
+// message. The response is an Order Mass Cancel Report (r), followed by an
+// Execution Report (8) for each cancelled order.
+//
+// Example:
+//
+//	params := MassCancelParams{
+//	    ClOrdID: "mcxl-1", Account: "portfolio-123",
+//	    MassCancelRequestType: constants.MassCancelRequestTypeSecurity, Symbol: "BTC-USD",
+//	}
+//	msg := BuildOrderMassCancelRequest(params, senderCompId, targetCompId)
+func BuildOrderMassCancelRequest(params MassCancelParams, senderCompId, targetCompId string) *quickfix.Message {
+	m := quickfix.NewMessage()
+	buildHeader(&m.Header, constants.MsgTypeOrderMassCancelRequest, senderCompId, targetCompId)
+
+	setString(&m.Body, constants.TagClOrdID, params.ClOrdID)
+	setString(&m.Body, constants.TagAccount, params.Account)
+	setString(&m.Body, constants.TagMassCancelRequestType, params.MassCancelRequestType)
+	setString(&m.Body, constants.TagTransactTime, time.Now().UTC().Format(constants.FixTimeFormat))
+
+	setStringIfNotEmpty(&m.Body, constants.TagSymbol, params.Symbol)
+	setStringIfNotEmpty(&m.Body, constants.TagSide, params.Side)
+
+	return m
+}
+
 // --- Quote Request (R) ---
 
 // QuoteRequestParams contains parameters for requesting a quote.
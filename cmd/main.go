@@ -17,27 +17,85 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"prime-fix-md-go/database"
 	"prime-fix-md-go/fixclient"
 	"prime-fix-md-go/formatter"
+	"prime-fix-md-go/gateway"
+	"prime-fix-md-go/replay"
 	"prime-fix-md-go/utils"
 
 	"github.com/quickfixgo/quickfix"
 )
 
+// defaultDbPath is the live database, which a replay must not write to.
+const defaultDbPath = "marketdata.db"
+
 func main() {
+	candleIntervals := flag.String("candles", "1s,1m,5m,1h", "OHLCV bar intervals to build from live trades, comma-separated; empty disables")
+	daemonConfigPath := flag.String("daemon", "", "run headless with the subscriptions in this JSON config instead of the REPL")
+	dbOverflow := flag.String("db-overflow", fixclient.OverflowBlock, "database writer policy when its queue is full: block, drop-oldest or spill")
+	dbPath := flag.String("db", defaultDbPath, "SQLite database path")
+	gatewayAddr := flag.String("gateway", "", "serve the HTTP/JSON and WebSocket gateway on this address, e.g. 127.0.0.1:8080; needs PRIME_GATEWAY_TOKEN")
+	replayPath := flag.String("replay", "", "replay this FIX journal offline instead of connecting")
+	replaySpeed := flag.Float64("replay-speed", 0, "replay timing: 0=as fast as possible, 1=original, 10=10x faster")
+	riskConfigPath := flag.String("risk", "", "apply the pre-trade limits in this JSON config to new orders")
+	flag.Parse()
+
 	fmt.Printf("%s\n\n", utils.FullVersion())
 
+	intervals, err := fixclient.ParseCandleIntervals(*candleIntervals)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *replayPath != "" {
+		if isDefaultDb(*dbPath) {
+			log.Fatalf("-replay needs its own database, not %s: pass e.g. -db replay.db", defaultDbPath)
+		}
+		if err := runReplay(*replayPath, *replaySpeed, *dbPath, *dbOverflow, intervals); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var daemonConfig *fixclient.DaemonConfig
+	if *daemonConfigPath != "" {
+		var err error
+		if daemonConfig, err = fixclient.LoadDaemonConfig(*daemonConfigPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	gatewayToken := os.Getenv("PRIME_GATEWAY_TOKEN")
+	if *gatewayAddr != "" && gatewayToken == "" {
+		log.Fatal("PRIME_GATEWAY_TOKEN must be set to serve the gateway")
+	}
+
+	var riskConfig *fixclient.RiskConfig
+	if *riskConfigPath != "" {
+		var err error
+		if riskConfig, err = fixclient.LoadRiskConfig(*riskConfigPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	settings, err := utils.LoadSettings("fix.cfg")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	db, err := database.NewMarketDataDb("marketdata.db")
+	db, err := database.NewMarketDataDb(*dbPath)
 	if err != nil {
 		log.Fatal("Database initialization failed:", err)
 	}
@@ -58,12 +116,55 @@ func main() {
 		os.Getenv("PRIME_PORTFOLIO_ID"),
 	)
 
+	writerConfig := fixclient.DefaultDbWriterConfig()
+	writerConfig.Overflow = *dbOverflow
+	writerConfig.SpillPath = spillPathFor(*dbPath)
+	dbWriter, err := fixclient.NewDbWriter(db, writerConfig)
+	if err != nil {
+		log.Fatal("database writer error:", err)
+	}
+	defer dbWriter.Close()
+
 	app := fixclient.NewFixApp(config, db)
+	app.DbWriter = dbWriter
+	app.Headless = daemonConfig != nil
+	if len(intervals) > 0 {
+		if err := app.EnableCandles(intervals); err != nil {
+			log.Fatal(err)
+		}
+		app.Candles.Start()
+		defer app.Candles.Stop()
+	}
+	if app.ReconcileMode, err = fixclient.ReconcileModeFromSettings(settings); err != nil {
+		log.Fatal(err)
+	}
+	if riskConfig != nil {
+		app.RiskChecks = riskConfig.Chain(app.OrderStore, app.OrderBook)
+	}
+	if *gatewayAddr != "" {
+		app.Bus = fixclient.NewMarketDataBus()
+		defer app.Bus.Close()
+	}
+
+	storeFactory, err := fixclient.NewMessageStoreFactory(settings, db)
+	if err != nil {
+		log.Fatal("message store error:", err)
+	}
+
+	logFactory := formatter.NewTableLogFactory()
+	journal, err := fixclient.NewJournal(settings, db)
+	if err != nil {
+		log.Fatal("journal error:", err)
+	}
+	if journal != nil {
+		defer journal.Close()
+		logFactory = formatter.NewTableLogFactoryWithJournal(journal)
+	}
 
 	initiator, err := quickfix.NewInitiator(app,
-		quickfix.NewMemoryStoreFactory(),
+		storeFactory,
 		settings,
-		formatter.NewTableLogFactory(),
+		logFactory,
 	)
 	if err != nil {
 		log.Fatal("initiator error:", err)
@@ -74,5 +175,94 @@ func main() {
 	}
 	defer initiator.Stop()
 
-	fixclient.Repl(app)
+	if *gatewayAddr != "" {
+		server, err := gateway.New(&fixclient.Client{App: app}, gatewayToken)
+		if err != nil {
+			log.Fatal("gateway error:", err)
+		}
+		if _, err := server.Start(*gatewayAddr); err != nil {
+			log.Fatal(err)
+		}
+		defer server.Close()
+	}
+
+	if daemonConfig == nil {
+		fixclient.Repl(app)
+		return
+	}
+
+	// Deferred calls run in reverse order: server.Close(), initiator.Stop()
+	// (sends Logout after the queued unsubscribes), journal.Close(),
+	// app.Bus.Close(), app.Candles.Stop(), dbWriter.Close() (flushes queued
+	// writes) and then db.Close()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	if err := fixclient.RunDaemon(app, daemonConfig, stop); err != nil {
+		log.Printf("Daemon error: %v", err)
+	}
+	log.Println("Shutting down")
+}
+
+// isDefaultDb reports whether path names the live database, however it is
+// spelled (e.g. ./marketdata.db or an absolute path).
+func isDefaultDb(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return true
+	}
+	def, err := filepath.Abs(defaultDbPath)
+	if err != nil {
+		return true
+	}
+	return filepath.Clean(abs) == filepath.Clean(def)
+}
+
+// spillPathFor names the database writer's spill file after its database, so
+// that data kept for one database is never replayed into another.
+func spillPathFor(dbPath string) string {
+	return strings.TrimSuffix(dbPath, filepath.Ext(dbPath)) + ".spill"
+}
+
+// runReplay feeds a recorded journal through a FixApp with no FIX session.
+// Parsed data goes to the same stores, database and display as a live run.
+func runReplay(path string, speed float64, dbPath, dbOverflow string, candleIntervals []time.Duration) error {
+	db, err := database.NewMarketDataDb(dbPath)
+	if err != nil {
+		return fmt.Errorf("database initialization failed: %v", err)
+	}
+	defer db.Close()
+
+	writerConfig := fixclient.DefaultDbWriterConfig()
+	writerConfig.Overflow = dbOverflow
+	writerConfig.SpillPath = spillPathFor(dbPath)
+	dbWriter, err := fixclient.NewDbWriter(db, writerConfig)
+	if err != nil {
+		return fmt.Errorf("database writer error: %v", err)
+	}
+	defer dbWriter.Close()
+
+	// Built without the database so no orders, positions or strategies are
+	// restored or persisted; only the replayed messages are written
+	app := fixclient.NewFixApp(&fixclient.Config{}, nil)
+	app.Db = db
+	app.DbWriter = dbWriter
+	if len(candleIntervals) > 0 {
+		if err := app.EnableCandles(candleIntervals); err != nil {
+			return err
+		}
+		// Bars close on the journal's entry times; the last ones when it ends
+		defer app.Candles.Flush()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	log.Printf("Replaying %s into %s", path, dbPath)
+	stats, err := replay.New(app, speed).RunFile(ctx, path)
+	log.Printf("Replay finished: %d delivered, %d outgoing skipped, %d malformed, %d rejected",
+		stats.Delivered, stats.Skipped, stats.Malformed, stats.Rejected)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
 }
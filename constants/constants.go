@@ -22,7 +22,12 @@ import "github.com/quickfixgo/quickfix"
 const (
 	// Admin Messages
 	MsgTypeLogon            = "A" // Logon
+	MsgTypeHeartbeat        = "0" // Heartbeat
+	MsgTypeTestRequest      = "1" // Test Request
+	MsgTypeResendRequest    = "2" // Resend Request
 	MsgTypeReject           = "3" // Session-level Reject
+	MsgTypeSequenceReset    = "4" // Sequence Reset
+	MsgTypeLogout           = "5" // Logout
 	MsgTypeBusinessReject   = "j" // Business Message Reject
 	MsgTypeMarketDataReject = "Y" // Market Data Request Reject
 
@@ -32,15 +37,18 @@ const (
 	MsgTypeMarketDataIncremental = "X" // Market Data Incremental Refresh
 
 	// Order Entry Messages
-	MsgTypeNewOrderSingle       = "D" // New Order Single
-	MsgTypeOrderCancelRequest   = "F" // Order Cancel Request
-	MsgTypeOrderCancelReplace   = "G" // Order Cancel/Replace Request
-	MsgTypeOrderStatusRequest   = "H" // Order Status Request
-	MsgTypeExecutionReport      = "8" // Execution Report
-	MsgTypeOrderCancelReject    = "9" // Order Cancel Reject
-	MsgTypeQuoteRequest         = "R" // Quote Request
-	MsgTypeQuote                = "S" // Quote
-	MsgTypeQuoteAcknowledgement = "b" // Quote Acknowledgement
+	MsgTypeNewOrderSingle         = "D"  // New Order Single
+	MsgTypeOrderCancelRequest     = "F"  // Order Cancel Request
+	MsgTypeOrderCancelReplace     = "G"  // Order Cancel/Replace Request
+	MsgTypeOrderStatusRequest     = "H"  // Order Status Request
+	MsgTypeOrderMassStatusRequest = "AF" // Order Mass Status Request
+	MsgTypeOrderMassCancelRequest = "q"  // Order Mass Cancel Request
+	MsgTypeOrderMassCancelReport  = "r"  // Order Mass Cancel Report
+	MsgTypeExecutionReport        = "8"  // Execution Report
+	MsgTypeOrderCancelReject      = "9"  // Order Cancel Reject
+	MsgTypeQuoteRequest           = "R"  // Quote Request
+	MsgTypeQuote                  = "S"  // Quote
+	MsgTypeQuoteAcknowledgement   = "b"  // Quote Acknowledgement
 )
 
 // --- Protocol Constants ---
@@ -53,6 +61,66 @@ const (
 	MsgSeqNumInit     = "1"
 )
 
+// --- Message Store Types ---
+// Selected with the MessageStore setting in fix.cfg.
+const (
+	SettingMessageStore    = "MessageStore"
+	MessageStoreTypeMemory = "memory" // Sequence numbers lost on restart
+	MessageStoreTypeFile   = "file"   // quickfix file store under FileStorePath
+	MessageStoreTypeSqlite = "sqlite" // Shared with marketdata.db
+)
+
+// --- Message Journal Types ---
+// Selected with the Journal setting in fix.cfg.
+const (
+	SettingJournal           = "Journal"
+	SettingJournalPath       = "JournalPath"       // File journal path (default fix-journal.log)
+	SettingJournalMaxSizeMB  = "JournalMaxSizeMB"  // Rotate the file journal at this size (default 100)
+	SettingJournalMaxBackups = "JournalMaxBackups" // Rotated files to keep (default 10)
+	JournalTypeNone          = "none"              // No journal
+	JournalTypeFile          = "file"              // Rotating text file
+	JournalTypeSqlite        = "sqlite"            // fix_messages table in marketdata.db
+)
+
+// --- Logon Reconciliation Modes ---
+// Selected with the ReconcileOnLogon setting in fix.cfg.
+const (
+	SettingReconcileOnLogon = "ReconcileOnLogon"
+	ReconcileModeNone       = "none"   // Do not query order status on logon
+	ReconcileModeMass       = "mass"   // One Order Mass Status Request (AF)
+	ReconcileModeOrders     = "orders" // One Order Status Request (H) per open order
+)
+
+// --- RFQ Lifecycle ---
+// Client-side status of a Quote Request, tracked by QuoteReqID.
+const (
+	RfqStatusRequested = "requested" // Quote Request (R) sent
+	RfqStatusQuoted    = "quoted"    // Quote (S) received and not yet expired
+	RfqStatusRejected  = "rejected"  // Quote Acknowledgement (b) rejected the request
+	RfqStatusAccepted  = "accepted"  // Order sent against the quote
+	RfqStatusExpired   = "expired"   // ValidUntilTime passed without an accept
+)
+
+// --- Synthetic Order Strategies ---
+// Order types the client builds from plain child orders.
+const (
+	StrategyKindOCO      = "oco"      // Limit and stop-limit; a fill on one cancels the other
+	StrategyKindBracket  = "bracket"  // Entry, then take-profit and stop-loss as an OCO pair
+	StrategyKindTrailing = "trailing" // Market order once the price retraces from its best
+
+	StrategyStatusActive   = "active"   // Legs working or waiting to be sent
+	StrategyStatusDone     = "done"     // Finished with at least one fill
+	StrategyStatusCanceled = "canceled" // Finished without a fill, or cancelled by the user
+)
+
+// --- Strategy Leg Roles ---
+const (
+	LegRoleEntry      = "entry"      // Bracket entry order
+	LegRoleTakeProfit = "takeprofit" // Limit leg of an OCO or bracket
+	LegRoleStopLoss   = "stoploss"   // Stop-limit leg of an OCO or bracket
+	LegRoleTrigger    = "trigger"    // Market order sent when a trailing stop triggers
+)
+
 // --- Subscription Request Types ---
 const (
 	SubscriptionRequestTypeSnapshot    = "0" // Snapshot
@@ -78,6 +146,13 @@ const (
 	MdUpdateTypeIncremental = "1" // Incremental refresh
 )
 
+// --- MD Update Action (Tag 279) ---
+const (
+	MdUpdateActionNew    = "0" // New
+	MdUpdateActionChange = "1" // Change
+	MdUpdateActionDelete = "2" // Delete
+)
+
 // --- Order Types (Tag 40) ---
 const (
 	OrdTypeMarket           = "1" // Market
@@ -137,15 +212,43 @@ const (
 	ExecTypeFilled        = "2" // Filled
 	ExecTypeDone          = "3" // Done
 	ExecTypeCanceled      = "4" // Canceled
+	ExecTypeReplaced      = "5" // Replaced
 	ExecTypePendingCancel = "6" // Pending Cancel
 	ExecTypeStopped       = "7" // Stopped
 	ExecTypeRejected      = "8" // Rejected
 	ExecTypePendingNew    = "A" // Pending New
 	ExecTypeExpired       = "C" // Expired
 	ExecTypeRestated      = "D" // Restated
+	ExecTypeTrade         = "F" // Trade (partial fill or fill)
 	ExecTypeOrderStatus   = "I" // Order Status
 )
 
+// --- Mass Status Request Type (Tag 585) ---
+const (
+	MassStatusReqTypeSecurity  = "1" // Status for orders for a security
+	MassStatusReqTypeAllOrders = "7" // Status for all orders
+)
+
+// --- Mass Cancel Request Type (Tag 530) ---
+const (
+	MassCancelRequestTypeSecurity  = "1" // Cancel orders for a security
+	MassCancelRequestTypeAllOrders = "7" // Cancel all orders
+)
+
+// --- Mass Cancel Response (Tag 531) ---
+const (
+	MassCancelResponseRejected  = "0" // Cancel request rejected
+	MassCancelResponseSecurity  = "1" // Cancelled orders for a security
+	MassCancelResponseAllOrders = "7" // Cancelled all orders
+)
+
+// --- Mass Cancel Reject Reason (Tag 532) ---
+const (
+	MassCancelRejectReasonNotSupported    = "0"  // Mass cancel not supported
+	MassCancelRejectReasonUnknownSecurity = "1"  // Invalid or unknown security
+	MassCancelRejectReasonOther           = "99" // Other
+)
+
 // --- Order Reject Reason (Tag 103) ---
 const (
 	OrdRejReasonBrokerOption   = "0"  // Broker option
@@ -158,6 +261,12 @@ const (
 	OrdRejReasonOther          = "99" // Other
 )
 
+// --- Cancel Reject Reason (Tag 102) ---
+const (
+	CxlRejReasonTooLate      = "0" // Too late to cancel
+	CxlRejReasonUnknownOrder = "1" // Unknown order
+)
+
 // --- Cancel Reject Response To (Tag 434) ---
 const (
 	CxlRejResponseToCancel  = "1" // Order Cancel Request (F)
@@ -292,6 +401,11 @@ var (
 	TagEffectiveTime  = quickfix.Tag(168)
 	TagMaxShow        = quickfix.Tag(210)
 
+	// Session Tags (resend handling)
+	TagPossDupFlag     = quickfix.Tag(43)
+	TagOrigSendingTime = quickfix.Tag(122)
+	TagResetSeqNumFlag = quickfix.Tag(141)
+
 	// Market Data Tags
 	TagMdReqId                 = quickfix.Tag(262)
 	TagSubscriptionRequestType = quickfix.Tag(263)
@@ -303,6 +417,8 @@ var (
 	TagMdEntryPx               = quickfix.Tag(270)
 	TagMdEntrySize             = quickfix.Tag(271)
 	TagMdEntryTime             = quickfix.Tag(273)
+	TagMdEntryId               = quickfix.Tag(278)
+	TagMdUpdateAction          = quickfix.Tag(279)
 	TagMdReqRejReason          = quickfix.Tag(281)
 	TagMdEntryPositionNo       = quickfix.Tag(290)
 
@@ -314,15 +430,24 @@ var (
 	TagRefTagID             = quickfix.Tag(371)
 	TagRefMsgType           = quickfix.Tag(372)
 	TagSessionRejectReason  = quickfix.Tag(373)
+	TagBusinessRejectRefID  = quickfix.Tag(379)
 	TagBusinessRejectReason = quickfix.Tag(380)
 
 	// Order Tags
-	TagCxlRejResponseTo  = quickfix.Tag(434)
-	TagUsername          = quickfix.Tag(553)
-	TagPassword          = quickfix.Tag(554)
-	TagTargetStrategy    = quickfix.Tag(847)
-	TagParticipationRate = quickfix.Tag(849)
-	TagDefaultApplVerId  = quickfix.Tag(1137)
+	TagCxlRejResponseTo       = quickfix.Tag(434)
+	TagMassCancelRequestType  = quickfix.Tag(530)
+	TagMassCancelResponse     = quickfix.Tag(531)
+	TagMassCancelRejectReason = quickfix.Tag(532)
+	TagTotalAffectedOrders    = quickfix.Tag(533)
+	TagUsername               = quickfix.Tag(553)
+	TagPassword               = quickfix.Tag(554)
+	TagMassStatusReqID        = quickfix.Tag(584)
+	TagMassStatusReqType      = quickfix.Tag(585)
+	TagTotNumReports          = quickfix.Tag(911)
+	TagLastRptRequested       = quickfix.Tag(912)
+	TagTargetStrategy         = quickfix.Tag(847)
+	TagParticipationRate      = quickfix.Tag(849)
+	TagDefaultApplVerId       = quickfix.Tag(1137)
 
 	// Coinbase Custom Tags
 	TagAggressorSide = quickfix.Tag(2446)
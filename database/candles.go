@@ -0,0 +1,80 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"prime-fix-md-go/decimal"
+)
+
+// CandleRecord is one row of the candles table: an OHLCV bar for one symbol
+// and interval, starting at Start.
+type CandleRecord struct {
+	Symbol     string
+	Interval   string // e.g. "1m"
+	Start      time.Time
+	Open       decimal.Decimal
+	High       decimal.Decimal
+	Low        decimal.Decimal
+	Close      decimal.Decimal
+	Volume     decimal.Decimal
+	VWAP       decimal.Decimal
+	TradeCount int
+}
+
+// SaveCandle inserts r, or replaces the bar with the same symbol, interval
+// and start.
+func (mdb *MarketDataDb) SaveCandle(r CandleRecord) error {
+	_, err := mdb.db.Exec(upsertCandleQuery, candleArgs(r)...)
+	return err
+}
+
+// SaveCandleBatch is SaveCandle within tx.
+func (mdb *MarketDataDb) SaveCandleBatch(tx *sql.Tx, r CandleRecord) error {
+	_, err := tx.Exec(upsertCandleQuery, candleArgs(r)...)
+	return err
+}
+
+// LoadCandles returns symbol's bars of interval starting at or after since,
+// oldest first.
+func (mdb *MarketDataDb) LoadCandles(symbol, interval string, since time.Time) ([]CandleRecord, error) {
+	rows, err := mdb.db.Query(selectCandlesQuery, symbol, interval, since.UTC().Format(timestampFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []CandleRecord
+	for rows.Next() {
+		var r CandleRecord
+		var start string
+		if err := rows.Scan(&r.Symbol, &r.Interval, &start, &r.Open, &r.High, &r.Low, &r.Close,
+			&r.Volume, &r.VWAP, &r.TradeCount); err != nil {
+			return nil, err
+		}
+		r.Start, _ = time.Parse(timestampFormat, start)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func candleArgs(r CandleRecord) []any {
+	return []any{r.Symbol, r.Interval, r.Start.UTC().Format(timestampFormat), r.Open, r.High, r.Low, r.Close,
+		r.Volume, r.VWAP, r.TradeCount, time.Now().UTC().Format(timestampFormat)}
+}
@@ -0,0 +1,75 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"prime-fix-md-go/decimal"
+)
+
+// TestSaveCandle_UpsertAndLoad verifies that bars are replaced by symbol,
+// interval and start, and that their values come back exactly.
+func TestSaveCandle_UpsertAndLoad(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	start := time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC)
+	bar := CandleRecord{
+		Symbol: "BTC-USD", Interval: "1m", Start: start,
+		Open: decimal.MustParse("50000.00"), High: decimal.MustParse("50010.00"),
+		Low: decimal.MustParse("49990.00"), Close: decimal.MustParse("50005.00"),
+		Volume: decimal.MustParse("0.00000001"), VWAP: decimal.MustParse("50000.00"), TradeCount: 1,
+	}
+	if err := db.SaveCandle(bar); err != nil {
+		t.Fatalf("SaveCandle failed: %v", err)
+	}
+	if err := db.SaveCandle(CandleRecord{Symbol: "BTC-USD", Interval: "1m", Start: start.Add(-time.Minute),
+		Open: bar.Open, High: bar.Open, Low: bar.Open, Close: bar.Open, Volume: bar.Volume, VWAP: bar.Open, TradeCount: 1}); err != nil {
+		t.Fatalf("SaveCandle failed: %v", err)
+	}
+
+	// A late trade amends the bar
+	bar.Close, bar.TradeCount = decimal.MustParse("50007.50"), 2
+	tx, err := db.BeginTransaction()
+	if err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	if err := db.SaveCandleBatch(tx, bar); err != nil {
+		t.Fatalf("SaveCandleBatch failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	candles, err := db.LoadCandles("BTC-USD", "1m", start)
+	if err != nil {
+		t.Fatalf("LoadCandles failed: %v", err)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("Expected 1 bar since %s, got %+v", start, candles)
+	}
+	got := candles[0]
+	if !got.Start.Equal(start) || got.Close.String() != "50007.50" || got.Volume.String() != "0.00000001" || got.TradeCount != 2 {
+		t.Errorf("Unexpected bar %+v", got)
+	}
+
+	if all, _ := db.LoadCandles("BTC-USD", "1m", time.Time{}); len(all) != 2 {
+		t.Errorf("Expected 2 bars in total, got %d", len(all))
+	}
+}
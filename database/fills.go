@@ -0,0 +1,75 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"database/sql"
+)
+
+// FillRecord is one row of the fills table. Prices and quantities are kept as
+// the decimal strings received on the wire.
+type FillRecord struct {
+	ExecID       string
+	ClOrdID      string
+	OrderID      string
+	Symbol       string
+	Side         string
+	LastPx       string
+	LastShares   string
+	Commission   string
+	MiscFees     string // JSON array, empty when the fill carried no fees
+	TransactTime string // FixTimeFormat, UTC
+}
+
+// StoreFill inserts a fill. Fills are keyed by ExecID, so storing a duplicate
+// is a no-op; the return value reports whether a row was inserted.
+func (mdb *MarketDataDb) StoreFill(f FillRecord) (bool, error) {
+	res, err := mdb.db.Exec(insertFillQuery, f.ExecID, f.ClOrdID, f.OrderID, f.Symbol, f.Side,
+		f.LastPx, f.LastShares, f.Commission, f.MiscFees, f.TransactTime)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetFills returns stored fills in execution order. Empty clOrdId or symbol
+// arguments match every fill.
+func (mdb *MarketDataDb) GetFills(clOrdId, symbol string) ([]FillRecord, error) {
+	rows, err := mdb.db.Query(selectFillsQuery, clOrdId, clOrdId, symbol, symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fills []FillRecord
+	for rows.Next() {
+		var f FillRecord
+		var orderId, side, commission, miscFees, transactTime sql.NullString
+		if err := rows.Scan(&f.ExecID, &f.ClOrdID, &orderId, &f.Symbol, &side,
+			&f.LastPx, &f.LastShares, &commission, &miscFees, &transactTime); err != nil {
+			return nil, err
+		}
+		f.OrderID = orderId.String
+		f.Side = side.String
+		f.Commission = commission.String
+		f.MiscFees = miscFees.String
+		f.TransactTime = transactTime.String
+		fills = append(fills, f)
+	}
+	return fills, rows.Err()
+}
@@ -0,0 +1,81 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"testing"
+)
+
+// TestStoreFill_DeduplicatesAndFilters verifies fills are unique by ExecID and
+// can be queried by ClOrdID and symbol.
+func TestStoreFill_DeduplicatesAndFilters(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	fills := []FillRecord{
+		{ExecID: "e1", ClOrdID: "c1", OrderID: "o1", Symbol: "BTC-USD", Side: "1", LastPx: "50000", LastShares: "0.1",
+			MiscFees: `[{"amt":"0.5","curr":"USD","type":"4"}]`, TransactTime: "20250102-03:04:05.000"},
+		{ExecID: "e2", ClOrdID: "c1", OrderID: "o1", Symbol: "BTC-USD", Side: "1", LastPx: "50010", LastShares: "0.2",
+			TransactTime: "20250102-03:04:06.000"},
+		{ExecID: "e3", ClOrdID: "c2", OrderID: "o2", Symbol: "ETH-USD", Side: "2", LastPx: "3000", LastShares: "1",
+			TransactTime: "20250102-03:04:07.000"},
+	}
+	for _, f := range fills {
+		inserted, err := db.StoreFill(f)
+		if err != nil {
+			t.Fatalf("StoreFill(%s) failed: %v", f.ExecID, err)
+		}
+		if !inserted {
+			t.Errorf("Expected %s to be inserted", f.ExecID)
+		}
+	}
+
+	inserted, err := db.StoreFill(fills[0])
+	if err != nil {
+		t.Fatalf("StoreFill duplicate failed: %v", err)
+	}
+	if inserted {
+		t.Error("Expected duplicate ExecID to be ignored")
+	}
+
+	all, err := db.GetFills("", "")
+	if err != nil {
+		t.Fatalf("GetFills failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 fills, got %d", len(all))
+	}
+	if all[0].MiscFees != fills[0].MiscFees || all[0].Commission != "" {
+		t.Errorf("Unexpected first fill: %+v", all[0])
+	}
+
+	byOrder, err := db.GetFills("c1", "")
+	if err != nil {
+		t.Fatalf("GetFills by ClOrdID failed: %v", err)
+	}
+	if len(byOrder) != 2 || byOrder[0].ExecID != "e1" || byOrder[1].ExecID != "e2" {
+		t.Errorf("Expected e1, e2 for c1, got %+v", byOrder)
+	}
+
+	bySymbol, err := db.GetFills("", "ETH-USD")
+	if err != nil {
+		t.Fatalf("GetFills by symbol failed: %v", err)
+	}
+	if len(bySymbol) != 1 || bySymbol[0].ExecID != "e3" {
+		t.Errorf("Expected e3 for ETH-USD, got %+v", bySymbol)
+	}
+}
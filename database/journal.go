@@ -0,0 +1,173 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	journalQueueSize = 10000
+	journalBatchSize = 200
+	journalFlushTick = 100 * time.Millisecond
+)
+
+// journalEntry is one row of the fix_messages table.
+type journalEntry struct {
+	loggedAt  string
+	direction string
+	sessionId string
+	msgType   string
+	seqNum    sql.NullInt64
+	message   string
+}
+
+// FixMessageJournal records raw FIX messages in the fix_messages table. Rows
+// are queued and inserted in batches by a background goroutine so journaling
+// does not put a SQLite commit on the session goroutine. A full queue blocks
+// rather than dropping: the journal is an audit trail.
+type FixMessageJournal struct {
+	db      *sql.DB
+	queue   chan journalEntry
+	done    chan struct{}
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// NewFixMessageJournal starts a journal writer on this database. Close the
+// journal before closing the database so queued rows are written.
+func (mdb *MarketDataDb) NewFixMessageJournal() *FixMessageJournal {
+	j := &FixMessageJournal{
+		db:    mdb.db,
+		queue: make(chan journalEntry, journalQueueSize),
+		done:  make(chan struct{}),
+	}
+	go j.run()
+	return j
+}
+
+// Record queues a message. msg_type and seq_num are extracted so the table can
+// be filtered without parsing the message.
+func (j *FixMessageJournal) Record(timestamp time.Time, direction, sessionId, message string) error {
+	entry := journalEntry{
+		loggedAt:  timestamp.UTC().Format(timestampFormat),
+		direction: direction,
+		sessionId: sessionId,
+		msgType:   fieldValue(message, "35"),
+		message:   message,
+	}
+	if seq, err := strconv.ParseInt(fieldValue(message, "34"), 10, 64); err == nil {
+		entry.seqNum = sql.NullInt64{Int64: seq, Valid: true}
+	}
+
+	j.closeMu.RLock()
+	defer j.closeMu.RUnlock()
+	if j.closed {
+		return fmt.Errorf("journal is closed")
+	}
+	j.queue <- entry
+	return nil
+}
+
+// Close writes all queued rows and stops the writer goroutine.
+func (j *FixMessageJournal) Close() error {
+	j.closeMu.Lock()
+	if !j.closed {
+		j.closed = true
+		close(j.queue)
+	}
+	j.closeMu.Unlock()
+
+	<-j.done
+	return nil
+}
+
+func (j *FixMessageJournal) run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(journalFlushTick)
+	defer ticker.Stop()
+
+	pending := make([]journalEntry, 0, journalBatchSize)
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if err := j.insert(pending); err != nil {
+			log.Printf("Failed to journal %d FIX message(s): %v", len(pending), err)
+		}
+		pending = pending[:0]
+	}
+
+	for {
+		select {
+		case entry, ok := <-j.queue:
+			if !ok {
+				flush()
+				return
+			}
+			pending = append(pending, entry)
+			if len(pending) >= journalBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (j *FixMessageJournal) insert(entries []journalEntry) error {
+	tx, err := j.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(insertFixMessageQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range entries {
+		if _, err := stmt.Exec(e.loggedAt, e.direction, e.sessionId, e.msgType, e.seqNum, e.message); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// fieldValue returns the value of tag in a SOH-delimited FIX message, or "".
+func fieldValue(msg, tag string) string {
+	prefix := "\x01" + tag + "="
+	start := strings.Index(msg, prefix)
+	if start < 0 {
+		return ""
+	}
+	start += len(prefix)
+	end := strings.IndexByte(msg[start:], '\x01')
+	if end < 0 {
+		return msg[start:]
+	}
+	return msg[start : start+end]
+}
@@ -0,0 +1,62 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFixMessageJournal_RecordAndClose(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	journal := db.NewFixMessageJournal()
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := journal.Record(ts, "IN", "FIXT.1.1:CLIENT->COIN", "8=FIXT.1.1\x019=10\x0135=W\x0134=42\x0110=000\x01"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := journal.Record(ts, "OUT", "FIXT.1.1:CLIENT->COIN", "garbage"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var direction, msgType string
+	var seqNum int
+	err := db.db.QueryRow("SELECT direction, msg_type, seq_num FROM fix_messages WHERE direction = 'IN'").
+		Scan(&direction, &msgType, &seqNum)
+	if err != nil {
+		t.Fatalf("Failed to query journal: %v", err)
+	}
+	if msgType != "W" || seqNum != 42 {
+		t.Errorf("Expected msg_type=W seq_num=42, got %s/%d", msgType, seqNum)
+	}
+
+	var count int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM fix_messages WHERE seq_num IS NULL").Scan(&count); err != nil {
+		t.Fatalf("Failed to query journal: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected unparseable message stored with NULL seq_num, got %d rows", count)
+	}
+
+	if err := journal.Record(ts, "IN", "S", "35=0\x01"); err == nil {
+		t.Error("Expected error recording to a closed journal")
+	}
+}
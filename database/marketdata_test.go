@@ -17,10 +17,14 @@
 package database
 
 import (
+	"database/sql"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"prime-fix-md-go/decimal"
 )
 
 func setupTestDB(t *testing.T) (*MarketDataDb, func()) {
@@ -284,3 +288,180 @@ func TestTransactionRollback(t *testing.T) {
 		t.Fatalf("Expected 0 trades after rollback, found %d", count)
 	}
 }
+
+// TestStoreTrade_KeepsDecimalText verifies that prices and sizes are stored
+// exactly as received rather than rounded through a float.
+func TestStoreTrade_KeepsDecimalText(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := db.StoreTrade("BTC-USD", "92233720368.54775807", "0.00000001", "Buy", "", 1, "req-1", false); err != nil {
+		t.Fatalf("Failed to store trade: %v", err)
+	}
+
+	var price, size string
+	if err := db.db.QueryRow("SELECT price, size FROM trades").Scan(&price, &size); err != nil {
+		t.Fatalf("Failed to query trade: %v", err)
+	}
+	if price != "92233720368.54775807" || size != "0.00000001" {
+		t.Errorf("Expected exact price and size, got %s and %s", price, size)
+	}
+}
+
+// TestNewMarketDataDb_MigratesRealColumns verifies that a database created
+// with REAL price columns is converted to TEXT, keeping its rows and indexes.
+func TestNewMarketDataDb_MigratesRealColumns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = old.Exec(`CREATE TABLE trades (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, symbol TEXT NOT NULL, price REAL NOT NULL, size REAL NOT NULL,
+		aggressor_side TEXT, trade_time TEXT, seq_num INTEGER, md_req_id TEXT, is_snapshot BOOLEAN,
+		received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP);
+		CREATE INDEX idx_trades_symbol_time ON trades(symbol, received_at);
+		INSERT INTO trades (symbol, price, size) VALUES ('BTC-USD', 50000.5, 1.5);`)
+	if err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	old.Close()
+
+	db, err := NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open old database: %v", err)
+	}
+	defer db.Close()
+
+	if real, err := db.hasRealColumn("trades", decimalColumns["trades"]); err != nil || real {
+		t.Fatalf("Expected trades columns to be TEXT, real=%v err=%v", real, err)
+	}
+	var price string
+	if err := db.db.QueryRow("SELECT price FROM trades WHERE id = 1").Scan(&price); err != nil || price != "50000.5" {
+		t.Errorf("Expected migrated price 50000.5, got %q (%v)", price, err)
+	}
+	var table string
+	err = db.db.QueryRow("SELECT tbl_name FROM sqlite_master WHERE name = 'idx_trades_symbol_time'").Scan(&table)
+	if err != nil || table != "trades" {
+		t.Errorf("Expected index on trades, got %q (%v)", table, err)
+	}
+
+	if err := db.StoreTrade("BTC-USD", "50001.00", "0.00000001", "Sell", "", 2, "req-1", false); err != nil {
+		t.Fatalf("Failed to store trade after migration: %v", err)
+	}
+	var id int
+	if err := db.db.QueryRow("SELECT id FROM trades WHERE size = '0.00000001'").Scan(&id); err != nil || id != 2 {
+		t.Errorf("Expected new trade with id 2, got %d (%v)", id, err)
+	}
+}
+
+// baselineSchema is the market data schema from before prices were stored as
+// decimal text.
+const baselineSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	session_id TEXT PRIMARY KEY,
+	symbol TEXT NOT NULL,
+	request_type TEXT NOT NULL,
+	data_types TEXT NOT NULL,
+	depth INTEGER,
+	md_req_id TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	is_active BOOLEAN DEFAULT 1
+);
+CREATE TABLE IF NOT EXISTS trades (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	symbol TEXT NOT NULL,
+	price REAL NOT NULL,
+	size REAL NOT NULL,
+	aggressor_side TEXT,
+	trade_time TEXT,
+	seq_num INTEGER,
+	md_req_id TEXT,
+	is_snapshot BOOLEAN,
+	received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS order_book (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	symbol TEXT NOT NULL,
+	side TEXT NOT NULL,
+	price REAL NOT NULL,
+	size REAL NOT NULL,
+	position INTEGER,
+	seq_num INTEGER,
+	md_req_id TEXT,
+	is_snapshot BOOLEAN,
+	received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS ohlcv (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	symbol TEXT NOT NULL,
+	data_type TEXT NOT NULL,
+	value REAL NOT NULL,
+	entry_time TEXT,
+	seq_num INTEGER,
+	md_req_id TEXT,
+	received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_trades_symbol_time ON trades(symbol, received_at);
+CREATE INDEX IF NOT EXISTS idx_orderbook_symbol_time ON order_book(symbol, received_at);
+CREATE INDEX IF NOT EXISTS idx_ohlcv_symbol_time ON ohlcv(symbol, received_at);
+CREATE INDEX IF NOT EXISTS idx_orderbook_symbol_side_pos ON order_book(symbol, side, position, received_at);
+`
+
+// TestNewMarketDataDb_MigratesBaselineSchema verifies that REAL values in a
+// baseline database, including ones SQLite would print with an exponent,
+// become decimal text that decimal.Parse accepts.
+func TestNewMarketDataDb_MigratesBaselineSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "baseline.db")
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = old.Exec(baselineSchema + `
+		INSERT INTO trades (symbol, price, size) VALUES ('BTC-USD', 50000.1, 0.00000001);
+		INSERT INTO trades (symbol, price, size) VALUES ('BTC-USD', 50000, 123456789.123);
+		INSERT INTO order_book (symbol, side, price, size, position) VALUES ('BTC-USD', 'bid', 0.0001, 1e-7, 1);
+		INSERT INTO ohlcv (symbol, data_type, value) VALUES ('BTC-USD', 'volume', 1.5e-9);`)
+	if err != nil {
+		t.Fatalf("Failed to create baseline schema: %v", err)
+	}
+	old.Close()
+
+	db, err := NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open baseline database: %v", err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"SELECT price FROM trades ORDER BY id", []string{"50000.1", "50000"}},
+		{"SELECT size FROM trades ORDER BY id", []string{"0.00000001", "123456789.123"}},
+		{"SELECT price FROM order_book", []string{"0.0001"}},
+		{"SELECT size FROM order_book", []string{"0.0000001"}},
+		{"SELECT value FROM ohlcv", []string{"0.0000000015"}},
+	}
+	for _, tt := range tests {
+		rows, err := db.db.Query(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		var got []string
+		for rows.Next() {
+			var value string
+			if err := rows.Scan(&value); err != nil {
+				t.Fatalf("%s: %v", tt.query, err)
+			}
+			if _, err := decimal.Parse(value); err != nil {
+				t.Errorf("%s: migrated value %q does not parse: %v", tt.query, value, err)
+			}
+			got = append(got, value)
+		}
+		rows.Close()
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: expected %v, got %v", tt.query, tt.want, got)
+		}
+	}
+}
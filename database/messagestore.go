@@ -0,0 +1,209 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// messageStoreFactory creates SQLite-backed quickfix message stores that share
+// the market data database connection.
+type messageStoreFactory struct {
+	db *sql.DB
+}
+
+// messageStore implements quickfix.MessageStore on top of the fix_session_state
+// and fix_session_messages tables. Sequence numbers are cached in memory and
+// written through on every change so they survive restarts.
+type messageStore struct {
+	db           *sql.DB
+	sessionKey   string
+	creationTime time.Time
+	nextSender   int
+	nextTarget   int
+}
+
+// NewMessageStoreFactory returns a quickfix.MessageStoreFactory that persists
+// FIX session state in this database. Closing a store does not close the
+// database; the MarketDataDb owner remains responsible for that.
+func (mdb *MarketDataDb) NewMessageStoreFactory() quickfix.MessageStoreFactory {
+	return messageStoreFactory{db: mdb.db}
+}
+
+func (f messageStoreFactory) Create(sessionID quickfix.SessionID) (quickfix.MessageStore, error) {
+	store := &messageStore{
+		db:         f.db,
+		sessionKey: sessionID.String(),
+	}
+	if err := store.Refresh(); err != nil {
+		return nil, fmt.Errorf("failed to load session state: %v", err)
+	}
+	return store, nil
+}
+
+func (s *messageStore) NextSenderMsgSeqNum() int {
+	return s.nextSender
+}
+
+func (s *messageStore) NextTargetMsgSeqNum() int {
+	return s.nextTarget
+}
+
+func (s *messageStore) IncrNextSenderMsgSeqNum() error {
+	return s.SetNextSenderMsgSeqNum(s.nextSender + 1)
+}
+
+func (s *messageStore) IncrNextTargetMsgSeqNum() error {
+	return s.SetNextTargetMsgSeqNum(s.nextTarget + 1)
+}
+
+func (s *messageStore) SetNextSenderMsgSeqNum(next int) error {
+	s.nextSender = next
+	return s.saveState(s.db)
+}
+
+func (s *messageStore) SetNextTargetMsgSeqNum(next int) error {
+	s.nextTarget = next
+	return s.saveState(s.db)
+}
+
+func (s *messageStore) CreationTime() time.Time {
+	return s.creationTime
+}
+
+// SetCreationTime is part of the quickfix.MessageStore interface, which does
+// not allow an error to be returned; persistence failures surface on the next
+// sequence number update instead.
+func (s *messageStore) SetCreationTime(t time.Time) {
+	s.creationTime = t.UTC()
+	_ = s.saveState(s.db)
+}
+
+func (s *messageStore) SaveMessage(seqNum int, msg []byte) error {
+	_, err := s.db.Exec(insertSessionMessageQuery, s.sessionKey, seqNum, msg)
+	return err
+}
+
+// SaveMessageAndIncrNextSenderMsgSeqNum stores the message and advances the
+// sender sequence number in one transaction, so a crash cannot leave a
+// persisted message without its sequence number (or vice versa).
+func (s *messageStore) SaveMessageAndIncrNextSenderMsgSeqNum(seqNum int, msg []byte) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(insertSessionMessageQuery, s.sessionKey, seqNum, msg); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	s.nextSender++
+	if err := s.saveState(tx); err != nil {
+		s.nextSender--
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.nextSender--
+		return err
+	}
+	return nil
+}
+
+func (s *messageStore) GetMessages(beginSeqNum, endSeqNum int) ([][]byte, error) {
+	var msgs [][]byte
+	err := s.IterateMessages(beginSeqNum, endSeqNum, func(msg []byte) error {
+		msgs = append(msgs, msg)
+		return nil
+	})
+	return msgs, err
+}
+
+func (s *messageStore) IterateMessages(beginSeqNum, endSeqNum int, cb func([]byte) error) error {
+	rows, err := s.db.Query(selectSessionMessagesQuery, s.sessionKey, beginSeqNum, endSeqNum)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg []byte
+		if err := rows.Scan(&msg); err != nil {
+			return err
+		}
+		if err := cb(msg); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Refresh reloads session state from the database, creating a fresh row with
+// sequence numbers of 1 if this session has never been seen before.
+func (s *messageStore) Refresh() error {
+	err := s.db.QueryRow(selectSessionStateQuery, s.sessionKey).Scan(&s.creationTime, &s.nextSender, &s.nextTarget)
+	if err == sql.ErrNoRows {
+		s.creationTime = time.Now().UTC()
+		s.nextSender = 1
+		s.nextTarget = 1
+		return s.saveState(s.db)
+	}
+	return err
+}
+
+// Reset discards stored messages and sets both sequence numbers back to 1.
+func (s *messageStore) Reset() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(deleteSessionMessagesQuery, s.sessionKey); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	s.creationTime = time.Now().UTC()
+	s.nextSender = 1
+	s.nextTarget = 1
+	if err := s.saveState(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close is a no-op; the underlying connection belongs to MarketDataDb.
+func (s *messageStore) Close() error {
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func (s *messageStore) saveState(e execer) error {
+	_, err := e.Exec(upsertSessionStateQuery, s.sessionKey, s.creationTime, s.nextSender, s.nextTarget)
+	return err
+}
@@ -0,0 +1,157 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+var testSessionID = quickfix.SessionID{BeginString: "FIXT.1.1", SenderCompID: "CLIENT", TargetCompID: "COIN"}
+
+func createTestStore(t *testing.T, db *MarketDataDb) quickfix.MessageStore {
+	t.Helper()
+	store, err := db.NewMessageStoreFactory().Create(testSessionID)
+	if err != nil {
+		t.Fatalf("Failed to create message store: %v", err)
+	}
+	return store
+}
+
+func TestMessageStore_NewSessionStartsAtOne(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	store := createTestStore(t, db)
+
+	if store.NextSenderMsgSeqNum() != 1 || store.NextTargetMsgSeqNum() != 1 {
+		t.Errorf("Expected seq nums 1/1, got %d/%d", store.NextSenderMsgSeqNum(), store.NextTargetMsgSeqNum())
+	}
+	if store.CreationTime().IsZero() {
+		t.Error("Creation time should be set")
+	}
+}
+
+func TestMessageStore_SequenceNumbersSurviveReopen(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	store := createTestStore(t, db)
+	if err := store.SaveMessageAndIncrNextSenderMsgSeqNum(1, []byte("msg-1")); err != nil {
+		t.Fatalf("Failed to save message: %v", err)
+	}
+	if err := store.SaveMessageAndIncrNextSenderMsgSeqNum(2, []byte("msg-2")); err != nil {
+		t.Fatalf("Failed to save message: %v", err)
+	}
+	if err := store.SetNextTargetMsgSeqNum(7); err != nil {
+		t.Fatalf("Failed to set target seq num: %v", err)
+	}
+	db.Close()
+
+	// Simulate a process restart
+	db, err = NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	store = createTestStore(t, db)
+
+	if store.NextSenderMsgSeqNum() != 3 {
+		t.Errorf("Expected next sender seq 3, got %d", store.NextSenderMsgSeqNum())
+	}
+	if store.NextTargetMsgSeqNum() != 7 {
+		t.Errorf("Expected next target seq 7, got %d", store.NextTargetMsgSeqNum())
+	}
+
+	msgs, err := store.GetMessages(1, 2)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(msgs) != 2 || string(msgs[0]) != "msg-1" || string(msgs[1]) != "msg-2" {
+		t.Errorf("Unexpected stored messages: %q", msgs)
+	}
+}
+
+func TestMessageStore_GetMessagesRange(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	store := createTestStore(t, db)
+	for seq := 1; seq <= 5; seq++ {
+		if err := store.SaveMessage(seq, []byte{byte('0' + seq)}); err != nil {
+			t.Fatalf("Failed to save message %d: %v", seq, err)
+		}
+	}
+
+	msgs, err := store.GetMessages(2, 4)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(msgs) != 3 || string(msgs[0]) != "2" || string(msgs[2]) != "4" {
+		t.Errorf("Expected messages 2..4, got %q", msgs)
+	}
+}
+
+func TestMessageStore_Reset(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	store := createTestStore(t, db)
+	_ = store.SaveMessageAndIncrNextSenderMsgSeqNum(1, []byte("msg-1"))
+	_ = store.SetNextTargetMsgSeqNum(10)
+
+	if err := store.Reset(); err != nil {
+		t.Fatalf("Failed to reset: %v", err)
+	}
+
+	if store.NextSenderMsgSeqNum() != 1 || store.NextTargetMsgSeqNum() != 1 {
+		t.Errorf("Expected seq nums 1/1 after reset, got %d/%d", store.NextSenderMsgSeqNum(), store.NextTargetMsgSeqNum())
+	}
+	msgs, _ := store.GetMessages(1, 10)
+	if len(msgs) != 0 {
+		t.Errorf("Expected no messages after reset, got %d", len(msgs))
+	}
+
+	// Reset state must also be persisted
+	reopened := createTestStore(t, db)
+	if reopened.NextTargetMsgSeqNum() != 1 {
+		t.Errorf("Expected persisted target seq 1 after reset, got %d", reopened.NextTargetMsgSeqNum())
+	}
+}
+
+func TestMessageStore_SessionsAreIsolated(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	store := createTestStore(t, db)
+	_ = store.SetNextSenderMsgSeqNum(42)
+
+	otherID := quickfix.SessionID{BeginString: "FIXT.1.1", SenderCompID: "OTHER", TargetCompID: "COIN"}
+	other, err := db.NewMessageStoreFactory().Create(otherID)
+	if err != nil {
+		t.Fatalf("Failed to create message store: %v", err)
+	}
+
+	if other.NextSenderMsgSeqNum() != 1 {
+		t.Errorf("Expected independent session to start at 1, got %d", other.NextSenderMsgSeqNum())
+	}
+}
@@ -0,0 +1,134 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// timestampFormat is a fixed-width UTC timestamp, so stored values sort and
+// compare correctly as text.
+const timestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// OrderRecord is one row of the orders table. Data holds the caller's
+// encoding of the full order; the other fields are indexed copies.
+type OrderRecord struct {
+	ClOrdID   string
+	OrderID   string
+	Symbol    string
+	OrdStatus string
+	UpdatedAt time.Time
+	Data      string
+}
+
+// QuoteRecord is one row of the quotes table.
+type QuoteRecord struct {
+	QuoteReqID string
+	QuoteID    string
+	Symbol     string
+	ValidUntil time.Time
+	Data       string
+}
+
+// SaveOrder inserts or replaces the order with r.ClOrdID.
+func (mdb *MarketDataDb) SaveOrder(r OrderRecord) error {
+	_, err := mdb.db.Exec(upsertOrderQuery, r.ClOrdID, r.OrderID, r.Symbol, r.OrdStatus,
+		r.UpdatedAt.UTC().Format(timestampFormat), r.Data)
+	return err
+}
+
+// LoadOrders returns stored orders whose OrdStatus is one of statuses, or
+// every order when no statuses are given.
+func (mdb *MarketDataDb) LoadOrders(statuses ...string) ([]OrderRecord, error) {
+	query := selectOrdersQuery
+	args := make([]any, len(statuses))
+	if len(statuses) > 0 {
+		query += " WHERE ord_status IN (?" + strings.Repeat(", ?", len(statuses)-1) + ")"
+		for i, status := range statuses {
+			args[i] = status
+		}
+	}
+	query += " ORDER BY updated_at"
+
+	rows, err := mdb.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []OrderRecord
+	for rows.Next() {
+		var r OrderRecord
+		var orderId, symbol, ordStatus sql.NullString
+		var updatedAt string
+		if err := rows.Scan(&r.ClOrdID, &orderId, &symbol, &ordStatus, &updatedAt, &r.Data); err != nil {
+			return nil, err
+		}
+		r.OrderID = orderId.String
+		r.Symbol = symbol.String
+		r.OrdStatus = ordStatus.String
+		r.UpdatedAt, _ = time.Parse(timestampFormat, updatedAt)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// DeleteOrder removes the order with clOrdId.
+func (mdb *MarketDataDb) DeleteOrder(clOrdId string) error {
+	_, err := mdb.db.Exec(deleteOrderQuery, clOrdId)
+	return err
+}
+
+// SaveQuote inserts or replaces the quote with r.QuoteReqID.
+func (mdb *MarketDataDb) SaveQuote(r QuoteRecord) error {
+	_, err := mdb.db.Exec(upsertQuoteQuery, r.QuoteReqID, r.QuoteID, r.Symbol,
+		r.ValidUntil.UTC().Format(timestampFormat), r.Data)
+	return err
+}
+
+// LoadQuotes returns stored quotes that are still valid at now, soonest
+// expiry first.
+func (mdb *MarketDataDb) LoadQuotes(now time.Time) ([]QuoteRecord, error) {
+	rows, err := mdb.db.Query(selectQuotesQuery, now.UTC().Format(timestampFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []QuoteRecord
+	for rows.Next() {
+		var r QuoteRecord
+		var quoteId, symbol sql.NullString
+		var validUntil string
+		if err := rows.Scan(&r.QuoteReqID, &quoteId, &symbol, &validUntil, &r.Data); err != nil {
+			return nil, err
+		}
+		r.QuoteID = quoteId.String
+		r.Symbol = symbol.String
+		r.ValidUntil, _ = time.Parse(timestampFormat, validUntil)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// DeleteQuote removes the quote with quoteReqId.
+func (mdb *MarketDataDb) DeleteQuote(quoteReqId string) error {
+	_, err := mdb.db.Exec(deleteQuoteQuery, quoteReqId)
+	return err
+}
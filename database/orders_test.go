@@ -0,0 +1,99 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSaveOrder_UpsertAndLoadByStatus verifies orders are replaced by ClOrdID
+// and can be loaded filtered by status.
+func TestSaveOrder_UpsertAndLoadByStatus(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []OrderRecord{
+		{ClOrdID: "c1", Symbol: "BTC-USD", OrdStatus: "A", UpdatedAt: now, Data: `{"v":1}`},
+		{ClOrdID: "c2", Symbol: "ETH-USD", OrdStatus: "0", UpdatedAt: now.Add(time.Second), Data: `{}`},
+		{ClOrdID: "c1", OrderID: "o1", Symbol: "BTC-USD", OrdStatus: "2", UpdatedAt: now.Add(2 * time.Second), Data: `{"v":2}`},
+	}
+	for _, r := range records {
+		if err := db.SaveOrder(r); err != nil {
+			t.Fatalf("SaveOrder(%s) failed: %v", r.ClOrdID, err)
+		}
+	}
+
+	all, err := db.LoadOrders()
+	if err != nil {
+		t.Fatalf("LoadOrders failed: %v", err)
+	}
+	if len(all) != 2 || all[0].ClOrdID != "c2" || all[1].ClOrdID != "c1" {
+		t.Fatalf("Expected c2, c1 by update time, got %+v", all)
+	}
+	if c1 := all[1]; c1.OrderID != "o1" || c1.Data != `{"v":2}` || !c1.UpdatedAt.Equal(now.Add(2*time.Second)) {
+		t.Errorf("Expected c1 to be replaced, got %+v", c1)
+	}
+
+	open, err := db.LoadOrders("0", "1", "A")
+	if err != nil {
+		t.Fatalf("LoadOrders by status failed: %v", err)
+	}
+	if len(open) != 1 || open[0].ClOrdID != "c2" {
+		t.Errorf("Expected only c2 open, got %+v", open)
+	}
+
+	if err := db.DeleteOrder("c2"); err != nil {
+		t.Fatalf("DeleteOrder failed: %v", err)
+	}
+	if open, _ := db.LoadOrders("0"); len(open) != 0 {
+		t.Errorf("Expected c2 deleted, got %+v", open)
+	}
+}
+
+// TestSaveQuote_LoadSkipsExpired verifies only quotes valid at the given time
+// are loaded.
+func TestSaveQuote_LoadSkipsExpired(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, r := range []QuoteRecord{
+		{QuoteReqID: "r1", QuoteID: "q1", Symbol: "BTC-USD", ValidUntil: now.Add(-time.Second), Data: `{}`},
+		{QuoteReqID: "r2", QuoteID: "q2", Symbol: "BTC-USD", ValidUntil: now.Add(time.Minute), Data: `{}`},
+	} {
+		if err := db.SaveQuote(r); err != nil {
+			t.Fatalf("SaveQuote(%s) failed: %v", r.QuoteReqID, err)
+		}
+	}
+
+	quotes, err := db.LoadQuotes(now)
+	if err != nil {
+		t.Fatalf("LoadQuotes failed: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].QuoteID != "q2" {
+		t.Errorf("Expected only q2, got %+v", quotes)
+	}
+
+	if err := db.DeleteQuote("r2"); err != nil {
+		t.Fatalf("DeleteQuote failed: %v", err)
+	}
+	if quotes, _ := db.LoadQuotes(now); len(quotes) != 0 {
+		t.Errorf("Expected r2 deleted, got %+v", quotes)
+	}
+}
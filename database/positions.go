@@ -0,0 +1,69 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"time"
+
+	"prime-fix-md-go/decimal"
+)
+
+// PositionSnapshot is one row of the position_snapshots table. MarkPrice and
+// UnrealizedPnL are nil when no market data was available. Amounts are
+// stored as decimal text and read back at the scale they were written with.
+type PositionSnapshot struct {
+	Symbol        string
+	NetQty        decimal.Decimal
+	AvgCost       decimal.Decimal
+	RealizedPnL   decimal.Decimal
+	Fees          decimal.Decimal
+	BoughtQty     decimal.Decimal
+	SoldQty       decimal.Decimal
+	MarkPrice     *decimal.Decimal
+	UnrealizedPnL *decimal.Decimal
+	SnapshotAt    time.Time
+}
+
+// StorePositionSnapshot appends a snapshot of one symbol's position.
+func (mdb *MarketDataDb) StorePositionSnapshot(s PositionSnapshot) error {
+	_, err := mdb.db.Exec(insertPositionSnapshotQuery, s.Symbol, s.NetQty, s.AvgCost, s.RealizedPnL,
+		s.Fees, s.BoughtQty, s.SoldQty, s.MarkPrice, s.UnrealizedPnL, s.SnapshotAt.UTC().Format(timestampFormat))
+	return err
+}
+
+// LoadLatestPositions returns the most recent snapshot for each symbol,
+// ordered by symbol.
+func (mdb *MarketDataDb) LoadLatestPositions() ([]PositionSnapshot, error) {
+	rows, err := mdb.db.Query(selectLatestPositionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []PositionSnapshot
+	for rows.Next() {
+		var s PositionSnapshot
+		var snapshotAt string
+		if err := rows.Scan(&s.Symbol, &s.NetQty, &s.AvgCost, &s.RealizedPnL, &s.Fees, &s.BoughtQty, &s.SoldQty,
+			&s.MarkPrice, &s.UnrealizedPnL, &snapshotAt); err != nil {
+			return nil, err
+		}
+		s.SnapshotAt, _ = time.Parse(timestampFormat, snapshotAt)
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
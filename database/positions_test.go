@@ -0,0 +1,116 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/decimal"
+)
+
+// TestStorePositionSnapshot_LoadLatest verifies that only the newest snapshot
+// per symbol is loaded, that amounts keep their scale, and that a missing
+// mark price round-trips as nil.
+func TestStorePositionSnapshot_LoadLatest(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	d := decimal.MustParse
+	mark, unrealized := d("51000.005"), d("500.0025000000")
+	for _, s := range []PositionSnapshot{
+		{Symbol: "ETH-USD", NetQty: d("2"), AvgCost: d("3000"), BoughtQty: d("2"), SnapshotAt: now},
+		{Symbol: "BTC-USD", NetQty: d("1"), AvgCost: d("50000"), BoughtQty: d("1"), SnapshotAt: now},
+		{Symbol: "BTC-USD", NetQty: d("0.50000000"), AvgCost: d("50000.0000000000"), RealizedPnL: d("400.0000000001"),
+			Fees: d("25"), BoughtQty: d("1"), SoldQty: d("0.5"), MarkPrice: &mark, UnrealizedPnL: &unrealized,
+			SnapshotAt: now.Add(time.Second)},
+	} {
+		if err := db.StorePositionSnapshot(s); err != nil {
+			t.Fatalf("StorePositionSnapshot(%s) failed: %v", s.Symbol, err)
+		}
+	}
+
+	positions, err := db.LoadLatestPositions()
+	if err != nil {
+		t.Fatalf("LoadLatestPositions failed: %v", err)
+	}
+	if len(positions) != 2 || positions[0].Symbol != "BTC-USD" || positions[1].Symbol != "ETH-USD" {
+		t.Fatalf("Expected the latest BTC-USD and ETH-USD snapshots, got %+v", positions)
+	}
+	btc := positions[0]
+	if btc.NetQty.String() != "0.50000000" || btc.RealizedPnL.String() != "400.0000000001" || btc.SoldQty.String() != "0.5" ||
+		!btc.SnapshotAt.Equal(now.Add(time.Second)) || btc.MarkPrice == nil || *btc.MarkPrice != mark ||
+		btc.UnrealizedPnL == nil || *btc.UnrealizedPnL != unrealized {
+		t.Errorf("Unexpected BTC-USD snapshot: %+v", btc)
+	}
+	if positions[1].MarkPrice != nil || positions[1].UnrealizedPnL != nil {
+		t.Errorf("Expected no ETH-USD mark price, got %+v", positions[1])
+	}
+}
+
+// TestNewMarketDataDb_MigratesRealPositions verifies that position snapshots
+// stored in REAL columns become decimal text and load as decimals, keeping a
+// NULL mark price.
+func TestNewMarketDataDb_MigratesRealPositions(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "positions.db")
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = old.Exec(`
+		CREATE TABLE position_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			symbol TEXT NOT NULL,
+			net_qty REAL NOT NULL,
+			avg_cost REAL NOT NULL,
+			realized_pnl REAL NOT NULL,
+			fees REAL NOT NULL,
+			bought_qty REAL NOT NULL,
+			sold_qty REAL NOT NULL,
+			mark_price REAL,
+			unrealized_pnl REAL,
+			snapshot_at TEXT NOT NULL
+		);
+		INSERT INTO position_snapshots (symbol, net_qty, avg_cost, realized_pnl, fees, bought_qty, sold_qty, snapshot_at)
+			VALUES ('BTC-USD', 1e-8, 50025.5, -12.25, 0.5, 1e-8, 0, '2025-01-02T03:04:05.000000Z');`)
+	if err != nil {
+		t.Fatalf("Failed to create REAL positions: %v", err)
+	}
+	old.Close()
+
+	db, err := NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open REAL database: %v", err)
+	}
+	defer db.Close()
+
+	if real, err := db.hasRealColumn("position_snapshots", decimalColumns["position_snapshots"]); err != nil || real {
+		t.Fatalf("Expected position_snapshots columns to be TEXT, real=%v err=%v", real, err)
+	}
+	positions, err := db.LoadLatestPositions()
+	if err != nil || len(positions) != 1 {
+		t.Fatalf("Expected one migrated position, got %+v (%v)", positions, err)
+	}
+	btc := positions[0]
+	if btc.NetQty.String() != "0.00000001" || btc.AvgCost.String() != "50025.5" || btc.RealizedPnL.String() != "-12.25" ||
+		btc.SoldQty.String() != "0" || btc.MarkPrice != nil || btc.UnrealizedPnL != nil {
+		t.Errorf("Unexpected migrated position: %+v", btc)
+	}
+}
@@ -17,7 +17,10 @@
 package database
 
 import (
+	"database/sql"
 	_ "embed"
+	"fmt"
+	"strconv"
 )
 
 //go:embed schema.sql
@@ -35,9 +38,214 @@ const (
 
 	insertOHLCVQuery = `INSERT INTO ohlcv (symbol, data_type, value, entry_time, seq_num, md_req_id) 
 			  VALUES (?, ?, ?, ?, ?, ?)`
+
+	selectSessionStateQuery = `SELECT creation_time, next_sender_seq_num, next_target_seq_num
+			  FROM fix_session_state WHERE session_key = ?`
+
+	upsertSessionStateQuery = `INSERT INTO fix_session_state (session_key, creation_time, next_sender_seq_num, next_target_seq_num)
+			  VALUES (?, ?, ?, ?)
+			  ON CONFLICT(session_key) DO UPDATE SET
+			  creation_time = excluded.creation_time,
+			  next_sender_seq_num = excluded.next_sender_seq_num,
+			  next_target_seq_num = excluded.next_target_seq_num`
+
+	insertSessionMessageQuery = `INSERT OR REPLACE INTO fix_session_messages (session_key, seq_num, message)
+			  VALUES (?, ?, ?)`
+
+	selectSessionMessagesQuery = `SELECT message FROM fix_session_messages
+			  WHERE session_key = ? AND seq_num >= ? AND seq_num <= ? ORDER BY seq_num`
+
+	deleteSessionMessagesQuery = `DELETE FROM fix_session_messages WHERE session_key = ?`
+
+	insertFixMessageQuery = `INSERT INTO fix_messages (logged_at, direction, session_id, msg_type, seq_num, message)
+			  VALUES (?, ?, ?, ?, ?, ?)`
+
+	insertFillQuery = `INSERT OR IGNORE INTO fills (exec_id, cl_ord_id, order_id, symbol, side, last_px, last_shares, commission, misc_fees, transact_time)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	selectFillsQuery = `SELECT exec_id, cl_ord_id, order_id, symbol, side, last_px, last_shares, commission, misc_fees, transact_time
+			  FROM fills WHERE (? = '' OR cl_ord_id = ?) AND (? = '' OR symbol = ?) ORDER BY transact_time, received_at`
+
+	upsertOrderQuery = `INSERT INTO orders (cl_ord_id, order_id, symbol, ord_status, updated_at, data)
+			  VALUES (?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(cl_ord_id) DO UPDATE SET
+			  order_id = excluded.order_id,
+			  symbol = excluded.symbol,
+			  ord_status = excluded.ord_status,
+			  updated_at = excluded.updated_at,
+			  data = excluded.data`
+
+	selectOrdersQuery = `SELECT cl_ord_id, order_id, symbol, ord_status, updated_at, data FROM orders`
+
+	deleteOrderQuery = `DELETE FROM orders WHERE cl_ord_id = ?`
+
+	upsertQuoteQuery = `INSERT INTO quotes (quote_req_id, quote_id, symbol, valid_until, data)
+			  VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT(quote_req_id) DO UPDATE SET
+			  quote_id = excluded.quote_id,
+			  symbol = excluded.symbol,
+			  valid_until = excluded.valid_until,
+			  data = excluded.data`
+
+	selectQuotesQuery = `SELECT quote_req_id, quote_id, symbol, valid_until, data FROM quotes
+			  WHERE valid_until > ? ORDER BY valid_until`
+
+	deleteQuoteQuery = `DELETE FROM quotes WHERE quote_req_id = ?`
+
+	upsertStrategyQuery = `INSERT INTO strategies (id, kind, symbol, status, updated_at, data)
+			  VALUES (?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET
+			  status = excluded.status,
+			  updated_at = excluded.updated_at,
+			  data = excluded.data`
+
+	selectStrategiesQuery = `SELECT id, kind, symbol, status, updated_at, data FROM strategies`
+
+	upsertCandleQuery = `INSERT INTO candles (symbol, interval, start_time, open, high, low, close, volume, vwap, trade_count, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(symbol, interval, start_time) DO UPDATE SET
+			  open = excluded.open,
+			  high = excluded.high,
+			  low = excluded.low,
+			  close = excluded.close,
+			  volume = excluded.volume,
+			  vwap = excluded.vwap,
+			  trade_count = excluded.trade_count,
+			  updated_at = excluded.updated_at`
+
+	selectCandlesQuery = `SELECT symbol, interval, start_time, open, high, low, close, volume, vwap, trade_count FROM candles
+			  WHERE symbol = ? AND interval = ? AND start_time >= ? ORDER BY start_time`
+
+	insertPositionSnapshotQuery = `INSERT INTO position_snapshots (symbol, net_qty, avg_cost, realized_pnl, fees, bought_qty, sold_qty, mark_price, unrealized_pnl, snapshot_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	selectLatestPositionsQuery = `SELECT symbol, net_qty, avg_cost, realized_pnl, fees, bought_qty, sold_qty, mark_price, unrealized_pnl, snapshot_at
+			  FROM position_snapshots WHERE id IN (SELECT MAX(id) FROM position_snapshots GROUP BY symbol) ORDER BY symbol`
 )
 
+// decimalColumns lists the columns that hold prices, sizes and amounts.
+// They were REAL before these became fixed-point decimals.
+var decimalColumns = map[string][]string{
+	"trades":     {"price", "size"},
+	"order_book": {"price", "size"},
+	"ohlcv":      {"value"},
+	"position_snapshots": {"net_qty", "avg_cost", "realized_pnl", "fees", "bought_qty", "sold_qty",
+		"mark_price", "unrealized_pnl"},
+}
+
 func (mdb *MarketDataDb) initSchema() error {
-	_, err := mdb.db.Exec(schemaSQL)
-	return err
+	if _, err := mdb.db.Exec(schemaSQL); err != nil {
+		return err
+	}
+	return mdb.migrateDecimalColumns()
+}
+
+// migrateDecimalColumns converts the decimalColumns of a database created
+// with REAL columns to TEXT. SQLite cannot change a column's type in place,
+// so each such table is renamed, recreated from schemaSQL, refilled and
+// dropped. Values stored as REAL become the shortest decimal text that reads
+// back as the same float.
+func (mdb *MarketDataDb) migrateDecimalColumns() error {
+	var tables []string
+	for table, columns := range decimalColumns {
+		real, err := mdb.hasRealColumn(table, columns)
+		if err != nil {
+			return err
+		}
+		if real {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	tx, err := mdb.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range tables {
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s RENAME TO %s_real", table, table)); err != nil {
+			return fmt.Errorf("migrating %s: %v", table, err)
+		}
+	}
+	// The renamed tables keep their indexes, so this only creates the tables
+	if _, err := tx.Exec(schemaSQL); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if _, err := tx.Exec(fmt.Sprintf("INSERT INTO %s SELECT * FROM %s_real", table, table)); err != nil {
+			return fmt.Errorf("migrating %s: %v", table, err)
+		}
+		for _, column := range decimalColumns[table] {
+			if err := rewriteRealValues(tx, table, column); err != nil {
+				return fmt.Errorf("migrating %s.%s: %v", table, column, err)
+			}
+		}
+		if _, err := tx.Exec(fmt.Sprintf("DROP TABLE %s_real", table)); err != nil {
+			return fmt.Errorf("migrating %s: %v", table, err)
+		}
+	}
+	// Recreate the indexes dropped with the old tables
+	if _, err := tx.Exec(schemaSQL); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rewriteRealValues replaces the text SQLite made of each REAL value copied
+// into column, which can have an exponent (1.0e-08) that decimal.Parse
+// rejects, with plain decimal text. Rows are matched on their id.
+func rewriteRealValues(tx *sql.Tx, table, column string) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT id, %s FROM %s_real WHERE typeof(%s) = 'real'", column, table, column))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	update, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", table, column))
+	if err != nil {
+		return err
+	}
+	defer update.Close()
+
+	for rows.Next() {
+		var id int64
+		var value float64
+		if err := rows.Scan(&id, &value); err != nil {
+			return err
+		}
+		if _, err := update.Exec(strconv.FormatFloat(value, 'f', -1, 64), id); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// hasRealColumn reports whether any of columns in table is declared REAL.
+func (mdb *MarketDataDb) hasRealColumn(table string, columns []string) (bool, error) {
+	rows, err := mdb.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			dflt             any
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		for _, column := range columns {
+			if name == column && colType == "REAL" {
+				return true, nil
+			}
+		}
+	}
+	return false, rows.Err()
 }
@@ -24,12 +24,15 @@ CREATE TABLE IF NOT EXISTS sessions (
 	is_active BOOLEAN DEFAULT 1
 );
 
+-- Prices, sizes and OHLCV values are TEXT holding the decimal exactly as
+-- received, so no precision is lost (e.g. 0.00000001 BTC).
+
 -- All trade data (snapshots + streaming)
 CREATE TABLE IF NOT EXISTS trades (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	symbol TEXT NOT NULL,
-	price REAL NOT NULL,
-	size REAL NOT NULL,
+	price TEXT NOT NULL,
+	size TEXT NOT NULL,
 	aggressor_side TEXT,        -- 'Buy', 'Sell'
 	trade_time TEXT,           -- Timestamp
 	seq_num INTEGER,           -- FIX sequence number
@@ -43,8 +46,8 @@ CREATE TABLE IF NOT EXISTS order_book (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	symbol TEXT NOT NULL,
 	side TEXT NOT NULL,        -- 'bid' or 'offer'
-	price REAL NOT NULL,
-	size REAL NOT NULL,
+	price TEXT NOT NULL,
+	size TEXT NOT NULL,
 	position INTEGER,          -- Book level (1=best, 2=second, etc.)
 	seq_num INTEGER,
 	md_req_id TEXT,
@@ -57,7 +60,7 @@ CREATE TABLE IF NOT EXISTS ohlcv (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	symbol TEXT NOT NULL,
 	data_type TEXT NOT NULL,   -- 'open', 'high', 'low', 'close', 'volume'
-	value REAL NOT NULL,
+	value TEXT NOT NULL,
 	entry_time TEXT,           -- Exchange timestamp  
 	seq_num INTEGER,
 	md_req_id TEXT,
@@ -68,4 +71,122 @@ CREATE TABLE IF NOT EXISTS ohlcv (
 CREATE INDEX IF NOT EXISTS idx_trades_symbol_time ON trades(symbol, received_at);
 CREATE INDEX IF NOT EXISTS idx_orderbook_symbol_time ON order_book(symbol, received_at);
 CREATE INDEX IF NOT EXISTS idx_ohlcv_symbol_time ON ohlcv(symbol, received_at);
-CREATE INDEX IF NOT EXISTS idx_orderbook_symbol_side_pos ON order_book(symbol, side, position, received_at);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_orderbook_symbol_side_pos ON order_book(symbol, side, position, received_at);
+
+-- FIX session state for the SQLite MessageStore (sequence numbers survive restarts)
+CREATE TABLE IF NOT EXISTS fix_session_state (
+	session_key TEXT PRIMARY KEY, -- quickfix SessionID string
+	creation_time TIMESTAMP NOT NULL,
+	next_sender_seq_num INTEGER NOT NULL,
+	next_target_seq_num INTEGER NOT NULL
+);
+
+-- Outgoing FIX messages kept for ResendRequest handling
+CREATE TABLE IF NOT EXISTS fix_session_messages (
+	session_key TEXT NOT NULL,
+	seq_num INTEGER NOT NULL,
+	message BLOB NOT NULL,
+	PRIMARY KEY (session_key, seq_num)
+);
+
+-- Raw FIX message journal (audit trail, secrets redacted)
+CREATE TABLE IF NOT EXISTS fix_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	logged_at TEXT NOT NULL,    -- RFC 3339 with microseconds, UTC
+	direction TEXT NOT NULL,    -- 'IN' or 'OUT'
+	session_id TEXT NOT NULL,
+	msg_type TEXT,
+	seq_num INTEGER,
+	message TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_fix_messages_session_time ON fix_messages(session_id, logged_at);
+
+-- Per-execution fills ledger, one row per ExecID
+CREATE TABLE IF NOT EXISTS fills (
+	exec_id TEXT PRIMARY KEY,  -- Tag 17
+	cl_ord_id TEXT NOT NULL,
+	order_id TEXT,
+	symbol TEXT NOT NULL,
+	side TEXT,                 -- Tag 54: '1' buy, '2' sell
+	last_px TEXT NOT NULL,
+	last_shares TEXT NOT NULL,
+	commission TEXT,
+	misc_fees TEXT,            -- JSON array of {amt, curr, type}
+	transact_time TEXT,        -- Tag 60, UTC
+	received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fills_cl_ord_id ON fills(cl_ord_id);
+CREATE INDEX IF NOT EXISTS idx_fills_symbol_time ON fills(symbol, transact_time);
+
+-- Orders and quotes tracked by the client, so order entry survives restarts.
+-- Rows are the JSON encoding of the client's Order/Quote; the other columns
+-- exist for lookups.
+CREATE TABLE IF NOT EXISTS orders (
+	cl_ord_id TEXT PRIMARY KEY, -- Tag 11
+	order_id TEXT,              -- Tag 37
+	symbol TEXT,
+	ord_status TEXT,            -- Tag 39
+	updated_at TEXT NOT NULL,   -- RFC 3339 with microseconds, UTC
+	data TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(ord_status);
+
+CREATE TABLE IF NOT EXISTS quotes (
+	quote_req_id TEXT PRIMARY KEY, -- Tag 131
+	quote_id TEXT,                 -- Tag 117
+	symbol TEXT,
+	valid_until TEXT,              -- RFC 3339 with microseconds, UTC
+	data TEXT NOT NULL
+);
+
+-- Position snapshots, one row per symbol each time a fill changes it. The
+-- latest row per symbol is reloaded on startup. Amounts are decimal TEXT
+-- like prices and sizes.
+CREATE TABLE IF NOT EXISTS position_snapshots (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	symbol TEXT NOT NULL,
+	net_qty TEXT NOT NULL,      -- Base units, negative when short
+	avg_cost TEXT NOT NULL,     -- Per base unit, including fees
+	realized_pnl TEXT NOT NULL, -- Quote currency, net of fees
+	fees TEXT NOT NULL,
+	bought_qty TEXT NOT NULL,
+	sold_qty TEXT NOT NULL,
+	mark_price TEXT,            -- NULL without market data
+	unrealized_pnl TEXT,
+	snapshot_at TEXT NOT NULL   -- RFC 3339 with microseconds, UTC
+);
+
+CREATE INDEX IF NOT EXISTS idx_position_snapshots_symbol ON position_snapshots(symbol, id);
+
+-- Client-side synthetic orders (OCO, bracket, trailing stop). Rows are the
+-- JSON encoding of the client's Strategy; active ones are reloaded on startup.
+CREATE TABLE IF NOT EXISTS strategies (
+	id TEXT PRIMARY KEY,
+	kind TEXT NOT NULL,
+	symbol TEXT,
+	status TEXT NOT NULL,
+	updated_at TEXT NOT NULL, -- RFC 3339 with microseconds, UTC
+	data TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_strategies_status ON strategies(status);
+
+-- OHLCV bars built by the client from live trades, one row per symbol,
+-- interval and bar. A bar amended by a late trade replaces its row.
+CREATE TABLE IF NOT EXISTS candles (
+	symbol TEXT NOT NULL,
+	interval TEXT NOT NULL,   -- '1s', '1m', '5m', '1h'
+	start_time TEXT NOT NULL, -- Bar open, RFC 3339 with microseconds, UTC
+	open TEXT NOT NULL,
+	high TEXT NOT NULL,
+	low TEXT NOT NULL,
+	close TEXT NOT NULL,
+	volume TEXT NOT NULL,
+	vwap TEXT NOT NULL,
+	trade_count INTEGER NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (symbol, interval, start_time)
+);
@@ -0,0 +1,75 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// StrategyRecord is one row of the strategies table. Data holds the caller's
+// encoding of the full strategy; the other fields are indexed copies.
+type StrategyRecord struct {
+	ID        string
+	Kind      string
+	Symbol    string
+	Status    string
+	UpdatedAt time.Time
+	Data      string
+}
+
+// SaveStrategy inserts or replaces the strategy with r.ID.
+func (mdb *MarketDataDb) SaveStrategy(r StrategyRecord) error {
+	_, err := mdb.db.Exec(upsertStrategyQuery, r.ID, r.Kind, r.Symbol, r.Status,
+		r.UpdatedAt.UTC().Format(timestampFormat), r.Data)
+	return err
+}
+
+// LoadStrategies returns stored strategies whose status is one of statuses,
+// or every strategy when no statuses are given, oldest update first.
+func (mdb *MarketDataDb) LoadStrategies(statuses ...string) ([]StrategyRecord, error) {
+	query := selectStrategiesQuery
+	args := make([]any, len(statuses))
+	if len(statuses) > 0 {
+		query += " WHERE status IN (?" + strings.Repeat(", ?", len(statuses)-1) + ")"
+		for i, status := range statuses {
+			args[i] = status
+		}
+	}
+	query += " ORDER BY updated_at"
+
+	rows, err := mdb.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []StrategyRecord
+	for rows.Next() {
+		var r StrategyRecord
+		var symbol sql.NullString
+		var updatedAt string
+		if err := rows.Scan(&r.ID, &r.Kind, &symbol, &r.Status, &updatedAt, &r.Data); err != nil {
+			return nil, err
+		}
+		r.Symbol = symbol.String
+		r.UpdatedAt, _ = time.Parse(timestampFormat, updatedAt)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
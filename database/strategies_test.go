@@ -0,0 +1,60 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSaveStrategy_UpsertAndLoadByStatus verifies strategies are replaced by
+// ID and can be loaded filtered by status.
+func TestSaveStrategy_UpsertAndLoadByStatus(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []StrategyRecord{
+		{ID: "s1", Kind: "oco", Symbol: "BTC-USD", Status: "active", UpdatedAt: now, Data: `{"v":1}`},
+		{ID: "s2", Kind: "trailing", Symbol: "ETH-USD", Status: "active", UpdatedAt: now.Add(time.Second), Data: `{}`},
+		{ID: "s1", Kind: "oco", Symbol: "BTC-USD", Status: "done", UpdatedAt: now.Add(2 * time.Second), Data: `{"v":2}`},
+	}
+	for _, r := range records {
+		if err := db.SaveStrategy(r); err != nil {
+			t.Fatalf("SaveStrategy(%s) failed: %v", r.ID, err)
+		}
+	}
+
+	all, err := db.LoadStrategies()
+	if err != nil {
+		t.Fatalf("LoadStrategies failed: %v", err)
+	}
+	if len(all) != 2 || all[0].ID != "s2" || all[1].ID != "s1" {
+		t.Fatalf("Expected s2, s1 by update time, got %+v", all)
+	}
+	if s1 := all[1]; s1.Status != "done" || s1.Data != `{"v":2}` || !s1.UpdatedAt.Equal(now.Add(2*time.Second)) {
+		t.Errorf("Expected s1 to be replaced, got %+v", s1)
+	}
+
+	active, err := db.LoadStrategies("active")
+	if err != nil {
+		t.Fatalf("LoadStrategies by status failed: %v", err)
+	}
+	if len(active) != 1 || active[0].ID != "s2" || active[0].Kind != "trailing" || active[0].Symbol != "ETH-USD" {
+		t.Errorf("Expected only s2 active, got %+v", active)
+	}
+}
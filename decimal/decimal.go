@@ -0,0 +1,566 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package decimal provides Decimal, a fixed-point number for prices and sizes.
+//
+// A Decimal is a scaled int64: Units / 10^Scale. Parsing keeps the scale the
+// value was written with, so "49999.00" stays at scale 2 and "0.00000001" at
+// scale 8. Prime quotes each product at its own increment, which makes the
+// scale effectively per symbol without a lookup table, and lets a value go
+// back to the wire or to storage exactly as it arrived.
+//
+// Values of different scales compare and add exactly. Multiplication and
+// division go through a 128-bit intermediate and round half away from zero to
+// the scale the caller asks for. Nothing in this package allocates except
+// String and the encoding methods that must return a new string or slice.
+package decimal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+)
+
+// MaxScale is the largest number of fractional digits a Decimal can hold.
+const MaxScale = 18
+
+var (
+	// ErrSyntax is returned for text that is not a plain decimal number.
+	ErrSyntax = errors.New("decimal: invalid syntax")
+	// ErrRange is returned when a result does not fit in an int64 at the
+	// requested scale, or the scale exceeds MaxScale.
+	ErrRange = errors.New("decimal: value out of range")
+	// ErrDivByZero is returned by Div for a zero divisor.
+	ErrDivByZero = errors.New("decimal: division by zero")
+)
+
+// pow10 holds 10^0 through 10^19, the largest power that fits in a uint64.
+var pow10 = [20]uint64{
+	1, 10, 100, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+	1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19,
+}
+
+// Decimal is a fixed-point number. The zero value is 0 at scale 0.
+type Decimal struct {
+	units int64
+	scale uint8
+}
+
+// New returns units / 10^scale. scale above MaxScale is clamped.
+func New(units int64, scale uint8) Decimal {
+	return Decimal{units: units, scale: min(scale, MaxScale)}
+}
+
+// Parse reads a plain decimal such as "50000", "-0.5" or "0.00000001". A
+// leading sign is allowed; exponents, spaces and separators are not.
+func Parse(s string) (Decimal, error) {
+	i := 0
+	neg := false
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		neg = s[i] == '-'
+		i++
+	}
+
+	var units uint64
+	var scale uint8
+	digits, dot := 0, false
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '.' {
+			if dot {
+				return Decimal{}, ErrSyntax
+			}
+			dot = true
+			continue
+		}
+		if c < '0' || c > '9' {
+			return Decimal{}, ErrSyntax
+		}
+		if dot {
+			if scale == MaxScale {
+				return Decimal{}, ErrRange
+			}
+			scale++
+		}
+		hi, lo := bits.Mul64(units, 10)
+		lo, carry := bits.Add64(lo, uint64(c-'0'), 0)
+		if hi != 0 || carry != 0 || lo > math.MaxInt64 {
+			return Decimal{}, ErrRange
+		}
+		units = lo
+		digits++
+	}
+	if digits == 0 {
+		return Decimal{}, ErrSyntax
+	}
+
+	d := Decimal{units: int64(units), scale: scale}
+	if neg {
+		d.units = -d.units
+	}
+	return d, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for constants and
+// tests.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(fmt.Sprintf("decimal.MustParse(%q): %v", s, err))
+	}
+	return d
+}
+
+// Units returns the scaled integer value.
+func (d Decimal) Units() int64 { return d.units }
+
+// Scale returns the number of fractional digits.
+func (d Decimal) Scale() uint8 { return d.scale }
+
+// IsZero reports whether d is zero at any scale.
+func (d Decimal) IsZero() bool { return d.units == 0 }
+
+// Sign returns -1, 0 or 1.
+func (d Decimal) Sign() int {
+	switch {
+	case d.units < 0:
+		return -1
+	case d.units > 0:
+		return 1
+	}
+	return 0
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{units: -d.units, scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	if d.units < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Cmp compares d and e exactly, returning -1, 0 or 1.
+func (d Decimal) Cmp(e Decimal) int {
+	if d.scale == e.scale || d.Sign() != e.Sign() || d.IsZero() {
+		return cmpInt64(d.units, e.units)
+	}
+	// Same sign: compare magnitudes at the common scale in 128 bits
+	hi1, lo1 := scaleUp(abs(d.units), max(d.scale, e.scale)-d.scale)
+	hi2, lo2 := scaleUp(abs(e.units), max(d.scale, e.scale)-e.scale)
+	c := cmp128(hi1, lo1, hi2, lo2)
+	if d.units < 0 {
+		return -c
+	}
+	return c
+}
+
+// Equal reports whether d and e are the same number, e.g. 1.50 and 1.5.
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Add returns d + e at the larger of their scales.
+func (d Decimal) Add(e Decimal) (Decimal, error) {
+	scale := max(d.scale, e.scale)
+	a, err := d.Rescale(scale)
+	if err != nil {
+		return Decimal{}, err
+	}
+	b, err := e.Rescale(scale)
+	if err != nil {
+		return Decimal{}, err
+	}
+	sum := a.units + b.units
+	if (sum > a.units) != (b.units > 0) {
+		return Decimal{}, ErrRange
+	}
+	return Decimal{units: sum, scale: scale}, nil
+}
+
+// Sub returns d - e at the larger of their scales.
+func (d Decimal) Sub(e Decimal) (Decimal, error) {
+	if e.units == math.MinInt64 {
+		return Decimal{}, ErrRange
+	}
+	return d.Add(e.Neg())
+}
+
+// Rescale returns d at scale without losing digits: a larger scale pads with
+// zeros, and a smaller one fails with ErrRange unless the dropped digits are
+// zero. Use Round to drop digits.
+func (d Decimal) Rescale(scale uint8) (Decimal, error) {
+	if scale > MaxScale {
+		return Decimal{}, ErrRange
+	}
+	if scale >= d.scale {
+		return fromUnsigned(d.units < 0, 0, abs(d.units), scale, scale-d.scale)
+	}
+	p := pow10[d.scale-scale]
+	if abs(d.units)%p != 0 {
+		return Decimal{}, ErrRange
+	}
+	return Decimal{units: d.units / int64(p), scale: scale}, nil
+}
+
+// Round returns d rounded half away from zero to scale. A scale at or above
+// d's pads with zeros like Rescale.
+func (d Decimal) Round(scale uint8) (Decimal, error) {
+	if scale > MaxScale {
+		return Decimal{}, ErrRange
+	}
+	if scale >= d.scale {
+		return d.Rescale(scale)
+	}
+	q := divRound(0, abs(d.units), pow10[d.scale-scale])
+	return fromUnsigned(d.units < 0, 0, q, scale, 0)
+}
+
+// Mul returns d × e rounded to scale.
+func (d Decimal) Mul(e Decimal, scale uint8) (Decimal, error) {
+	if scale > MaxScale {
+		return Decimal{}, ErrRange
+	}
+	neg := (d.units < 0) != (e.units < 0)
+	hi, lo := bits.Mul64(abs(d.units), abs(e.units))
+	productScale := d.scale + e.scale
+	if scale >= productScale {
+		return fromUnsigned(neg, hi, lo, scale, scale-productScale)
+	}
+	p := pow10[productScale-scale]
+	if hi >= p {
+		return Decimal{}, ErrRange
+	}
+	return fromUnsigned(neg, 0, divRound(hi, lo, p), scale, 0)
+}
+
+// Div returns d ÷ e rounded to scale.
+func (d Decimal) Div(e Decimal, scale uint8) (Decimal, error) {
+	if e.units == 0 {
+		return Decimal{}, ErrDivByZero
+	}
+	if scale > MaxScale {
+		return Decimal{}, ErrRange
+	}
+	neg := (d.units < 0) != (e.units < 0)
+	num, den := abs(d.units), abs(e.units)
+
+	// d/e = (num × 10^(scale + e.scale - d.scale)) / den at scale
+	var hi, lo uint64
+	if exp := int(scale) + int(e.scale) - int(d.scale); exp >= 0 {
+		hi, lo = scaleUp(num, uint8(exp))
+	} else {
+		h, l := bits.Mul64(den, pow10[-exp])
+		if h != 0 {
+			return Decimal{}, ErrRange
+		}
+		lo, den = num, l
+	}
+	if hi >= den {
+		return Decimal{}, ErrRange
+	}
+	return fromUnsigned(neg, 0, divRound(hi, lo, den), scale, 0)
+}
+
+// Float64 returns the nearest float64. Use it only for display.
+func (d Decimal) Float64() float64 {
+	if d.scale == 0 {
+		return float64(d.units)
+	}
+	return float64(d.units) / float64(pow10[d.scale])
+}
+
+// String formats d with exactly Scale fractional digits, e.g. "49999.00".
+func (d Decimal) String() string {
+	var buf [24]byte
+	return string(d.AppendText(buf[:0]))
+}
+
+// AppendText appends the String form of d to b.
+func (d Decimal) AppendText(b []byte) []byte {
+	if d.units < 0 {
+		b = append(b, '-')
+	}
+	var digits [20]byte
+	n := strconv.AppendUint(digits[:0], abs(d.units), 10)
+	if int(d.scale) < len(n) {
+		intLen := len(n) - int(d.scale)
+		b = append(b, n[:intLen]...)
+		if d.scale > 0 {
+			b = append(b, '.')
+			b = append(b, n[intLen:]...)
+		}
+		return b
+	}
+	b = append(b, '0', '.')
+	for range int(d.scale) - len(n) {
+		b = append(b, '0')
+	}
+	return append(b, n...)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return d.AppendText(nil), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Besides the string MarshalText
+// produces it accepts a plain JSON number, such as a limit in a config file,
+// without going through float64.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+	}
+	return d.UnmarshalText(data)
+}
+
+// Value implements driver.Valuer. Decimals are stored as TEXT in their String
+// form, so SQLite keeps every digit.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner for TEXT columns. Integers are accepted as is;
+// floats, from rows written before a column became TEXT, are converted
+// through their shortest decimal form.
+func (d *Decimal) Scan(src any) error {
+	var err error
+	switch v := src.(type) {
+	case string:
+		*d, err = Parse(v)
+	case []byte:
+		*d, err = Parse(string(v))
+	case int64:
+		*d = Decimal{units: v}
+	case float64:
+		*d, err = Parse(strconv.FormatFloat(v, 'f', -1, 64))
+	case nil:
+		*d = Decimal{}
+	default:
+		err = fmt.Errorf("decimal: cannot scan %T", src)
+	}
+	return err
+}
+
+// Notional returns px × qty rounded to scale, the quote-currency value of a
+// quantity at a price.
+func Notional(px, qty Decimal, scale uint8) (Decimal, error) {
+	return px.Mul(qty, scale)
+}
+
+// VWAP accumulates executions and returns their volume-weighted average
+// price. Sums are kept exactly in 128 bits, at the largest scale seen. The
+// zero value is ready to use.
+type VWAP struct {
+	notionalHi, notionalLo uint64 // Σ px × qty at notionalScale
+	qty                    uint64 // Σ qty at qtyScale
+	notionalScale          uint8
+	qtyScale               uint8
+}
+
+// Add records qty at px. Both must be non-negative.
+func (v *VWAP) Add(px, qty Decimal) error {
+	if px.units < 0 || qty.units < 0 {
+		return ErrRange
+	}
+	if qty.scale > v.qtyScale {
+		hi, lo := scaleUp(v.qty, qty.scale-v.qtyScale)
+		if hi != 0 {
+			return ErrRange
+		}
+		v.qty, v.qtyScale = lo, qty.scale
+	}
+	if s := px.scale + qty.scale; s > v.notionalScale {
+		hi, lo, ok := mul128(v.notionalHi, v.notionalLo, s-v.notionalScale)
+		if !ok {
+			return ErrRange
+		}
+		v.notionalHi, v.notionalLo, v.notionalScale = hi, lo, s
+	}
+
+	qHi, q := scaleUp(uint64(qty.units), v.qtyScale-qty.scale)
+	sum, carry := bits.Add64(v.qty, q, 0)
+	if qHi != 0 || carry != 0 {
+		return ErrRange
+	}
+	hi, lo := bits.Mul64(uint64(px.units), uint64(qty.units))
+	hi, lo, ok := mul128(hi, lo, v.notionalScale-px.scale-qty.scale)
+	if !ok {
+		return ErrRange
+	}
+	nlo, c := bits.Add64(v.notionalLo, lo, 0)
+	nhi, c := bits.Add64(v.notionalHi, hi, c)
+	if c != 0 {
+		return ErrRange
+	}
+	v.qty, v.notionalHi, v.notionalLo = sum, nhi, nlo
+	return nil
+}
+
+// Qty returns the total quantity added.
+func (v *VWAP) Qty() Decimal {
+	return Decimal{units: int64(v.qty), scale: v.qtyScale}
+}
+
+// Notional returns the total px × qty rounded to scale.
+func (v *VWAP) Notional(scale uint8) (Decimal, error) {
+	if scale > MaxScale {
+		return Decimal{}, ErrRange
+	}
+	if scale >= v.notionalScale {
+		return fromUnsigned(false, v.notionalHi, v.notionalLo, scale, scale-v.notionalScale)
+	}
+	p := pow10[v.notionalScale-scale]
+	if v.notionalHi >= p {
+		return Decimal{}, ErrRange
+	}
+	return fromUnsigned(false, 0, divRound(v.notionalHi, v.notionalLo, p), scale, 0)
+}
+
+// Price returns the average price rounded to scale, or ErrDivByZero before
+// any quantity has been added.
+func (v *VWAP) Price(scale uint8) (Decimal, error) {
+	if v.qty == 0 {
+		return Decimal{}, ErrDivByZero
+	}
+	if scale > MaxScale {
+		return Decimal{}, ErrRange
+	}
+	// Σ(px × qty) / Σqty is at notionalScale - qtyScale; shift to scale
+	hi, lo, den := v.notionalHi, v.notionalLo, v.qty
+	if exp := int(scale) + int(v.qtyScale) - int(v.notionalScale); exp >= 0 {
+		var ok bool
+		if hi, lo, ok = mul128(hi, lo, uint8(exp)); !ok {
+			return Decimal{}, ErrRange
+		}
+	} else {
+		h, l := bits.Mul64(den, pow10[-exp])
+		if h != 0 {
+			return Decimal{}, ErrRange
+		}
+		den = l
+	}
+	if hi >= den {
+		return Decimal{}, ErrRange
+	}
+	return fromUnsigned(false, 0, divRound(hi, lo, den), scale, 0)
+}
+
+// --- 128-bit helpers ---
+
+func abs(x int64) uint64 {
+	if x < 0 {
+		return uint64(-x) // MinInt64 wraps to its own magnitude, 1<<63
+	}
+	return uint64(x)
+}
+
+func cmpInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func cmp128(hi1, lo1, hi2, lo2 uint64) int {
+	switch {
+	case hi1 != hi2:
+		return cmpUint64(hi1, hi2)
+	default:
+		return cmpUint64(lo1, lo2)
+	}
+}
+
+func cmpUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// scaleUp returns x × 10^n as a 128-bit value. n is at most MaxScale, so the
+// result always fits.
+func scaleUp(x uint64, n uint8) (hi, lo uint64) {
+	return bits.Mul64(x, pow10[n])
+}
+
+// mul128 returns (hi, lo) × 10^n, or false on overflow.
+func mul128(hi, lo uint64, n uint8) (uint64, uint64, bool) {
+	if n == 0 {
+		return hi, lo, true
+	}
+	if n >= uint8(len(pow10)) {
+		return 0, 0, false
+	}
+	p := pow10[n]
+	h1, l1 := bits.Mul64(lo, p)
+	h2, l2 := bits.Mul64(hi, p)
+	h, c := bits.Add64(h1, l2, 0)
+	if h2 != 0 || c != 0 {
+		return 0, 0, false
+	}
+	return h, l1, true
+}
+
+// divRound returns (hi, lo) ÷ d rounded half away from zero. The caller
+// ensures hi < d so the quotient fits in 64 bits.
+func divRound(hi, lo, d uint64) uint64 {
+	q, r := bits.Div64(hi, lo, d)
+	if r >= d-r {
+		q++
+	}
+	return q
+}
+
+// fromUnsigned builds a Decimal at scale from the magnitude (hi, lo) × 10^n,
+// or fails with ErrRange if it does not fit in an int64.
+func fromUnsigned(neg bool, hi, lo uint64, scale, n uint8) (Decimal, error) {
+	hi, lo, ok := mul128(hi, lo, n)
+	if !ok || hi != 0 || lo > 1<<63 || (lo == 1<<63 && !neg) {
+		return Decimal{}, ErrRange
+	}
+	units := int64(lo)
+	if neg {
+		units = -units
+	}
+	return Decimal{units: units, scale: scale}, nil
+}
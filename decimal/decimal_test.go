@@ -0,0 +1,243 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestParse_KeepsScaleAndRoundTrips verifies that parsing keeps the number of
+// fractional digits and that String gives back the same text.
+func TestParse_KeepsScaleAndRoundTrips(t *testing.T) {
+	tests := []struct {
+		in    string
+		units int64
+		scale uint8
+		out   string
+	}{
+		{"50000", 50000, 0, "50000"},
+		{"49999.00", 4999900, 2, "49999.00"},
+		{"0.00000001", 1, 8, "0.00000001"},
+		{"-1.5", -15, 1, "-1.5"},
+		{"+2.25", 225, 2, "2.25"},
+		{".5", 5, 1, "0.5"},
+		{"7.", 7, 0, "7"},
+		{"9223372036854775807", math.MaxInt64, 0, "9223372036854775807"},
+		{"92233720368.54775807", math.MaxInt64, 8, "92233720368.54775807"},
+	}
+	for _, tt := range tests {
+		d, err := Parse(tt.in)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.in, err)
+		}
+		if d.Units() != tt.units || d.Scale() != tt.scale {
+			t.Errorf("Parse(%q) = %d@%d, want %d@%d", tt.in, d.Units(), d.Scale(), tt.units, tt.scale)
+		}
+		if got := d.String(); got != tt.out {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.out)
+		}
+	}
+}
+
+// TestParse_RejectsInvalidInput verifies syntax and range errors.
+func TestParse_RejectsInvalidInput(t *testing.T) {
+	for _, in := range []string{"", "-", ".", "1.2.3", "1e5", " 1", "1,000", "abc"} {
+		if _, err := Parse(in); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) error = %v, want ErrSyntax", in, err)
+		}
+	}
+	for _, in := range []string{"9223372036854775808", "0.0000000000000000001"} {
+		if _, err := Parse(in); !errors.Is(err, ErrRange) {
+			t.Errorf("Parse(%q) error = %v, want ErrRange", in, err)
+		}
+	}
+}
+
+// TestParse_DoesNotAllocate verifies that the market data parser can call
+// Parse on the hot path.
+func TestParse_DoesNotAllocate(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = Parse("49999.12345678")
+	})
+	if allocs != 0 {
+		t.Errorf("Parse allocated %v times, want 0", allocs)
+	}
+}
+
+// TestDecimal_CmpAcrossScales verifies exact comparison of values written
+// with different precision.
+func TestDecimal_CmpAcrossScales(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.50", "1.5", 0},
+		{"0.00000001", "0", 1},
+		{"-0.1", "-0.10000000000000001", 1},
+		{"92233720368.54775807", "92233720368.547758", 1},
+		{"-3", "2.5", -1},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.a).Cmp(MustParse(tt.b)); got != tt.want {
+			t.Errorf("Cmp(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestDecimal_AddSub verifies that sums are exact where float64 is not, and
+// that overflow is reported.
+func TestDecimal_AddSub(t *testing.T) {
+	sum := MustParse("0")
+	for range 10 {
+		var err error
+		if sum, err = sum.Add(MustParse("0.1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sum.String() != "1.0" {
+		t.Errorf("ten 0.1s = %s, want 1.0", sum)
+	}
+
+	diff, err := MustParse("50001.00").Sub(MustParse("49999.995"))
+	if err != nil || diff.String() != "1.005" {
+		t.Errorf("Sub = %s, %v; want 1.005", diff, err)
+	}
+	if neg := diff.Neg(); neg.String() != "-1.005" || neg.Abs() != diff {
+		t.Errorf("Neg = %s, Abs = %s; want -1.005 and 1.005", neg, neg.Abs())
+	}
+
+	if _, err := New(math.MaxInt64, 0).Add(New(1, 0)); !errors.Is(err, ErrRange) {
+		t.Errorf("overflowing Add error = %v, want ErrRange", err)
+	}
+}
+
+// TestDecimal_RescaleAndRound verifies that Rescale never drops digits and
+// Round rounds half away from zero.
+func TestDecimal_RescaleAndRound(t *testing.T) {
+	if d, err := MustParse("1.5").Rescale(4); err != nil || d.String() != "1.5000" {
+		t.Errorf("Rescale up = %s, %v", d, err)
+	}
+	if d, err := MustParse("1.500").Rescale(1); err != nil || d.String() != "1.5" {
+		t.Errorf("Rescale down = %s, %v", d, err)
+	}
+	if _, err := MustParse("1.55").Rescale(1); !errors.Is(err, ErrRange) {
+		t.Errorf("lossy Rescale error = %v, want ErrRange", err)
+	}
+
+	for in, want := range map[string]string{"1.25": "1.3", "-1.25": "-1.3", "1.24": "1.2", "0.05": "0.1"} {
+		if d, err := MustParse(in).Round(1); err != nil || d.String() != want {
+			t.Errorf("Round(%s) = %s, %v; want %s", in, d, err, want)
+		}
+	}
+}
+
+// TestDecimal_MulDiv verifies products and quotients that need more than
+// 64 bits in between.
+func TestDecimal_MulDiv(t *testing.T) {
+	// 92233720368.54775807 × 1000.00000000 overflows int64 before rescaling
+	px, qty := MustParse("92233720.36854775"), MustParse("1000.00000000")
+	n, err := Notional(px, qty, 2)
+	if err != nil || n.String() != "92233720368.55" {
+		t.Errorf("Notional = %s, %v; want 92233720368.55", n, err)
+	}
+
+	n, err = Notional(MustParse("50000.01"), MustParse("0.00000001"), 8)
+	if err != nil || n.String() != "0.00050000" {
+		t.Errorf("Notional = %s, %v; want 0.00050000", n, err)
+	}
+
+	q, err := MustParse("10").Div(MustParse("3"), 4)
+	if err != nil || q.String() != "3.3333" {
+		t.Errorf("10/3 = %s, %v; want 3.3333", q, err)
+	}
+	q, err = MustParse("-2").Div(MustParse("3"), 2)
+	if err != nil || q.String() != "-0.67" {
+		t.Errorf("-2/3 = %s, %v; want -0.67", q, err)
+	}
+	q, err = MustParse("1.23456").Div(MustParse("0.001"), 0)
+	if err != nil || q.String() != "1235" {
+		t.Errorf("1.23456/0.001 = %s, %v; want 1235", q, err)
+	}
+	if _, err := MustParse("1").Div(Decimal{}, 2); !errors.Is(err, ErrDivByZero) {
+		t.Errorf("Div by zero error = %v, want ErrDivByZero", err)
+	}
+}
+
+// TestVWAP_AveragesExactly verifies the volume-weighted average over fills
+// of different precision.
+func TestVWAP_AveragesExactly(t *testing.T) {
+	var v VWAP
+	if _, err := v.Price(2); !errors.Is(err, ErrDivByZero) {
+		t.Errorf("empty VWAP error = %v, want ErrDivByZero", err)
+	}
+
+	fills := [][2]string{{"50000.00", "0.5"}, {"50010.5", "0.25"}, {"49990", "0.00000001"}}
+	for _, f := range fills {
+		if err := v.Add(MustParse(f[0]), MustParse(f[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := v.Qty().String(); got != "0.75000001" {
+		t.Errorf("Qty = %s, want 0.75000001", got)
+	}
+	if n, err := v.Notional(2); err != nil || n.String() != "37502.63" {
+		t.Errorf("Notional = %s, %v; want 37502.63", n, err)
+	}
+	// 37502.6254999 / 0.75000001 = 50003.49999982...
+	if p, err := v.Price(4); err != nil || p.String() != "50003.5000" {
+		t.Errorf("Price = %s, %v; want 50003.5000", p, err)
+	}
+}
+
+// TestDecimal_JSONAndSQL verifies that Decimals encode as strings, decode
+// from strings or JSON numbers, and scan from every type SQLite can return.
+func TestDecimal_JSONAndSQL(t *testing.T) {
+	data, err := json.Marshal(struct{ Px Decimal }{MustParse("0.00000001")})
+	if err != nil || string(data) != `{"Px":"0.00000001"}` {
+		t.Fatalf("Marshal = %s, %v", data, err)
+	}
+	var decoded struct{ Px Decimal }
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Px != MustParse("0.00000001") {
+		t.Fatalf("Unmarshal = %v, %v", decoded.Px, err)
+	}
+
+	var limits struct{ Max, Pct Decimal }
+	if err := json.Unmarshal([]byte(`{"Max": 100000.50, "Pct": "0.25"}`), &limits); err != nil ||
+		limits.Max.String() != "100000.50" || limits.Pct.String() != "0.25" {
+		t.Fatalf("Unmarshal number = %v, %v, %v", limits.Max, limits.Pct, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Max": 1e5}`), &limits); err == nil {
+		t.Error("expected an exponent to be rejected")
+	}
+
+	if v, _ := MustParse("1.10").Value(); v != "1.10" {
+		t.Errorf("Value = %v, want 1.10", v)
+	}
+	for src, want := range map[any]string{"2.50": "2.50", int64(3): "3", 0.1: "0.1"} {
+		var d Decimal
+		if err := d.Scan(src); err != nil || d.String() != want {
+			t.Errorf("Scan(%v) = %s, %v; want %s", src, d, err, want)
+		}
+	}
+	var d Decimal
+	if err := d.Scan([]byte("7.25")); err != nil || d.String() != "7.25" {
+		t.Errorf("Scan([]byte) = %s, %v", d, err)
+	}
+}
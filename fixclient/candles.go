@@ -0,0 +1,478 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
+)
+
+const (
+	// DefaultCandleLateness is how long a bar stays open after its interval
+	// ends, for trades delivered out of MdEntryTime order.
+	DefaultCandleLateness = 2 * time.Second
+
+	// candleHistory is the number of closed bars kept per symbol and interval,
+	// for display and for amending bars hit by late trades.
+	candleHistory = 120
+
+	// candleFlushInterval is how often Start checks for bars due to close
+	// when no trades arrive.
+	candleFlushInterval = 250 * time.Millisecond
+)
+
+// DefaultCandleIntervals are the bar sizes built by default.
+var DefaultCandleIntervals = []time.Duration{time.Second, time.Minute, 5 * time.Minute, time.Hour}
+
+// Candle is an OHLCV bar built from trades whose MdEntryTime falls in
+// [Start, Start+Interval).
+type Candle struct {
+	Symbol   string          `json:"symbol"`
+	Interval time.Duration   `json:"interval"`
+	Start    time.Time       `json:"start"`
+	Open     decimal.Decimal `json:"open"`
+	High     decimal.Decimal `json:"high"`
+	Low      decimal.Decimal `json:"low"`
+	Close    decimal.Decimal `json:"close"`
+	Volume   decimal.Decimal `json:"volume"`
+	VWAP     decimal.Decimal `json:"vwap"` // At the finest price increment traded
+	Trades   int             `json:"trades"`
+	Closed   bool            `json:"closed"`            // Emitted; false while the interval is open
+	Amended  bool            `json:"amended,omitempty"` // Changed by a trade after it was emitted
+
+	vwap       decimal.VWAP
+	priceScale uint8
+	openTime   time.Time // Entry time of the trade that set Open
+	closeTime  time.Time // Entry time of the trade that set Close
+}
+
+// End returns the end of the bar's interval.
+func (c *Candle) End() time.Time {
+	return c.Start.Add(c.Interval)
+}
+
+// add includes a trade at px for qty, made at entryTime, in the bar. Open and
+// Close follow entry time rather than arrival, so a late trade only changes
+// them if it is the earliest or latest in the bar.
+func (c *Candle) add(px, qty decimal.Decimal, entryTime time.Time) error {
+	if err := c.vwap.Add(px, qty); err != nil {
+		return err
+	}
+	if c.Trades == 0 || entryTime.Before(c.openTime) {
+		c.Open, c.openTime = px, entryTime
+	}
+	if c.Trades == 0 || !entryTime.Before(c.closeTime) {
+		c.Close, c.closeTime = px, entryTime
+	}
+	if c.Trades == 0 || px.Cmp(c.High) > 0 {
+		c.High = px
+	}
+	if c.Trades == 0 || px.Cmp(c.Low) < 0 {
+		c.Low = px
+	}
+	c.Trades++
+	c.priceScale = max(c.priceScale, px.Scale())
+	c.Volume = c.vwap.Qty()
+	c.VWAP, _ = c.vwap.Price(c.priceScale)
+	return nil
+}
+
+// CandleStats counts bars and trades handled by a CandleAggregator.
+type CandleStats struct {
+	Emitted     int64 // Bars emitted on close
+	Amended     int64 // Closed bars emitted again after a late trade
+	LateDropped int64 // Trades too late for any bar still held
+}
+
+// candleKey identifies one series of bars.
+type candleKey struct {
+	symbol   string
+	interval time.Duration
+}
+
+// candleSeries holds one symbol's bars of one interval, each list sorted by
+// Start.
+type candleSeries struct {
+	open   []*Candle // Not yet emitted, usually one
+	closed []Candle  // Last candleHistory emitted bars
+}
+
+// CandleAggregator builds OHLCV bars of several intervals per symbol from
+// live trades and hands each bar to emit when its interval closes.
+//
+// Bars are keyed by MdEntryTime (273), falling back to receipt time. A bar
+// closes once the latest entry time seen for its symbol, or the wall clock
+// while Start is running, passes its end by the lateness window. A trade for
+// a bar that has already closed amends it and the bar is emitted again, as
+// long as it is among the last candleHistory bars; older trades are dropped.
+//
+// emit is called without the aggregator's lock, from the goroutine that
+// closed the bar. Candles passed to it are copies.
+type CandleAggregator struct {
+	mu        sync.Mutex
+	intervals []time.Duration
+	lateness  time.Duration
+	series    map[candleKey]*candleSeries
+	watermark map[string]time.Time // Latest entry time per symbol
+	emit      func([]Candle)
+
+	emitted     atomic.Int64
+	amended     atomic.Int64
+	lateDropped atomic.Int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewCandleAggregator creates an aggregator for intervals, which must be
+// whole seconds.
+func NewCandleAggregator(intervals []time.Duration, lateness time.Duration, emit func([]Candle)) (*CandleAggregator, error) {
+	if len(intervals) == 0 {
+		return nil, fmt.Errorf("no candle intervals")
+	}
+	for _, interval := range intervals {
+		if interval < time.Second || interval%time.Second != 0 {
+			return nil, fmt.Errorf("candle interval %s must be a whole number of seconds", interval)
+		}
+	}
+	if emit == nil {
+		emit = func([]Candle) {}
+	}
+	return &CandleAggregator{
+		intervals: slices.Clone(intervals),
+		lateness:  lateness,
+		series:    make(map[candleKey]*candleSeries),
+		watermark: make(map[string]time.Time),
+		emit:      emit,
+	}, nil
+}
+
+// EnableCandles sets Candles to an aggregator for intervals whose closed bars
+// are written to the candles table and published on Bus, when set. Call
+// Candles.Start for live sessions.
+func (a *FixApp) EnableCandles(intervals []time.Duration) error {
+	candles, err := NewCandleAggregator(intervals, DefaultCandleLateness, a.emitCandles)
+	if err != nil {
+		return err
+	}
+	a.Candles = candles
+	return nil
+}
+
+// emitCandles stores and publishes bars handed over by Candles.
+func (a *FixApp) emitCandles(candles []Candle) {
+	a.storeCandlesToDatabase(candles)
+	if a.Bus != nil {
+		a.Bus.publishCandles(candles)
+	}
+}
+
+// ParseCandleIntervals parses a comma-separated list such as "1s,1m,5m,1h".
+// An empty string yields no intervals.
+func ParseCandleIntervals(s string) ([]time.Duration, error) {
+	var intervals []time.Duration
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		interval, err := time.ParseDuration(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid candle interval %q", field)
+		}
+		if !slices.Contains(intervals, interval) {
+			intervals = append(intervals, interval)
+		}
+	}
+	slices.Sort(intervals)
+	return intervals, nil
+}
+
+// Intervals returns the bar sizes being built, shortest first.
+func (ca *CandleAggregator) Intervals() []time.Duration {
+	return slices.Clone(ca.intervals)
+}
+
+// AddTrades adds the trade entries (EntryType 2) among entries to the bars
+// of every interval, then emits the bars their entry times have closed.
+func (ca *CandleAggregator) AddTrades(entries []Trade) {
+	var emitted []Candle
+	var symbols []string
+
+	ca.mu.Lock()
+	for i := range entries {
+		trade := &entries[i]
+		if trade.EntryType != constants.MdEntryTypeTrade || trade.Symbol == "" {
+			continue
+		}
+		px, err := trade.PriceDecimal()
+		if err != nil || px.Sign() <= 0 {
+			continue
+		}
+		qty, err := trade.SizeDecimal()
+		if err != nil || qty.Sign() <= 0 {
+			continue
+		}
+
+		if !slices.Contains(symbols, trade.Symbol) {
+			symbols = append(symbols, trade.Symbol)
+		}
+		entryTime := mdEntryTime(trade)
+		if entryTime.After(ca.watermark[trade.Symbol]) {
+			ca.watermark[trade.Symbol] = entryTime
+		}
+		for _, interval := range ca.intervals {
+			if bar := ca.addToBar(trade.Symbol, interval, px, qty, entryTime); bar != nil {
+				emitted = append(emitted, *bar)
+			}
+		}
+	}
+	for _, symbol := range symbols {
+		emitted = ca.closeDue(symbol, ca.watermark[symbol], emitted)
+	}
+	ca.mu.Unlock()
+
+	ca.publish(emitted)
+}
+
+// addToBar adds one trade to its bar of interval. It returns the bar when a
+// closed one was amended and must be emitted again. Caller holds ca.mu.
+func (ca *CandleAggregator) addToBar(symbol string, interval time.Duration, px, qty decimal.Decimal, entryTime time.Time) *Candle {
+	key := candleKey{symbol, interval}
+	series := ca.series[key]
+	if series == nil {
+		series = &candleSeries{}
+		ca.series[key] = series
+	}
+	start := entryTime.Truncate(interval)
+
+	// Still open: the common case
+	for _, bar := range series.open {
+		if bar.Start.Equal(start) {
+			_ = bar.add(px, qty, entryTime)
+			return nil
+		}
+	}
+
+	// Late: amend a bar already emitted, or emit one for an interval that
+	// closed without trades
+	idx, found := slices.BinarySearchFunc(series.closed, start, compareCandleStart)
+	due := !start.Add(interval+ca.lateness).After(ca.watermark[symbol]) ||
+		(len(series.closed) > 0 && start.Before(series.closed[len(series.closed)-1].Start))
+	if found || due {
+		if !found && idx == 0 && len(series.closed) >= candleHistory {
+			ca.lateDropped.Add(1)
+			return nil
+		}
+		if !found {
+			series.closed = slices.Insert(series.closed, idx, Candle{Symbol: symbol, Interval: interval, Start: start, Closed: true})
+		}
+		bar := &series.closed[idx]
+		if bar.add(px, qty, entryTime) != nil {
+			return nil
+		}
+		bar.Amended = found
+		if found {
+			ca.amended.Add(1)
+		} else {
+			ca.emitted.Add(1)
+		}
+		amended := *bar
+		trimCandles(series)
+		return &amended
+	}
+
+	bar := &Candle{Symbol: symbol, Interval: interval, Start: start}
+	_ = bar.add(px, qty, entryTime)
+	idx, _ = slices.BinarySearchFunc(series.open, start, func(c *Candle, t time.Time) int {
+		return c.Start.Compare(t)
+	})
+	series.open = slices.Insert(series.open, idx, bar)
+	return nil
+}
+
+// closeDue moves symbol's open bars that end at least the lateness window
+// before now to their closed history and appends them to emitted. Caller
+// holds ca.mu.
+func (ca *CandleAggregator) closeDue(symbol string, now time.Time, emitted []Candle) []Candle {
+	for _, interval := range ca.intervals {
+		series := ca.series[candleKey{symbol, interval}]
+		if series == nil {
+			continue
+		}
+		n := 0
+		for n < len(series.open) && !series.open[n].End().Add(ca.lateness).After(now) {
+			bar := series.open[n]
+			bar.Closed = true
+			idx, _ := slices.BinarySearchFunc(series.closed, bar.Start, compareCandleStart)
+			series.closed = slices.Insert(series.closed, idx, *bar)
+			emitted = append(emitted, *bar)
+			ca.emitted.Add(1)
+			n++
+		}
+		if n > 0 {
+			series.open = slices.Delete(series.open, 0, n)
+			trimCandles(series)
+		}
+	}
+	return emitted
+}
+
+func compareCandleStart(c Candle, start time.Time) int {
+	return c.Start.Compare(start)
+}
+
+func trimCandles(series *candleSeries) {
+	if extra := len(series.closed) - candleHistory; extra > 0 {
+		series.closed = slices.Delete(series.closed, 0, extra)
+	}
+}
+
+// CloseDue emits every bar whose interval ended at least the lateness window
+// before now, whether or not a later trade has arrived.
+func (ca *CandleAggregator) CloseDue(now time.Time) {
+	ca.mu.Lock()
+	var emitted []Candle
+	for symbol := range ca.watermark {
+		emitted = ca.closeDue(symbol, now, emitted)
+	}
+	ca.mu.Unlock()
+	ca.publish(emitted)
+}
+
+// Flush emits every open bar, e.g. at the end of a replay when no more
+// trades will arrive.
+func (ca *CandleAggregator) Flush() {
+	ca.CloseDue(time.Unix(1<<62, 0))
+}
+
+// Start closes bars on the wall clock every candleFlushInterval, so the last
+// bar before a quiet period is emitted without waiting for the next trade.
+// Call Stop to end it; bars still open at Stop are not emitted.
+func (ca *CandleAggregator) Start() {
+	ca.stop = make(chan struct{})
+	ca.done = make(chan struct{})
+	go func() {
+		defer close(ca.done)
+		ticker := time.NewTicker(candleFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ca.stop:
+				return
+			case now := <-ticker.C:
+				ca.CloseDue(now)
+			}
+		}
+	}()
+}
+
+// Stop ends the goroutine started by Start and waits for it to exit.
+func (ca *CandleAggregator) Stop() {
+	if ca.stop == nil {
+		return
+	}
+	close(ca.stop)
+	<-ca.done
+	ca.stop = nil
+}
+
+// Recent returns up to n of symbol's most recent bars of interval, oldest
+// first, including the bars still open.
+func (ca *CandleAggregator) Recent(symbol string, interval time.Duration, n int) []Candle {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	series := ca.series[candleKey{symbol, interval}]
+	if series == nil || n <= 0 {
+		return nil
+	}
+	result := slices.Clone(series.closed)
+	for _, bar := range series.open {
+		result = append(result, *bar)
+	}
+	slices.SortStableFunc(result, func(a, b Candle) int { return a.Start.Compare(b.Start) })
+	if len(result) > n {
+		result = result[len(result)-n:]
+	}
+	return result
+}
+
+// Stats returns the aggregator's counters.
+func (ca *CandleAggregator) Stats() CandleStats {
+	return CandleStats{
+		Emitted:     ca.emitted.Load(),
+		Amended:     ca.amended.Load(),
+		LateDropped: ca.lateDropped.Load(),
+	}
+}
+
+func (ca *CandleAggregator) publish(candles []Candle) {
+	if len(candles) > 0 {
+		ca.emit(candles)
+	}
+}
+
+// mdEntryTimeLayouts are the MdEntryTime (273) formats accepted, with or
+// without fractional seconds.
+var mdEntryTimeLayouts = []string{"20060102-15:04:05.999999999", time.RFC3339Nano}
+
+// mdEntryTime returns when trade was made: its MdEntryTime as a full UTC
+// timestamp, or as a time of day on the receipt date (the previous day when
+// that would put it well after receipt, i.e. across midnight). Without a
+// usable MdEntryTime the receipt time is used.
+func mdEntryTime(trade *Trade) time.Time {
+	received := trade.Timestamp
+	if received.IsZero() {
+		received = time.Now()
+	}
+	if trade.Time == "" {
+		return received
+	}
+	for _, layout := range mdEntryTimeLayouts {
+		if t, err := time.Parse(layout, trade.Time); err == nil {
+			return t
+		}
+	}
+	if clock, err := time.Parse("15:04:05.999999999", trade.Time); err == nil {
+		day := received.UTC().Truncate(24 * time.Hour)
+		t := day.Add(clock.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)))
+		if t.Sub(received) > 12*time.Hour {
+			t = t.Add(-24 * time.Hour)
+		}
+		return t
+	}
+	return received
+}
+
+// candleIntervalName formats an interval the way it is stored, e.g. "5m".
+func candleIntervalName(interval time.Duration) string {
+	switch {
+	case interval%time.Hour == 0:
+		return fmt.Sprintf("%dh", interval/time.Hour)
+	case interval%time.Minute == 0:
+		return fmt.Sprintf("%dm", interval/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", interval/time.Second)
+	}
+}
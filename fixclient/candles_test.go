@@ -0,0 +1,224 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/database"
+)
+
+// candleTrade returns a trade entry for symbol made at entryTime.
+func candleTrade(symbol, price, size, entryTime string) Trade {
+	return Trade{Symbol: symbol, EntryType: "2", Price: price, Size: size, Time: entryTime}
+}
+
+// newTestCandles returns an aggregator of intervals and a function returning
+// every bar it has emitted so far.
+func newTestCandles(t *testing.T, intervals ...time.Duration) (*CandleAggregator, func() []Candle) {
+	t.Helper()
+	var mu sync.Mutex
+	var emitted []Candle
+	ca, err := NewCandleAggregator(intervals, 2*time.Second, func(candles []Candle) {
+		mu.Lock()
+		defer mu.Unlock()
+		emitted = append(emitted, candles...)
+	})
+	if err != nil {
+		t.Fatalf("NewCandleAggregator failed: %v", err)
+	}
+	return ca, func() []Candle {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]Candle(nil), emitted...)
+	}
+}
+
+// TestCandleAggregator_EmitsBarWhenIntervalCloses verifies OHLCV, trade count
+// and VWAP of a bar, and that it is only emitted once a trade's entry time
+// passes its end by the lateness window.
+func TestCandleAggregator_EmitsBarWhenIntervalCloses(t *testing.T) {
+	ca, emitted := newTestCandles(t, time.Second, time.Minute)
+
+	ca.AddTrades([]Trade{
+		candleTrade("BTC-USD", "50000.00", "0.5", "20250101-12:00:00.100"),
+		candleTrade("BTC-USD", "50010.00", "0.25", "20250101-12:00:00.400"),
+		candleTrade("BTC-USD", "49990.00", "0.25", "20250101-12:00:00.900"),
+		{Symbol: "BTC-USD", EntryType: "0", Price: "1", Size: "1", Time: "20250101-12:00:00.950"},
+	})
+	ca.AddTrades([]Trade{candleTrade("BTC-USD", "50001.00", "1", "20250101-12:00:02.500")})
+	if got := emitted(); len(got) != 0 {
+		t.Fatalf("expected no bar within the lateness window, got %+v", got)
+	}
+
+	ca.AddTrades([]Trade{candleTrade("BTC-USD", "50002.00", "1", "20250101-12:00:03.000")})
+	got := emitted()
+	if len(got) != 1 {
+		t.Fatalf("expected the 12:00:00 1s bar, got %+v", got)
+	}
+	bar := got[0]
+	if bar.Interval != time.Second || !bar.Start.Equal(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)) || !bar.Closed {
+		t.Errorf("unexpected bar %s %s closed=%v", bar.Start, bar.Interval, bar.Closed)
+	}
+	if bar.Open.String() != "50000.00" || bar.High.String() != "50010.00" || bar.Low.String() != "49990.00" ||
+		bar.Close.String() != "49990.00" || bar.Volume.String() != "1.00" || bar.Trades != 3 {
+		t.Errorf("unexpected OHLCV %s/%s/%s/%s %s x%d", bar.Open, bar.High, bar.Low, bar.Close, bar.Volume, bar.Trades)
+	}
+	// (25000 + 12502.5 + 12497.5) / 1
+	if bar.VWAP.String() != "50000.00" {
+		t.Errorf("expected VWAP 50000.00, got %s", bar.VWAP)
+	}
+
+	recent := ca.Recent("BTC-USD", time.Minute, 10)
+	if len(recent) != 1 || recent[0].Closed || recent[0].Trades != 5 {
+		t.Errorf("expected one open 1m bar with 5 trades, got %+v", recent)
+	}
+}
+
+// TestCandleAggregator_LateTradesUseEntryTime verifies that trades delivered
+// out of order land in the bar of their MdEntryTime: within the lateness
+// window they set Open by time rather than arrival, and after the bar closed
+// they amend it and it is emitted again.
+func TestCandleAggregator_LateTradesUseEntryTime(t *testing.T) {
+	ca, emitted := newTestCandles(t, time.Second)
+
+	ca.AddTrades([]Trade{candleTrade("BTC-USD", "101", "1", "20250101-12:00:00.500")})
+	ca.AddTrades([]Trade{candleTrade("BTC-USD", "102", "1", "20250101-12:00:01.200")})
+	ca.AddTrades([]Trade{candleTrade("BTC-USD", "100", "1", "20250101-12:00:00.100")}) // late, still open
+	ca.AddTrades([]Trade{candleTrade("BTC-USD", "103", "1", "20250101-12:00:04.000")})
+
+	got := emitted()
+	if len(got) != 2 {
+		t.Fatalf("expected the 12:00:00 and 12:00:01 bars, got %+v", got)
+	}
+	if bar := got[0]; bar.Open.String() != "100" || bar.Close.String() != "101" || bar.Trades != 2 {
+		t.Errorf("expected open 100 and close 101 by entry time, got %+v", bar)
+	}
+
+	// After close: the bar is amended and emitted again
+	ca.AddTrades([]Trade{candleTrade("BTC-USD", "99", "2", "20250101-12:00:00.900")})
+	got = emitted()
+	if len(got) != 3 {
+		t.Fatalf("expected the amended bar to be emitted, got %+v", got)
+	}
+	amended := got[2]
+	if !amended.Amended || !amended.Start.Equal(got[0].Start) || amended.Close.String() != "99" ||
+		amended.Low.String() != "99" || amended.Volume.String() != "4" || amended.Trades != 3 {
+		t.Errorf("unexpected amended bar %+v", amended)
+	}
+	if stats := ca.Stats(); stats.Emitted != 2 || stats.Amended != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// An interval that closed without trades gets its own bar
+	ca.AddTrades([]Trade{candleTrade("BTC-USD", "104", "1", "20250101-12:00:05.000")})
+	ca.AddTrades([]Trade{candleTrade("BTC-USD", "98", "1", "20250101-12:00:02.300")})
+	if got := emitted(); len(got) != 4 || got[3].Amended || got[3].Start.Second() != 2 {
+		t.Errorf("expected a new 12:00:02 bar, got %+v", got)
+	}
+	recent := ca.Recent("BTC-USD", time.Second, 10)
+	if len(recent) != 5 || recent[2].Start.Second() != 2 || recent[3].Closed || recent[4].Closed {
+		t.Errorf("expected bars in start order with 12:00:04 and 12:00:05 open, got %+v", recent)
+	}
+}
+
+// TestCandleAggregator_CloseDueAndFlush verifies that bars close on the wall
+// clock without another trade, and that Flush emits whatever is still open.
+func TestCandleAggregator_CloseDueAndFlush(t *testing.T) {
+	ca, emitted := newTestCandles(t, time.Second, time.Minute)
+	ca.AddTrades([]Trade{candleTrade("ETH-USD", "3000.5", "2", "20250101-12:00:00.000")})
+
+	ca.CloseDue(time.Date(2025, 1, 1, 12, 0, 2, 999, time.UTC))
+	if got := emitted(); len(got) != 0 {
+		t.Fatalf("expected nothing before the lateness window ends, got %+v", got)
+	}
+	ca.CloseDue(time.Date(2025, 1, 1, 12, 0, 3, 0, time.UTC))
+	if got := emitted(); len(got) != 1 || got[0].Interval != time.Second {
+		t.Fatalf("expected the 1s bar, got %+v", got)
+	}
+
+	// A trade delayed past the wall-clock close amends the bar instead of
+	// opening a second one
+	ca.AddTrades([]Trade{candleTrade("ETH-USD", "3001", "1", "20250101-12:00:00.500")})
+	if got := emitted(); len(got) != 2 || !got[1].Amended || got[1].Trades != 2 {
+		t.Fatalf("expected the 1s bar to be amended, got %+v", got)
+	}
+
+	ca.Flush()
+	if got := emitted(); len(got) != 3 || got[2].Interval != time.Minute || got[2].Trades != 2 {
+		t.Errorf("expected Flush to emit the open 1m bar, got %+v", got)
+	}
+}
+
+// TestMdEntryTime_Formats verifies the MdEntryTime formats accepted and the
+// fallback to receipt time.
+func TestMdEntryTime_Formats(t *testing.T) {
+	received := time.Date(2025, 1, 2, 0, 0, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"20250101-12:00:00.123", time.Date(2025, 1, 1, 12, 0, 0, 123e6, time.UTC)},
+		{"20250101-12:00:00", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"2025-01-01T12:00:00.5Z", time.Date(2025, 1, 1, 12, 0, 0, 5e8, time.UTC)},
+		{"00:00:04.250", time.Date(2025, 1, 2, 0, 0, 4, 25e7, time.UTC)},
+		{"23:59:59.000", time.Date(2025, 1, 1, 23, 59, 59, 0, time.UTC)}, // Before midnight
+		{"", received},
+		{"garbage", received},
+	}
+	for _, tt := range tests {
+		trade := Trade{Time: tt.value, Timestamp: received}
+		if got := mdEntryTime(&trade); !got.Equal(tt.want) {
+			t.Errorf("mdEntryTime(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestHandleMarketDataMessage_StoresCandles verifies that bars built from
+// incremental trades are written to the candles table when they close.
+func TestHandleMarketDataMessage_StoresCandles(t *testing.T) {
+	db, err := database.NewMarketDataDb(filepath.Join(t.TempDir(), "candles.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	app := NewFixApp(&Config{}, db)
+	app.Headless = true
+	if err := app.EnableCandles([]time.Duration{time.Second}); err != nil {
+		t.Fatalf("EnableCandles failed: %v", err)
+	}
+
+	header := "35=X\x0149=COIN\x0156=CLIENT\x0152=20250101-12:00:05.000\x01262=req-1\x0155=BTC-USD\x01268=1\x01279=0\x01269=2\x01"
+	app.handleMarketDataMessage(parseFixMessage(t, header+"34=2\x01270=50000.00\x01271=0.00000001\x01273=20250101-12:00:00.100\x01"))
+	app.handleMarketDataMessage(parseFixMessage(t, header+"34=3\x01270=50001.00\x01271=0.1\x01273=20250101-12:00:03.000\x01"))
+
+	candles, err := db.LoadCandles("BTC-USD", "1s", time.Time{})
+	if err != nil {
+		t.Fatalf("LoadCandles failed: %v", err)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("expected 1 stored bar, got %+v", candles)
+	}
+	if c := candles[0]; c.Open.String() != "50000.00" || c.Volume.String() != "0.00000001" || c.TradeCount != 1 ||
+		!c.Start.Equal(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected stored bar %+v", c)
+	}
+}
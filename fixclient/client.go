@@ -0,0 +1,470 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fixclient provides a Go API for embedding the client in a service.
+//
+// Client wraps a FixApp that runs silently: nothing is printed (Observer is
+// nil) and market data is published on a MarketDataBus. Its requests return
+// typed results and errors, and wait for Prime's answer:
+//
+//	Subscribe   ──V──▶  first Snapshot (W) / Incremental (X)  or  Market Data Request Reject (Y)
+//	PlaceOrder  ──D──▶  first Execution Report (8) past Pending New, Rejected included
+//	CancelOrder ──F──▶  Execution Report (8) past Pending Cancel  or  Order Cancel Reject (9)
+//
+// A Business Message Reject (j) whose BusinessRejectRefID names the request
+// also answers it. The wait is bounded by the caller's context; a request
+// that times out has still been sent.
+package fixclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"prime-fix-md-go/builder"
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/database"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+var (
+	// ErrNotLoggedOn is returned for requests made while the FIX session is
+	// down.
+	ErrNotLoggedOn = errors.New("FIX session is not logged on")
+
+	// ErrLogonRejected is returned by WaitForLogon when Prime refused the
+	// logon and the client stopped reconnecting.
+	ErrLogonRejected = errors.New("FIX logon rejected")
+
+	// ErrUnknownSubscription is returned for an MdReqId that is not a
+	// tracked subscription.
+	ErrUnknownSubscription = errors.New("no such market data subscription")
+
+	// ErrUnknownOrder is returned for an order the OrderStore does not hold.
+	ErrUnknownOrder = errors.New("no such order")
+
+	// ErrOrderNotOpen is returned when cancelling an order that is already
+	// filled, cancelled, rejected or otherwise done.
+	ErrOrderNotOpen = errors.New("order is not open")
+)
+
+// lastOrderId holds the last nanosecond timestamp used for an order ClOrdID.
+var lastOrderId atomic.Int64
+
+// OrderRejectedError is returned when Prime rejects an order or a cancel,
+// with an Execution Report, an Order Cancel Reject or a Business Message
+// Reject.
+type OrderRejectedError struct {
+	ClOrdID string
+	Reason  string // Description of the reject reason
+	Text    string
+}
+
+func (e *OrderRejectedError) Error() string {
+	msg := fmt.Sprintf("order %s rejected: %s", e.ClOrdID, e.Reason)
+	if e.Text != "" {
+		msg += " (" + e.Text + ")"
+	}
+	return msg
+}
+
+// MarketDataRejectedError is returned when Prime rejects a market data
+// request.
+type MarketDataRejectedError struct {
+	Reject MarketDataReject
+}
+
+func (e *MarketDataRejectedError) Error() string {
+	msg := fmt.Sprintf("market data request %s rejected: %s", e.Reject.MdReqId, e.Reject.ReasonDesc)
+	if e.Reject.Text != "" {
+		msg += " (" + e.Reject.Text + ")"
+	}
+	return msg
+}
+
+// SubscribeRequest describes a Market Data Request (V).
+type SubscribeRequest struct {
+	Symbols    []string `json:"symbols"`
+	Snapshot   bool     `json:"snapshot,omitempty"`   // One snapshot instead of a snapshot and live updates
+	Depth      int      `json:"depth,omitempty"`      // Book levels per side; 0 for the full book
+	EntryTypes []string `json:"entryTypes,omitempty"` // MdEntryType values (constants.MdEntryType*); defaults to bids and offers
+}
+
+// Client is the programmatic API to a Prime FIX session. A Client can also
+// wrap a FixApp whose session is run elsewhere, e.g. alongside the REPL, as
+// &Client{App: app}; Start and Stop are then not used.
+type Client struct {
+	App *FixApp
+
+	initiator *quickfix.Initiator
+}
+
+// NewClient creates a silent FixApp for config, with a market data bus. db
+// may be nil to keep everything in memory.
+func NewClient(config *Config, db *database.MarketDataDb) *Client {
+	app := NewFixApp(config, db)
+	app.Headless = true
+	app.Observer = nil
+	app.Bus = NewMarketDataBus()
+	return &Client{App: app}
+}
+
+// Bus returns the bus to register market data handlers on.
+func (c *Client) Bus() *MarketDataBus {
+	return c.App.Bus
+}
+
+// Start connects to Prime with settings, e.g. from utils.LoadSettings. The
+// FIX session log is discarded. Use WaitForLogon before sending requests.
+func (c *Client) Start(settings *quickfix.Settings) error {
+	storeFactory, err := NewMessageStoreFactory(settings, c.App.Db)
+	if err != nil {
+		return fmt.Errorf("message store: %w", err)
+	}
+	initiator, err := quickfix.NewInitiator(c.App, storeFactory, settings, quickfix.NewNullLogFactory())
+	if err != nil {
+		return fmt.Errorf("initiator: %w", err)
+	}
+	if err := initiator.Start(); err != nil {
+		return fmt.Errorf("starting initiator: %w", err)
+	}
+	c.initiator = initiator
+	return nil
+}
+
+// Stop logs out, disconnects and closes the bus once its handlers have
+// drained.
+func (c *Client) Stop() {
+	if c.initiator != nil {
+		c.initiator.Stop()
+		c.initiator = nil
+	}
+	if c.App.Bus != nil {
+		c.App.Bus.Close()
+	}
+}
+
+// WaitForLogon blocks until the session is logged on.
+func (c *Client) WaitForLogon(ctx context.Context) error {
+	ticker := time.NewTicker(logonPollInterval)
+	defer ticker.Stop()
+	for {
+		if c.App.IsLoggedOn() {
+			return nil
+		}
+		if c.App.ShouldExit() {
+			return ErrLogonRejected
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Subscribe sends a market data request and waits for its first data or its
+// reject. With several symbols the first answer for any of them counts.
+// Live subscriptions are resent after a reconnect until Unsubscribe.
+func (c *Client) Subscribe(ctx context.Context, req SubscribeRequest) (*Subscription, error) {
+	if len(req.Symbols) == 0 {
+		return nil, errors.New("no symbols to subscribe to")
+	}
+	if req.Depth < 0 {
+		return nil, fmt.Errorf("invalid depth %d", req.Depth)
+	}
+	if !c.App.IsLoggedOn() {
+		return nil, ErrNotLoggedOn
+	}
+
+	subscriptionType := constants.SubscriptionRequestTypeSubscribe
+	if req.Snapshot {
+		subscriptionType = constants.SubscriptionRequestTypeSnapshot
+	}
+	entryTypes := req.EntryTypes
+	if len(entryTypes) == 0 {
+		entryTypes = []string{constants.MdEntryTypeBid, constants.MdEntryTypeOffer}
+	}
+	marketDepth := strconv.Itoa(req.Depth)
+
+	reqId := newMdReqId()
+	reply := c.App.replies.add(reqId)
+	if err := c.App.requestMarketData(reqId, req.Symbols, subscriptionType, marketDepth, entryTypes); err != nil {
+		c.App.replies.remove(reqId)
+		return nil, fmt.Errorf("sending market data request: %w", err)
+	}
+	if err := c.App.replies.wait(ctx, reqId, reply); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("market data request %s sent, no data yet: %w", reqId, err)
+		}
+		return nil, err
+	}
+
+	if sub, exists := c.App.TradeStore.GetSubscriptionStatus()[reqId]; exists {
+		return sub, nil
+	}
+	return &Subscription{
+		LastUpdate:       time.Now(),
+		Symbols:          req.Symbols,
+		EntryTypes:       entryTypes,
+		Symbol:           req.Symbols[0],
+		SubscriptionType: subscriptionType,
+		MdReqId:          reqId,
+		OriginalMdReqId:  reqId,
+		MarketDepth:      marketDepth,
+		SnapshotReceived: true,
+	}, nil
+}
+
+// Unsubscribe ends the live subscription with mdReqId. Prime does not
+// acknowledge unsubscribes.
+func (c *Client) Unsubscribe(mdReqId string) error {
+	if !c.App.IsLoggedOn() {
+		return ErrNotLoggedOn
+	}
+	_, err := c.App.unsubscribe(mdReqId)
+	return err
+}
+
+// PlaceOrder runs the pre-trade risk checks, sends params as a New Order
+// Single and waits for Prime to acknowledge or reject it. Empty ClOrdID and
+// Account are filled in, OrdType defaults to limit with a price and market
+// without, and TimeInForce to GTC. The order is returned as tracked when the
+// answer arrived, also when it was rejected (*OrderRejectedError) or the
+// wait timed out. A risk check failure is a *RiskRejection and sends nothing.
+func (c *Client) PlaceOrder(ctx context.Context, params builder.NewOrderParams) (*Order, error) {
+	if !c.App.IsLoggedOn() {
+		return nil, ErrNotLoggedOn
+	}
+	if params.ClOrdID == "" {
+		params.ClOrdID = newClOrdID()
+	}
+	if params.Account == "" {
+		params.Account = c.App.Config.PortfolioId
+	}
+	if params.OrdType == "" {
+		params.OrdType = constants.OrdTypeMarket
+		if params.Price != "" {
+			params.OrdType = constants.OrdTypeLimit
+		}
+	}
+	if params.TimeInForce == "" {
+		params.TimeInForce = constants.TimeInForceGTC
+	}
+
+	reply := c.App.replies.add(params.ClOrdID)
+	if err := c.App.submitOrder(params); err != nil {
+		c.App.replies.remove(params.ClOrdID)
+		return nil, err
+	}
+	err := c.App.replies.wait(ctx, params.ClOrdID, reply)
+	order := c.App.OrderStore.GetOrder(params.ClOrdID)
+	if err != nil && ctx.Err() != nil {
+		return order, fmt.Errorf("order %s sent, not acknowledged: %w", params.ClOrdID, err)
+	}
+	return order, err
+}
+
+// CancelOrder cancels the open order id (any ClOrdID in its cancel/replace
+// chain, or its OrderID) and waits for Prime to confirm or reject the cancel.
+// The order is returned as tracked when the answer arrived.
+func (c *Client) CancelOrder(ctx context.Context, id string) (*Order, error) {
+	if !c.App.IsLoggedOn() {
+		return nil, ErrNotLoggedOn
+	}
+	order := c.App.OrderStore.ResolveOrder(id)
+	if order == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOrder, id)
+	}
+	if !isOpenStatus(order.OrdStatus) {
+		return order, fmt.Errorf("%w: %s is %s", ErrOrderNotOpen, order.ClOrdID, getOrdStatusDesc(order.OrdStatus))
+	}
+
+	cancelId := newCancelClOrdID()
+	reply := c.App.replies.add(cancelId)
+	if err := c.App.sendCancelAs(cancelId, order); err != nil {
+		c.App.replies.remove(cancelId)
+		return order, fmt.Errorf("sending cancel: %w", err)
+	}
+	err := c.App.replies.wait(ctx, cancelId, reply)
+	if current := c.App.OrderStore.ResolveOrder(order.ClOrdID); current != nil {
+		order = current
+	}
+	if err != nil && ctx.Err() != nil {
+		return order, fmt.Errorf("cancel %s sent, not confirmed: %w", cancelId, err)
+	}
+	return order, err
+}
+
+// submitOrder runs the pre-trade risk checks on params and sends them as a
+// New Order Single (D). The order is tracked before it is sent so that
+// execution reports for it always find it.
+func (a *FixApp) submitOrder(params builder.NewOrderParams) error {
+	if err := a.RiskChecks.Check(params); err != nil {
+		return err
+	}
+
+	order := &Order{
+		ClOrdID:        params.ClOrdID,
+		Symbol:         params.Symbol,
+		Side:           params.Side,
+		OrdType:        params.OrdType,
+		OrderQty:       params.OrderQty,
+		CashOrderQty:   params.CashOrderQty,
+		Price:          params.Price,
+		StopPx:         params.StopPx,
+		TargetStrategy: params.TargetStrategy,
+		TimeInForce:    params.TimeInForce,
+		OrdStatus:      constants.OrdStatusPendingNew,
+		Account:        params.Account,
+	}
+	a.OrderStore.AddOrder(order)
+
+	msg := builder.BuildNewOrderSingle(params, a.Config.SenderCompId, a.Config.TargetCompId)
+	if err := quickfix.SendToTarget(msg, a.SessionId); err != nil {
+		a.OrderStore.RemoveOrder(params.ClOrdID)
+		return fmt.Errorf("sending order: %w", err)
+	}
+	return nil
+}
+
+// resolveOrderReply answers a PlaceOrder or CancelOrder waiting on er's
+// ClOrdID. Pending reports are not an answer.
+func (a *FixApp) resolveOrderReply(er *ExecutionReport) {
+	switch er.ExecType {
+	case constants.ExecTypePendingNew, constants.ExecTypePendingCancel:
+		return
+	case constants.ExecTypeRejected:
+	default:
+		if er.OrdStatus != constants.OrdStatusRejected {
+			a.replies.resolve(er.ClOrdID, nil)
+			return
+		}
+	}
+	a.replies.resolve(er.ClOrdID, &OrderRejectedError{
+		ClOrdID: er.ClOrdID,
+		Reason:  getOrdRejReasonDesc(er.OrdRejReason),
+		Text:    er.Text,
+	})
+}
+
+// resolveBusinessReject answers the request a Business Message Reject (j)
+// refers to by BusinessRejectRefID.
+func (a *FixApp) resolveBusinessReject(reject *BusinessReject) {
+	if reject.BusinessRejectRefID == "" {
+		return
+	}
+	reason := "business reject: " + getBusinessRejectReasonDesc(reject.BusinessRejectReason)
+	if reject.RefMsgType == constants.MsgTypeMarketDataRequest {
+		a.replies.resolve(reject.BusinessRejectRefID, &MarketDataRejectedError{Reject: MarketDataReject{
+			MdReqId:    reject.BusinessRejectRefID,
+			Symbol:     a.TradeStore.SubscriptionSymbol(reject.BusinessRejectRefID),
+			Reason:     reject.BusinessRejectReason,
+			ReasonDesc: reason,
+			Text:       reject.Text,
+			Time:       time.Now(),
+		}})
+		return
+	}
+	a.replies.resolve(reject.BusinessRejectRefID, &OrderRejectedError{
+		ClOrdID: reject.BusinessRejectRefID,
+		Reason:  reason,
+		Text:    reject.Text,
+	})
+}
+
+// cancelRejectReason describes an Order Cancel Reject (9).
+func cancelRejectReason(reject *OrderCancelReject) string {
+	reason := "cancel rejected"
+	if reject.CxlRejResponseTo == constants.CxlRejResponseToReplace {
+		reason = "replace rejected"
+	}
+	if reject.CxlRejReason != "" {
+		reason += " (CxlRejReason " + reject.CxlRejReason + ")"
+	}
+	return reason
+}
+
+// newClOrdID returns a unique ClOrdID for a new order.
+func newClOrdID() string {
+	return "ord_" + strconv.FormatInt(nextNano(&lastOrderId), 10)
+}
+
+// pendingReplies holds the Client calls waiting for Prime to answer a
+// request, keyed by ClOrdID or MdReqId. The first answer resolves a call;
+// later ones are ignored.
+type pendingReplies struct {
+	mu      sync.Mutex
+	waiters map[string]chan error
+	count   atomic.Int32 // len(waiters), so resolve skips the lock when idle
+}
+
+// add registers a waiter for id. Call it before the request is sent.
+func (p *pendingReplies) add(id string) <-chan error {
+	reply := make(chan error, 1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.waiters == nil {
+		p.waiters = make(map[string]chan error)
+	}
+	p.waiters[id] = reply
+	p.count.Store(int32(len(p.waiters)))
+	return reply
+}
+
+// remove drops the waiter for id, e.g. when its request could not be sent.
+func (p *pendingReplies) remove(id string) {
+	p.take(id)
+}
+
+// resolve answers the waiter for id, if any.
+// HOT PATH: called for every market data message; one atomic load when no
+// request is waiting.
+func (p *pendingReplies) resolve(id string, err error) {
+	if id == "" || p.count.Load() == 0 {
+		return
+	}
+	if reply := p.take(id); reply != nil {
+		reply <- err
+	}
+}
+
+// wait blocks until the waiter for id is answered or ctx is done.
+func (p *pendingReplies) wait(ctx context.Context, id string, reply <-chan error) error {
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		p.remove(id)
+		return ctx.Err()
+	}
+}
+
+func (p *pendingReplies) take(id string) chan error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	reply, exists := p.waiters[id]
+	if exists {
+		delete(p.waiters, id)
+		p.count.Store(int32(len(p.waiters)))
+	}
+	return reply
+}
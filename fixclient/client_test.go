@@ -0,0 +1,87 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/constants"
+)
+
+// TestPendingReplies_FirstAnswerWins verifies that a waiter gets the first
+// answer for its ID, that later answers and other IDs are ignored, and that
+// a timed-out waiter is dropped.
+func TestPendingReplies_FirstAnswerWins(t *testing.T) {
+	var p pendingReplies
+	p.resolve("ord-1", nil) // Nothing waiting
+
+	reply := p.add("ord-1")
+	p.resolve("ord-2", errors.New("other order"))
+	p.resolve("ord-1", nil)
+	p.resolve("ord-1", errors.New("late"))
+	if err := p.wait(context.Background(), "ord-1", reply); err != nil {
+		t.Errorf("expected the first answer, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	reply = p.add("ord-3")
+	if err := p.wait(ctx, "ord-3", reply); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if p.count.Load() != 0 || len(p.waiters) != 0 {
+		t.Errorf("expected no waiters left, got %d", len(p.waiters))
+	}
+}
+
+// TestFixApp_ResolveOrderReply verifies that pending reports do not answer
+// an order, that the first other report does, and that rejects, including
+// Business Message Rejects, become typed errors.
+func TestFixApp_ResolveOrderReply(t *testing.T) {
+	app := &FixApp{Config: &Config{}, OrderStore: NewOrderStore(), TradeStore: NewTradeStore(10, ""), Headless: true}
+
+	acked := app.replies.add("ord-1")
+	app.resolveOrderReply(&ExecutionReport{ClOrdID: "ord-1", ExecType: constants.ExecTypePendingNew, OrdStatus: constants.OrdStatusPendingNew})
+	select {
+	case err := <-acked:
+		t.Fatalf("expected Pending New not to answer, got %v", err)
+	default:
+	}
+	app.resolveOrderReply(&ExecutionReport{ClOrdID: "ord-1", ExecType: constants.ExecTypeNew, OrdStatus: constants.OrdStatusNew})
+	if err := <-acked; err != nil {
+		t.Errorf("expected New to acknowledge the order, got %v", err)
+	}
+
+	rejected := app.replies.add("ord-2")
+	app.resolveOrderReply(&ExecutionReport{ClOrdID: "ord-2", ExecType: constants.ExecTypeRejected,
+		OrdStatus: constants.OrdStatusRejected, OrdRejReason: constants.OrdRejReasonUnknownSymbol, Text: "no such product"})
+	var orderErr *OrderRejectedError
+	if err := <-rejected; !errors.As(err, &orderErr) || orderErr.ClOrdID != "ord-2" || orderErr.Text != "no such product" {
+		t.Errorf("expected an OrderRejectedError for ord-2, got %v", err)
+	}
+
+	rejected = app.replies.add("md-1")
+	app.resolveBusinessReject(&BusinessReject{RefMsgType: constants.MsgTypeMarketDataRequest,
+		BusinessRejectRefID: "md-1", BusinessRejectReason: constants.BusinessRejectReasonNotAuthorized})
+	var mdErr *MarketDataRejectedError
+	if err := <-rejected; !errors.As(err, &mdErr) || mdErr.Reject.MdReqId != "md-1" {
+		t.Errorf("expected a MarketDataRejectedError for md-1, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"prime-fix-md-go/constants"
+)
+
+// logonPollInterval is how often RunDaemon checks for the initial logon.
+const logonPollInterval = 100 * time.Millisecond
+
+// unsubscribeGracePeriod gives queued unsubscribes time to reach the wire
+// before the caller stops the initiator.
+const unsubscribeGracePeriod = 500 * time.Millisecond
+
+// DaemonConfig lists the market data requests a headless recorder sends once
+// the session is logged on.
+//
+// Example:
+//
+//	{
+//	  "subscriptions": [
+//	    {"symbols": ["BTC-USD", "ETH-USD"], "mode": "subscribe", "depth": 10},
+//	    {"symbols": ["BTC-USD"], "mode": "subscribe", "entryTypes": ["trades"]},
+//	    {"symbols": ["SOL-USD"], "mode": "snapshot", "entryTypes": ["open", "close", "high", "low", "volume"]}
+//	  ]
+//	}
+type DaemonConfig struct {
+	Subscriptions []DaemonSubscription `json:"subscriptions"`
+}
+
+// DaemonSubscription is one market data request. Mode is "snapshot" or
+// "subscribe"; Depth follows the md --depth flag (0=full book). EntryTypes
+// accepts trades, bids, offers, open, close, high, low and volume, and
+// defaults to bids and offers like the md command.
+type DaemonSubscription struct {
+	Symbols    []string `json:"symbols"`
+	Mode       string   `json:"mode"`
+	Depth      int      `json:"depth"`
+	EntryTypes []string `json:"entryTypes"`
+}
+
+// daemonEntryTypes maps config entry type names to MdEntryType values.
+var daemonEntryTypes = map[string]string{
+	"trades": constants.MdEntryTypeTrade,
+	"bids":   constants.MdEntryTypeBid,
+	"offers": constants.MdEntryTypeOffer,
+	"open":   constants.MdEntryTypeOpen,
+	"close":  constants.MdEntryTypeClose,
+	"high":   constants.MdEntryTypeHigh,
+	"low":    constants.MdEntryTypeLow,
+	"volume": constants.MdEntryTypeVolume,
+}
+
+// LoadDaemonConfig reads and validates a daemon config file.
+func LoadDaemonConfig(path string) (*DaemonConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daemon config: %v", err)
+	}
+
+	var cfg DaemonConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon config: %v", err)
+	}
+
+	if len(cfg.Subscriptions) == 0 {
+		return nil, fmt.Errorf("daemon config %s has no subscriptions", path)
+	}
+	for i, sub := range cfg.Subscriptions {
+		if _, _, _, err := sub.request(); err != nil {
+			return nil, fmt.Errorf("subscription %d: %v", i+1, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// request converts the config entry into the arguments of a market data request.
+func (s DaemonSubscription) request() (subscriptionType, marketDepth string, entryTypes []string, err error) {
+	if len(s.Symbols) == 0 {
+		return "", "", nil, fmt.Errorf("no symbols")
+	}
+
+	switch strings.ToLower(s.Mode) {
+	case "snapshot":
+		subscriptionType = constants.SubscriptionRequestTypeSnapshot
+	case "subscribe":
+		subscriptionType = constants.SubscriptionRequestTypeSubscribe
+	default:
+		return "", "", nil, fmt.Errorf("invalid mode %q (expected snapshot or subscribe)", s.Mode)
+	}
+
+	if s.Depth < 0 {
+		return "", "", nil, fmt.Errorf("invalid depth %d", s.Depth)
+	}
+	marketDepth = strconv.Itoa(s.Depth)
+
+	for _, name := range s.EntryTypes {
+		entryType, ok := daemonEntryTypes[strings.ToLower(name)]
+		if !ok {
+			return "", "", nil, fmt.Errorf("unknown entry type %q", name)
+		}
+		entryTypes = append(entryTypes, entryType)
+	}
+	if len(entryTypes) == 0 {
+		entryTypes = []string{constants.MdEntryTypeBid, constants.MdEntryTypeOffer}
+	}
+
+	return subscriptionType, marketDepth, entryTypes, nil
+}
+
+// RunDaemon runs the client without the REPL. It waits for the first logon,
+// sends every configured request and then blocks until stop fires. Subscriptions
+// are replayed automatically on reconnect. On stop, all tracked subscriptions
+// are unsubscribed; the caller is then responsible for stopping the initiator
+// and closing the database.
+func RunDaemon(app *FixApp, cfg *DaemonConfig, stop <-chan os.Signal) error {
+	ticker := time.NewTicker(logonPollInterval)
+	defer ticker.Stop()
+
+	for !app.IsLoggedOn() {
+		select {
+		case sig := <-stop:
+			log.Printf("Received %v before logon, shutting down", sig)
+			return nil
+		case <-ticker.C:
+			if app.ShouldExit() {
+				return fmt.Errorf("authentication failed, check your credentials")
+			}
+		}
+	}
+
+	for _, sub := range cfg.Subscriptions {
+		subscriptionType, marketDepth, entryTypes, _ := sub.request() // validated by LoadDaemonConfig
+		description := "Snapshot"
+		if subscriptionType == constants.SubscriptionRequestTypeSubscribe {
+			description = "Live Subscription"
+		}
+		app.sendMarketDataRequestWithOptions(sub.Symbols, subscriptionType, marketDepth, entryTypes, description)
+	}
+	log.Printf("Daemon running with %d configured request(s)", len(cfg.Subscriptions))
+
+	for {
+		select {
+		case sig := <-stop:
+			log.Printf("Received %v, unsubscribing", sig)
+			app.unsubscribeAll()
+			time.Sleep(unsubscribeGracePeriod)
+			return nil
+		case <-ticker.C:
+			if app.ShouldExit() {
+				return fmt.Errorf("authentication failed, check your credentials")
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/constants"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// Tests for headless daemon mode.
+// These tests verify config parsing/validation and that the daemon exits
+// cleanly on a shutdown signal.
+
+func writeDaemonConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "daemon.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+// TestLoadDaemonConfig_Valid verifies that a config is parsed and converted to
+// the same request arguments the md command would produce.
+func TestLoadDaemonConfig_Valid(t *testing.T) {
+	path := writeDaemonConfig(t, `{"subscriptions": [
+		{"symbols": ["BTC-USD", "ETH-USD"], "mode": "subscribe", "depth": 10},
+		{"symbols": ["SOL-USD"], "mode": "Snapshot", "entryTypes": ["trades", "volume"]}
+	]}`)
+
+	cfg, err := LoadDaemonConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Subscriptions) != 2 {
+		t.Fatalf("expected 2 subscriptions, got %d", len(cfg.Subscriptions))
+	}
+
+	subType, depth, entryTypes, _ := cfg.Subscriptions[0].request()
+	if subType != constants.SubscriptionRequestTypeSubscribe || depth != "10" {
+		t.Errorf("first request: got type=%s depth=%s", subType, depth)
+	}
+	if len(entryTypes) != 2 || entryTypes[0] != constants.MdEntryTypeBid || entryTypes[1] != constants.MdEntryTypeOffer {
+		t.Errorf("expected default bid/offer entry types, got %v", entryTypes)
+	}
+
+	subType, depth, entryTypes, _ = cfg.Subscriptions[1].request()
+	if subType != constants.SubscriptionRequestTypeSnapshot || depth != "0" {
+		t.Errorf("second request: got type=%s depth=%s", subType, depth)
+	}
+	if len(entryTypes) != 2 || entryTypes[0] != constants.MdEntryTypeTrade || entryTypes[1] != constants.MdEntryTypeVolume {
+		t.Errorf("expected trade/volume entry types, got %v", entryTypes)
+	}
+}
+
+// TestLoadDaemonConfig_Invalid verifies that bad configs fail at startup rather
+// than after the session is up.
+func TestLoadDaemonConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"malformed json", `{"subscriptions": [`},
+		{"no subscriptions", `{"subscriptions": []}`},
+		{"no symbols", `{"subscriptions": [{"mode": "subscribe"}]}`},
+		{"bad mode", `{"subscriptions": [{"symbols": ["BTC-USD"], "mode": "stream"}]}`},
+		{"negative depth", `{"subscriptions": [{"symbols": ["BTC-USD"], "mode": "subscribe", "depth": -1}]}`},
+		{"unknown entry type", `{"subscriptions": [{"symbols": ["BTC-USD"], "mode": "subscribe", "entryTypes": ["vwap"]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadDaemonConfig(writeDaemonConfig(t, tt.content)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := LoadDaemonConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+// TestRunDaemon_StopBeforeLogon verifies that a shutdown signal received while
+// still waiting for logon returns promptly without sending anything.
+func TestRunDaemon_StopBeforeLogon(t *testing.T) {
+	app := NewFixApp(&Config{}, nil)
+	cfg := &DaemonConfig{Subscriptions: []DaemonSubscription{{Symbols: []string{"BTC-USD"}, Mode: "subscribe"}}}
+
+	stop := make(chan os.Signal, 1)
+	stop <- syscall.SIGTERM
+
+	done := make(chan error, 1)
+	go func() { done <- RunDaemon(app, cfg, stop) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunDaemon did not return after stop signal")
+	}
+
+	if subs := app.TradeStore.GetSubscriptionStatus(); len(subs) != 0 {
+		t.Errorf("expected no subscriptions before logon, got %d", len(subs))
+	}
+}
+
+// TestRunDaemon_AuthFailureExits verifies that the daemon gives up when the
+// app flags an authentication failure instead of looping forever.
+func TestRunDaemon_AuthFailureExits(t *testing.T) {
+	app := NewFixApp(&Config{}, nil)
+	app.shouldExit.Store(true)
+	cfg := &DaemonConfig{Subscriptions: []DaemonSubscription{{Symbols: []string{"BTC-USD"}, Mode: "subscribe"}}}
+
+	if err := RunDaemon(app, cfg, make(chan os.Signal)); err == nil {
+		t.Error("expected error after authentication failure")
+	}
+}
+
+// TestFixApp_OnlyRefusedLogonExits verifies that a logout before the first
+// logon is treated as a refused Logon, and that drops after a session has
+// been established, including failed reconnect attempts, are not.
+func TestFixApp_OnlyRefusedLogonExits(t *testing.T) {
+	app := NewFixApp(&Config{}, nil)
+	app.Headless = true
+
+	app.OnLogout(quickfix.SessionID{})
+	if !app.ShouldExit() {
+		t.Error("expected a logout before the first logon to exit")
+	}
+	app.shouldExit.Store(false)
+
+	app.OnLogon(quickfix.SessionID{})
+	if !app.IsLoggedOn() {
+		t.Fatal("expected the app to be logged on")
+	}
+	app.OnLogout(quickfix.SessionID{})
+	if app.ShouldExit() {
+		t.Error("expected a drop after logon to wait for the reconnect")
+	}
+
+	// A reconnect attempt that fails before its logon completes
+	app.OnLogout(quickfix.SessionID{})
+	if app.ShouldExit() {
+		t.Error("expected a failed reconnect to keep retrying")
+	}
+}
@@ -0,0 +1,514 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fixclient provides an asynchronous, batched database writer.
+//
+// HOT PATH [5]: DbWriter moves SQLite persistence off the quickfix callback.
+// FromApp only pays for a channel send; a dedicated goroutine drains the queue
+// and writes many messages per transaction.
+//
+// Pipeline:
+//
+//	FromApp ──Enqueue()──▶ [bounded queue] ──▶ writer goroutine ──▶ one tx per batch
+//	                          │ full?
+//	                          ▼
+//	              block | drop-oldest | spill (JSON lines file, replayed when idle)
+//
+// Batching:
+// A transaction is committed when BatchSize messages are pending or when
+// FlushInterval elapses, whichever comes first.
+//
+// Ordering:
+// Once a message has been spilled, newer messages are spilled after it until
+// the spill file has been written, and a transaction that fails is kept in
+// the replay file ahead of everything spilled since. Messages therefore reach
+// the database in the order they were enqueued.
+//
+// Concurrency Model:
+// Enqueue is called from the FIX message handler goroutine; Stats may be called
+// from any goroutine. Counters are atomics so Stats never takes a lock.
+package fixclient
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"prime-fix-md-go/database"
+)
+
+// Overflow policies applied when the queue is full.
+const (
+	OverflowBlock      = "block"       // Back-pressure: Enqueue waits for space
+	OverflowDropOldest = "drop-oldest" // Discard the oldest queued message
+	OverflowSpill      = "spill"       // Append to SpillPath, replayed when the queue is idle
+)
+
+// DbWriterConfig controls queueing and batching for DbWriter.
+type DbWriterConfig struct {
+	QueueSize     int           // Maximum queued messages
+	BatchSize     int           // Messages per transaction
+	FlushInterval time.Duration // Maximum time a message waits before commit
+	Overflow      string        // OverflowBlock, OverflowDropOldest or OverflowSpill
+	SpillPath     string        // Spill file, required for OverflowSpill; also keeps failed transactions
+}
+
+// DefaultDbWriterConfig returns settings suitable for a single market data session.
+func DefaultDbWriterConfig() DbWriterConfig {
+	return DbWriterConfig{
+		QueueSize:     10000,
+		BatchSize:     100,
+		FlushInterval: 100 * time.Millisecond,
+		Overflow:      OverflowBlock,
+		SpillPath:     "marketdata.spill",
+	}
+}
+
+// DbWriterStats is a point-in-time view of the writer counters.
+type DbWriterStats struct {
+	QueueDepth    int
+	QueueCapacity int
+	Enqueued      int64
+	Written       int64 // Messages committed to the database
+	Dropped       int64 // Messages discarded by OverflowDropOldest
+	Spilled       int64 // Messages written to the spill file
+	Errors        int64 // Failed transactions (kept for replay when a SpillPath is set)
+}
+
+// dbBatch is one market data message worth of entries, or a set of closed
+// candles.
+type dbBatch struct {
+	Trades     []Trade  `json:"trades"`
+	SeqNum     string   `json:"seqNum"`
+	IsSnapshot bool     `json:"isSnapshot"`
+	Candles    []Candle `json:"candles,omitempty"`
+}
+
+// DbWriter persists market data on a background goroutine.
+type DbWriter struct {
+	cfg   DbWriterConfig
+	queue chan dbBatch
+	write func([]dbBatch) error // one transaction for all batches
+
+	spillMu      sync.Mutex
+	spillPending atomic.Bool
+	backlog      bool // Replay file holds messages older than the queue; writer goroutine only
+
+	enqueued atomic.Int64
+	written  atomic.Int64
+	dropped  atomic.Int64
+	spilled  atomic.Int64
+	errors   atomic.Int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewDbWriter starts a writer goroutine that persists to db. Call Close to
+// flush pending messages before closing the database.
+func NewDbWriter(db *database.MarketDataDb, cfg DbWriterConfig) (*DbWriter, error) {
+	return newDbWriter(cfg, func(batches []dbBatch) error {
+		return writeBatchesToDatabase(db, batches)
+	})
+}
+
+func newDbWriter(cfg DbWriterConfig, write func([]dbBatch) error) (*DbWriter, error) {
+	switch cfg.Overflow {
+	case OverflowBlock, OverflowDropOldest:
+	case OverflowSpill:
+		if cfg.SpillPath == "" {
+			return nil, fmt.Errorf("overflow policy %q requires a spill path", cfg.Overflow)
+		}
+	default:
+		return nil, fmt.Errorf("unknown overflow policy %q (expected %s, %s or %s)",
+			cfg.Overflow, OverflowBlock, OverflowDropOldest, OverflowSpill)
+	}
+	if cfg.QueueSize <= 0 || cfg.BatchSize <= 0 || cfg.FlushInterval <= 0 {
+		return nil, fmt.Errorf("queue size, batch size and flush interval must be positive")
+	}
+
+	w := &DbWriter{
+		cfg:   cfg,
+		queue: make(chan dbBatch, cfg.QueueSize),
+		write: write,
+		done:  make(chan struct{}),
+	}
+
+	// Pick up anything spilled, or left mid-replay, by a previous run. It is
+	// written before any new message
+	if cfg.SpillPath != "" {
+		for _, path := range []string{cfg.SpillPath, w.replayPath()} {
+			if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+				w.spillPending.Store(true)
+				w.backlog = w.backlog || path == w.replayPath()
+			}
+		}
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Enqueue hands a message's entries to the writer. The trades slice must not
+// be modified by the caller afterwards.
+// HOT PATH [5]: a channel send in the common case.
+func (w *DbWriter) Enqueue(trades []Trade, seqNum string, isSnapshot bool) {
+	if len(trades) == 0 {
+		return
+	}
+	w.enqueue(dbBatch{Trades: trades, SeqNum: seqNum, IsSnapshot: isSnapshot})
+}
+
+// EnqueueCandles hands closed bars to the writer, under the same overflow
+// policy as market data.
+func (w *DbWriter) EnqueueCandles(candles []Candle) {
+	if len(candles) == 0 {
+		return
+	}
+	w.enqueue(dbBatch{Candles: candles})
+}
+
+func (w *DbWriter) enqueue(b dbBatch) {
+	w.enqueued.Add(1)
+
+	// Newer messages follow spilled ones into the spill file, so that they
+	// are not written ahead of them
+	if w.spillPending.Load() {
+		spilled, err := w.spill(b, true)
+		if err != nil {
+			log.Printf("Failed to spill market data to %s: %v", w.cfg.SpillPath, err)
+			w.errors.Add(1)
+		}
+		if spilled {
+			return
+		}
+	}
+
+	// Fast path: space available
+	select {
+	case w.queue <- b:
+		return
+	default:
+	}
+
+	switch w.cfg.Overflow {
+	case OverflowBlock:
+		w.queue <- b
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- b:
+				return
+			default:
+			}
+			select {
+			case <-w.queue:
+				w.dropped.Add(1)
+			default:
+			}
+		}
+	case OverflowSpill:
+		if _, err := w.spill(b, false); err != nil {
+			log.Printf("Failed to spill market data to %s: %v", w.cfg.SpillPath, err)
+			w.errors.Add(1)
+		}
+	}
+}
+
+// Stats returns the current queue depth and counters.
+func (w *DbWriter) Stats() DbWriterStats {
+	return DbWriterStats{
+		QueueDepth:    len(w.queue),
+		QueueCapacity: cap(w.queue),
+		Enqueued:      w.enqueued.Load(),
+		Written:       w.written.Load(),
+		Dropped:       w.dropped.Load(),
+		Spilled:       w.spilled.Load(),
+		Errors:        w.errors.Load(),
+	}
+}
+
+// Close stops accepting messages, writes everything still queued (and any
+// spilled data) and waits for the writer goroutine to exit. Enqueue must not
+// be called after Close.
+func (w *DbWriter) Close() {
+	w.closeOnce.Do(func() {
+		close(w.queue)
+	})
+	<-w.done
+}
+
+// run is the writer goroutine: batch by size or time, replay spill when idle.
+func (w *DbWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	pending := make([]dbBatch, 0, w.cfg.BatchSize)
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if !w.commit(pending) {
+			w.keepFailed(pending)
+		}
+		clear(pending) // release trade slices for GC
+		pending = pending[:0]
+	}
+
+	for {
+		select {
+		case b, ok := <-w.queue:
+			if !ok {
+				flush()
+				w.drainSpill()
+				return
+			}
+			// Behind a failed transaction, queued messages wait in the
+			// replay file too
+			if w.backlog {
+				w.keepFailed([]dbBatch{b})
+				continue
+			}
+			pending = append(pending, b)
+			if len(pending) >= w.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			if len(w.queue) == 0 {
+				w.drainSpill()
+			}
+		}
+	}
+}
+
+// drainSpill replays the replay and spill files until both are written, or a
+// commit fails and the rest is left for a later tick.
+func (w *DbWriter) drainSpill() {
+	for w.spillPending.Load() && w.replaySpill() {
+	}
+}
+
+// commit writes batches in one transaction and reports whether it succeeded.
+func (w *DbWriter) commit(batches []dbBatch) bool {
+	if err := w.write(batches); err != nil {
+		log.Printf("Failed to write %d market data message(s) to database: %v", len(batches), err)
+		w.errors.Add(1)
+		return false
+	}
+	w.written.Add(int64(len(batches)))
+	return true
+}
+
+// spill appends a message to the spill file as one JSON line and reports
+// whether it did. With onlyPending it is appended only while earlier
+// messages are still waiting to be replayed.
+func (w *DbWriter) spill(b dbBatch, onlyPending bool) (bool, error) {
+	line, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+
+	w.spillMu.Lock()
+	defer w.spillMu.Unlock()
+
+	if onlyPending && !w.spillPending.Load() {
+		return false, nil
+	}
+	if err := appendLines(w.cfg.SpillPath, [][]byte{line}); err != nil {
+		return false, err
+	}
+
+	w.spilled.Add(1)
+	w.spillPending.Store(true)
+	return true, nil
+}
+
+// keepFailed appends batches whose transaction failed, or that are queued
+// behind one, to the replay file, which is written before anything spilled.
+// Without a SpillPath they are lost.
+func (w *DbWriter) keepFailed(batches []dbBatch) {
+	if w.cfg.SpillPath == "" {
+		return
+	}
+
+	lines := make([][]byte, 0, len(batches))
+	for _, b := range batches {
+		line, err := json.Marshal(b)
+		if err != nil {
+			log.Printf("Dropping unwritable market data message: %v", err)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := appendLines(w.replayPath(), lines); err != nil {
+		log.Printf("Failed to keep %d market data message(s) for replay: %v", len(lines), err)
+		return
+	}
+
+	w.backlog = true
+	w.spillPending.Store(true)
+}
+
+// appendLines appends each line and a newline to path.
+func appendLines(path string, lines [][]byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(f)
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return errors.Join(buf.Flush(), f.Close())
+}
+
+// replaySpill writes spilled messages to the database and reports whether
+// all of them were. A replay file left by a failed commit is written first;
+// otherwise the spill file is renamed to the replay file under the spill
+// lock, so Enqueue keeps spilling to a fresh file instead of waiting on the
+// database. The replay file is removed once every message in it is
+// committed; after a failed commit it keeps the messages not yet written, for
+// the next idle tick.
+func (w *DbWriter) replaySpill() bool {
+	if !w.spillPending.Load() {
+		return true
+	}
+	replayPath := w.replayPath()
+
+	w.spillMu.Lock()
+	if _, err := os.Stat(replayPath); os.IsNotExist(err) {
+		if err := os.Rename(w.cfg.SpillPath, replayPath); err != nil {
+			w.spillMu.Unlock()
+			if os.IsNotExist(err) {
+				w.spillPending.Store(false)
+				return true
+			}
+			log.Printf("Failed to take over spill file %s: %v", w.cfg.SpillPath, err)
+			return false
+		}
+	}
+	w.spillMu.Unlock()
+	w.backlog = true
+
+	if !w.replayFile(replayPath) {
+		return false
+	}
+	if err := os.Remove(replayPath); err != nil {
+		log.Printf("Failed to remove spill file %s: %v", replayPath, err)
+		return false
+	}
+	w.backlog = false
+
+	// Anything spilled meanwhile is replayed next
+	w.spillMu.Lock()
+	if _, err := os.Stat(w.cfg.SpillPath); os.IsNotExist(err) {
+		w.spillPending.Store(false)
+	}
+	w.spillMu.Unlock()
+	return true
+}
+
+// replayFile commits the messages in path and reports whether all of them
+// were written. On a failed commit the file is rewritten with the messages
+// from the failed batch on.
+func (w *DbWriter) replayFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true
+		}
+		log.Printf("Failed to open spill file %s: %v", path, err)
+		return false
+	}
+	defer f.Close()
+
+	batch := make([]dbBatch, 0, w.cfg.BatchSize)
+	lines := make([][]byte, 0, w.cfg.BatchSize)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) // snapshots can be large
+	for {
+		more := scanner.Scan()
+		if more {
+			var b dbBatch
+			if err := json.Unmarshal(scanner.Bytes(), &b); err != nil {
+				log.Printf("Skipping corrupt spill entry: %v", err)
+				continue
+			}
+			batch = append(batch, b)
+			lines = append(lines, bytes.Clone(scanner.Bytes()))
+			if len(batch) < w.cfg.BatchSize {
+				continue
+			}
+		}
+		if len(batch) > 0 && !w.commit(batch) {
+			w.keepUnwritten(path, lines, scanner)
+			return false
+		}
+		if !more {
+			break
+		}
+		batch, lines = batch[:0], lines[:0]
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Printf("Failed to read spill file %s: %v", path, err)
+		return false
+	}
+	return true
+}
+
+// keepUnwritten replaces path with lines followed by the rest of scanner.
+func (w *DbWriter) keepUnwritten(path string, lines [][]byte, scanner *bufio.Scanner) {
+	tmpPath := path + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		log.Printf("Failed to keep unwritten spill entries: %v", err)
+		return
+	}
+	buf := bufio.NewWriter(out)
+	for _, line := range lines {
+		buf.Write(append(line, '\n'))
+	}
+	for scanner.Scan() {
+		buf.Write(append(scanner.Bytes(), '\n'))
+	}
+	err = errors.Join(scanner.Err(), buf.Flush(), out.Close())
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		// The file as it was is still there; entries already written may be
+		// written again on the next attempt
+		log.Printf("Failed to keep unwritten spill entries: %v", err)
+		_ = os.Remove(tmpPath)
+	}
+}
+
+// replayPath is where the spill file is moved while it is being replayed.
+func (w *DbWriter) replayPath() string {
+	return w.cfg.SpillPath + ".replay"
+}
@@ -0,0 +1,109 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Benchmarks for FromApp latency with database persistence.
+// These benchmarks show that with a DbWriter the hot path cost does not depend
+// on how fast the database commits: "async/slow-disk" injects a 2ms sleep per
+// transaction yet should match "async/fast-disk".
+// Run with: go test -bench=FromApp -benchmem ./fixclient/
+package fixclient
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/database"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// parseBenchmarkMessage builds a quickfix.Message with its raw bytes populated,
+// as FromApp receives it from the session.
+func parseBenchmarkMessage(b *testing.B, numEntries int) *quickfix.Message {
+	b.Helper()
+	raw := generateFIXMessage(numEntries)
+
+	// generateFIXMessage uses a placeholder BodyLength; ParseMessage checks it
+	bodyStart := strings.Index(raw, "\x0135=") + 1
+	bodyEnd := strings.LastIndex(raw, "10=")
+	raw = "8=FIX.4.4\x019=" + strconv.Itoa(bodyEnd-bodyStart) + raw[bodyStart-1:]
+
+	msg := quickfix.NewMessage()
+	if err := quickfix.ParseMessage(msg, bytes.NewBufferString(raw)); err != nil {
+		b.Fatalf("failed to parse message: %v", err)
+	}
+	return msg
+}
+
+func newBenchmarkDb(b *testing.B) *database.MarketDataDb {
+	b.Helper()
+	db, err := database.NewMarketDataDb(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("failed to open database: %v", err)
+	}
+	b.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// BenchmarkFromApp measures FromApp for a 10-entry snapshot with each
+// persistence mode.
+func BenchmarkFromApp(b *testing.B) {
+	// Large queue and block policy so the measurement is never a drop or spill
+	cfg := DbWriterConfig{QueueSize: 1 << 20, BatchSize: 100, FlushInterval: 100 * time.Millisecond, Overflow: OverflowBlock}
+	slowDisk := func([]dbBatch) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	}
+	fastDisk := func([]dbBatch) error { return nil }
+
+	benchCases := []struct {
+		name  string
+		write func([]dbBatch) error // nil = synchronous SQLite
+	}{
+		{"async/fast-disk", fastDisk},
+		{"async/slow-disk", slowDisk},
+		{"sync/sqlite", nil},
+	}
+
+	for _, bc := range benchCases {
+		b.Run(bc.name, func(b *testing.B) {
+			db := newBenchmarkDb(b)
+			app := NewFixApp(&Config{}, db)
+			app.Headless = true
+
+			if bc.write != nil {
+				w, err := newDbWriter(cfg, bc.write)
+				if err != nil {
+					b.Fatalf("failed to create writer: %v", err)
+				}
+				defer w.Close()
+				app.DbWriter = w
+			}
+
+			msg := parseBenchmarkMessage(b, 10)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				app.FromApp(msg, app.SessionId)
+			}
+			b.StopTimer()
+		})
+	}
+}
@@ -0,0 +1,510 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/database"
+	"prime-fix-md-go/decimal"
+)
+
+// Tests for the asynchronous database writer.
+// These tests verify batching triggers, overflow policies, spill replay and
+// that Close flushes everything before returning.
+
+// recordingSink captures the batches handed to the write function.
+type recordingSink struct {
+	mu      sync.Mutex
+	calls   [][]dbBatch
+	release chan struct{} // when set, each write waits for a receive
+	err     error         // returned by write when set, without recording
+}
+
+func (s *recordingSink) write(batches []dbBatch) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.calls = append(s.calls, append([]dbBatch(nil), batches...))
+	return nil
+}
+
+func (s *recordingSink) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *recordingSink) seqNums() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for _, call := range s.calls {
+		for _, b := range call {
+			out = append(out, b.SeqNum)
+		}
+	}
+	return out
+}
+
+func (s *recordingSink) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.calls)
+}
+
+func testWriterTrades(seq int) []Trade {
+	return []Trade{{Symbol: "BTC-USD", Price: "50000.00", Size: "1.0", EntryType: constants.MdEntryTypeBid,
+		Position: "1", SeqNum: strconv.Itoa(seq)}}
+}
+
+// TestDbWriter_BatchesBySize verifies that a full batch is written in one call
+// without waiting for the flush interval.
+func TestDbWriter_BatchesBySize(t *testing.T) {
+	sink := &recordingSink{}
+	w, err := newDbWriter(DbWriterConfig{QueueSize: 100, BatchSize: 5, FlushInterval: time.Hour, Overflow: OverflowBlock}, sink.write)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	for i := 1; i <= 5; i++ {
+		w.Enqueue(testWriterTrades(i), strconv.Itoa(i), false)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sink.callCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sink.callCount() != 1 {
+		t.Fatalf("expected 1 write call, got %d", sink.callCount())
+	}
+	if got := len(sink.calls[0]); got != 5 {
+		t.Errorf("expected 5 messages in the batch, got %d", got)
+	}
+	if stats := w.Stats(); stats.Written != 5 || stats.Enqueued != 5 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestDbWriter_FlushesOnInterval verifies that a partial batch is written once
+// the flush interval elapses.
+func TestDbWriter_FlushesOnInterval(t *testing.T) {
+	sink := &recordingSink{}
+	w, err := newDbWriter(DbWriterConfig{QueueSize: 100, BatchSize: 100, FlushInterval: 10 * time.Millisecond, Overflow: OverflowBlock}, sink.write)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	w.Enqueue(testWriterTrades(1), "1", true)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sink.callCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := sink.seqNums(); len(got) != 1 || got[0] != "1" {
+		t.Errorf("expected seq 1 to be flushed, got %v", got)
+	}
+}
+
+// TestDbWriter_DropOldest verifies that a full queue discards the oldest
+// messages, keeps the newest and counts the drops.
+func TestDbWriter_DropOldest(t *testing.T) {
+	sink := &recordingSink{release: make(chan struct{})}
+	w, err := newDbWriter(DbWriterConfig{QueueSize: 2, BatchSize: 1, FlushInterval: time.Hour, Overflow: OverflowDropOldest}, sink.write)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Seq 1 is taken by the writer, which then blocks in the sink
+	w.Enqueue(testWriterTrades(1), "1", false)
+	deadline := time.Now().Add(2 * time.Second)
+	for w.Stats().QueueDepth != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 2; i <= 5; i++ {
+		w.Enqueue(testWriterTrades(i), strconv.Itoa(i), false)
+	}
+
+	stats := w.Stats()
+	if stats.Dropped != 2 {
+		t.Errorf("expected 2 dropped, got %d", stats.Dropped)
+	}
+	if stats.QueueDepth != 2 {
+		t.Errorf("expected queue depth 2, got %d", stats.QueueDepth)
+	}
+
+	close(sink.release)
+	w.Close()
+
+	got := sink.seqNums()
+	want := []string{"1", "4", "5"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v written, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v written, got %v", want, got)
+			break
+		}
+	}
+}
+
+// TestDbWriter_SpillAndReplay verifies that overflow goes to the spill file and
+// is written to the sink once the writer catches up, after which the file is
+// removed.
+func TestDbWriter_SpillAndReplay(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "md.spill")
+	sink := &recordingSink{release: make(chan struct{})}
+	w, err := newDbWriter(DbWriterConfig{QueueSize: 1, BatchSize: 1, FlushInterval: 10 * time.Millisecond,
+		Overflow: OverflowSpill, SpillPath: spillPath}, sink.write)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w.Enqueue(testWriterTrades(1), "1", false)
+	deadline := time.Now().Add(2 * time.Second)
+	for w.Stats().QueueDepth != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	w.Enqueue(testWriterTrades(2), "2", false) // fills the queue
+	w.Enqueue(testWriterTrades(3), "3", false) // spilled
+	w.Enqueue(testWriterTrades(4), "4", false) // spilled
+
+	if stats := w.Stats(); stats.Spilled != 2 {
+		t.Errorf("expected 2 spilled, got %d", stats.Spilled)
+	}
+	if _, err := os.Stat(spillPath); err != nil {
+		t.Fatalf("expected spill file: %v", err)
+	}
+
+	close(sink.release)
+	deadline = time.Now().Add(2 * time.Second)
+	for len(sink.seqNums()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.Close()
+
+	if got := sink.seqNums(); !slices.Equal(got, []string{"1", "2", "3", "4"}) {
+		t.Fatalf("expected the 4 messages written in order, got %v", got)
+	}
+	if _, err := os.Stat(spillPath); !os.IsNotExist(err) {
+		t.Errorf("expected spill file to be removed after replay, stat err=%v", err)
+	}
+	if stats := w.Stats(); stats.Written != 4 {
+		t.Errorf("expected 4 written, got %d", stats.Written)
+	}
+}
+
+// TestDbWriter_SpillKeptOnFailedCommit verifies that spilled messages survive
+// a failed replay commit and are written once the database recovers.
+func TestDbWriter_SpillKeptOnFailedCommit(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "md.spill")
+	var lines []byte
+	for seq := 1; seq <= 3; seq++ {
+		line, err := json.Marshal(dbBatch{Trades: testWriterTrades(seq), SeqNum: strconv.Itoa(seq)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+	if err := os.WriteFile(spillPath, lines, 0o644); err != nil {
+		t.Fatalf("failed to write spill file: %v", err)
+	}
+
+	sink := &recordingSink{}
+	sink.setErr(errors.New("database is locked"))
+	w, err := newDbWriter(DbWriterConfig{QueueSize: 4, BatchSize: 2, FlushInterval: 10 * time.Millisecond,
+		Overflow: OverflowSpill, SpillPath: spillPath}, sink.write)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for w.Stats().Errors < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := w.Stats(); stats.Errors < 2 {
+		t.Fatalf("expected repeated failed replays, got %d errors", stats.Errors)
+	}
+	if len(sink.seqNums()) != 0 {
+		t.Fatalf("expected nothing written while the database fails, got %v", sink.seqNums())
+	}
+
+	sink.setErr(nil)
+	deadline = time.Now().Add(2 * time.Second)
+	for len(sink.seqNums()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.Close()
+
+	if got := sink.seqNums(); len(got) != 3 {
+		t.Fatalf("expected the 3 spilled messages written after recovery, got %v", got)
+	}
+	for _, path := range []string{spillPath, spillPath + ".replay"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed after replay, stat err=%v", path, err)
+		}
+	}
+}
+
+// TestDbWriter_LeftoverSpillWrittenFirst verifies that messages spilled by a
+// previous run are written before new ones.
+func TestDbWriter_LeftoverSpillWrittenFirst(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "md.spill")
+	var lines []byte
+	for seq := 1; seq <= 2; seq++ {
+		line, err := json.Marshal(dbBatch{Trades: testWriterTrades(seq), SeqNum: strconv.Itoa(seq)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+	if err := os.WriteFile(spillPath, lines, 0o644); err != nil {
+		t.Fatalf("failed to write spill file: %v", err)
+	}
+
+	sink := &recordingSink{}
+	w, err := newDbWriter(DbWriterConfig{QueueSize: 4, BatchSize: 1, FlushInterval: 10 * time.Millisecond,
+		Overflow: OverflowSpill, SpillPath: spillPath}, sink.write)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Enqueue(testWriterTrades(3), "3", false)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.seqNums()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.Close()
+
+	if got := sink.seqNums(); !slices.Equal(got, []string{"1", "2", "3"}) {
+		t.Errorf("expected the spilled messages before the new one, got %v", got)
+	}
+}
+
+// TestDbWriter_FailedCommitKeptInOrder verifies that a failed transaction is
+// kept for replay instead of lost, ahead of the messages queued after it.
+func TestDbWriter_FailedCommitKeptInOrder(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "md.spill")
+	sink := &recordingSink{}
+	sink.setErr(errors.New("database is locked"))
+	w, err := newDbWriter(DbWriterConfig{QueueSize: 4, BatchSize: 1, FlushInterval: 10 * time.Millisecond,
+		Overflow: OverflowBlock, SpillPath: spillPath}, sink.write)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w.Enqueue(testWriterTrades(1), "1", false)
+	w.Enqueue(testWriterTrades(2), "2", false)
+	deadline := time.Now().Add(2 * time.Second)
+	for w.Stats().Errors < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := w.Stats(); stats.Errors < 2 || stats.Written != 0 {
+		t.Fatalf("expected failed commits, got %+v", stats)
+	}
+
+	sink.setErr(nil)
+	w.Enqueue(testWriterTrades(3), "3", false)
+	deadline = time.Now().Add(2 * time.Second)
+	for len(sink.seqNums()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.Close()
+
+	if got := sink.seqNums(); !slices.Equal(got, []string{"1", "2", "3"}) {
+		t.Errorf("expected the failed messages written first after recovery, got %v", got)
+	}
+	for _, path := range []string{spillPath, spillPath + ".replay"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed after replay, stat err=%v", path, err)
+		}
+	}
+}
+
+// TestDbWriter_SpillNotBlockedByReplay verifies that Enqueue can spill while
+// a replay commit is waiting on the database.
+func TestDbWriter_SpillNotBlockedByReplay(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "md.spill")
+	line, err := json.Marshal(dbBatch{Trades: testWriterTrades(1), SeqNum: "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(spillPath, append(line, '\n'), 0o644); err != nil {
+		t.Fatalf("failed to write spill file: %v", err)
+	}
+
+	sink := &recordingSink{release: make(chan struct{})}
+	w, err := newDbWriter(DbWriterConfig{QueueSize: 1, BatchSize: 1, FlushInterval: 10 * time.Millisecond,
+		Overflow: OverflowSpill, SpillPath: spillPath}, sink.write)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Wait for the replay to take over the spill file and block in write
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(spillPath + ".replay"); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.Enqueue(testWriterTrades(2), "2", false) // spilled behind the replay
+		w.Enqueue(testWriterTrades(3), "3", false)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Enqueue blocked on the spill replay")
+	}
+
+	close(sink.release)
+	deadline = time.Now().Add(2 * time.Second)
+	for len(sink.seqNums()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.Close()
+	if got := sink.seqNums(); len(got) != 3 {
+		t.Errorf("expected 3 messages written, got %v", got)
+	}
+}
+
+// TestDbWriter_CloseFlushesQueue verifies that Close writes every queued
+// message to the database before returning.
+func TestDbWriter_CloseFlushesQueue(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "md.db")
+	db, err := database.NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	w, err := NewDbWriter(db, DbWriterConfig{QueueSize: 100, BatchSize: 50, FlushInterval: time.Hour, Overflow: OverflowBlock})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	app := NewFixApp(&Config{}, db)
+	app.DbWriter = w
+	for i := 1; i <= 10; i++ {
+		trades := []Trade{{Symbol: "ETH-USD", Price: "3000.00", Size: "2.0", Aggressor: "Buy",
+			Time: "20250101-12:00:00", EntryType: constants.MdEntryTypeTrade, MdReqId: "req-1"}}
+		app.storeTradesToDatabase(trades, strconv.Itoa(i), false)
+	}
+	w.Close()
+
+	// Read back through a separate connection, as another process would
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	var count int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM trades WHERE symbol = ?", "ETH-USD").Scan(&count); err != nil {
+		t.Fatalf("failed to query trades: %v", err)
+	}
+	if count != 10 {
+		t.Errorf("expected 10 trades in database after Close, got %d", count)
+	}
+	if stats := w.Stats(); stats.Written != 10 || stats.Errors != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestDbWriter_WritesCandles verifies that closed bars go through the queue
+// to the candles table, and survive the JSON encoding used by the spill file.
+func TestDbWriter_WritesCandles(t *testing.T) {
+	db, err := database.NewMarketDataDb(filepath.Join(t.TempDir(), "md.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	w, err := NewDbWriter(db, DbWriterConfig{QueueSize: 100, BatchSize: 50, FlushInterval: time.Hour, Overflow: OverflowBlock})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Date(2025, 1, 1, 12, 5, 0, 0, time.UTC)
+	bar := Candle{Symbol: "BTC-USD", Interval: 5 * time.Minute, Start: start}
+	if err := bar.add(decimal.MustParse("50000.00"), decimal.MustParse("0.00000001"), start); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	line, err := json.Marshal(dbBatch{Candles: []Candle{bar}})
+	if err != nil {
+		t.Fatalf("failed to encode batch: %v", err)
+	}
+	var spilled dbBatch
+	if err := json.Unmarshal(line, &spilled); err != nil {
+		t.Fatalf("failed to decode batch: %v", err)
+	}
+
+	w.EnqueueCandles(spilled.Candles)
+	w.Close()
+
+	candles, err := db.LoadCandles("BTC-USD", "5m", start)
+	if err != nil {
+		t.Fatalf("LoadCandles failed: %v", err)
+	}
+	if len(candles) != 1 || candles[0].Close.String() != "50000.00" || candles[0].Volume.String() != "0.00000001" {
+		t.Errorf("unexpected stored candles %+v", candles)
+	}
+}
+
+// TestNewDbWriter_InvalidConfig verifies that bad settings are rejected up front.
+func TestNewDbWriter_InvalidConfig(t *testing.T) {
+	sink := &recordingSink{}
+	tests := []struct {
+		name string
+		cfg  DbWriterConfig
+	}{
+		{"unknown policy", DbWriterConfig{QueueSize: 1, BatchSize: 1, FlushInterval: time.Second, Overflow: "discard"}},
+		{"spill without path", DbWriterConfig{QueueSize: 1, BatchSize: 1, FlushInterval: time.Second, Overflow: OverflowSpill}},
+		{"zero queue", DbWriterConfig{QueueSize: 0, BatchSize: 1, FlushInterval: time.Second, Overflow: OverflowBlock}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newDbWriter(tt.cfg, sink.write); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -19,6 +19,8 @@ package fixclient
 import (
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 
 	"prime-fix-md-go/constants"
 )
@@ -29,18 +31,31 @@ func (a *FixApp) displayHelp() {
   md <symbol> [flags...]        - Market data request
   unsubscribe <symbol|reqId>    - Stop subscription(s)
   status                        - Show active subscriptions
+  book <symbol> [levels]        - Show live order book
+  candles <symbol> [interval] [count]  - Show OHLCV bars built from live trades
 
   --- Order Entry ---
   order <buy|sell> <symbol> <qty> [price] [flags...]  - Submit new order
   cancel <clOrdId|orderId>      - Cancel an order
+  cancelall [symbol] [--side S] - Cancel all open orders (kill switch)
   replace <clOrdId> [--qty Q] [--price P]  - Modify an order
   ordstatus <clOrdId|orderId>   - Request order status
   orders                        - List tracked orders
+  history <clOrdId|orderId>     - Show an order's lifecycle events
+  fills [clOrdId|symbol]        - List executions
+  positions                     - Show positions and PnL
+
+  --- Strategies (client-side) ---
+  oco <buy|sell> <symbol> <qty> --limit P --stop S  - One-cancels-other pair
+  bracket <buy|sell> <symbol> <qty> [price] --tp P --sl S  - Entry with exits
+  trail <buy|sell> <symbol> <qty> --amount A|--pct P  - Trailing stop
+  strategies                    - List strategies and their legs
+  cancelstrategy <id>           - Stop a strategy and cancel its legs
 
   --- RFQ (Request for Quote) ---
   rfq <buy|sell> <symbol> <qty> - Request a quote
   accept <quoteId|quoteReqId>   - Accept a received quote
-  quotes                        - List received quotes
+  quotes                        - List RFQs with status and time left
 
   --- General ---
   help                          - Show this help message
@@ -66,6 +81,8 @@ Examples:
   order sell ETH-USD 1.5 --type market    - Market sell 1.5 ETH
   rfq buy BTC-USD 1.0                     - Request buy quote for 1 BTC
   cancel ord_123                          - Cancel order
+  cancelall BTC-USD --side buy            - Cancel all open BTC-USD buys
+  bracket buy BTC-USD 0.1 50000 --tp 52000 --sl 49000
 `)
 }
 
@@ -151,6 +168,41 @@ func (a *FixApp) displayIncrementalTrades(trades []Trade) {
 	}
 }
 
+func (a *FixApp) displayOrderBook(symbol string, bids, offers []BookLevel) {
+	bid, offer, hasBid, hasOffer := a.OrderBook.BestBidOffer(symbol)
+
+	fmt.Printf("\nOrder Book for %s", symbol)
+	if hasBid && hasOffer {
+		if spread, err := offer.PriceVal.Sub(bid.PriceVal); err == nil {
+			fmt.Printf(" (Spread: %s)", spread)
+		}
+	}
+	if last := a.OrderBook.LastUpdate(symbol); !last.IsZero() {
+		fmt.Printf(" - Updated %s", last.Format("15:04:05.000"))
+	}
+	fmt.Println()
+
+	fmt.Printf("┌─────┬────────────────┬───────────────┬───────────────┬────────────────┐\n")
+	fmt.Printf("│ Lvl │ Bid Size       │ Bid           │ Offer         │ Offer Size     │\n")
+	fmt.Printf("├─────┼────────────────┼───────────────┼───────────────┼────────────────┤\n")
+
+	rows := len(bids)
+	if len(offers) > rows {
+		rows = len(offers)
+	}
+	for i := 0; i < rows; i++ {
+		var bidSize, bidPx, offerPx, offerSize string
+		if i < len(bids) {
+			bidSize, bidPx = bids[i].Size, bids[i].Price
+		}
+		if i < len(offers) {
+			offerPx, offerSize = offers[i].Price, offers[i].Size
+		}
+		fmt.Printf("│ %-3d │ %14s │ %13s │ %-13s │ %-14s │\n", i+1, bidSize, bidPx, offerPx, offerSize)
+	}
+	fmt.Printf("└─────┴────────────────┴───────────────┴───────────────┴────────────────┘\n")
+}
+
 func (a *FixApp) getSubscriptionTypeDesc(subType string) string {
 	switch subType {
 	case "0":
@@ -266,6 +318,9 @@ func (a *FixApp) displayExecutionReport(er *ExecutionReport) {
 	if er.Commission != "" && er.Commission != "0" {
 		log.Printf("   Commission: %s", er.Commission)
 	}
+	for _, fee := range er.MiscFees {
+		log.Printf("   Fee: %s %s (%s)", fee.Amt, fee.Curr, getMiscFeeTypeDesc(fee.Type))
+	}
 	if er.OrdRejReason != "" {
 		log.Printf("   Reject Reason: %s (%s)", er.OrdRejReason, getOrdRejReasonDesc(er.OrdRejReason))
 	}
@@ -291,6 +346,40 @@ func (a *FixApp) displayOrderCancelReject(reject *OrderCancelReject) {
 	}
 }
 
+func (a *FixApp) displayOrderMassCancelReport(report *OrderMassCancelReport) {
+	if report.MassCancelResponse == constants.MassCancelResponseRejected {
+		log.Printf("Order Mass Cancel Rejected")
+	} else {
+		log.Printf("Order Mass Cancel Accepted")
+	}
+	log.Printf("   ClOrdID: %s, Scope: %s", report.ClOrdID, massCancelScope(report.Symbol, report.Side))
+	if report.MassCancelResponse == constants.MassCancelResponseRejected {
+		log.Printf("   Reason: %s (%s)", report.MassCancelRejectReason, getMassCancelRejectReasonDesc(report.MassCancelRejectReason))
+	} else if report.TotalAffectedOrders != "" {
+		log.Printf("   Orders cancelled: %s", report.TotalAffectedOrders)
+	}
+	if report.Text != "" {
+		log.Printf("   Text: %s", report.Text)
+	}
+}
+
+// displayReconciliation summarizes a logon reconciliation: orders whose state
+// changed while disconnected and open orders no status report was received for.
+func (a *FixApp) displayReconciliation(checked int, changed []orderChange, unreported []string, timedOut bool) {
+	if timedOut {
+		log.Printf("Order Reconciliation timed out after %s", reconcileTimeout)
+	} else {
+		log.Printf("Order Reconciliation complete")
+	}
+	log.Printf("   Checked: %d, Changed: %d, Not reported: %d", checked, len(changed), len(unreported))
+	for _, c := range changed {
+		log.Printf("   %s: %s", c.ClOrdID, strings.Join(c.Changes, ", "))
+	}
+	for _, id := range unreported {
+		log.Printf("   %s: no status received (use 'ordstatus %s')", id, id)
+	}
+}
+
 func (a *FixApp) displayQuote(quote *Quote) {
 	log.Printf("Quote Received")
 	log.Printf("   QuoteID: %s, QuoteReqID: %s", quote.QuoteID, quote.QuoteReqID)
@@ -307,9 +396,12 @@ func (a *FixApp) displayQuote(quote *Quote) {
 	}
 }
 
-func (a *FixApp) displayQuoteAck(ack *QuoteAck) {
+func (a *FixApp) displayQuoteAck(ack *QuoteAck, rfq *Quote) {
 	log.Printf("Quote Request Rejected")
 	log.Printf("   QuoteReqID: %s, Symbol: %s", ack.QuoteReqID, ack.Symbol)
+	if rfq.OrderQty != "" {
+		log.Printf("   Requested: %s %s (at %s)", getSideDesc(rfq.Side), rfq.OrderQty, rfq.RequestedAt.Format("15:04:05.000"))
+	}
 	log.Printf("   Reason: %s (%s)", ack.QuoteRejectReason, getQuoteRejectReasonDesc(ack.QuoteRejectReason))
 	if ack.Text != "" {
 		log.Printf("   Text: %s", ack.Text)
@@ -334,6 +426,9 @@ func (a *FixApp) displayBusinessReject(reject *BusinessReject) {
 	log.Printf("Business Message Reject")
 	log.Printf("   RefSeqNum: %s, RefMsgType: %s", reject.RefSeqNum, reject.RefMsgType)
 	log.Printf("   Reason: %s (%s)", reject.BusinessRejectReason, getBusinessRejectReasonDesc(reject.BusinessRejectReason))
+	if reject.BusinessRejectRefID != "" {
+		log.Printf("   RefID: %s", reject.BusinessRejectRefID)
+	}
 	if reject.Text != "" {
 		log.Printf("   Text: %s", reject.Text)
 	}
@@ -353,6 +448,8 @@ func getExecTypeDesc(execType string) string {
 		return "Done"
 	case constants.ExecTypeCanceled:
 		return "Canceled"
+	case constants.ExecTypeReplaced:
+		return "Replaced"
 	case constants.ExecTypePendingCancel:
 		return "Pending Cancel"
 	case constants.ExecTypeStopped:
@@ -365,6 +462,8 @@ func getExecTypeDesc(execType string) string {
 		return "Expired"
 	case constants.ExecTypeRestated:
 		return "Restated"
+	case constants.ExecTypeTrade:
+		return "Trade"
 	case constants.ExecTypeOrderStatus:
 		return "Order Status"
 	default:
@@ -443,6 +542,39 @@ func getOrdRejReasonDesc(reason string) string {
 	}
 }
 
+func getMiscFeeTypeDesc(feeType string) string {
+	switch feeType {
+	case constants.MiscFeeTypeFinancing:
+		return "Financing"
+	case constants.MiscFeeTypeClientComm:
+		return "Client Commission"
+	case constants.MiscFeeTypeCESComm:
+		return "CES Commission"
+	case constants.MiscFeeTypeVenueFee:
+		return "Venue Fee"
+	default:
+		return feeType
+	}
+}
+
+// formatMiscFeeTotals renders per-currency fee totals as "1.25 USD, 0.001 BTC".
+func formatMiscFeeTotals(totals map[string]string) string {
+	if len(totals) == 0 {
+		return "-"
+	}
+	currencies := make([]string, 0, len(totals))
+	for curr := range totals {
+		currencies = append(currencies, curr)
+	}
+	sort.Strings(currencies)
+
+	parts := make([]string, len(currencies))
+	for i, curr := range currencies {
+		parts[i] = strings.TrimSpace(totals[curr] + " " + curr)
+	}
+	return strings.Join(parts, ", ")
+}
+
 func getQuoteRejectReasonDesc(reason string) string {
 	switch reason {
 	case constants.QuoteRejectReasonUnknownSymbol:
@@ -493,6 +625,19 @@ func getSessionRejectReasonDesc(reason string) string {
 	}
 }
 
+func getMassCancelRejectReasonDesc(reason string) string {
+	switch reason {
+	case constants.MassCancelRejectReasonNotSupported:
+		return "Mass cancel not supported"
+	case constants.MassCancelRejectReasonUnknownSecurity:
+		return "Invalid or unknown security"
+	case constants.MassCancelRejectReasonOther:
+		return "Other"
+	default:
+		return "Unknown reason"
+	}
+}
+
 func getBusinessRejectReasonDesc(reason string) string {
 	switch reason {
 	case constants.BusinessRejectReasonOther:
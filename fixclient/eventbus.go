@@ -0,0 +1,528 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fixclient provides a typed publish/subscribe bus for market data.
+//
+// MarketDataBus lets code embedding this client react to market data without
+// changing handleMarketDataMessage. Handlers are registered per event kind
+// (OnTrade, OnBookUpdate, OnBBO, OnCandle, OnReject), optionally filtered by
+// symbol.
+//
+// Delivery:
+//
+//	FIX handler ──publish──▶ [bounded queue per subscriber] ──▶ subscriber goroutine ──▶ handler
+//	                            │ full?
+//	                            ▼
+//	                 drop oldest, flag slow consumer
+//
+// Publishing never blocks the FIX message handler: each subscriber has its
+// own queue and goroutine, so a slow handler only delays its own events. When
+// a queue is full the oldest queued event is dropped, since market data
+// consumers want the latest state. A subscriber whose queue passes three
+// quarters full, or that loses an event, is flagged slow until its goroutine
+// drains the queue below a quarter.
+//
+// Concurrency Model:
+// Events are published from the FIX message handler goroutine and, for
+// candles, from the aggregator's ticker. Each handler is called from a single
+// goroutine, in publish order. With no subscriber for a kind, publishing it
+// costs one atomic load.
+package fixclient
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"prime-fix-md-go/constants"
+)
+
+// DefaultSubscriberQueueSize is the queue length used when SubscriberConfig
+// leaves QueueSize at zero.
+const DefaultSubscriberQueueSize = 1024
+
+// Event kinds, as reported in SubscriberStats.
+const (
+	EventTrade      = "trade"
+	EventBookUpdate = "book"
+	EventBBO        = "bbo"
+	EventCandle     = "candle"
+	EventReject     = "reject"
+)
+
+// BookUpdate carries the bid/offer entries one market data message applied
+// to a symbol's book. Entries share the message's slice and must not be
+// modified.
+type BookUpdate struct {
+	Symbol     string    `json:"symbol"`
+	IsSnapshot bool      `json:"isSnapshot"` // Entries replaced the book rather than changing it
+	SeqNum     string    `json:"seqNum"`
+	Entries    []Trade   `json:"entries"`
+	Time       time.Time `json:"time"`
+}
+
+// BBO is a symbol's top of book after a change to the best bid or offer
+// price or size.
+type BBO struct {
+	Symbol   string    `json:"symbol"`
+	Bid      BookLevel `json:"bid"`
+	Offer    BookLevel `json:"offer"`
+	HasBid   bool      `json:"hasBid"`
+	HasOffer bool      `json:"hasOffer"`
+	SeqNum   string    `json:"seqNum"`
+	Time     time.Time `json:"time"`
+}
+
+// MarketDataReject is a Market Data Request Reject (Y). Symbol is the
+// rejected subscription's symbol, or empty when the request is unknown.
+type MarketDataReject struct {
+	MdReqId    string    `json:"mdReqId"`
+	Symbol     string    `json:"symbol"`
+	Reason     string    `json:"reason"` // MdReqRejReason (281)
+	ReasonDesc string    `json:"reasonDesc"`
+	Text       string    `json:"text,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// SubscriberConfig controls one subscription.
+type SubscriberConfig struct {
+	Name      string   // Used in logs and stats; defaults to "<kind>#<n>"
+	Symbols   []string // Only events for these symbols; empty for all
+	QueueSize int      // Events buffered before the oldest is dropped
+
+	// OnSlow is called when the subscriber becomes slow. It runs on the
+	// publishing goroutine and must neither block nor unsubscribe.
+	OnSlow func(SubscriberStats)
+}
+
+// SubscriberStats is a point-in-time view of one subscriber.
+type SubscriberStats struct {
+	Name          string
+	Kind          string
+	Symbols       []string
+	QueueDepth    int
+	QueueCapacity int
+	Published     int64 // Events queued for the subscriber
+	Delivered     int64 // Events its handler has returned from
+	Dropped       int64 // Events discarded because the queue was full
+	SlowEvents    int64 // Times the subscriber was flagged slow
+	Slow          bool
+}
+
+// Subscriber is the handle returned when a handler is registered.
+type Subscriber struct {
+	stats       func() SubscriberStats
+	unsubscribe func()
+	done        <-chan struct{}
+}
+
+// Stats returns the subscriber's queue depth and counters.
+func (s *Subscriber) Stats() SubscriberStats {
+	return s.stats()
+}
+
+// Unsubscribe stops publishing to the subscriber. Events already queued are
+// still delivered; Done is closed after the last one. It may be called more
+// than once, including from the handler.
+func (s *Subscriber) Unsubscribe() {
+	s.unsubscribe()
+}
+
+// Done is closed once the subscriber has been unsubscribed and its queue
+// drained.
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// MarketDataBus fans market data events out to registered handlers.
+type MarketDataBus struct {
+	trades  topic[Trade]
+	books   topic[BookUpdate]
+	bbos    topic[BBO]
+	candles topic[Candle]
+	rejects topic[MarketDataReject]
+
+	nextId atomic.Int64
+
+	bboMu   sync.Mutex
+	lastBBO map[string]BBO // Last published top of book per symbol
+}
+
+// NewMarketDataBus creates a bus with no subscribers.
+func NewMarketDataBus() *MarketDataBus {
+	return &MarketDataBus{
+		trades:  topic[Trade]{kind: EventTrade},
+		books:   topic[BookUpdate]{kind: EventBookUpdate},
+		bbos:    topic[BBO]{kind: EventBBO},
+		candles: topic[Candle]{kind: EventCandle},
+		rejects: topic[MarketDataReject]{kind: EventReject},
+		lastBBO: make(map[string]BBO),
+	}
+}
+
+// OnTrade registers handler for trade entries (EntryType 2) from snapshots
+// and incrementals; Trade.IsSnapshot tells them apart.
+func (b *MarketDataBus) OnTrade(cfg SubscriberConfig, handler func(Trade)) *Subscriber {
+	return subscribe(b, &b.trades, cfg, handler)
+}
+
+// OnBookUpdate registers handler for the book entries of each message, per
+// symbol.
+func (b *MarketDataBus) OnBookUpdate(cfg SubscriberConfig, handler func(BookUpdate)) *Subscriber {
+	return subscribe(b, &b.books, cfg, handler)
+}
+
+// OnBBO registers handler for changes to the best bid or offer.
+func (b *MarketDataBus) OnBBO(cfg SubscriberConfig, handler func(BBO)) *Subscriber {
+	return subscribe(b, &b.bbos, cfg, handler)
+}
+
+// OnCandle registers handler for candles as they close, and again when a
+// late trade amends them (Candle.Amended).
+func (b *MarketDataBus) OnCandle(cfg SubscriberConfig, handler func(Candle)) *Subscriber {
+	return subscribe(b, &b.candles, cfg, handler)
+}
+
+// OnReject registers handler for Market Data Request Rejects. Rejects for an
+// unknown request have no symbol and reach every reject subscriber.
+func (b *MarketDataBus) OnReject(cfg SubscriberConfig, handler func(MarketDataReject)) *Subscriber {
+	return subscribe(b, &b.rejects, cfg, handler)
+}
+
+// Subscribers returns the stats of every current subscriber, grouped by kind.
+func (b *MarketDataBus) Subscribers() []SubscriberStats {
+	var stats []SubscriberStats
+	stats = b.trades.stats(stats)
+	stats = b.books.stats(stats)
+	stats = b.bbos.stats(stats)
+	stats = b.candles.stats(stats)
+	stats = b.rejects.stats(stats)
+	return stats
+}
+
+// Close unsubscribes every subscriber and waits for their queues to drain.
+// It must not be called from a handler.
+func (b *MarketDataBus) Close() {
+	var done []<-chan struct{}
+	done = b.trades.closeAll(done)
+	done = b.books.closeAll(done)
+	done = b.bbos.closeAll(done)
+	done = b.candles.closeAll(done)
+	done = b.rejects.closeAll(done)
+	for _, d := range done {
+		<-d
+	}
+}
+
+// publishMarketData publishes the trades, book updates and top-of-book
+// changes in one market data message, after its entries were applied to
+// book. symbol is the message's Symbol (55), used for entries without one.
+func (b *MarketDataBus) publishMarketData(symbol string, entries []Trade, seqNum string, isSnapshot bool, book *OrderBook) {
+	if b.trades.active() {
+		for i := range entries {
+			if entries[i].EntryType == constants.MdEntryTypeTrade {
+				b.trades.publish(entrySymbol(&entries[i], symbol), entries[i])
+			}
+		}
+	}
+
+	wantBooks, wantBBO := b.books.active(), b.bbos.active()
+	if (!wantBooks && !wantBBO) || !hasBookEntries(entries) {
+		return
+	}
+	now := time.Now()
+	for _, sym := range bookSymbols(entries, symbol) {
+		if wantBooks {
+			b.books.publish(sym, BookUpdate{
+				Symbol:     sym,
+				IsSnapshot: isSnapshot,
+				SeqNum:     seqNum,
+				Entries:    bookEntriesFor(entries, sym, symbol),
+				Time:       now,
+			})
+		}
+		if wantBBO {
+			b.publishBBO(sym, seqNum, now, book)
+		}
+	}
+}
+
+// publishCandles publishes closed or amended bars.
+func (b *MarketDataBus) publishCandles(candles []Candle) {
+	if !b.candles.active() {
+		return
+	}
+	for _, c := range candles {
+		b.candles.publish(c.Symbol, c)
+	}
+}
+
+// publishReject publishes a Market Data Request Reject.
+func (b *MarketDataBus) publishReject(reject MarketDataReject) {
+	if b.rejects.active() {
+		b.rejects.publish(reject.Symbol, reject)
+	}
+}
+
+// publishBBO publishes sym's top of book if it differs from the last one
+// published.
+func (b *MarketDataBus) publishBBO(sym, seqNum string, now time.Time, book *OrderBook) {
+	bid, offer, hasBid, hasOffer := book.BestBidOffer(sym)
+	bbo := BBO{Symbol: sym, Bid: bid, Offer: offer, HasBid: hasBid, HasOffer: hasOffer, SeqNum: seqNum, Time: now}
+
+	b.bboMu.Lock()
+	last, seen := b.lastBBO[sym]
+	changed := !seen || !sameLevel(last.Bid, last.HasBid, bid, hasBid) || !sameLevel(last.Offer, last.HasOffer, offer, hasOffer)
+	if changed {
+		b.lastBBO[sym] = bbo
+	}
+	b.bboMu.Unlock()
+
+	if changed {
+		b.bbos.publish(sym, bbo)
+	}
+}
+
+func sameLevel(a BookLevel, hasA bool, b BookLevel, hasB bool) bool {
+	if hasA != hasB {
+		return false
+	}
+	return !hasA || (a.PriceVal.Equal(b.PriceVal) && a.SizeVal.Equal(b.SizeVal))
+}
+
+// entrySymbol returns the entry's own Symbol (55), or the message's.
+func entrySymbol(entry *Trade, symbol string) string {
+	if entry.Symbol != "" {
+		return entry.Symbol
+	}
+	return symbol
+}
+
+// bookSymbols lists the symbols whose books entries change, in order of
+// first appearance.
+func bookSymbols(entries []Trade, symbol string) []string {
+	var symbols []string
+	for i := range entries {
+		if !isBookEntry(&entries[i]) {
+			continue
+		}
+		if sym := entrySymbol(&entries[i], symbol); !slices.Contains(symbols, sym) {
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols
+}
+
+// bookEntriesFor returns sym's book entries. The common single-symbol
+// message with only book entries is returned without copying.
+func bookEntriesFor(entries []Trade, sym, symbol string) []Trade {
+	all := true
+	for i := range entries {
+		if !isBookEntry(&entries[i]) || entrySymbol(&entries[i], symbol) != sym {
+			all = false
+			break
+		}
+	}
+	if all {
+		return entries
+	}
+
+	var result []Trade
+	for i := range entries {
+		if isBookEntry(&entries[i]) && entrySymbol(&entries[i], symbol) == sym {
+			result = append(result, entries[i])
+		}
+	}
+	return result
+}
+
+// topic holds the subscribers of one event kind.
+type topic[T any] struct {
+	kind  string
+	mu    sync.RWMutex
+	subs  []*subscriber[T]
+	count atomic.Int32
+}
+
+func (t *topic[T]) active() bool {
+	return t.count.Load() > 0
+}
+
+// publish queues ev for every subscriber interested in symbol.
+func (t *topic[T]) publish(symbol string, ev T) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, s := range t.subs {
+		if s.wants(symbol) {
+			s.offer(ev)
+		}
+	}
+}
+
+func (t *topic[T]) remove(s *subscriber[T]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if i := slices.Index(t.subs, s); i >= 0 {
+		t.subs = slices.Delete(t.subs, i, i+1)
+		t.count.Add(-1)
+		close(s.queue) // No publish holds the read lock now
+	}
+}
+
+func (t *topic[T]) stats(stats []SubscriberStats) []SubscriberStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, s := range t.subs {
+		stats = append(stats, s.snapshot())
+	}
+	return stats
+}
+
+func (t *topic[T]) closeAll(done []<-chan struct{}) []<-chan struct{} {
+	t.mu.Lock()
+	subs := t.subs
+	t.subs = nil
+	t.count.Store(0)
+	for _, s := range subs {
+		close(s.queue)
+		done = append(done, s.done)
+	}
+	t.mu.Unlock()
+	return done
+}
+
+// subscribe registers handler on t and starts its goroutine.
+func subscribe[T any](b *MarketDataBus, t *topic[T], cfg SubscriberConfig, handler func(T)) *Subscriber {
+	size := cfg.QueueSize
+	if size <= 0 {
+		size = DefaultSubscriberQueueSize
+	}
+	name := cfg.Name
+	if name == "" {
+		name = fmt.Sprintf("%s#%d", t.kind, b.nextId.Add(1))
+	}
+	s := &subscriber[T]{
+		name:    name,
+		kind:    t.kind,
+		symbols: slices.Clone(cfg.Symbols),
+		queue:   make(chan T, size),
+		handler: handler,
+		onSlow:  cfg.OnSlow,
+		done:    make(chan struct{}),
+	}
+
+	t.mu.Lock()
+	t.subs = append(t.subs, s)
+	t.count.Add(1)
+	t.mu.Unlock()
+
+	go s.run()
+
+	var once sync.Once
+	return &Subscriber{
+		stats:       s.snapshot,
+		unsubscribe: func() { once.Do(func() { t.remove(s) }) },
+		done:        s.done,
+	}
+}
+
+// subscriber is one handler with its queue and counters.
+type subscriber[T any] struct {
+	name    string
+	kind    string
+	symbols []string
+	queue   chan T
+	handler func(T)
+	onSlow  func(SubscriberStats)
+
+	published  atomic.Int64
+	delivered  atomic.Int64
+	dropped    atomic.Int64
+	slowEvents atomic.Int64
+	slow       atomic.Bool
+
+	done chan struct{}
+}
+
+// wants reports whether the subscriber filters in symbol. Events without a
+// symbol pass every filter.
+func (s *subscriber[T]) wants(symbol string) bool {
+	return len(s.symbols) == 0 || symbol == "" || slices.Contains(s.symbols, symbol)
+}
+
+// offer queues ev, dropping the oldest queued events to make room. Caller
+// holds the topic's read lock, so the queue is open.
+func (s *subscriber[T]) offer(ev T) {
+	s.published.Add(1)
+	for {
+		select {
+		case s.queue <- ev:
+			if len(s.queue) >= cap(s.queue)*3/4 {
+				s.markSlow()
+			}
+			return
+		default:
+		}
+		select {
+		case <-s.queue:
+			s.dropped.Add(1)
+			s.markSlow()
+		default:
+		}
+	}
+}
+
+func (s *subscriber[T]) markSlow() {
+	if !s.slow.CompareAndSwap(false, true) {
+		return
+	}
+	s.slowEvents.Add(1)
+	log.Printf("Slow market data subscriber %s: %d/%d events queued, %d dropped",
+		s.name, len(s.queue), cap(s.queue), s.dropped.Load())
+	if s.onSlow != nil {
+		s.onSlow(s.snapshot())
+	}
+}
+
+// run delivers queued events until the queue is closed and drained.
+func (s *subscriber[T]) run() {
+	defer close(s.done)
+	for ev := range s.queue {
+		s.handler(ev)
+		s.delivered.Add(1)
+		if s.slow.Load() && len(s.queue) <= cap(s.queue)/4 && s.slow.CompareAndSwap(true, false) {
+			log.Printf("Market data subscriber %s caught up (%d dropped in total)", s.name, s.dropped.Load())
+		}
+	}
+}
+
+func (s *subscriber[T]) snapshot() SubscriberStats {
+	return SubscriberStats{
+		Name:          s.name,
+		Kind:          s.kind,
+		Symbols:       slices.Clone(s.symbols),
+		QueueDepth:    len(s.queue),
+		QueueCapacity: cap(s.queue),
+		Published:     s.published.Load(),
+		Delivered:     s.delivered.Load(),
+		Dropped:       s.dropped.Load(),
+		SlowEvents:    s.slowEvents.Load(),
+		Slow:          s.slow.Load(),
+	}
+}
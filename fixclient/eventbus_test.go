@@ -0,0 +1,199 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"testing"
+	"time"
+)
+
+// collect returns a handler that forwards events to a buffered channel.
+func collect[T any]() (func(T), chan T) {
+	ch := make(chan T, 100)
+	return func(ev T) { ch <- ev }, ch
+}
+
+// receive waits for the next event on ch.
+func receive[T any](t *testing.T, ch chan T) T {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(2 * time.Second):
+		var zero T
+		t.Fatalf("no %T event delivered", zero)
+		return zero
+	}
+}
+
+// expectNone fails if ch receives an event shortly.
+func expectNone[T any](t *testing.T, ch chan T) {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestMarketDataBus_TypedHandlersFilterBySymbol verifies that market data
+// handled by FixApp reaches trade, book and BBO handlers, that symbol
+// filters apply, and that BBO is only published when the top of book moves.
+func TestMarketDataBus_TypedHandlersFilterBySymbol(t *testing.T) {
+	app := NewFixApp(&Config{}, nil)
+	app.Headless = true
+	app.Bus = NewMarketDataBus()
+	defer app.Bus.Close()
+
+	onBtcTrade, btcTrades := collect[Trade]()
+	onTrade, allTrades := collect[Trade]()
+	onBook, books := collect[BookUpdate]()
+	onBBO, bbos := collect[BBO]()
+	app.Bus.OnTrade(SubscriberConfig{Symbols: []string{"BTC-USD"}}, onBtcTrade)
+	app.Bus.OnTrade(SubscriberConfig{}, onTrade)
+	app.Bus.OnBookUpdate(SubscriberConfig{Symbols: []string{"BTC-USD"}}, onBook)
+	app.Bus.OnBBO(SubscriberConfig{}, onBBO)
+
+	app.handleMarketDataMessage(parseFixMessage(t, "35=W\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01262=req-1\x0155=BTC-USD\x01268=4\x01"+
+		"269=0\x01270=49999\x01271=1\x01290=1\x01"+
+		"269=0\x01270=49998\x01271=2\x01290=2\x01"+
+		"269=1\x01270=50001\x01271=1.5\x01290=1\x01"+
+		"269=2\x01270=50000\x01271=0.1\x01"))
+	app.handleMarketDataMessage(parseFixMessage(t, "35=X\x0149=COIN\x0156=CLIENT\x0134=3\x0152=20250101-12:00:01.000\x01262=req-2\x0155=ETH-USD\x01268=1\x01"+
+		"279=0\x01269=2\x01270=3000\x01271=4\x01"))
+
+	if trade := receive(t, btcTrades); trade.Symbol != "BTC-USD" || trade.Price != "50000" || !trade.IsSnapshot {
+		t.Errorf("unexpected BTC-USD trade %+v", trade)
+	}
+	expectNone(t, btcTrades)
+	if first, second := receive(t, allTrades), receive(t, allTrades); first.Symbol != "BTC-USD" || second.Symbol != "ETH-USD" {
+		t.Errorf("expected BTC-USD then ETH-USD trades, got %s and %s", first.Symbol, second.Symbol)
+	}
+
+	if update := receive(t, books); update.Symbol != "BTC-USD" || !update.IsSnapshot || len(update.Entries) != 3 || update.SeqNum != "2" {
+		t.Errorf("unexpected book update %+v", update)
+	}
+	if bbo := receive(t, bbos); !bbo.HasBid || !bbo.HasOffer || bbo.Bid.Price != "49999" || bbo.Offer.Price != "50001" {
+		t.Errorf("unexpected BBO %+v", bbo)
+	}
+
+	// A change below the top of book is a book update but not a BBO change
+	header := "35=X\x0149=COIN\x0156=CLIENT\x0152=20250101-12:00:02.000\x01262=req-1\x0155=BTC-USD\x01268=1\x01"
+	app.handleMarketDataMessage(parseFixMessage(t, header+"34=4\x01279=1\x01269=0\x01270=49998\x01271=3\x01"))
+	if update := receive(t, books); update.IsSnapshot || len(update.Entries) != 1 || update.Entries[0].Size != "3" {
+		t.Errorf("unexpected book update %+v", update)
+	}
+	expectNone(t, bbos)
+
+	app.handleMarketDataMessage(parseFixMessage(t, header+"34=5\x01279=1\x01269=1\x01270=50001\x01271=0.5\x01"))
+	if bbo := receive(t, bbos); bbo.Offer.Size != "0.5" || bbo.Bid.Price != "49999" || bbo.SeqNum != "5" {
+		t.Errorf("unexpected BBO %+v", bbo)
+	}
+}
+
+// TestMarketDataBus_SlowConsumerDropsOldest verifies that a subscriber that
+// falls behind loses its oldest events rather than blocking the publisher,
+// is flagged slow, and recovers once its queue drains.
+func TestMarketDataBus_SlowConsumerDropsOldest(t *testing.T) {
+	bus := NewMarketDataBus()
+	defer bus.Close()
+
+	release := make(chan struct{})
+	slowCalls := make(chan SubscriberStats, 10)
+	var got []int
+	sub := bus.OnCandle(SubscriberConfig{Name: "strategy", QueueSize: 4, OnSlow: func(s SubscriberStats) { slowCalls <- s }},
+		func(c Candle) {
+			<-release
+			got = append(got, c.Trades)
+		})
+
+	const published = 20
+	start := time.Now()
+	for i := 1; i <= published; i++ {
+		bus.publishCandles([]Candle{{Symbol: "BTC-USD", Trades: i}})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("publishing blocked on the slow subscriber for %s", elapsed)
+	}
+
+	stats := sub.Stats()
+	if !stats.Slow || stats.Dropped == 0 || stats.Published != published || stats.QueueDepth > 4 {
+		t.Errorf("expected a slow subscriber with drops, got %+v", stats)
+	}
+	if s := receive(t, slowCalls); s.Name != "strategy" || s.Kind != EventCandle {
+		t.Errorf("unexpected OnSlow stats %+v", s)
+	}
+	expectNone(t, slowCalls)
+
+	close(release)
+	sub.Unsubscribe()
+	<-sub.Done()
+
+	stats = sub.Stats()
+	if stats.Slow || stats.SlowEvents != 1 {
+		t.Errorf("expected the subscriber to have caught up once, got %+v", stats)
+	}
+	if stats.Delivered+stats.Dropped != published || int(stats.Delivered) != len(got) {
+		t.Errorf("delivered %d + dropped %d != published %d (handler saw %d)",
+			stats.Delivered, stats.Dropped, published, len(got))
+	}
+	if len(got) == 0 || got[len(got)-1] != published {
+		t.Errorf("expected the newest event to survive, got %v", got)
+	}
+}
+
+// TestMarketDataBus_RejectsAndCandles verifies that rejects are routed by the
+// rejected subscription's symbol, that closed candles are published, and that
+// Unsubscribe stops delivery.
+func TestMarketDataBus_RejectsAndCandles(t *testing.T) {
+	app := NewFixApp(&Config{}, nil)
+	app.Headless = true
+	app.Bus = NewMarketDataBus()
+	defer app.Bus.Close()
+	if err := app.EnableCandles([]time.Duration{time.Second}); err != nil {
+		t.Fatalf("EnableCandles failed: %v", err)
+	}
+
+	onBtcReject, btcRejects := collect[MarketDataReject]()
+	onEthReject, ethRejects := collect[MarketDataReject]()
+	onCandle, candles := collect[Candle]()
+	app.Bus.OnReject(SubscriberConfig{Symbols: []string{"BTC-USD"}}, onBtcReject)
+	app.Bus.OnReject(SubscriberConfig{Symbols: []string{"ETH-USD"}}, onEthReject)
+	candleSub := app.Bus.OnCandle(SubscriberConfig{Symbols: []string{"BTC-USD"}}, onCandle)
+
+	app.TradeStore.AddSubscription("BTC-USD", "1", "req-1")
+	app.handleMarketDataReject(parseFixMessage(t, "35=Y\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01262=req-1\x01281=0\x0158=bad symbol\x01"))
+	if reject := receive(t, btcRejects); reject.MdReqId != "req-1" || reject.Symbol != "BTC-USD" || reject.ReasonDesc != "Unknown symbol" || reject.Text != "bad symbol" {
+		t.Errorf("unexpected reject %+v", reject)
+	}
+	expectNone(t, ethRejects)
+
+	header := "35=X\x0149=COIN\x0156=CLIENT\x0152=20250101-12:00:05.000\x01262=req-2\x0155=BTC-USD\x01268=1\x01279=0\x01269=2\x01"
+	app.handleMarketDataMessage(parseFixMessage(t, header+"34=3\x01270=50000\x01271=1\x01273=20250101-12:00:00.100\x01"))
+	app.handleMarketDataMessage(parseFixMessage(t, header+"34=4\x01270=50001\x01271=1\x01273=20250101-12:00:03.000\x01"))
+	if c := receive(t, candles); c.Symbol != "BTC-USD" || c.Open.String() != "50000" || c.Trades != 1 {
+		t.Errorf("unexpected candle %+v", c)
+	}
+
+	candleSub.Unsubscribe()
+	<-candleSub.Done()
+	app.Candles.Flush()
+	expectNone(t, candles)
+	if n := len(app.Bus.Subscribers()); n != 2 {
+		t.Errorf("expected 2 subscribers after unsubscribing, got %d", n)
+	}
+}
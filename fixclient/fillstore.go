@@ -0,0 +1,213 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
+)
+
+// Fill is a single execution against an order. Unlike Order, which only keeps
+// the latest LastPx/LastShares, every fill of a partially-filled order is kept.
+type Fill struct {
+	ExecID       string    `json:"execId"`
+	ClOrdID      string    `json:"clOrdId"`
+	OrderID      string    `json:"orderId"`
+	Symbol       string    `json:"symbol"`
+	Side         string    `json:"side"` // "1" buy, "2" sell
+	LastPx       string    `json:"lastPx"`
+	LastShares   string    `json:"lastShares"`
+	Commission   string    `json:"commission,omitempty"`
+	MiscFees     []MiscFee `json:"miscFees,omitempty"`
+	TransactTime string    `json:"transactTime"` // Tag 60, or receipt time if absent
+}
+
+// FillStore is an in-memory, ExecID-deduplicated ledger of fills in the order
+// they were received.
+type FillStore struct {
+	mu     sync.RWMutex
+	fills  []Fill
+	execId map[string]bool
+}
+
+func NewFillStore() *FillStore {
+	return &FillStore{
+		execId: make(map[string]bool),
+	}
+}
+
+// AddFill records fill unless its ExecID has already been seen. It returns
+// true if the fill was added.
+func (fs *FillStore) AddFill(fill Fill) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.execId[fill.ExecID] {
+		return false
+	}
+	fs.execId[fill.ExecID] = true
+	fill.MiscFees = slices.Clone(fill.MiscFees)
+	fs.fills = append(fs.fills, fill)
+	return true
+}
+
+// Restore seeds the store with fills recorded by a previous run, so their
+// ExecIDs are recognised when Prime redelivers them.
+func (fs *FillStore) Restore(fills []Fill) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, fill := range fills {
+		if fs.execId[fill.ExecID] {
+			continue
+		}
+		fs.execId[fill.ExecID] = true
+		fill.MiscFees = slices.Clone(fill.MiscFees)
+		fs.fills = append(fs.fills, fill)
+	}
+}
+
+// GetFillsByClOrdID returns the fills of one order, oldest first.
+func (fs *FillStore) GetFillsByClOrdID(clOrdId string) []Fill {
+	return fs.filter(func(f *Fill) bool { return f.ClOrdID == clOrdId })
+}
+
+// GetFillsBySymbol returns the fills for a product, oldest first.
+func (fs *FillStore) GetFillsBySymbol(symbol string) []Fill {
+	return fs.filter(func(f *Fill) bool { return f.Symbol == symbol })
+}
+
+// GetAllFills returns every fill, oldest first.
+func (fs *FillStore) GetAllFills() []Fill {
+	return fs.filter(func(*Fill) bool { return true })
+}
+
+func (fs *FillStore) filter(match func(*Fill) bool) []Fill {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	var result []Fill
+	for i := range fs.fills {
+		if match(&fs.fills[i]) {
+			fill := fs.fills[i]
+			fill.MiscFees = slices.Clone(fill.MiscFees)
+			result = append(result, fill)
+		}
+	}
+	return result
+}
+
+// FillTotal sums the fills of one symbol and side.
+type FillTotal struct {
+	Symbol   string
+	Side     string
+	Count    int
+	Qty      decimal.Decimal
+	Notional decimal.Decimal // Σ LastPx × LastShares, at the finest price scale
+	VWAP     decimal.Decimal // Notional / Qty, at the finest price scale
+}
+
+// SummarizeFills totals fills per symbol and side, sorted by symbol with buys
+// first. Sums are exact; only Notional and VWAP are rounded, to the finest
+// price increment among the fills. Fills with an unparseable price or
+// quantity are skipped.
+func SummarizeFills(fills []Fill) []FillTotal {
+	type key struct{ symbol, side string }
+	type acc struct {
+		vwap  decimal.VWAP
+		count int
+		scale uint8
+	}
+	accs := make(map[key]*acc)
+	for i := range fills {
+		px, err1 := decimal.Parse(fills[i].LastPx)
+		qty, err2 := decimal.Parse(fills[i].LastShares)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		k := key{fills[i].Symbol, fills[i].Side}
+		a, ok := accs[k]
+		if !ok {
+			a = &acc{}
+			accs[k] = a
+		}
+		if a.vwap.Add(px, qty) != nil {
+			continue
+		}
+		a.count++
+		a.scale = max(a.scale, px.Scale())
+	}
+
+	totals := make([]FillTotal, 0, len(accs))
+	for k, a := range accs {
+		if a.count == 0 {
+			continue
+		}
+		total := FillTotal{Symbol: k.symbol, Side: k.side, Count: a.count, Qty: a.vwap.Qty()}
+		total.Notional, _ = a.vwap.Notional(a.scale)
+		total.VWAP, _ = a.vwap.Price(a.scale)
+		totals = append(totals, total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Symbol != totals[j].Symbol {
+			return totals[i].Symbol < totals[j].Symbol
+		}
+		return totals[i].Side < totals[j].Side
+	})
+	return totals
+}
+
+// fillFromExecReport returns the fill carried by er, if any. Fills are
+// reported with ExecType Trade (F) or the legacy Partial Fill (1) and
+// Filled (2) values; reports without an ExecID or a non-zero LastShares are
+// status updates, not executions.
+func fillFromExecReport(er *ExecutionReport) (Fill, bool) {
+	switch er.ExecType {
+	case constants.ExecTypeTrade, constants.ExecTypePartialFill, constants.ExecTypeFilled:
+	default:
+		return Fill{}, false
+	}
+	if er.ExecID == "" {
+		return Fill{}, false
+	}
+	if qty, err := decimal.Parse(er.LastShares); err != nil || qty.IsZero() {
+		return Fill{}, false
+	}
+
+	transactTime := er.TransactTime
+	if transactTime == "" {
+		transactTime = time.Now().UTC().Format(constants.FixTimeFormat)
+	}
+
+	return Fill{
+		ExecID:       er.ExecID,
+		ClOrdID:      er.ClOrdID,
+		OrderID:      er.OrderID,
+		Symbol:       er.Symbol,
+		Side:         er.Side,
+		LastPx:       er.LastPx,
+		LastShares:   er.LastShares,
+		Commission:   er.Commission,
+		MiscFees:     er.MiscFees,
+		TransactTime: transactTime,
+	}, true
+}
@@ -0,0 +1,195 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"path/filepath"
+	"testing"
+
+	"prime-fix-md-go/database"
+)
+
+// TestFillStore_DeduplicatesByExecID verifies a redelivered execution is only
+// recorded once.
+func TestFillStore_DeduplicatesByExecID(t *testing.T) {
+	store := NewFillStore()
+	fill := Fill{ExecID: "exec-1", ClOrdID: "ord-1", Symbol: "BTC-USD", LastPx: "50000", LastShares: "0.1"}
+
+	if !store.AddFill(fill) {
+		t.Fatal("expected first fill to be added")
+	}
+	if store.AddFill(fill) {
+		t.Error("expected duplicate ExecID to be ignored")
+	}
+	if n := len(store.GetAllFills()); n != 1 {
+		t.Errorf("expected 1 fill, got %d", n)
+	}
+}
+
+// TestFillStore_QueryByClOrdIDAndSymbol verifies fills are returned per order
+// and per product in the order they were received.
+func TestFillStore_QueryByClOrdIDAndSymbol(t *testing.T) {
+	store := NewFillStore()
+	store.AddFill(Fill{ExecID: "e1", ClOrdID: "ord-1", Symbol: "BTC-USD", LastShares: "0.1"})
+	store.AddFill(Fill{ExecID: "e2", ClOrdID: "ord-2", Symbol: "ETH-USD", LastShares: "1"})
+	store.AddFill(Fill{ExecID: "e3", ClOrdID: "ord-1", Symbol: "BTC-USD", LastShares: "0.2"})
+
+	byOrder := store.GetFillsByClOrdID("ord-1")
+	if len(byOrder) != 2 || byOrder[0].ExecID != "e1" || byOrder[1].ExecID != "e3" {
+		t.Errorf("expected e1, e3 for ord-1, got %+v", byOrder)
+	}
+	bySymbol := store.GetFillsBySymbol("ETH-USD")
+	if len(bySymbol) != 1 || bySymbol[0].ExecID != "e2" {
+		t.Errorf("expected e2 for ETH-USD, got %+v", bySymbol)
+	}
+	if fills := store.GetFillsByClOrdID("unknown"); len(fills) != 0 {
+		t.Errorf("expected no fills for unknown order, got %+v", fills)
+	}
+}
+
+// TestSummarizeFills_ExactVWAPPerSymbolAndSide verifies that totals are kept
+// per symbol and side and that tiny sizes are not lost next to large ones.
+func TestSummarizeFills_ExactVWAPPerSymbolAndSide(t *testing.T) {
+	totals := SummarizeFills([]Fill{
+		{Symbol: "BTC-USD", Side: "1", LastPx: "50000.00", LastShares: "0.5"},
+		{Symbol: "BTC-USD", Side: "1", LastPx: "50010.50", LastShares: "0.00000001"},
+		{Symbol: "BTC-USD", Side: "2", LastPx: "50100", LastShares: "0.1"},
+		{Symbol: "ETH-USD", Side: "1", LastPx: "bad", LastShares: "1"},
+	})
+	if len(totals) != 2 {
+		t.Fatalf("expected 2 totals, got %+v", totals)
+	}
+
+	buy := totals[0]
+	if buy.Side != "1" || buy.Count != 2 || buy.Qty.String() != "0.50000001" {
+		t.Errorf("unexpected buy total %+v", buy)
+	}
+	// 25000.000500105 rounds to the 0.01 price increment
+	if buy.Notional.String() != "25000.00" || buy.VWAP.String() != "50000.00" {
+		t.Errorf("buy notional/VWAP = %s/%s, want 25000.00/50000.00", buy.Notional, buy.VWAP)
+	}
+	if sell := totals[1]; sell.Side != "2" || sell.Notional.String() != "5010" || sell.VWAP.String() != "50100" {
+		t.Errorf("unexpected sell total %+v", sell)
+	}
+}
+
+// TestFillFromExecReport_OnlyExecutions verifies that only reports carrying a
+// non-zero execution become fills.
+func TestFillFromExecReport_OnlyExecutions(t *testing.T) {
+	tests := []struct {
+		name string
+		er   ExecutionReport
+		want bool
+	}{
+		{"trade", ExecutionReport{ExecID: "e1", ExecType: "F", LastShares: "0.1"}, true},
+		{"partial fill", ExecutionReport{ExecID: "e2", ExecType: "1", LastShares: "0.1"}, true},
+		{"filled", ExecutionReport{ExecID: "e3", ExecType: "2", LastShares: "0.1"}, true},
+		{"new", ExecutionReport{ExecID: "e4", ExecType: "0", LastShares: "0"}, false},
+		{"status with zero qty", ExecutionReport{ExecID: "e5", ExecType: "F", LastShares: "0"}, false},
+		{"no exec id", ExecutionReport{ExecType: "F", LastShares: "0.1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := fillFromExecReport(&tt.er); got != tt.want {
+				t.Errorf("fillFromExecReport() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHandleExecutionReport_RecordsEachFill verifies that every partial fill
+// of an order is kept in the ledger and persisted once, even when a report is
+// redelivered.
+func TestHandleExecutionReport_RecordsEachFill(t *testing.T) {
+	db, err := database.NewMarketDataDb(filepath.Join(t.TempDir(), "fills.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	app := &FixApp{OrderStore: NewOrderStore(), FillStore: NewFillStore(), Positions: NewPositions(),
+		TradeStore: NewTradeStore(100, ""), Db: db}
+	header := "35=8\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01"
+	first := header + "11=twap-1\x0137=exch-1\x0117=exec-1\x0139=1\x01150=F\x0155=BTC-USD\x0154=1\x01" +
+		"38=1\x0114=0.4\x01151=0.6\x0131=50000\x0132=0.4\x0112=2\x0160=20250101-12:00:00.000\x01" +
+		"136=1\x01137=0.50\x01138=USD\x01139=4\x01"
+	second := header + "11=twap-1\x0137=exch-1\x0117=exec-2\x0139=2\x01150=F\x0155=BTC-USD\x0154=1\x01" +
+		"38=1\x0114=1\x01151=0\x0131=50100\x0132=0.6\x0160=20250101-12:05:00.000\x01"
+
+	app.handleExecutionReport(parseFixMessage(t, first))
+	app.handleExecutionReport(parseFixMessage(t, second))
+	app.handleExecutionReport(parseFixMessage(t, first))
+
+	fills := app.FillStore.GetFillsByClOrdID("twap-1")
+	if len(fills) != 2 {
+		t.Fatalf("expected 2 fills, got %+v", fills)
+	}
+	if f := fills[0]; f.LastPx != "50000" || f.LastShares != "0.4" || f.Commission != "2" ||
+		f.TransactTime != "20250101-12:00:00.000" || len(f.MiscFees) != 1 {
+		t.Errorf("unexpected first fill %+v", f)
+	}
+	if f := fills[1]; f.ExecID != "exec-2" || f.LastPx != "50100" || f.LastShares != "0.6" {
+		t.Errorf("unexpected second fill %+v", f)
+	}
+
+	stored, err := db.GetFills("twap-1", "")
+	if err != nil {
+		t.Fatalf("GetFills failed: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("expected 2 stored fills, got %+v", stored)
+	}
+	if stored[0].MiscFees != `[{"amt":"0.50","curr":"USD","type":"4"}]` {
+		t.Errorf("unexpected stored fees %q", stored[0].MiscFees)
+	}
+}
+
+// TestHandleExecutionReport_FillRedeliveredAfterRestart verifies that a fill
+// stored by a previous run is neither recorded nor applied to positions again.
+func TestHandleExecutionReport_FillRedeliveredAfterRestart(t *testing.T) {
+	db, err := database.NewMarketDataDb(filepath.Join(t.TempDir(), "fills.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	fill := "35=8\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01" +
+		"11=twap-1\x0137=exch-1\x0117=exec-1\x0139=1\x01150=F\x0155=BTC-USD\x0154=1\x01" +
+		"38=1\x0114=0.4\x01151=0.6\x0131=50000\x0132=0.4\x0160=20250101-12:00:00.000\x01"
+
+	first := NewFixApp(&Config{}, db)
+	first.Headless = true
+	first.handleExecutionReport(parseFixMessage(t, fill))
+
+	restarted := NewFixApp(&Config{}, db)
+	restarted.Headless = true
+	if fills := restarted.FillStore.GetAllFills(); len(fills) != 1 || fills[0].ExecID != "exec-1" {
+		t.Fatalf("expected the stored fill to be restored, got %+v", fills)
+	}
+	restarted.handleExecutionReport(parseFixMessage(t, fill))
+	if pos, ok := restarted.Positions.Get("BTC-USD"); !ok || pos.BoughtQty.String() != "0.4" {
+		t.Errorf("expected the position bought 0.4 once, got %+v", pos)
+	}
+
+	// Without the restored ledger the fills table still recognises the fill
+	bare := &FixApp{OrderStore: NewOrderStore(), FillStore: NewFillStore(), Positions: NewPositions(),
+		TradeStore: NewTradeStore(100, ""), Db: db, Headless: true}
+	bare.handleExecutionReport(parseFixMessage(t, fill))
+	if _, ok := bare.Positions.Get("BTC-USD"); ok {
+		t.Error("expected a fill already in the database not to move the position")
+	}
+}
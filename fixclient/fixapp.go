@@ -40,6 +40,7 @@ Each message triggers this sequence; optimizations here have the highest impact.
 │     • Extracts message metadata (symbol, reqId, seqNum)                      │
 │     • Calls extractTrades() for parsing                                      │
 │     • Calls TradeStore.AddTrades() for storage                               │
+│     • Calls OrderBook.Apply*() to maintain the live L2 book                  │
 │     • Calls storeTradesToDatabase() for persistence (optional)               │
 │     • Cost: ~200ns (field extractions) + downstream costs                    │
 └─────────────────────────────────────────────────────────────────────────────┘
@@ -79,9 +80,10 @@ Each message triggers this sequence; optimizations here have the highest impact.
                                      ▼
 ┌─────────────────────────────────────────────────────────────────────────────┐
 │ [5] storeTradesToDatabase() - storage.go (OPTIONAL)              PERSISTENCE │
-│     • SQLite transaction with batch inserts                                  │
-│     • Cost: ~1-10ms depending on batch size and disk                         │
-│     • Can be made async to not block hot path                                │
+│     • With DbWriter: Enqueue() onto a bounded queue (dbwriter.go)            │
+│     • Writer goroutine batches many messages per SQLite transaction          │
+│     • Cost: ~100ns channel send, independent of disk speed                   │
+│     • Without DbWriter: synchronous tx per message (~1-10ms)                 │
 └─────────────────────────────────────────────────────────────────────────────┘
 
 PERFORMANCE CHARACTERISTICS (Apple M4 Pro benchmarks):
@@ -104,7 +106,11 @@ OPTIMIZATION NOTES:
 package fixclient
 
 import (
+	"errors"
 	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"prime-fix-md-go/builder"
@@ -129,11 +135,36 @@ type FixApp struct {
 
 	SessionId  quickfix.SessionID
 	TradeStore *TradeStore
+	OrderBook  *OrderBook
 	OrderStore *OrderStore
+	FillStore  *FillStore
+	Positions  *Positions
+	Strategies *Strategies
+	Candles    *CandleAggregator // Optional: set by EnableCandles
+	Bus        *MarketDataBus    // Optional: publishes market data to subscribers
+	Observer   Observer          // Told about handled messages; the console by default, nil for none
 	Db         *database.MarketDataDb
-
-	shouldExit    bool
-	lastLogonTime time.Time
+	DbWriter   *DbWriter // Optional: batches Db writes off the hot path
+
+	// Headless suppresses interactive output (help, per-message tables) when
+	// running as a daemon without the REPL.
+	Headless bool
+
+	// RiskChecks are applied to every new order before it is sent; an order
+	// that fails one is not sent.
+	RiskChecks RiskChain
+
+	// ReconcileMode selects how open orders are re-queried after each logon
+	// (constants.ReconcileMode*); empty means none.
+	ReconcileMode string
+	reconcile     atomic.Pointer[reconciliation]
+	massCancel    atomic.Pointer[massCancel] // Pending CancelAll request
+	replies       pendingReplies             // Client calls awaiting an answer
+	strategyMu    sync.Mutex                 // Serializes strategy decisions with the orders they send
+
+	shouldExit   atomic.Bool
+	loggedOn     atomic.Bool
+	everLoggedOn atomic.Bool // Set by the first successful logon
 }
 
 func NewConfig(apiKey, apiSecret, passphrase, senderCompId, targetCompId, portfolioId string) *Config {
@@ -150,14 +181,61 @@ func NewConfig(apiKey, apiSecret, passphrase, senderCompId, targetCompId, portfo
 func NewFixApp(config *Config, db *database.MarketDataDb) *FixApp {
 	tradeStore := NewTradeStore(10000, "")
 	orderStore := NewOrderStore()
+	positions := NewPositions()
+	fillStore := NewFillStore()
+	strategies := NewStrategies()
+	var restoredQuotes []*Quote
+	if db != nil {
+		if orders, quotes, err := loadOrderStore(db, time.Now()); err != nil {
+			log.Printf("Order storage unavailable, starting empty: %v", err)
+		} else {
+			orderStore.Restore(orders, quotes)
+			restoredQuotes = quotes
+			if len(orders) > 0 || len(quotes) > 0 {
+				log.Printf("Restored %d open order(s) and %d quote(s) from the database", len(orders), len(quotes))
+			}
+		}
+		orderStore.SetPersister(NewDbOrderPersister(db))
+
+		if restored, err := loadFills(db); err != nil {
+			log.Printf("Fill history unavailable, starting empty: %v", err)
+		} else {
+			fillStore.Restore(restored)
+		}
+
+		if restored, err := loadPositions(db); err != nil {
+			log.Printf("Position snapshots unavailable, starting flat: %v", err)
+		} else {
+			positions.Restore(restored)
+		}
+
+		if restored, err := loadStrategies(db); err != nil {
+			log.Printf("Strategy storage unavailable, starting without strategies: %v", err)
+		} else if len(restored) > 0 {
+			strategies.Restore(restored)
+			log.Printf("Restored %d active strategies from the database", len(restored))
+		}
+		strategies.SetPersister(NewDbStrategyPersister(db))
+	}
 
-	return &FixApp{
+	app := &FixApp{
 		Config:     config,
 		TradeStore: tradeStore,
+		OrderBook:  NewOrderBook(),
 		OrderStore: orderStore,
+		FillStore:  fillStore,
+		Positions:  positions,
+		Strategies: strategies,
 		Db:         db,
-		shouldExit: false,
 	}
+	app.Observer = consoleObserver{app}
+	for _, quote := range restoredQuotes {
+		app.scheduleQuoteExpiry(quote)
+		if isFinishedQuote(quote) {
+			app.pruneQuoteLater(quote.QuoteReqID)
+		}
+	}
+	return app
 }
 
 func (a *FixApp) OnCreate(sid quickfix.SessionID) {
@@ -166,11 +244,16 @@ func (a *FixApp) OnCreate(sid quickfix.SessionID) {
 
 func (a *FixApp) OnLogout(sid quickfix.SessionID) {
 	log.Println("Logout", sid)
-
-	timeSinceLogon := time.Since(a.lastLogonTime)
-	if timeSinceLogon < 5*time.Second || a.lastLogonTime.IsZero() {
+	a.loggedOn.Store(false)
+	a.TradeStore.MarkSubscriptionsStale()
+
+	// quickfix also reports a Logon that was answered with a Logout, or not
+	// at all, as a logout. Only the first logon is treated as a credentials
+	// check; once a session has been established every drop, including one
+	// while logging on again, reconnects as usual.
+	if !a.everLoggedOn.Load() {
 		log.Printf("Authentication failed. Exiting to prevent reconnection loop.")
-		a.shouldExit = true
+		a.shouldExit.Store(true)
 	}
 }
 
@@ -181,24 +264,56 @@ func (a *FixApp) FromAdmin(msg *quickfix.Message, _ quickfix.SessionID) quickfix
 	return nil
 }
 
-func (a *FixApp) ToApp(_ *quickfix.Message, _ quickfix.SessionID) error {
+// ToApp is called before every outgoing application message. For messages
+// replayed in response to a ResendRequest, returning ErrDoNotSend makes
+// quickfix send a SequenceReset-GapFill in their place.
+func (a *FixApp) ToApp(msg *quickfix.Message, _ quickfix.SessionID) error {
+	if isPossDup(msg) && !shouldResend(msg, time.Now()) {
+		logResendDecision(msg)
+		a.resubscribeIfTracked(msg)
+		return quickfix.ErrDoNotSend
+	}
 	return nil
 }
 
 func (a *FixApp) OnLogon(sid quickfix.SessionID) {
 	a.SessionId = sid
-	a.lastLogonTime = time.Now()
 	log.Println("✓ FIX logon", sid)
-	a.displayConnectionSuccess()
-	a.displayHelp()
+	a.observer().OnLogon()
+	// Logged on only once the tracked subscriptions have been resent, so
+	// that requests made meanwhile (e.g. by RunDaemon) are not resent too
+	a.resubscribeAll()
+	a.loggedOn.Store(true)
+	a.everLoggedOn.Store(true)
+
+	// Strategies resume once reconciliation has brought their orders up to date
+	if !a.startReconciliation() {
+		a.resumeStrategies()
+	}
+}
+
+// IsLoggedOn reports whether the FIX session is currently logged on.
+func (a *FixApp) IsLoggedOn() bool {
+	return a.loggedOn.Load()
 }
 
 func (a *FixApp) ToAdmin(msg *quickfix.Message, _ quickfix.SessionID) {
 	if t, _ := msg.Header.GetString(constants.TagMsgType); t == constants.MsgTypeLogon {
-		ts := time.Now().UTC().Format(constants.FixTimeFormat)
+		// quickfix fills SendingTime and MsgSeqNum before calling ToAdmin. With
+		// ResetSeqNumFlag=Y the sequence is reset to 1 after this callback.
+		ts, err := msg.Header.GetString(constants.TagSendingTime)
+		if err != nil {
+			ts = time.Now().UTC().Format(constants.FixTimeFormat)
+		}
+		seqNum, err := msg.Header.GetString(constants.TagMsgSeqNum)
+		if reset, _ := msg.Body.GetBool(constants.TagResetSeqNumFlag); reset || err != nil {
+			seqNum = constants.MsgSeqNumInit
+		}
+
 		builder.BuildLogon(
 			&msg.Body,
 			ts,
+			seqNum,
 			a.Config.ApiKey,
 			a.Config.ApiSecret,
 			a.Config.Passphrase,
@@ -226,6 +341,18 @@ func (a *FixApp) FromApp(msg *quickfix.Message, _ quickfix.SessionID) quickfix.M
 		a.handleExecutionReport(msg)
 	case constants.MsgTypeOrderCancelReject:
 		a.handleOrderCancelReject(msg)
+	case constants.MsgTypeOrderMassCancelReport:
+		a.handleOrderMassCancelReport(&OrderMassCancelReport{
+			ClOrdID:                utils.GetString(msg, constants.TagClOrdID),
+			OrderID:                utils.GetString(msg, constants.TagOrderID),
+			MassCancelRequestType:  utils.GetString(msg, constants.TagMassCancelRequestType),
+			MassCancelResponse:     utils.GetString(msg, constants.TagMassCancelResponse),
+			MassCancelRejectReason: utils.GetString(msg, constants.TagMassCancelRejectReason),
+			TotalAffectedOrders:    utils.GetString(msg, constants.TagTotalAffectedOrders),
+			Symbol:                 utils.GetString(msg, constants.TagSymbol),
+			Side:                   utils.GetString(msg, constants.TagSide),
+			Text:                   utils.GetString(msg, constants.TagText),
+		})
 	case constants.MsgTypeQuote:
 		a.handleQuote(msg)
 	case constants.MsgTypeQuoteAcknowledgement:
@@ -244,11 +371,21 @@ func (a *FixApp) handleMarketDataReject(msg *quickfix.Message) {
 	rejReason := utils.GetString(msg, constants.TagMdReqRejReason)
 	text := utils.GetString(msg, constants.TagText)
 
-	reasonDesc := getMdReqRejReasonDesc(rejReason)
+	reject := MarketDataReject{
+		MdReqId:    mdReqId,
+		Symbol:     a.TradeStore.SubscriptionSymbol(mdReqId),
+		Reason:     rejReason,
+		ReasonDesc: getMdReqRejReasonDesc(rejReason),
+		Text:       text,
+		Time:       time.Now(),
+	}
 
-	a.displayMarketDataReject(mdReqId, rejReason, reasonDesc, text)
+	a.observer().OnMarketDataReject(reject)
+	if a.Bus != nil {
+		a.Bus.publishReject(reject)
+	}
 	a.TradeStore.RemoveSubscriptionByReqId(mdReqId)
-	a.displayMarketDataRejectHelp(rejReason)
+	a.replies.resolve(mdReqId, &MarketDataRejectedError{Reject: reject})
 }
 
 func getMdReqRejReasonDesc(reason string) string {
@@ -277,7 +414,7 @@ func getMdReqRejReasonDesc(reason string) string {
 }
 
 func (a *FixApp) ShouldExit() bool {
-	return a.shouldExit
+	return a.shouldExit.Load()
 }
 
 // handleMarketDataMessage processes market data snapshots and incremental updates.
@@ -288,14 +425,11 @@ func (a *FixApp) handleMarketDataMessage(msg *quickfix.Message) {
 	msgType, _ := msg.Header.GetString(constants.TagMsgType)
 	mdReqId := utils.GetString(msg, constants.TagMdReqId)
 	symbol := utils.GetString(msg, constants.TagSymbol)
-	noMdEntries := utils.GetString(msg, constants.TagNoMdEntries)
 	seqNum, _ := msg.Header.GetString(constants.TagMsgSeqNum)
 
 	isSnapshot := msgType == constants.MsgTypeMarketDataSnapshot
 	isIncremental := msgType == constants.MsgTypeMarketDataIncremental
 
-	a.displayMarketDataReceived(msgType, symbol, mdReqId, noMdEntries, seqNum)
-
 	// HOT PATH [3]: Parse raw FIX message into Trade structs
 	// Cost: O(n*m) where n=entries, m=message length
 	trades := a.extractTrades(msg, symbol, mdReqId, isSnapshot, seqNum)
@@ -303,32 +437,52 @@ func (a *FixApp) handleMarketDataMessage(msg *quickfix.Message) {
 	// HOT PATH [4]: Store in ring buffer - O(1) per trade, zero allocs
 	a.TradeStore.AddTrades(symbol, trades, isSnapshot, mdReqId)
 
-	// HOT PATH [5]: Optional persistence - can block if sync
-	// Consider making async for high-throughput scenarios
+	// Maintain live L2 book from bid/offer entries. A snapshot for a book
+	// request replaces the book even when it is empty; trade-only snapshots
+	// leave it alone
+	if isSnapshot && (hasBookEntries(trades) || a.TradeStore.SubscriptionHasBook(mdReqId)) {
+		a.OrderBook.ApplySnapshot(symbol, trades)
+	} else if isIncremental {
+		a.OrderBook.ApplyIncremental(symbol, trades)
+	}
+
+	// Trailing stops follow live trades; skipped without an active one
+	if isIncremental && a.Strategies.HasTrailing() {
+		a.onStrategyTrades(trades)
+	}
+
+	// Candles are built from live trades only: snapshot trades are history
+	// that a resubscribe would count twice
+	if isIncremental && a.Candles != nil {
+		a.Candles.AddTrades(trades)
+	}
+
+	// Subscribers get their own queues, so this never waits on a handler
+	if a.Bus != nil {
+		a.Bus.publishMarketData(symbol, trades, seqNum, isSnapshot, a.OrderBook)
+	}
+
+	// HOT PATH [5]: Optional persistence - a queue send when DbWriter is set,
+	// otherwise a synchronous transaction that blocks on disk
 	a.storeTradesToDatabase(trades, seqNum, isSnapshot)
 
+	// The first data for a request answers a caller waiting on it
+	a.replies.resolve(mdReqId, nil)
+
 	// Display is not part of hot path critical section
-	if isSnapshot {
-		a.displaySnapshotTrades(trades, symbol)
-	} else if isIncremental {
-		a.displayIncrementalTrades(trades)
-	}
+	a.observer().OnMarketData(msgType, symbol, mdReqId, seqNum, trades)
 }
 
 // handleExecutionReport processes Execution Report (8) messages.
 // Updates order state and displays execution details.
 //
-// TODO: MiscFees repeating group (Tags 136-139) is not currently parsed.
-// Per Coinbase Prime FIX API, Execution Reports may contain:
-//   - Tag 136 (NoMiscFees) - number of fee entries
-//   - Tag 137 (MiscFeeAmt) - fee amount
-//   - Tag 138 (MiscFeeCurr) - fee currency
-//   - Tag 139 (MiscFeeType) - fee type (1=Financing, 2=ClientComm, 3=CESComm, 4=VenueFee)
-//
-// See: https://docs.cdp.coinbase.com/prime/fix-api/order-entry-messages
+// The MiscFees repeating group (Tags 136-139) carries the fees charged on this
+// execution (financing, client commission, CES commission, venue fee); see
+// parseMiscFees and https://docs.cdp.coinbase.com/prime/fix-api/order-entry-messages
 func (a *FixApp) handleExecutionReport(msg *quickfix.Message) {
 	er := &ExecutionReport{
 		ClOrdID:      utils.GetString(msg, constants.TagClOrdID),
+		OrigClOrdID:  utils.GetString(msg, constants.TagOrigClOrdID),
 		OrderID:      utils.GetString(msg, constants.TagOrderID),
 		ExecID:       utils.GetString(msg, constants.TagExecID),
 		Account:      utils.GetString(msg, constants.TagAccount),
@@ -350,10 +504,87 @@ func (a *FixApp) handleExecutionReport(msg *quickfix.Message) {
 		NetAvgPx:     utils.GetString(msg, constants.TagNetAvgPrice),
 		OrdRejReason: utils.GetString(msg, constants.TagOrdRejReason),
 		Text:         utils.GetString(msg, constants.TagText),
+		TransactTime: utils.GetString(msg, constants.TagTransactTime),
+
+		MassStatusReqID:  utils.GetString(msg, constants.TagMassStatusReqID),
+		TotNumReports:    utils.GetString(msg, constants.TagTotNumReports),
+		LastRptRequested: utils.GetString(msg, constants.TagLastRptRequested),
+	}
+	if utils.GetString(msg, constants.TagNoMiscFees) != "" {
+		er.MiscFees = parseMiscFees(msg.String())
+	}
+
+	if a.handleReconciliationReport(er) {
+		return
+	}
+
+	// Cumulative fees on the order before this report price the execution
+	var prev *Order
+	if er.LastShares != "" {
+		if prev = a.OrderStore.GetOrder(er.ClOrdID); prev == nil && er.OrderID != "" {
+			prev = a.OrderStore.GetOrderByOrderID(er.OrderID)
+		}
+	}
+
+	// A refused report is still displayed, and its execution still recorded:
+	// only the order's state is protected from it
+	if err := a.OrderStore.UpdateOrderFromExecReport(er); errors.Is(err, ErrDuplicateExecID) {
+		log.Printf("Ignoring duplicate execution report: %v", err)
+		return
+	} else if errors.Is(err, ErrUntrackedOrder) {
+		log.Printf("Tracking order from execution report: %v", err)
+	} else if err != nil {
+		log.Printf("Execution report not applied to order: %v", err)
 	}
+	if fill, ok := fillFromExecReport(er); ok && a.FillStore.AddFill(fill) && a.storeFillToDatabase(fill) {
+		if pos, ok := a.Positions.ApplyExecution(er, prev); ok {
+			a.storePositionSnapshot(pos)
+		}
+	}
+	a.afterExecutionReport(er)
+}
 
-	a.OrderStore.UpdateOrderFromExecReport(er)
-	a.displayExecutionReport(er)
+// afterExecutionReport shows er once OrderStore has applied it and passes it
+// on to the request and strategy waiting on the order.
+func (a *FixApp) afterExecutionReport(er *ExecutionReport) {
+	a.observer().OnExecutionReport(er)
+	a.resolveOrderReply(er)
+	a.onStrategyOrderUpdate(er.ClOrdID)
+	a.pruneAcceptedQuote(er.ClOrdID)
+}
+
+// parseMiscFees extracts the MiscFees repeating group from a raw FIX message.
+// Like the market data parser it reads the raw string because the session runs
+// without a data dictionary. Each entry starts at MiscFeeAmt (137), the
+// group's first field; the group ends at the first field outside 137-139.
+func parseMiscFees(rawMsg string) []MiscFee {
+	start := strings.Index(rawMsg, "\x01136=")
+	if start < 0 {
+		return nil
+	}
+
+	var fees []MiscFee
+	fields := strings.Split(rawMsg[start+1:], "\x01")
+	for _, field := range fields[1:] {
+		tag, value, _ := strings.Cut(field, "=")
+		switch tag {
+		case "137":
+			fees = append(fees, MiscFee{Amt: value})
+		case "138":
+			if len(fees) == 0 {
+				return nil
+			}
+			fees[len(fees)-1].Curr = value
+		case "139":
+			if len(fees) == 0 {
+				return nil
+			}
+			fees[len(fees)-1].Type = value
+		default:
+			return fees
+		}
+	}
+	return fees
 }
 
 // handleOrderCancelReject processes Order Cancel Reject (9) messages.
@@ -368,7 +599,12 @@ func (a *FixApp) handleOrderCancelReject(msg *quickfix.Message) {
 		Text:             utils.GetString(msg, constants.TagText),
 	}
 
-	a.displayOrderCancelReject(reject)
+	a.observer().OnOrderCancelReject(reject)
+	a.replies.resolve(reject.ClOrdID, &OrderRejectedError{
+		ClOrdID: reject.ClOrdID,
+		Reason:  cancelRejectReason(reject),
+		Text:    reject.Text,
+	})
 }
 
 // handleQuote processes Quote (S) messages from RFQ responses.
@@ -392,10 +628,12 @@ func (a *FixApp) handleQuote(msg *quickfix.Message) {
 	}
 
 	a.OrderStore.AddQuote(quote)
-	a.displayQuote(quote)
+	a.scheduleQuoteExpiry(quote)
+	a.observer().OnQuote(quote)
 }
 
-// handleQuoteAck processes Quote Acknowledgement (b) messages (rejections).
+// handleQuoteAck processes Quote Acknowledgement (b) messages (rejections)
+// and marks the RFQ they answer rejected.
 func (a *FixApp) handleQuoteAck(msg *quickfix.Message) {
 	ack := &QuoteAck{
 		QuoteID:           utils.GetString(msg, constants.TagQuoteID),
@@ -407,7 +645,9 @@ func (a *FixApp) handleQuoteAck(msg *quickfix.Message) {
 		Text:              utils.GetString(msg, constants.TagText),
 	}
 
-	a.displayQuoteAck(ack)
+	rfq := a.OrderStore.RejectQuoteRequest(ack)
+	a.pruneQuoteLater(ack.QuoteReqID)
+	a.observer().OnQuoteAck(ack, rfq)
 }
 
 // handleSessionReject processes session-level Reject (3) messages.
@@ -420,7 +660,8 @@ func (a *FixApp) handleSessionReject(msg *quickfix.Message) {
 		Text:                utils.GetString(msg, constants.TagText),
 	}
 
-	a.displaySessionReject(reject)
+	a.observer().OnSessionReject(reject)
+	a.handleMassCancelRejected(reject.RefMsgType)
 }
 
 // handleBusinessReject processes Business Message Reject (j) messages.
@@ -429,8 +670,12 @@ func (a *FixApp) handleBusinessReject(msg *quickfix.Message) {
 		RefSeqNum:            utils.GetString(msg, constants.TagRefSeqNum),
 		RefMsgType:           utils.GetString(msg, constants.TagRefMsgType),
 		BusinessRejectReason: utils.GetString(msg, constants.TagBusinessRejectReason),
+		BusinessRejectRefID:  utils.GetString(msg, constants.TagBusinessRejectRefID),
 		Text:                 utils.GetString(msg, constants.TagText),
 	}
 
-	a.displayBusinessReject(reject)
+	a.observer().OnBusinessReject(reject)
+	a.handleMassCancelRejected(reject.RefMsgType)
+	a.handleReconciliationReject(reject)
+	a.resolveBusinessReject(reject)
 }
@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"fmt"
+	"strings"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/database"
+	"prime-fix-md-go/formatter"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+const (
+	defaultJournalPath       = "fix-journal.log"
+	defaultJournalMaxSizeMB  = 100
+	defaultJournalMaxBackups = 10
+)
+
+// NewJournal selects the raw message journal from the Journal setting in the
+// [DEFAULT] section of fix.cfg:
+//   - none:   no journal (default)
+//   - file:   rotating text file at JournalPath, rotated at JournalMaxSizeMB
+//     keeping JournalMaxBackups old files
+//   - sqlite: fix_messages table in the market data database (requires db)
+//
+// A nil Journal is returned when journaling is disabled.
+func NewJournal(settings *quickfix.Settings, db *database.MarketDataDb) (formatter.Journal, error) {
+	global := settings.GlobalSettings()
+
+	journalType := constants.JournalTypeNone
+	if global.HasSetting(constants.SettingJournal) {
+		value, _ := global.Setting(constants.SettingJournal)
+		journalType = strings.ToLower(strings.TrimSpace(value))
+	}
+
+	switch journalType {
+	case constants.JournalTypeNone, "":
+		return nil, nil
+	case constants.JournalTypeFile:
+		path := defaultJournalPath
+		if global.HasSetting(constants.SettingJournalPath) {
+			path, _ = global.Setting(constants.SettingJournalPath)
+		}
+		maxSizeMB, err := intSetting(global, constants.SettingJournalMaxSizeMB, defaultJournalMaxSizeMB)
+		if err != nil {
+			return nil, err
+		}
+		maxBackups, err := intSetting(global, constants.SettingJournalMaxBackups, defaultJournalMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		journal, err := formatter.NewFileJournal(path, int64(maxSizeMB)*1024*1024, maxBackups)
+		if err != nil {
+			return nil, err
+		}
+		return journal, nil
+	case constants.JournalTypeSqlite:
+		if db == nil {
+			return nil, fmt.Errorf("journal %q requires a database", journalType)
+		}
+		return db.NewFixMessageJournal(), nil
+	default:
+		return nil, fmt.Errorf("unknown journal %q (expected %s, %s or %s)", journalType,
+			constants.JournalTypeNone, constants.JournalTypeFile, constants.JournalTypeSqlite)
+	}
+}
+
+func intSetting(settings *quickfix.SessionSettings, name string, defaultValue int) (int, error) {
+	if !settings.HasSetting(name) {
+		return defaultValue, nil
+	}
+	value, err := settings.IntSetting(name)
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("%s must not be negative", name)
+	}
+	return value, nil
+}
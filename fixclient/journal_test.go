@@ -0,0 +1,56 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"path/filepath"
+	"testing"
+
+	"prime-fix-md-go/formatter"
+)
+
+// Tests for journal selection from fix.cfg.
+
+const testSessionSection = "[SESSION]\nBeginString=FIXT.1.1\nSenderCompID=A\nTargetCompID=B\n"
+
+// TestNewJournal_Selection verifies each Journal setting value.
+func TestNewJournal_Selection(t *testing.T) {
+	journal, err := NewJournal(parseTestSettings(t, "[DEFAULT]\n"+testSessionSection), nil)
+	if err != nil || journal != nil {
+		t.Errorf("expected no journal by default, got %v, %v", journal, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "journal.log")
+	journal, err = NewJournal(parseTestSettings(t, "[DEFAULT]\nJournal=file\nJournalPath="+path+"\nJournalMaxSizeMB=1\n"+testSessionSection), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := journal.(*formatter.FileJournal); !ok {
+		t.Errorf("expected file journal, got %T", journal)
+	}
+	_ = journal.Close()
+
+	if _, err := NewJournal(parseTestSettings(t, "[DEFAULT]\nJournal=sqlite\n"+testSessionSection), nil); err == nil {
+		t.Error("expected error for sqlite journal without a database")
+	}
+	if _, err := NewJournal(parseTestSettings(t, "[DEFAULT]\nJournal=syslog\n"+testSessionSection), nil); err == nil {
+		t.Error("expected error for unknown journal")
+	}
+	if _, err := NewJournal(parseTestSettings(t, "[DEFAULT]\nJournal=file\nJournalMaxBackups=-1\n"+testSessionSection), nil); err == nil {
+		t.Error("expected error for negative JournalMaxBackups")
+	}
+}
@@ -0,0 +1,220 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"prime-fix-md-go/builder"
+	"prime-fix-md-go/constants"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// massCancelTimeout bounds how long CancelAll waits for an Order Mass Cancel
+// Report before falling back to per-order cancels.
+const massCancelTimeout = 5 * time.Second
+
+// lastCancelId holds the last nanosecond timestamp used for a cancel ClOrdID
+// so that cancels sent in a loop never share an id.
+var lastCancelId atomic.Int64
+
+// massCancel is an Order Mass Cancel Request (q) awaiting its report.
+type massCancel struct {
+	clOrdId string
+	symbol  string // Empty for all symbols
+	side    string // Empty for both sides
+
+	mu    sync.Mutex
+	timer *time.Timer
+	done  bool
+}
+
+// finish marks m answered and returns false if it already was.
+func (m *massCancel) finish() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done {
+		return false
+	}
+	m.done = true
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	return true
+}
+
+// CancelAll is the kill switch: it cancels every open order, or only those in
+// symbol and/or on side when given, with a single Order Mass Cancel Request
+// (q). If Prime rejects the request or no Order Mass Cancel Report (r) arrives
+// within massCancelTimeout, each matching order from OrderStore.GetOpenOrders
+// is cancelled individually instead. A side without a symbol has no mass
+// cancel request type that honours it, so those orders are cancelled
+// individually straight away. Returns the request's ClOrdID, or "" when
+// per-order cancels were sent.
+func (a *FixApp) CancelAll(symbol, side string) (string, error) {
+	if symbol == "" && side != "" {
+		sent := a.cancelOpenOrders(symbol, side)
+		log.Printf("Sent %d cancel request(s) for %s", sent, massCancelScope(symbol, side))
+		return "", nil
+	}
+
+	reqType := constants.MassCancelRequestTypeAllOrders
+	if symbol != "" {
+		reqType = constants.MassCancelRequestTypeSecurity
+	}
+
+	m := &massCancel{
+		clOrdId: "mcxl_" + strconv.FormatInt(nextNano(&lastCancelId), 10),
+		symbol:  symbol,
+		side:    side,
+	}
+	if prev := a.massCancel.Swap(m); prev != nil {
+		prev.finish()
+	}
+
+	msg := builder.BuildOrderMassCancelRequest(builder.MassCancelParams{
+		ClOrdID:               m.clOrdId,
+		Account:               a.Config.PortfolioId,
+		MassCancelRequestType: reqType,
+		Symbol:                symbol,
+		Side:                  side,
+	}, a.Config.SenderCompId, a.Config.TargetCompId)
+
+	m.mu.Lock()
+	m.timer = time.AfterFunc(massCancelTimeout, func() {
+		a.fallBackToOrderCancels(m, fmt.Sprintf("no Order Mass Cancel Report after %s", massCancelTimeout))
+	})
+	m.mu.Unlock()
+
+	if err := quickfix.SendToTarget(msg, a.SessionId); err != nil {
+		m.finish()
+		a.massCancel.CompareAndSwap(m, nil)
+		return "", fmt.Errorf("sending order mass cancel request: %w", err)
+	}
+	log.Printf("Order mass cancel sent (ClOrdID: %s, scope: %s)", m.clOrdId, massCancelScope(symbol, side))
+	return m.clOrdId, nil
+}
+
+// handleOrderMassCancelReport processes Order Mass Cancel Report (r) messages.
+// The cancelled orders themselves are reported by Execution Reports.
+func (a *FixApp) handleOrderMassCancelReport(report *OrderMassCancelReport) {
+	a.observer().OnOrderMassCancelReport(report)
+
+	m := a.massCancel.Load()
+	if m == nil || m.clOrdId != report.ClOrdID {
+		return
+	}
+	if report.MassCancelResponse == constants.MassCancelResponseRejected {
+		a.fallBackToOrderCancels(m, "mass cancel rejected")
+		return
+	}
+	if m.finish() {
+		a.massCancel.CompareAndSwap(m, nil)
+	}
+}
+
+// handleMassCancelRejected falls back to per-order cancels when the pending
+// mass cancel is refused at the session or business level (e.g. an
+// unsupported message type), which carries no ClOrdID to match on.
+func (a *FixApp) handleMassCancelRejected(refMsgType string) {
+	if refMsgType != constants.MsgTypeOrderMassCancelRequest {
+		return
+	}
+	if m := a.massCancel.Load(); m != nil {
+		a.fallBackToOrderCancels(m, "mass cancel request rejected")
+	}
+}
+
+// fallBackToOrderCancels cancels each open order in m's scope individually,
+// unless m has already been answered.
+func (a *FixApp) fallBackToOrderCancels(m *massCancel, reason string) {
+	if !m.finish() {
+		return
+	}
+	a.massCancel.CompareAndSwap(m, nil)
+
+	log.Printf("Falling back to per-order cancels (%s)", reason)
+	sent := a.cancelOpenOrders(m.symbol, m.side)
+	log.Printf("Sent %d cancel request(s) for %s", sent, massCancelScope(m.symbol, m.side))
+}
+
+// cancelOpenOrders sends an Order Cancel Request (F) for every open order in
+// symbol and on side (empty matches any) and returns how many were sent.
+func (a *FixApp) cancelOpenOrders(symbol, side string) int {
+	sent := 0
+	for _, order := range a.OrderStore.GetOpenOrders() {
+		if (symbol != "" && order.Symbol != symbol) || (side != "" && order.Side != side) {
+			continue
+		}
+		if _, err := a.sendCancel(order); err != nil {
+			log.Printf("Error sending cancel for %s: %v", order.ClOrdID, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// sendCancel sends an Order Cancel Request (F) for order and returns the
+// cancel's ClOrdID.
+func (a *FixApp) sendCancel(order *Order) (string, error) {
+	newClOrdID := newCancelClOrdID()
+	if err := a.sendCancelAs(newClOrdID, order); err != nil {
+		return "", err
+	}
+	return newClOrdID, nil
+}
+
+// sendCancelAs sends an Order Cancel Request (F) for order under newClOrdID.
+func (a *FixApp) sendCancelAs(newClOrdID string, order *Order) error {
+	params := builder.CancelOrderParams{
+		ClOrdID:     newClOrdID,
+		OrigClOrdID: order.ClOrdID,
+		OrderID:     order.OrderID,
+		Account:     a.Config.PortfolioId,
+		Symbol:      order.Symbol,
+		Side:        order.Side,
+		OrderQty:    order.OrderQty,
+	}
+
+	msg := builder.BuildOrderCancelRequest(params, a.Config.SenderCompId, a.Config.TargetCompId)
+	return quickfix.SendToTarget(msg, a.SessionId)
+}
+
+// newCancelClOrdID returns a unique ClOrdID for an Order Cancel Request.
+func newCancelClOrdID() string {
+	return "cxl_" + strconv.FormatInt(nextNano(&lastCancelId), 10)
+}
+
+// massCancelScope describes which orders a mass cancel covers, e.g.
+// "all BTC-USD Buy orders".
+func massCancelScope(symbol, side string) string {
+	scope := "all"
+	if symbol != "" {
+		scope += " " + symbol
+	}
+	if side != "" {
+		scope += " " + getSideDesc(side)
+	}
+	return scope + " orders"
+}
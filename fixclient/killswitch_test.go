@@ -0,0 +1,67 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"testing"
+
+	"prime-fix-md-go/constants"
+)
+
+// TestMassCancel_ReportResolvesPending verifies that only the report for the
+// pending request resolves it, whether accepted or rejected, and that rejects
+// of other message types are ignored.
+func TestMassCancel_ReportResolvesPending(t *testing.T) {
+	app := &FixApp{Config: &Config{}, OrderStore: NewOrderStore(), Headless: true}
+
+	pending := &massCancel{clOrdId: "mcxl-1"}
+	app.massCancel.Store(pending)
+	app.handleOrderMassCancelReport(&OrderMassCancelReport{ClOrdID: "mcxl-0", MassCancelResponse: constants.MassCancelResponseAllOrders})
+	app.handleMassCancelRejected(constants.MsgTypeNewOrderSingle)
+	if app.massCancel.Load() != pending {
+		t.Fatal("expected unrelated messages to leave the mass cancel pending")
+	}
+	app.handleOrderMassCancelReport(&OrderMassCancelReport{ClOrdID: "mcxl-1", MassCancelResponse: constants.MassCancelResponseAllOrders, TotalAffectedOrders: "2"})
+	if app.massCancel.Load() != nil || !pending.done {
+		t.Error("expected an accepted report to resolve the mass cancel")
+	}
+
+	// Falling back without a session sends nothing but still resolves it
+	rejected := &massCancel{clOrdId: "mcxl-2"}
+	app.massCancel.Store(rejected)
+	app.handleMassCancelRejected(constants.MsgTypeOrderMassCancelRequest)
+	if app.massCancel.Load() != nil || !rejected.done {
+		t.Error("expected a business reject of the request to resolve the mass cancel")
+	}
+}
+
+// TestMassCancelScope verifies the description of a kill switch's scope.
+func TestMassCancelScope(t *testing.T) {
+	tests := []struct {
+		symbol, side, want string
+	}{
+		{"", "", "all orders"},
+		{"BTC-USD", "", "all BTC-USD orders"},
+		{"BTC-USD", constants.SideSell, "all BTC-USD Sell orders"},
+		{"", constants.SideBuy, "all Buy orders"},
+	}
+	for _, tt := range tests {
+		if got := massCancelScope(tt.symbol, tt.side); got != tt.want {
+			t.Errorf("massCancelScope(%q, %q) = %q, want %q", tt.symbol, tt.side, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/database"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/config"
+	"github.com/quickfixgo/quickfix/store/file"
+)
+
+// maxResendAge bounds how old an application message may be and still be
+// resent in response to a ResendRequest. Older messages are gap-filled: a
+// stale order or subscription replayed after a long disconnect is more likely
+// to cause harm (unexpected fills, duplicate MdReqId rejects) than help.
+const maxResendAge = 30 * time.Second
+
+// NewMessageStoreFactory selects the quickfix MessageStore from the
+// MessageStore setting in the [DEFAULT] section of fix.cfg:
+//   - memory: sequence numbers are lost on restart
+//   - file:   quickfix file store under FileStorePath
+//   - sqlite: tables in the market data database (requires db)
+//
+// When MessageStore is not set, the file store is used if FileStorePath is
+// configured, otherwise the memory store.
+func NewMessageStoreFactory(settings *quickfix.Settings, db *database.MarketDataDb) (quickfix.MessageStoreFactory, error) {
+	storeType := resolveMessageStoreType(settings)
+
+	switch storeType {
+	case constants.MessageStoreTypeMemory:
+		return quickfix.NewMemoryStoreFactory(), nil
+	case constants.MessageStoreTypeFile:
+		return file.NewStoreFactory(settings), nil
+	case constants.MessageStoreTypeSqlite:
+		if db == nil {
+			return nil, fmt.Errorf("message store %q requires a database", storeType)
+		}
+		return db.NewMessageStoreFactory(), nil
+	default:
+		return nil, fmt.Errorf("unknown message store %q (expected %s, %s or %s)", storeType,
+			constants.MessageStoreTypeMemory, constants.MessageStoreTypeFile, constants.MessageStoreTypeSqlite)
+	}
+}
+
+func resolveMessageStoreType(settings *quickfix.Settings) string {
+	global := settings.GlobalSettings()
+	if global.HasSetting(constants.SettingMessageStore) {
+		storeType, _ := global.Setting(constants.SettingMessageStore)
+		return strings.ToLower(strings.TrimSpace(storeType))
+	}
+
+	for _, session := range settings.SessionSettings() {
+		if session.HasSetting(config.FileStorePath) {
+			return constants.MessageStoreTypeFile
+		}
+	}
+	return constants.MessageStoreTypeMemory
+}
+
+// shouldResend decides whether an application message replayed by quickfix
+// in response to a ResendRequest (PossDupFlag=Y) is sent again or gap-filled.
+// Market data requests are always gap-filled; a tracked subscription is sent
+// again with a fresh MdReqId instead (see FixApp.ToApp). Other messages are
+// resent only if their original sending time is within maxResendAge.
+func shouldResend(msg *quickfix.Message, now time.Time) bool {
+	msgType, _ := msg.Header.GetString(constants.TagMsgType)
+	if msgType == constants.MsgTypeMarketDataRequest {
+		return false
+	}
+
+	origSendingTime, err := msg.Header.GetTime(constants.TagOrigSendingTime)
+	if err != nil {
+		return false
+	}
+	return now.Sub(origSendingTime) <= maxResendAge
+}
+
+// isPossDup reports whether quickfix is replaying msg for a ResendRequest.
+func isPossDup(msg *quickfix.Message) bool {
+	possDup, err := msg.Header.GetBool(constants.TagPossDupFlag)
+	return err == nil && possDup
+}
+
+// logResendDecision records why a replayed message was skipped.
+func logResendDecision(msg *quickfix.Message) {
+	msgType, _ := msg.Header.GetString(constants.TagMsgType)
+	seqNum, _ := msg.Header.GetString(constants.TagMsgSeqNum)
+	log.Printf("Gap-filling resend of stale message (type %s, seq %s)", msgType, seqNum)
+}
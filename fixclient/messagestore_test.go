@@ -0,0 +1,158 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/utils"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// Tests for message store selection and resend policy.
+// These tests verify that fix.cfg selects the intended store and that stale
+// application messages are gap-filled rather than replayed.
+
+func parseTestSettings(t *testing.T, cfg string) *quickfix.Settings {
+	t.Helper()
+	settings, err := quickfix.ParseSettings(strings.NewReader(cfg))
+	if err != nil {
+		t.Fatalf("failed to parse settings: %v", err)
+	}
+	return settings
+}
+
+// TestResolveMessageStoreType verifies explicit selection and the defaults
+// derived from FileStorePath.
+func TestResolveMessageStoreType(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  string
+		want string
+	}{
+		{
+			name: "explicit sqlite",
+			cfg:  "[DEFAULT]\nMessageStore=SQLite\n[SESSION]\nBeginString=FIXT.1.1\nSenderCompID=A\nTargetCompID=B\n",
+			want: constants.MessageStoreTypeSqlite,
+		},
+		{
+			name: "file store path implies file",
+			cfg:  "[DEFAULT]\n[SESSION]\nBeginString=FIXT.1.1\nSenderCompID=A\nTargetCompID=B\nFileStorePath=./Sessions/\n",
+			want: constants.MessageStoreTypeFile,
+		},
+		{
+			name: "no settings defaults to memory",
+			cfg:  "[DEFAULT]\n[SESSION]\nBeginString=FIXT.1.1\nSenderCompID=A\nTargetCompID=B\n",
+			want: constants.MessageStoreTypeMemory,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveMessageStoreType(parseTestSettings(t, tt.cfg)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewMessageStoreFactory_Errors verifies that unknown store types and a
+// sqlite store without a database are rejected at startup.
+func TestNewMessageStoreFactory_Errors(t *testing.T) {
+	unknown := parseTestSettings(t, "[DEFAULT]\nMessageStore=redis\n[SESSION]\nBeginString=FIXT.1.1\nSenderCompID=A\nTargetCompID=B\n")
+	if _, err := NewMessageStoreFactory(unknown, nil); err == nil {
+		t.Error("expected error for unknown store type")
+	}
+
+	sqlite := parseTestSettings(t, "[DEFAULT]\nMessageStore=sqlite\n[SESSION]\nBeginString=FIXT.1.1\nSenderCompID=A\nTargetCompID=B\n")
+	if _, err := NewMessageStoreFactory(sqlite, nil); err == nil {
+		t.Error("expected error for sqlite store without database")
+	}
+}
+
+// TestShouldResend verifies the resend policy for replayed messages.
+func TestShouldResend(t *testing.T) {
+	now := time.Now().UTC()
+
+	newMsg := func(msgType string, origSendingTime time.Time) *quickfix.Message {
+		msg := quickfix.NewMessage()
+		msg.Header.SetString(constants.TagMsgType, msgType)
+		msg.Header.SetBool(constants.TagPossDupFlag, true)
+		if !origSendingTime.IsZero() {
+			msg.Header.SetField(constants.TagOrigSendingTime, quickfix.FIXUTCTimestamp{Time: origSendingTime})
+		}
+		return msg
+	}
+
+	tests := []struct {
+		name string
+		msg  *quickfix.Message
+		want bool
+	}{
+		{"recent order is resent", newMsg(constants.MsgTypeNewOrderSingle, now.Add(-5*time.Second)), true},
+		{"stale order is gap-filled", newMsg(constants.MsgTypeNewOrderSingle, now.Add(-5*time.Minute)), false},
+		{"market data request is gap-filled", newMsg(constants.MsgTypeMarketDataRequest, now), false},
+		{"missing OrigSendingTime is gap-filled", newMsg(constants.MsgTypeNewOrderSingle, time.Time{}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !isPossDup(tt.msg) {
+				t.Fatal("expected PossDupFlag to be detected")
+			}
+			if got := shouldResend(tt.msg, now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestToAdmin_LogonSignsSentSeqNum verifies that the Logon signature covers
+// the MsgSeqNum the Logon is sent with, so that a session resumed with
+// ResetOnLogon=N authenticates, and 1 when the sequence is being reset.
+func TestToAdmin_LogonSignsSentSeqNum(t *testing.T) {
+	app := NewFixApp(NewConfig("key", "secret", "pass", "CLIENT", "COIN", "portfolio"), nil)
+	const ts = "20250101-12:00:00.000"
+
+	tests := []struct {
+		name, seqNum string
+		reset        bool
+		want         string
+	}{
+		{"resumed", "42", false, "42"},
+		{"reset", "42", true, constants.MsgSeqNumInit},
+	}
+	for _, tt := range tests {
+		msg := quickfix.NewMessage()
+		msg.Header.SetString(constants.TagMsgType, constants.MsgTypeLogon)
+		msg.Header.SetString(constants.TagSendingTime, ts)
+		msg.Header.SetString(constants.TagMsgSeqNum, tt.seqNum)
+		if tt.reset {
+			msg.Body.SetBool(constants.TagResetSeqNumFlag, true)
+		}
+		app.ToAdmin(msg, quickfix.SessionID{})
+
+		got, _ := msg.Body.GetString(constants.TagHmac)
+		if want := utils.Sign(ts, constants.MsgTypeLogon, tt.want, "key", "COIN", "pass", "secret"); got != want {
+			t.Errorf("%s: expected the signature over MsgSeqNum %s", tt.name, tt.want)
+		}
+	}
+}
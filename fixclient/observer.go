@@ -0,0 +1,123 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"strconv"
+
+	"prime-fix-md-go/constants"
+)
+
+// Observer is told about each message FixApp has handled, after its stores
+// are updated. FixApp's default observer prints them to the console; set
+// FixApp.Observer to nil to run silently, or to your own implementation.
+// Methods are called on the FIX message handler goroutine and should return
+// quickly. Embed NopObserver to implement only some of them.
+type Observer interface {
+	OnLogon()
+	OnMarketData(msgType, symbol, mdReqId, seqNum string, entries []Trade)
+	OnMarketDataReject(reject MarketDataReject)
+	OnExecutionReport(er *ExecutionReport)
+	OnOrderCancelReject(reject *OrderCancelReject)
+	OnOrderMassCancelReport(report *OrderMassCancelReport)
+	OnQuote(quote *Quote)
+	OnQuoteAck(ack *QuoteAck, rfq *Quote)
+	OnSessionReject(reject *SessionReject)
+	OnBusinessReject(reject *BusinessReject)
+}
+
+// NopObserver ignores every message.
+type NopObserver struct{}
+
+func (NopObserver) OnLogon()                                                              {}
+func (NopObserver) OnMarketData(msgType, symbol, mdReqId, seqNum string, entries []Trade) {}
+func (NopObserver) OnMarketDataReject(MarketDataReject)                                   {}
+func (NopObserver) OnExecutionReport(*ExecutionReport)                                    {}
+func (NopObserver) OnOrderCancelReject(*OrderCancelReject)                                {}
+func (NopObserver) OnOrderMassCancelReport(*OrderMassCancelReport)                        {}
+func (NopObserver) OnQuote(*Quote)                                                        {}
+func (NopObserver) OnQuoteAck(*QuoteAck, *Quote)                                          {}
+func (NopObserver) OnSessionReject(*SessionReject)                                        {}
+func (NopObserver) OnBusinessReject(*BusinessReject)                                      {}
+
+// observer returns a.Observer, or a NopObserver when it is nil.
+func (a *FixApp) observer() Observer {
+	if a.Observer == nil {
+		return NopObserver{}
+	}
+	return a.Observer
+}
+
+// consoleObserver is the default Observer: the display functions in
+// display.go. Help and per-message market data output are skipped when the
+// app is Headless.
+type consoleObserver struct {
+	a *FixApp
+}
+
+func (c consoleObserver) OnLogon() {
+	if !c.a.Headless {
+		c.a.displayConnectionSuccess()
+		c.a.displayHelp()
+	}
+}
+
+func (c consoleObserver) OnMarketData(msgType, symbol, mdReqId, seqNum string, entries []Trade) {
+	if c.a.Headless {
+		return
+	}
+	c.a.displayMarketDataReceived(msgType, symbol, mdReqId, strconv.Itoa(len(entries)), seqNum)
+	switch msgType {
+	case constants.MsgTypeMarketDataSnapshot:
+		c.a.displaySnapshotTrades(entries, symbol)
+	case constants.MsgTypeMarketDataIncremental:
+		c.a.displayIncrementalTrades(entries)
+	}
+}
+
+func (c consoleObserver) OnMarketDataReject(reject MarketDataReject) {
+	c.a.displayMarketDataReject(reject.MdReqId, reject.Reason, reject.ReasonDesc, reject.Text)
+	c.a.displayMarketDataRejectHelp(reject.Reason)
+}
+
+func (c consoleObserver) OnExecutionReport(er *ExecutionReport) {
+	c.a.displayExecutionReport(er)
+}
+
+func (c consoleObserver) OnOrderCancelReject(reject *OrderCancelReject) {
+	c.a.displayOrderCancelReject(reject)
+}
+
+func (c consoleObserver) OnOrderMassCancelReport(report *OrderMassCancelReport) {
+	c.a.displayOrderMassCancelReport(report)
+}
+
+func (c consoleObserver) OnQuote(quote *Quote) {
+	c.a.displayQuote(quote)
+}
+
+func (c consoleObserver) OnQuoteAck(ack *QuoteAck, rfq *Quote) {
+	c.a.displayQuoteAck(ack, rfq)
+}
+
+func (c consoleObserver) OnSessionReject(reject *SessionReject) {
+	c.a.displaySessionReject(reject)
+}
+
+func (c consoleObserver) OnBusinessReject(reject *BusinessReject) {
+	c.a.displayBusinessReject(reject)
+}
@@ -0,0 +1,361 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fixclient provides a live L2 order book reconstructed from market data.
+//
+// OrderBook keeps one book per symbol. Each book is seeded from a Market Data
+// Snapshot (W) and then mutated by Market Data Incremental Refresh (X) entries.
+//
+// Book Layout:
+// Each side is a slice of price levels kept sorted best-first:
+//   - bids: descending price (highest bid at index 0)
+//   - offers: ascending price (lowest offer at index 0)
+//
+// Levels are keyed on price and mutated according to MDUpdateAction (279):
+// New/Change upsert the level, Delete removes it. When an incremental entry
+// carries no usable price (e.g. a delete identified only by MdEntryPositionNo),
+// the position is used to locate the level instead.
+//
+// Concurrency Model:
+// Same as TradeStore - single writer (FIX message handler goroutine), multiple
+// readers (REPL, display), protected by sync.RWMutex.
+//
+// Performance Characteristics:
+// - Level lookup: O(log n) binary search on the sorted side
+// - Level insert/delete: O(n) slice shift, n = levels on that side (small for L2)
+// - BestBidOffer: O(1)
+package fixclient
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
+)
+
+// BookLevel is a single aggregated price level on one side of the book.
+// Price and Size keep the original FIX strings for display; the parsed
+// values are used for ordering and arithmetic. Prices compare exactly, so
+// "50000.0" and "50000.00" are the same level.
+type BookLevel struct {
+	Price    string          `json:"price"`
+	Size     string          `json:"size"`
+	PriceVal decimal.Decimal `json:"-"`
+	SizeVal  decimal.Decimal `json:"-"`
+}
+
+// bookSide holds the sorted levels for one side of a symbol's book.
+type bookSide struct {
+	levels     []BookLevel
+	descending bool // true for bids, false for offers
+}
+
+// symbolBook is the full two-sided book for a single symbol.
+type symbolBook struct {
+	LastUpdate time.Time
+	SeqNum     string
+	bids       bookSide
+	offers     bookSide
+}
+
+// OrderBook maintains live L2 books for every symbol with book subscriptions.
+type OrderBook struct {
+	mu    sync.RWMutex
+	books map[string]*symbolBook // symbol -> book
+}
+
+// NewOrderBook creates an empty OrderBook.
+func NewOrderBook() *OrderBook {
+	return &OrderBook{
+		books: make(map[string]*symbolBook),
+	}
+}
+
+// ApplySnapshot replaces the books in a Market Data Snapshot (W) with its
+// bid/offer entries. Entries carrying their own Symbol (55) go to that
+// symbol's book and the rest to symbol's. Every book in the snapshot is reset,
+// so one without bid/offer entries leaves that book empty. Non-book entries
+// (trades, OHLCV) are ignored.
+func (ob *OrderBook) ApplySnapshot(symbol string, entries []Trade) {
+	books := make(map[string]*symbolBook)
+	if symbol != "" {
+		books[symbol] = newSymbolBook()
+	}
+	for i := range entries {
+		entrySymbol := entries[i].Symbol
+		if entrySymbol == "" {
+			entrySymbol = symbol
+		}
+		book, exists := books[entrySymbol]
+		if !exists {
+			book = newSymbolBook()
+			books[entrySymbol] = book
+		}
+		book.apply(&entries[i])
+		book.SeqNum = entries[i].SeqNum
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	now := time.Now()
+	for entrySymbol, book := range books {
+		book.LastUpdate = now
+		ob.books[entrySymbol] = book
+	}
+}
+
+// ApplyIncremental mutates books with the bid/offer entries from a Market Data
+// Incremental Refresh (X). Entries carrying their own Symbol (55) update that
+// symbol's book; the rest update symbol's book. A book is created on first use
+// so that incrementals received before the snapshot are not lost.
+func (ob *OrderBook) ApplyIncremental(symbol string, entries []Trade) {
+	if !hasBookEntries(entries) {
+		return
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	now := time.Now()
+	for i := range entries {
+		if !isBookEntry(&entries[i]) {
+			continue
+		}
+		entrySymbol := entries[i].Symbol
+		if entrySymbol == "" {
+			entrySymbol = symbol
+		}
+		book, exists := ob.books[entrySymbol]
+		if !exists {
+			book = newSymbolBook()
+			ob.books[entrySymbol] = book
+		}
+		book.apply(&entries[i])
+		book.LastUpdate = now
+		book.SeqNum = entries[i].SeqNum
+	}
+}
+
+// BestBidOffer returns the top of book for symbol. The boolean results report
+// whether each side currently has at least one level.
+func (ob *OrderBook) BestBidOffer(symbol string) (bid BookLevel, offer BookLevel, hasBid bool, hasOffer bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	book, exists := ob.books[symbol]
+	if !exists {
+		return
+	}
+	if len(book.bids.levels) > 0 {
+		bid, hasBid = book.bids.levels[0], true
+	}
+	if len(book.offers.levels) > 0 {
+		offer, hasOffer = book.offers.levels[0], true
+	}
+	return
+}
+
+// TopLevels returns up to n levels per side, best first. n <= 0 returns the
+// full book. The returned slices are copies and safe to retain.
+func (ob *OrderBook) TopLevels(symbol string, n int) (bids []BookLevel, offers []BookLevel) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	book, exists := ob.books[symbol]
+	if !exists {
+		return nil, nil
+	}
+	return copyLevels(book.bids.levels, n), copyLevels(book.offers.levels, n)
+}
+
+// DepthAtPrice returns the resting size at price on the given side
+// (constants.MdEntryTypeBid or constants.MdEntryTypeOffer), or zero if no level exists.
+func (ob *OrderBook) DepthAtPrice(symbol, side, price string) decimal.Decimal {
+	px, err := decimal.Parse(price)
+	if err != nil {
+		return decimal.Decimal{}
+	}
+
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	book, exists := ob.books[symbol]
+	if !exists {
+		return decimal.Decimal{}
+	}
+	s := book.side(side)
+	if s == nil {
+		return decimal.Decimal{}
+	}
+	if idx, found := s.find(px); found {
+		return s.levels[idx].SizeVal
+	}
+	return decimal.Decimal{}
+}
+
+// LastUpdate returns the time of the most recent change to symbol's book.
+func (ob *OrderBook) LastUpdate(symbol string) time.Time {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	if book, exists := ob.books[symbol]; exists {
+		return book.LastUpdate
+	}
+	return time.Time{}
+}
+
+// Symbols returns the symbols that currently have a book.
+func (ob *OrderBook) Symbols() []string {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	result := make([]string, 0, len(ob.books))
+	for symbol := range ob.books {
+		result = append(result, symbol)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Clear removes the book for symbol, e.g. after unsubscribing.
+func (ob *OrderBook) Clear(symbol string) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	delete(ob.books, symbol)
+}
+
+// --- symbolBook internals (caller holds the OrderBook lock) ---
+
+func newSymbolBook() *symbolBook {
+	return &symbolBook{
+		bids:   bookSide{descending: true},
+		offers: bookSide{descending: false},
+	}
+}
+
+func (b *symbolBook) side(entryType string) *bookSide {
+	switch entryType {
+	case constants.MdEntryTypeBid:
+		return &b.bids
+	case constants.MdEntryTypeOffer:
+		return &b.offers
+	default:
+		return nil
+	}
+}
+
+// apply applies a single MD entry to the book.
+// An explicit MdUpdateAction (279) of Delete removes the level; New and Change
+// upsert it. Without an action (snapshots, or venues that omit 279), a zero or
+// missing size removes the level and anything else upserts it.
+func (b *symbolBook) apply(entry *Trade) {
+	s := b.side(entry.EntryType)
+	if s == nil {
+		return
+	}
+
+	px, pxErr := entry.PriceDecimal()
+	size, _ := entry.SizeDecimal()
+
+	isDelete := size.IsZero()
+	switch entry.UpdateAction {
+	case constants.MdUpdateActionDelete:
+		isDelete = true
+	case constants.MdUpdateActionNew, constants.MdUpdateActionChange:
+		isDelete = size.IsZero() // a change to zero size empties the level
+	}
+
+	if pxErr != nil {
+		// No usable price - fall back to position (1-based) for deletes
+		if isDelete {
+			s.deletePosition(entry.Position)
+		}
+		return
+	}
+
+	if isDelete {
+		s.delete(px)
+		return
+	}
+	s.upsert(BookLevel{Price: entry.Price, Size: entry.Size, PriceVal: px, SizeVal: size})
+}
+
+// find returns the index of price, or the insertion point if not present.
+func (s *bookSide) find(px decimal.Decimal) (int, bool) {
+	idx := sort.Search(len(s.levels), func(i int) bool {
+		if s.descending {
+			return s.levels[i].PriceVal.Cmp(px) <= 0
+		}
+		return s.levels[i].PriceVal.Cmp(px) >= 0
+	})
+	return idx, idx < len(s.levels) && s.levels[idx].PriceVal.Equal(px)
+}
+
+func (s *bookSide) upsert(level BookLevel) {
+	idx, found := s.find(level.PriceVal)
+	if found {
+		s.levels[idx] = level
+		return
+	}
+	s.levels = append(s.levels, BookLevel{})
+	copy(s.levels[idx+1:], s.levels[idx:])
+	s.levels[idx] = level
+}
+
+func (s *bookSide) delete(px decimal.Decimal) {
+	if idx, found := s.find(px); found {
+		s.levels = append(s.levels[:idx], s.levels[idx+1:]...)
+	}
+}
+
+func (s *bookSide) deletePosition(position string) {
+	pos, err := strconv.Atoi(position)
+	if err != nil || pos < 1 || pos > len(s.levels) {
+		return
+	}
+	idx := pos - 1
+	s.levels = append(s.levels[:idx], s.levels[idx+1:]...)
+}
+
+// --- Helper Functions ---
+
+func isBookEntry(entry *Trade) bool {
+	return entry.EntryType == constants.MdEntryTypeBid || entry.EntryType == constants.MdEntryTypeOffer
+}
+
+func hasBookEntries(entries []Trade) bool {
+	for i := range entries {
+		if isBookEntry(&entries[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyLevels(levels []BookLevel, n int) []BookLevel {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	if n == 0 {
+		return nil
+	}
+	result := make([]BookLevel, n)
+	copy(result, levels[:n])
+	return result
+}
@@ -0,0 +1,301 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"prime-fix-md-go/constants"
+)
+
+// Tests for OrderBook behavior.
+// These tests verify that the live L2 book is seeded from snapshots, mutated by
+// incrementals, and exposes consistent best bid/offer and depth views.
+
+// TestOrderBook_SnapshotSeedsSortedBook verifies that a snapshot builds both sides
+// sorted best-first regardless of entry order in the message.
+func TestOrderBook_SnapshotSeedsSortedBook(t *testing.T) {
+	book := NewOrderBook()
+
+	book.ApplySnapshot("BTC-USD", []Trade{
+		{EntryType: "0", Price: "49998.00", Size: "1.0"},
+		{EntryType: "0", Price: "49999.00", Size: "2.0"},
+		{EntryType: "1", Price: "50002.00", Size: "3.0"},
+		{EntryType: "1", Price: "50001.00", Size: "4.0"},
+	})
+
+	bids, offers := book.TopLevels("BTC-USD", 0)
+	if len(bids) != 2 || len(offers) != 2 {
+		t.Fatalf("expected 2 bids and 2 offers, got %d and %d", len(bids), len(offers))
+	}
+	if bids[0].Price != "49999.00" || bids[1].Price != "49998.00" {
+		t.Errorf("bids not sorted descending: %s, %s", bids[0].Price, bids[1].Price)
+	}
+	if offers[0].Price != "50001.00" || offers[1].Price != "50002.00" {
+		t.Errorf("offers not sorted ascending: %s, %s", offers[0].Price, offers[1].Price)
+	}
+}
+
+// TestOrderBook_SnapshotReplacesExistingBook verifies that a new snapshot discards
+// levels from the previous book rather than merging into it.
+func TestOrderBook_SnapshotReplacesExistingBook(t *testing.T) {
+	book := NewOrderBook()
+
+	book.ApplySnapshot("BTC-USD", []Trade{{EntryType: "0", Price: "100", Size: "1"}})
+	book.ApplySnapshot("BTC-USD", []Trade{{EntryType: "0", Price: "200", Size: "1"}})
+
+	bids, _ := book.TopLevels("BTC-USD", 0)
+	if len(bids) != 1 || bids[0].Price != "200" {
+		t.Fatalf("expected only the level from the second snapshot, got %+v", bids)
+	}
+}
+
+// TestOrderBook_EmptySnapshotClearsBook verifies that a snapshot without
+// bid/offer entries leaves the symbol's book empty.
+func TestOrderBook_EmptySnapshotClearsBook(t *testing.T) {
+	book := NewOrderBook()
+
+	book.ApplySnapshot("BTC-USD", []Trade{{EntryType: "0", Price: "100", Size: "1"}})
+	book.ApplySnapshot("BTC-USD", nil)
+
+	if _, _, hasBid, hasOffer := book.BestBidOffer("BTC-USD"); hasBid || hasOffer {
+		t.Fatal("expected an empty snapshot to clear the book")
+	}
+}
+
+// TestOrderBook_SnapshotPerEntrySymbol verifies that a multi-symbol snapshot
+// seeds each entry's own symbol and replaces every book it covers.
+func TestOrderBook_SnapshotPerEntrySymbol(t *testing.T) {
+	book := NewOrderBook()
+	book.ApplySnapshot("ETH-USD", []Trade{{EntryType: "1", Price: "3100", Size: "1"}})
+
+	book.ApplySnapshot("BTC-USD", []Trade{
+		{Symbol: "BTC-USD", EntryType: "0", Price: "50000", Size: "1"},
+		{Symbol: "ETH-USD", EntryType: "0", Price: "3000", Size: "2"},
+	})
+
+	btcBids, _ := book.TopLevels("BTC-USD", 0)
+	ethBids, ethOffers := book.TopLevels("ETH-USD", 0)
+	if len(btcBids) != 1 || btcBids[0].Price != "50000" {
+		t.Errorf("expected only the BTC-USD entry in its book, got %+v", btcBids)
+	}
+	if len(ethBids) != 1 || ethBids[0].Price != "3000" || len(ethOffers) != 0 {
+		t.Errorf("expected the ETH-USD book replaced by its entry, got bids %+v offers %+v", ethBids, ethOffers)
+	}
+}
+
+// TestFixApp_SnapshotReplacesRequestedBook verifies that an empty snapshot
+// for a book request clears the book while a trade-only snapshot for the same
+// symbol leaves it alone.
+func TestFixApp_SnapshotReplacesRequestedBook(t *testing.T) {
+	app := NewFixApp(&Config{}, nil)
+	app.Headless = true
+	app.TradeStore.AddSubscriptionRequest([]string{"BTC-USD"}, "1", "req-book", "0",
+		[]string{constants.MdEntryTypeBid, constants.MdEntryTypeOffer})
+	app.TradeStore.AddSubscription("BTC-USD", "1", "req-trades")
+
+	header := "35=W\x0149=COIN\x0156=CLIENT\x0152=20250101-12:00:00.000\x0155=BTC-USD\x01"
+	app.handleMarketDataMessage(parseFixMessage(t, header+"34=2\x01262=req-book\x01268=1\x01269=0\x01270=49999\x01271=1\x01"))
+	app.handleMarketDataMessage(parseFixMessage(t, header+"34=3\x01262=req-trades\x01268=1\x01269=2\x01270=50000\x01271=0.1\x01"))
+	if _, _, hasBid, _ := app.OrderBook.BestBidOffer("BTC-USD"); !hasBid {
+		t.Fatal("expected a trade-only snapshot to keep the book")
+	}
+
+	app.handleMarketDataMessage(parseFixMessage(t, header+"34=4\x01262=req-book\x01268=0\x01"))
+	if _, _, hasBid, _ := app.OrderBook.BestBidOffer("BTC-USD"); hasBid {
+		t.Error("expected an empty book snapshot to clear the book")
+	}
+}
+
+// TestOrderBook_IncrementalNewChangeDelete verifies the three incremental
+// operations: adding a level, changing its size, and deleting it with size 0.
+func TestOrderBook_IncrementalNewChangeDelete(t *testing.T) {
+	book := NewOrderBook()
+	book.ApplySnapshot("BTC-USD", []Trade{
+		{EntryType: "0", Price: "100", Size: "1"},
+		{EntryType: "1", Price: "102", Size: "1"},
+	})
+
+	// New level that improves the bid
+	book.ApplyIncremental("BTC-USD", []Trade{{EntryType: "0", Price: "101", Size: "5"}})
+	bid, _, _, _ := book.BestBidOffer("BTC-USD")
+	if bid.Price != "101" || bid.SizeVal.Float64() != 5 {
+		t.Fatalf("expected best bid 5@101, got %s@%s", bid.Size, bid.Price)
+	}
+
+	// Change size at existing price
+	book.ApplyIncremental("BTC-USD", []Trade{{EntryType: "0", Price: "101", Size: "7"}})
+	if got := book.DepthAtPrice("BTC-USD", "0", "101"); got.String() != "7" {
+		t.Fatalf("expected depth 7 at 101, got %v", got)
+	}
+
+	// Delete via zero size
+	book.ApplyIncremental("BTC-USD", []Trade{{EntryType: "0", Price: "101", Size: "0"}})
+	bid, _, _, _ = book.BestBidOffer("BTC-USD")
+	if bid.Price != "100" {
+		t.Fatalf("expected best bid to fall back to 100, got %s", bid.Price)
+	}
+}
+
+// TestOrderBook_ExplicitUpdateAction verifies that MDUpdateAction takes
+// precedence over size inference: Delete removes a level even when the venue
+// repeats the last size.
+func TestOrderBook_ExplicitUpdateAction(t *testing.T) {
+	book := NewOrderBook()
+	book.ApplySnapshot("BTC-USD", []Trade{{EntryType: "1", Price: "102", Size: "1"}})
+
+	book.ApplyIncremental("BTC-USD", []Trade{{EntryType: "1", Price: "102", Size: "1", UpdateAction: "2"}})
+
+	if _, _, _, hasOffer := book.BestBidOffer("BTC-USD"); hasOffer {
+		t.Fatal("explicit delete should remove the level")
+	}
+}
+
+// TestOrderBook_PerEntrySymbol verifies that incremental entries carrying their
+// own Symbol update that symbol's book.
+func TestOrderBook_PerEntrySymbol(t *testing.T) {
+	book := NewOrderBook()
+
+	book.ApplyIncremental("BTC-USD", []Trade{
+		{Symbol: "BTC-USD", EntryType: "0", Price: "50000", Size: "1", UpdateAction: "0"},
+		{Symbol: "ETH-USD", EntryType: "0", Price: "3000", Size: "2", UpdateAction: "0"},
+	})
+
+	btc, _, _, _ := book.BestBidOffer("BTC-USD")
+	eth, _, _, _ := book.BestBidOffer("ETH-USD")
+	if btc.Price != "50000" || eth.Price != "3000" {
+		t.Fatalf("expected entries routed by symbol, got BTC=%s ETH=%s", btc.Price, eth.Price)
+	}
+}
+
+// TestOrderBook_DeleteByPosition verifies that a delete without a price removes
+// the level at the given MdEntryPositionNo.
+func TestOrderBook_DeleteByPosition(t *testing.T) {
+	book := NewOrderBook()
+	book.ApplySnapshot("ETH-USD", []Trade{
+		{EntryType: "1", Price: "3001", Size: "1"},
+		{EntryType: "1", Price: "3002", Size: "1"},
+		{EntryType: "1", Price: "3003", Size: "1"},
+	})
+
+	book.ApplyIncremental("ETH-USD", []Trade{{EntryType: "1", Position: "2"}})
+
+	_, offers := book.TopLevels("ETH-USD", 0)
+	if len(offers) != 2 || offers[0].Price != "3001" || offers[1].Price != "3003" {
+		t.Fatalf("expected offers 3001, 3003 after deleting position 2, got %+v", offers)
+	}
+}
+
+// TestOrderBook_PriceKeyIgnoresFormatting verifies that "100" and "100.00" are
+// treated as the same level.
+func TestOrderBook_PriceKeyIgnoresFormatting(t *testing.T) {
+	book := NewOrderBook()
+	book.ApplySnapshot("BTC-USD", []Trade{{EntryType: "0", Price: "100.00", Size: "1"}})
+	book.ApplyIncremental("BTC-USD", []Trade{{EntryType: "0", Price: "100", Size: "2"}})
+
+	bids, _ := book.TopLevels("BTC-USD", 0)
+	if len(bids) != 1 {
+		t.Fatalf("expected 1 level, got %d", len(bids))
+	}
+	if bids[0].SizeVal.Float64() != 2 {
+		t.Errorf("expected size 2, got %v", bids[0].SizeVal)
+	}
+}
+
+// TestOrderBook_PriceKeyIsExact verifies that prices float64 cannot tell
+// apart stay separate levels.
+func TestOrderBook_PriceKeyIsExact(t *testing.T) {
+	book := NewOrderBook()
+	book.ApplySnapshot("BTC-USD", []Trade{
+		{EntryType: "0", Price: "92233720368.54775807", Size: "1"},
+		{EntryType: "0", Price: "92233720368.54775806", Size: "2"},
+	})
+
+	bids, _ := book.TopLevels("BTC-USD", 0)
+	if len(bids) != 2 || bids[0].Price != "92233720368.54775807" {
+		t.Fatalf("expected 2 levels with the higher bid first, got %+v", bids)
+	}
+}
+
+// TestOrderBook_TopLevelsLimitAndCopy verifies the level limit and that
+// returned slices do not alias internal state.
+func TestOrderBook_TopLevelsLimitAndCopy(t *testing.T) {
+	book := NewOrderBook()
+	entries := make([]Trade, 0, 20)
+	for i := 0; i < 20; i++ {
+		entries = append(entries, Trade{EntryType: "0", Price: strconv.Itoa(100 - i), Size: "1"})
+	}
+	book.ApplySnapshot("BTC-USD", entries)
+
+	bids, _ := book.TopLevels("BTC-USD", 5)
+	if len(bids) != 5 {
+		t.Fatalf("expected 5 levels, got %d", len(bids))
+	}
+
+	bids[0].Price = "MODIFIED"
+	again, _ := book.TopLevels("BTC-USD", 1)
+	if again[0].Price == "MODIFIED" {
+		t.Error("TopLevels should return a copy")
+	}
+}
+
+// TestOrderBook_UnknownSymbol verifies that queries for symbols without a book
+// return empty results.
+func TestOrderBook_UnknownSymbol(t *testing.T) {
+	book := NewOrderBook()
+
+	if _, _, hasBid, hasOffer := book.BestBidOffer("NONE"); hasBid || hasOffer {
+		t.Error("expected no BBO for unknown symbol")
+	}
+	if bids, offers := book.TopLevels("NONE", 10); bids != nil || offers != nil {
+		t.Error("expected nil levels for unknown symbol")
+	}
+	if got := book.DepthAtPrice("NONE", "0", "100"); !got.IsZero() {
+		t.Errorf("expected 0 depth, got %v", got)
+	}
+}
+
+// TestOrderBook_ConcurrentReadWrite verifies the book is safe for a single
+// writer with concurrent readers (run with -race).
+func TestOrderBook_ConcurrentReadWrite(t *testing.T) {
+	book := NewOrderBook()
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			book.ApplyIncremental("BTC-USD", []Trade{
+				{EntryType: "0", Price: strconv.Itoa(100 + i%10), Size: strconv.Itoa(i % 3)},
+			})
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				book.BestBidOffer("BTC-USD")
+				book.TopLevels("BTC-USD", 5)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,180 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"prime-fix-md-go/database"
+)
+
+// OrderPersister stores orders and quotes so an OrderStore can be rebuilt
+// after a restart. OrderStore calls it with its lock held, so changes reach
+// storage in the order they were made. Arguments are copies the persister may
+// keep.
+type OrderPersister interface {
+	SaveOrder(order *Order) error
+	DeleteOrder(clOrdID string) error
+	SaveQuote(quote *Quote) error
+	DeleteQuote(quoteReqID string) error
+}
+
+// SetPersister enables write-through of every order and quote change to p.
+// Existing entries are not written; call it before the store is used.
+func (os *OrderStore) SetPersister(p OrderPersister) {
+	os.mu.Lock()
+	defer os.mu.Unlock()
+	os.persister = p
+}
+
+// persistOrder writes a copy of order. Storage errors are logged rather than
+// returned: the in-memory store stays authoritative for the session.
+// Caller must hold os.mu.
+func (os *OrderStore) persistOrder(order *Order) {
+	if os.persister == nil {
+		return
+	}
+	if err := os.persister.SaveOrder(order.clone()); err != nil {
+		log.Printf("Failed to persist order %s: %v", order.ClOrdID, err)
+	}
+}
+
+// persistQuote writes a copy of quote, logging storage errors like
+// persistOrder. Caller must hold os.mu.
+func (os *OrderStore) persistQuote(quote *Quote) {
+	if os.persister == nil {
+		return
+	}
+	saved := *quote
+	if err := os.persister.SaveQuote(&saved); err != nil {
+		log.Printf("Failed to persist quote %s: %v", quote.QuoteReqID, err)
+	}
+}
+
+// Restore adds previously persisted orders and quotes, keeping their
+// timestamps. Restored entries are not written back to the persister. The
+// cancel/replace chain of each order is rebuilt from its Amendments and
+// History, so earlier ClOrdIDs still resolve to it.
+func (os *OrderStore) Restore(orders []*Order, quotes []*Quote) {
+	os.mu.Lock()
+	defer os.mu.Unlock()
+	for _, order := range orders {
+		os.orders[order.ClOrdID] = order.clone()
+		for i, amendment := range order.Amendments {
+			next := order.ClOrdID
+			if i+1 < len(order.Amendments) {
+				next = order.Amendments[i+1].ClOrdID
+			}
+			os.links[amendment.ClOrdID] = next
+		}
+		for _, event := range order.History {
+			if event.ClOrdID != order.ClOrdID {
+				os.links[event.ClOrdID] = order.ClOrdID
+			}
+		}
+	}
+	for _, quote := range quotes {
+		restored := *quote
+		os.quotes[quote.QuoteReqID] = &restored
+	}
+}
+
+// dbOrderPersister stores orders and quotes in the market data database as
+// JSON.
+type dbOrderPersister struct {
+	db *database.MarketDataDb
+}
+
+// NewDbOrderPersister returns an OrderPersister backed by the orders and
+// quotes tables of db.
+func NewDbOrderPersister(db *database.MarketDataDb) OrderPersister {
+	return &dbOrderPersister{db: db}
+}
+
+func (p *dbOrderPersister) SaveOrder(order *Order) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	return p.db.SaveOrder(database.OrderRecord{
+		ClOrdID:   order.ClOrdID,
+		OrderID:   order.OrderID,
+		Symbol:    order.Symbol,
+		OrdStatus: order.OrdStatus,
+		UpdatedAt: order.UpdatedAt,
+		Data:      string(data),
+	})
+}
+
+func (p *dbOrderPersister) DeleteOrder(clOrdID string) error {
+	return p.db.DeleteOrder(clOrdID)
+}
+
+func (p *dbOrderPersister) SaveQuote(quote *Quote) error {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+	return p.db.SaveQuote(database.QuoteRecord{
+		QuoteReqID: quote.QuoteReqID,
+		QuoteID:    quote.QuoteID,
+		Symbol:     quote.Symbol,
+		ValidUntil: quote.ValidUntilTime,
+		Data:       string(data),
+	})
+}
+
+func (p *dbOrderPersister) DeleteQuote(quoteReqID string) error {
+	return p.db.DeleteQuote(quoteReqID)
+}
+
+// loadOrderStore reads the open orders and unexpired quotes saved in db.
+// Filled, canceled and rejected orders stay in the database but are not
+// reloaded.
+func loadOrderStore(db *database.MarketDataDb, now time.Time) ([]*Order, []*Quote, error) {
+	orderRecords, err := db.LoadOrders(openOrdStatuses...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load orders: %v", err)
+	}
+	orders := make([]*Order, 0, len(orderRecords))
+	for _, r := range orderRecords {
+		order := &Order{}
+		if err := json.Unmarshal([]byte(r.Data), order); err != nil {
+			log.Printf("Skipping unreadable stored order %s: %v", r.ClOrdID, err)
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	quoteRecords, err := db.LoadQuotes(now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load quotes: %v", err)
+	}
+	quotes := make([]*Quote, 0, len(quoteRecords))
+	for _, r := range quoteRecords {
+		quote := &Quote{}
+		if err := json.Unmarshal([]byte(r.Data), quote); err != nil {
+			log.Printf("Skipping unreadable stored quote %s: %v", r.QuoteReqID, err)
+			continue
+		}
+		quotes = append(quotes, quote)
+	}
+	return orders, quotes, nil
+}
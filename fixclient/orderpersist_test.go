@@ -0,0 +1,124 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/database"
+)
+
+// TestOrderStore_PersistsAcrossRestart verifies that orders and quotes written
+// through one FixApp are reloaded by the next, and that terminal orders and
+// expired quotes are left behind.
+func TestOrderStore_PersistsAcrossRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "orders.db")
+	db, err := database.NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	app := NewFixApp(&Config{}, db)
+	app.OrderStore.AddOrder(&Order{ClOrdID: "open-1", Symbol: "BTC-USD", Side: "1", OrderQty: "1", Price: "50000", OrdStatus: "A"})
+	app.OrderStore.AddOrder(&Order{ClOrdID: "done-1", Symbol: "BTC-USD", Side: "1", OrderQty: "1", OrdStatus: "A"})
+	app.OrderStore.UpdateOrderFromExecReport(&ExecutionReport{
+		ClOrdID: "open-1", OrderID: "exch-1", Symbol: "BTC-USD", Side: "1", OrdStatus: "1", ExecType: "F",
+		ExecID: "e1", CumQty: "0.4", LeavesQty: "0.6", LastShares: "0.4", LastPx: "50000",
+		MiscFees: []MiscFee{{Amt: "0.5", Curr: "USD", Type: "4"}},
+	})
+	app.OrderStore.UpdateOrderFromExecReport(&ExecutionReport{
+		ClOrdID: "done-1", OrderID: "exch-2", Symbol: "BTC-USD", Side: "1", OrdStatus: "4", ExecType: "4", ExecID: "e2",
+	})
+	app.OrderStore.AddQuote(&Quote{QuoteReqID: "req-1", QuoteID: "q-1", Symbol: "BTC-USD", ValidUntilTime: time.Now().Add(time.Hour)})
+	app.OrderStore.AddQuote(&Quote{QuoteReqID: "req-2", QuoteID: "q-2", Symbol: "BTC-USD", ValidUntilTime: time.Now().Add(-time.Hour)})
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+
+	db, err = database.NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	restarted := NewFixApp(&Config{}, db)
+
+	order := restarted.OrderStore.GetOrder("open-1")
+	if order == nil {
+		t.Fatal("expected open-1 to be restored")
+	}
+	if order.OrderID != "exch-1" || order.CumQty != "0.4" || order.Price != "50000" || len(order.MiscFees) != 1 {
+		t.Errorf("unexpected restored order %+v", order)
+	}
+	if restarted.OrderStore.GetOrderByOrderID("exch-1") == nil {
+		t.Error("expected open-1 to be found by OrderID")
+	}
+	if restarted.OrderStore.GetOrder("done-1") != nil {
+		t.Error("expected canceled order not to be restored")
+	}
+	if restarted.OrderStore.GetQuoteByQuoteID("q-1") == nil {
+		t.Error("expected unexpired quote to be restored")
+	}
+	if restarted.OrderStore.GetQuote("req-2") != nil {
+		t.Error("expected expired quote not to be restored")
+	}
+
+	restarted.OrderStore.RemoveOrder("open-1")
+	if orders, _ := db.LoadOrders(); len(orders) != 1 || orders[0].ClOrdID != "done-1" {
+		t.Errorf("expected RemoveOrder to delete open-1 from storage, got %+v", orders)
+	}
+}
+
+// TestOrderStore_RestoresReplaceChain verifies that a replaced order's
+// earlier ClOrdIDs still resolve to it after a restart.
+func TestOrderStore_RestoresReplaceChain(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "orders.db")
+	db, err := database.NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	app := NewFixApp(&Config{}, db)
+	app.OrderStore.AddOrder(&Order{ClOrdID: "lmt-1", OrderID: "exch-1", Symbol: "BTC-USD", Side: "1", OrderQty: "1", Price: "50000", OrdStatus: "0"})
+	for _, er := range []*ExecutionReport{
+		{ClOrdID: "rep-1", OrigClOrdID: "lmt-1", OrderID: "exch-1", ExecID: "e1", ExecType: "5", OrdStatus: "0", Price: "50100"},
+		{ClOrdID: "rep-2", OrigClOrdID: "rep-1", OrderID: "exch-1", ExecID: "e2", ExecType: "5", OrdStatus: "0", Price: "50200"},
+		{ClOrdID: "cxl-1", OrigClOrdID: "rep-2", OrderID: "exch-1", ExecID: "e3", ExecType: "6", OrdStatus: "6"},
+	} {
+		if err := app.OrderStore.UpdateOrderFromExecReport(er); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	db.Close()
+
+	db, err = database.NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	restarted := NewFixApp(&Config{}, db)
+
+	for _, id := range []string{"lmt-1", "rep-1", "rep-2", "cxl-1"} {
+		if order := restarted.OrderStore.ResolveOrder(id); order == nil || order.ClOrdID != "rep-2" {
+			t.Errorf("expected %s to resolve to rep-2 after restart, got %+v", id, order)
+		}
+	}
+	if restarted.OrderStore.GetOrder("lmt-1") != nil {
+		t.Error("expected the replaced version not to be restored")
+	}
+}
@@ -0,0 +1,217 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"prime-fix-md-go/constants"
+)
+
+// maxOrderEvents bounds the history kept per order; the oldest events are
+// dropped first.
+const maxOrderEvents = 100
+
+// ErrDuplicateExecID is returned by UpdateOrderFromExecReport for a report
+// whose ExecID the order has already applied, e.g. a PossDup resend.
+var ErrDuplicateExecID = errors.New("duplicate ExecID")
+
+// ErrUntrackedOrder is returned by UpdateOrderFromExecReport for a report on
+// an order that was not tracked, e.g. one placed in another session. The
+// report is still applied, so the order is tracked from then on.
+var ErrUntrackedOrder = errors.New("execution report for an untracked order")
+
+// OrderEvent is one entry in an order's history: an execution report that
+// was applied, flagged or refused, or a local change such as a replace.
+type OrderEvent struct {
+	Time       time.Time `json:"time"`
+	ClOrdID    string    `json:"clOrdId"` // ID the report was sent for
+	ExecID     string    `json:"execId,omitempty"`
+	ExecType   string    `json:"execType,omitempty"`
+	FromStatus string    `json:"fromStatus,omitempty"`
+	OrdStatus  string    `json:"ordStatus"` // Status reported
+	CumQty     string    `json:"cumQty,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	Refused    bool      `json:"refused,omitempty"` // Not applied to the order
+	Unknown    bool      `json:"unknown,omitempty"` // The order was not tracked before this report
+}
+
+// TransitionError describes an execution report that would move an order
+// into a state it cannot reach from its current one, e.g. Filled → New, or
+// that reports less filled quantity than already seen.
+type TransitionError struct {
+	ClOrdID string
+	ExecID  string
+	From    string
+	To      string
+	Reason  string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order %s: illegal transition %s → %s (ExecID %s): %s",
+		e.ClOrdID, getOrdStatusDesc(e.From), getOrdStatusDesc(e.To), e.ExecID, e.Reason)
+}
+
+// orderTransitions lists the statuses each OrdStatus may move to, besides
+// staying where it is. Filled, Canceled, Rejected, Expired and Replaced are
+// final. Statuses not listed (Calculated, Accepted for Bidding) are not
+// checked.
+var orderTransitions = map[string][]string{
+	constants.OrdStatusPendingNew: {
+		constants.OrdStatusNew, constants.OrdStatusPartiallyFilled, constants.OrdStatusFilled,
+		constants.OrdStatusRejected, constants.OrdStatusCanceled, constants.OrdStatusExpired,
+		constants.OrdStatusPendingCancel, constants.OrdStatusPendingReplace, constants.OrdStatusSuspended,
+		constants.OrdStatusStopped, constants.OrdStatusDoneForDay,
+	},
+	constants.OrdStatusNew: {
+		constants.OrdStatusPartiallyFilled, constants.OrdStatusFilled, constants.OrdStatusCanceled,
+		constants.OrdStatusExpired, constants.OrdStatusPendingCancel, constants.OrdStatusPendingReplace,
+		constants.OrdStatusReplaced, constants.OrdStatusSuspended, constants.OrdStatusStopped,
+		constants.OrdStatusDoneForDay,
+	},
+	constants.OrdStatusPartiallyFilled: {
+		constants.OrdStatusFilled, constants.OrdStatusCanceled, constants.OrdStatusExpired,
+		constants.OrdStatusPendingCancel, constants.OrdStatusPendingReplace, constants.OrdStatusReplaced,
+		constants.OrdStatusSuspended, constants.OrdStatusStopped, constants.OrdStatusDoneForDay,
+	},
+	constants.OrdStatusPendingCancel: {
+		constants.OrdStatusNew, constants.OrdStatusPartiallyFilled, constants.OrdStatusFilled,
+		constants.OrdStatusCanceled, constants.OrdStatusExpired, constants.OrdStatusDoneForDay,
+	},
+	constants.OrdStatusPendingReplace: {
+		constants.OrdStatusNew, constants.OrdStatusPartiallyFilled, constants.OrdStatusFilled,
+		constants.OrdStatusCanceled, constants.OrdStatusExpired, constants.OrdStatusReplaced,
+		constants.OrdStatusPendingCancel, constants.OrdStatusDoneForDay,
+	},
+	constants.OrdStatusSuspended: {
+		constants.OrdStatusNew, constants.OrdStatusPartiallyFilled, constants.OrdStatusFilled,
+		constants.OrdStatusCanceled, constants.OrdStatusExpired, constants.OrdStatusPendingCancel,
+	},
+	constants.OrdStatusStopped: {
+		constants.OrdStatusPartiallyFilled, constants.OrdStatusFilled, constants.OrdStatusCanceled,
+		constants.OrdStatusExpired,
+	},
+	constants.OrdStatusDoneForDay: {
+		constants.OrdStatusNew, constants.OrdStatusPartiallyFilled, constants.OrdStatusCanceled,
+		constants.OrdStatusExpired,
+	},
+	constants.OrdStatusFilled:   {},
+	constants.OrdStatusCanceled: {},
+	constants.OrdStatusRejected: {},
+	constants.OrdStatusExpired:  {},
+	constants.OrdStatusReplaced: {},
+}
+
+// checkTransition returns an error if er cannot be applied to order: its
+// OrdStatus is not reachable from the order's, or its CumQty is below the
+// order's, which means the report is older than one already seen.
+func checkTransition(order *Order, er *ExecutionReport) *TransitionError {
+	transitionError := func(reason string) *TransitionError {
+		return &TransitionError{ClOrdID: order.ClOrdID, ExecID: er.ExecID,
+			From: order.OrdStatus, To: er.OrdStatus, Reason: reason}
+	}
+
+	if cum, prev := parseDecimalOrZero(er.CumQty), parseDecimalOrZero(order.CumQty); er.CumQty != "" && cum.Cmp(prev) < 0 {
+		return transitionError(fmt.Sprintf("CumQty %s is below %s", er.CumQty, order.CumQty))
+	}
+	if order.OrdStatus == "" || er.OrdStatus == "" || er.OrdStatus == order.OrdStatus {
+		return nil
+	}
+	allowed, known := orderTransitions[order.OrdStatus]
+	if !known || slices.Contains(allowed, er.OrdStatus) {
+		return nil
+	}
+	if len(allowed) == 0 {
+		return transitionError("order is already " + getOrdStatusDesc(order.OrdStatus))
+	}
+	return transitionError("not a valid order lifecycle step")
+}
+
+// isFinalStatus reports whether an order in status can no longer change.
+func isFinalStatus(status string) bool {
+	allowed, known := orderTransitions[status]
+	return known && len(allowed) == 0
+}
+
+// isStatusReport reports whether er restates the order's state rather than
+// describing an event: replies to status requests and restatements. Prime's
+// view of the order is authoritative, so these are applied even when the
+// transition looks illegal, and flagged in the history.
+func isStatusReport(er *ExecutionReport) bool {
+	return er.ExecType == constants.ExecTypeOrderStatus || er.ExecType == constants.ExecTypeRestated
+}
+
+// hasExec reports whether the order, or an order it replaced, has already
+// applied execID. ExecIDs is checked first because History is bounded and
+// starts over after a replace; History covers orders stored before ExecIDs
+// was kept.
+func (o *Order) hasExec(execID string) bool {
+	if execID == "" {
+		return false
+	}
+	if slices.Contains(o.ExecIDs, execID) {
+		return true
+	}
+	for i := len(o.History) - 1; i >= 0; i-- {
+		if o.History[i].ExecID == execID && !o.History[i].Refused {
+			return true
+		}
+	}
+	return false
+}
+
+// recordEvent appends an event for er to the order's history and, unless
+// it was refused, remembers its ExecID.
+func (o *Order) recordEvent(er *ExecutionReport, note string, refused bool) {
+	if !refused && er.ExecID != "" && !slices.Contains(o.ExecIDs, er.ExecID) {
+		o.ExecIDs = append(o.ExecIDs, er.ExecID)
+	}
+	o.appendEvent(OrderEvent{
+		Time:       time.Now(),
+		ClOrdID:    er.ClOrdID,
+		ExecID:     er.ExecID,
+		ExecType:   er.ExecType,
+		FromStatus: o.OrdStatus,
+		OrdStatus:  er.OrdStatus,
+		CumQty:     er.CumQty,
+		Note:       note,
+		Refused:    refused,
+	})
+}
+
+func (o *Order) appendEvent(event OrderEvent) {
+	o.History = append(o.History, event)
+	if len(o.History) > maxOrderEvents {
+		o.History = slices.Delete(o.History, 0, len(o.History)-maxOrderEvents)
+	}
+}
+
+// replacementStatus is the status of the order that results from a replace.
+// Prime reports its current status, but a report that carries Replaced (5)
+// as the OrdStatus describes the order being replaced instead.
+func replacementStatus(er *ExecutionReport) string {
+	if er.OrdStatus != constants.OrdStatusReplaced {
+		return er.OrdStatus
+	}
+	if parseDecimalOrZero(er.CumQty).Sign() > 0 {
+		return constants.OrdStatusPartiallyFilled
+	}
+	return constants.OrdStatusNew
+}
@@ -0,0 +1,214 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"prime-fix-md-go/constants"
+)
+
+// Tests for order lifecycle validation.
+
+// TestOrderStore_RefusesIllegalTransitions verifies that a report moving a
+// filled order back to New, or a fill older than the order's CumQty, is not
+// applied but is kept in the history.
+func TestOrderStore_RefusesIllegalTransitions(t *testing.T) {
+	store := NewOrderStore()
+	store.AddOrder(&Order{ClOrdID: "ord-1", OrdStatus: constants.OrdStatusPendingNew})
+
+	reports := []*ExecutionReport{
+		{ClOrdID: "ord-1", ExecID: "e1", ExecType: constants.ExecTypeNew, OrdStatus: constants.OrdStatusNew, CumQty: "0"},
+		{ClOrdID: "ord-1", ExecID: "e3", ExecType: constants.ExecTypeTrade, OrdStatus: constants.OrdStatusFilled, CumQty: "1"},
+	}
+	for _, er := range reports {
+		if err := store.UpdateOrderFromExecReport(er); err != nil {
+			t.Fatalf("expected %s to apply, got %v", er.ExecID, err)
+		}
+	}
+
+	// The partial fill arrives after the fill that completed the order
+	late := &ExecutionReport{ClOrdID: "ord-1", ExecID: "e2", ExecType: constants.ExecTypeTrade,
+		OrdStatus: constants.OrdStatusPartiallyFilled, CumQty: "0.4"}
+	var transitionErr *TransitionError
+	if err := store.UpdateOrderFromExecReport(late); !errors.As(err, &transitionErr) {
+		t.Fatalf("expected a TransitionError, got %v", err)
+	}
+	if transitionErr.From != constants.OrdStatusFilled || transitionErr.To != constants.OrdStatusPartiallyFilled {
+		t.Errorf("unexpected transition %s → %s", transitionErr.From, transitionErr.To)
+	}
+
+	reopen := &ExecutionReport{ClOrdID: "ord-1", ExecID: "e4", ExecType: constants.ExecTypeNew, OrdStatus: constants.OrdStatusNew}
+	if err := store.UpdateOrderFromExecReport(reopen); !errors.As(err, &transitionErr) {
+		t.Fatalf("expected Filled → New to be refused, got %v", err)
+	}
+
+	order := store.GetOrder("ord-1")
+	if order.OrdStatus != constants.OrdStatusFilled || order.CumQty != "1" {
+		t.Errorf("expected the order to stay filled, got status %s CumQty %s", order.OrdStatus, order.CumQty)
+	}
+	if len(order.History) != 4 || !order.History[2].Refused || !order.History[3].Refused {
+		t.Errorf("expected 2 applied and 2 refused events, got %+v", order.History)
+	}
+}
+
+// TestOrderStore_IgnoresDuplicateExecID verifies that a resent report is
+// reported as a duplicate and not recorded again.
+func TestOrderStore_IgnoresDuplicateExecID(t *testing.T) {
+	store := NewOrderStore()
+	store.AddOrder(&Order{ClOrdID: "ord-1", OrdStatus: constants.OrdStatusNew})
+	fill := &ExecutionReport{ClOrdID: "ord-1", ExecID: "e1", ExecType: constants.ExecTypeTrade,
+		OrdStatus: constants.OrdStatusPartiallyFilled, CumQty: "0.5"}
+
+	if err := store.UpdateOrderFromExecReport(fill); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.UpdateOrderFromExecReport(fill); !errors.Is(err, ErrDuplicateExecID) {
+		t.Errorf("expected ErrDuplicateExecID, got %v", err)
+	}
+	if n := len(store.GetOrder("ord-1").History); n != 1 {
+		t.Errorf("expected 1 event, got %d", n)
+	}
+}
+
+// TestOrderStore_DuplicateExecIDOutlivesHistory verifies that a resent fill
+// is still recognised once it has dropped out of the bounded history, and
+// after the order has been replaced.
+func TestOrderStore_DuplicateExecIDOutlivesHistory(t *testing.T) {
+	store := NewOrderStore()
+	store.AddOrder(&Order{ClOrdID: "twap-1", OrdStatus: constants.OrdStatusNew})
+	fill := &ExecutionReport{ClOrdID: "twap-1", ExecID: "e1", ExecType: constants.ExecTypeTrade,
+		OrdStatus: constants.OrdStatusPartiallyFilled, CumQty: "1"}
+	store.UpdateOrderFromExecReport(fill)
+	for i := 1; i <= maxOrderEvents; i++ {
+		store.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: "twap-1", ExecID: fmt.Sprintf("s%d", i),
+			ExecType: constants.ExecTypeOrderStatus, OrdStatus: constants.OrdStatusPartiallyFilled, CumQty: "1"})
+	}
+	if err := store.UpdateOrderFromExecReport(fill); !errors.Is(err, ErrDuplicateExecID) {
+		t.Errorf("expected ErrDuplicateExecID beyond the history, got %v", err)
+	}
+
+	replace := &ExecutionReport{ClOrdID: "twap-2", OrigClOrdID: "twap-1", ExecID: "r1",
+		ExecType: constants.ExecTypeReplaced, OrdStatus: constants.OrdStatusReplaced, CumQty: "1"}
+	if err := store.UpdateOrderFromExecReport(replace); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resent := *fill
+	resent.ClOrdID = "twap-2"
+	if err := store.UpdateOrderFromExecReport(&resent); !errors.Is(err, ErrDuplicateExecID) {
+		t.Errorf("expected ErrDuplicateExecID after the replace, got %v", err)
+	}
+	if order := store.GetOrder("twap-2"); order.CumQty != "1" {
+		t.Errorf("expected CumQty to stay 1, got %s", order.CumQty)
+	}
+}
+
+// TestOrderStore_FlagsUntrackedOrder verifies that a report for an order that
+// is not tracked starts tracking it, flags the event and says so.
+func TestOrderStore_FlagsUntrackedOrder(t *testing.T) {
+	store := NewOrderStore()
+	report := &ExecutionReport{ClOrdID: "other-1", OrderID: "ord-7", ExecID: "e1", ExecType: constants.ExecTypeNew,
+		OrdStatus: constants.OrdStatusNew, Symbol: "BTC-USD"}
+
+	if err := store.UpdateOrderFromExecReport(report); !errors.Is(err, ErrUntrackedOrder) {
+		t.Fatalf("expected ErrUntrackedOrder, got %v", err)
+	}
+	order := store.GetOrder("other-1")
+	if order == nil || order.OrdStatus != constants.OrdStatusNew {
+		t.Fatalf("expected the order to be tracked from the report, got %+v", order)
+	}
+	if event := order.History[0]; !event.Unknown || event.Refused {
+		t.Errorf("expected an applied unknown-order event, got %+v", event)
+	}
+}
+
+// TestOrderStore_AppliesStatusReportsDespiteTransition verifies that an order
+// status reply is authoritative: it is applied and flagged in the history.
+func TestOrderStore_AppliesStatusReportsDespiteTransition(t *testing.T) {
+	store := NewOrderStore()
+	store.AddOrder(&Order{ClOrdID: "ord-1", OrdStatus: constants.OrdStatusCanceled})
+
+	status := &ExecutionReport{ClOrdID: "ord-1", ExecID: "s1", ExecType: constants.ExecTypeOrderStatus,
+		OrdStatus: constants.OrdStatusNew}
+	if err := store.UpdateOrderFromExecReport(status); err != nil {
+		t.Fatalf("expected the status report to apply, got %v", err)
+	}
+
+	order := store.GetOrder("ord-1")
+	if order.OrdStatus != constants.OrdStatusNew {
+		t.Errorf("expected status New, got %s", order.OrdStatus)
+	}
+	if event := order.History[0]; event.Refused || event.Note == "" || event.FromStatus != constants.OrdStatusCanceled {
+		t.Errorf("expected a flagged Canceled → New event, got %+v", event)
+	}
+}
+
+// TestOrderStore_FollowsCancelReplaceChain verifies that a replace report
+// retires the original order and continues it under the new ClOrdID, and
+// that a cancel report updates the order named by OrigClOrdID.
+func TestOrderStore_FollowsCancelReplaceChain(t *testing.T) {
+	store := NewOrderStore()
+	store.AddOrder(&Order{ClOrdID: "lmt-1", Symbol: "BTC-USD", Side: constants.SideBuy, OrdType: constants.OrdTypeLimit,
+		OrderQty: "1", Price: "49000", OrdStatus: constants.OrdStatusNew, OrderID: "ord-9"})
+
+	replace := &ExecutionReport{ClOrdID: "rep-1", OrigClOrdID: "lmt-1", OrderID: "ord-9", ExecID: "e1",
+		ExecType: constants.ExecTypeReplaced, OrdStatus: constants.OrdStatusReplaced, Symbol: "BTC-USD",
+		Side: constants.SideBuy, OrderQty: "2", Price: "49500", CumQty: "0"}
+	if err := store.UpdateOrderFromExecReport(replace); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	orig, rep := store.GetOrder("lmt-1"), store.GetOrder("rep-1")
+	if orig.OrdStatus != constants.OrdStatusReplaced || orig.History[0].Note != "replaced by rep-1" {
+		t.Errorf("expected lmt-1 to be replaced, got %+v", orig)
+	}
+	if rep.OrdStatus != constants.OrdStatusNew || rep.OrigClOrdID != "lmt-1" || rep.OrderQty != "2" || rep.OrdType != constants.OrdTypeLimit {
+		t.Errorf("expected rep-1 to continue lmt-1 as New, got %+v", rep)
+	}
+
+	cancel := &ExecutionReport{ClOrdID: "cxl-1", OrigClOrdID: "rep-1", OrderID: "ord-9", ExecID: "e2",
+		ExecType: constants.ExecTypeCanceled, OrdStatus: constants.OrdStatusCanceled, Symbol: "BTC-USD", Side: constants.SideBuy}
+	if err := store.UpdateOrderFromExecReport(cancel); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.GetOrder("cxl-1") != nil {
+		t.Error("expected the cancel not to create an order")
+	}
+	if rep = store.GetOrder("rep-1"); rep.OrdStatus != constants.OrdStatusCanceled || rep.History[1].ClOrdID != "cxl-1" {
+		t.Errorf("expected rep-1 to be canceled by cxl-1, got %+v", rep)
+	}
+	if n := len(store.GetOpenOrders()); n != 0 {
+		t.Errorf("expected no open orders, got %d", n)
+	}
+}
+
+// TestOrder_HistoryIsBounded verifies that only the latest maxOrderEvents
+// events are kept.
+func TestOrder_HistoryIsBounded(t *testing.T) {
+	store := NewOrderStore()
+	for i := 1; i <= maxOrderEvents+5; i++ {
+		store.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: "twap-1", ExecID: fmt.Sprintf("e%d", i),
+			ExecType: constants.ExecTypeTrade, OrdStatus: constants.OrdStatusPartiallyFilled, CumQty: fmt.Sprint(i)})
+	}
+
+	history := store.GetOrder("twap-1").History
+	if len(history) != maxOrderEvents || history[0].ExecID != "e6" {
+		t.Errorf("expected %d events starting at e6, got %d starting at %s", maxOrderEvents, len(history), history[0].ExecID)
+	}
+}
@@ -21,8 +21,14 @@
 package fixclient
 
 import (
+	"fmt"
+	"log"
+	"slices"
 	"sync"
 	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
 )
 
 // Order represents an order's current state as tracked by the client.
@@ -35,6 +41,7 @@ type Order struct {
 
 	// String fields (16 bytes each)
 	ClOrdID        string `json:"clOrdId"`        // Client order ID
+	OrigClOrdID    string `json:"origClOrdId"`    // ClOrdID of the order this one replaced
 	OrderID        string `json:"orderId"`        // Exchange-assigned order ID
 	Symbol         string `json:"symbol"`         // Product pair (e.g., BTC-USD)
 	Side           string `json:"side"`           // "1" buy, "2" sell
@@ -59,9 +66,10 @@ type Order struct {
 	ExecID     string `json:"execId"`     // Last execution ID
 
 	// Fee information
-	Commission string `json:"commission"` // Total commission
-	FilledAmt  string `json:"filledAmt"`  // Cumulative quote currency impact
-	NetAvgPx   string `json:"netAvgPx"`   // Net average price with fees
+	Commission string    `json:"commission"`         // Total commission
+	FilledAmt  string    `json:"filledAmt"`          // Cumulative quote currency impact
+	NetAvgPx   string    `json:"netAvgPx"`           // Net average price with fees
+	MiscFees   []MiscFee `json:"miscFees,omitempty"` // Per-fill fees, one entry per fee per ExecID
 
 	// Rejection/Error info
 	OrdRejReason string `json:"ordRejReason,omitempty"` // If rejected
@@ -69,13 +77,45 @@ type Order struct {
 
 	// Account info
 	Account string `json:"account"` // Portfolio ID
+
+	// RFQ this order accepted a quote from
+	QuoteReqID string `json:"quoteReqId,omitempty"`
+
+	// Synthetic order (OCO, bracket, trailing stop) this order is a leg of
+	StrategyID string `json:"strategyId,omitempty"`
+
+	// Lifecycle
+	History    []OrderEvent     `json:"history,omitempty"`    // Oldest first, see maxOrderEvents
+	Amendments []OrderAmendment `json:"amendments,omitempty"` // Versions this order replaced, oldest first
+	ExecIDs    []string         `json:"execIds,omitempty"`    // Every ExecID applied to it and the versions it replaced
+}
+
+// OrderAmendment is an earlier version of an order, before a cancel/replace
+// gave it a new ClOrdID.
+type OrderAmendment struct {
+	ClOrdID      string    `json:"clOrdId"`
+	OrderQty     string    `json:"orderQty,omitempty"`
+	CashOrderQty string    `json:"cashOrderQty,omitempty"`
+	Price        string    `json:"price,omitempty"`
+	ReplacedAt   time.Time `json:"replacedAt"`
+}
+
+// MiscFee is one entry of the MiscFees repeating group (Tags 136-139).
+type MiscFee struct {
+	ExecID string `json:"execId,omitempty"` // Execution the fee was charged on
+	Amt    string `json:"amt"`              // Tag 137
+	Curr   string `json:"curr"`             // Tag 138
+	Type   string `json:"type"`             // Tag 139, see constants.MiscFeeType*
 }
 
-// Quote represents a received quote from the RFQ process.
+// Quote represents an RFQ, keyed by QuoteReqID, from the Quote Request
+// through the quote received for it to its acceptance, rejection or expiry.
 type Quote struct {
 	// Time fields
+	RequestedAt    time.Time `json:"requestedAt,omitempty"`
 	ReceivedAt     time.Time `json:"receivedAt"`
 	ValidUntilTime time.Time `json:"validUntilTime"`
+	UpdatedAt      time.Time `json:"updatedAt,omitempty"`
 
 	// Identifiers
 	QuoteID    string `json:"quoteId"`
@@ -83,21 +123,38 @@ type Quote struct {
 	Account    string `json:"account"`
 	Symbol     string `json:"symbol"`
 
+	// Request
+	Side     string `json:"side,omitempty"`     // Side requested
+	OrderQty string `json:"orderQty,omitempty"` // Quantity requested
+
 	// Quote values (only one set populated based on side)
 	BidPx     string `json:"bidPx,omitempty"`     // For sells
 	BidSize   string `json:"bidSize,omitempty"`   // For sells
 	OfferPx   string `json:"offerPx,omitempty"`   // For buys
 	OfferSize string `json:"offerSize,omitempty"` // For buys
+
+	// Lifecycle, see constants.RfqStatus*
+	Status       string `json:"status,omitempty"`
+	RejectReason string `json:"rejectReason,omitempty"` // Tag 300 of the rejection
+	Text         string `json:"text,omitempty"`
+
+	// Order that accepted the quote, kept current from its execution reports
+	ClOrdID   string `json:"clOrdId,omitempty"`
+	OrderID   string `json:"orderId,omitempty"`
+	OrdStatus string `json:"ordStatus,omitempty"`
+	CumQty    string `json:"cumQty,omitempty"`
+	AvgPx     string `json:"avgPx,omitempty"`
 }
 
 // ExecutionReport represents a parsed Execution Report (8) message.
 type ExecutionReport struct {
 	// Identifiers
-	ClOrdID string `json:"clOrdId"`
-	OrderID string `json:"orderId"`
-	ExecID  string `json:"execId"`
-	Account string `json:"account"`
-	Symbol  string `json:"symbol"`
+	ClOrdID     string `json:"clOrdId"`
+	OrigClOrdID string `json:"origClOrdId,omitempty"` // Set on cancel and replace reports
+	OrderID     string `json:"orderId"`
+	ExecID      string `json:"execId"`
+	Account     string `json:"account"`
+	Symbol      string `json:"symbol"`
 
 	// Status
 	OrdStatus string `json:"ordStatus"`
@@ -118,16 +175,23 @@ type ExecutionReport struct {
 	LastShares string `json:"lastShares,omitempty"`
 
 	// Fees
-	Commission string `json:"commission,omitempty"`
-	FilledAmt  string `json:"filledAmt,omitempty"`
-	NetAvgPx   string `json:"netAvgPx,omitempty"`
+	Commission string    `json:"commission,omitempty"`
+	FilledAmt  string    `json:"filledAmt,omitempty"`
+	NetAvgPx   string    `json:"netAvgPx,omitempty"`
+	MiscFees   []MiscFee `json:"miscFees,omitempty"`
 
 	// Error info
 	OrdRejReason string `json:"ordRejReason,omitempty"`
 	Text         string `json:"text,omitempty"`
 
 	// Timing
+	TransactTime  string `json:"transactTime,omitempty"`
 	EffectiveTime string `json:"effectiveTime,omitempty"`
+
+	// Mass status response (reports to an Order Mass Status Request)
+	MassStatusReqID  string `json:"massStatusReqId,omitempty"`
+	TotNumReports    string `json:"totNumReports,omitempty"`
+	LastRptRequested string `json:"lastRptRequested,omitempty"`
 }
 
 // OrderCancelReject represents a parsed Order Cancel Reject (9) message.
@@ -155,9 +219,23 @@ type BusinessReject struct {
 	RefSeqNum            string `json:"refSeqNum"`
 	RefMsgType           string `json:"refMsgType"`
 	BusinessRejectReason string `json:"businessRejectReason"`
+	BusinessRejectRefID  string `json:"businessRejectRefId,omitempty"`
 	Text                 string `json:"text,omitempty"`
 }
 
+// OrderMassCancelReport represents a parsed Order Mass Cancel Report (r).
+type OrderMassCancelReport struct {
+	ClOrdID                string `json:"clOrdId"`
+	OrderID                string `json:"orderId"`
+	MassCancelRequestType  string `json:"massCancelRequestType"`
+	MassCancelResponse     string `json:"massCancelResponse"` // "0" rejected, otherwise the request type
+	MassCancelRejectReason string `json:"massCancelRejectReason,omitempty"`
+	TotalAffectedOrders    string `json:"totalAffectedOrders,omitempty"`
+	Symbol                 string `json:"symbol,omitempty"`
+	Side                   string `json:"side,omitempty"`
+	Text                   string `json:"text,omitempty"`
+}
+
 // QuoteAck represents a parsed Quote Acknowledgement (b) message (rejection).
 type QuoteAck struct {
 	QuoteID           string `json:"quoteId,omitempty"`
@@ -170,16 +248,23 @@ type QuoteAck struct {
 }
 
 // OrderStore provides thread-safe storage for orders and quotes.
+//
+// A cancel/replace gives an order a new ClOrdID. The store links each
+// replaced ClOrdID, and the ClOrdID of each cancel, to the ClOrdID that
+// followed it, so ResolveOrder finds the live order from any ID in the chain.
 type OrderStore struct {
-	mu     sync.RWMutex
-	orders map[string]*Order // ClOrdID -> Order
-	quotes map[string]*Quote // QuoteReqID -> Quote
+	mu        sync.RWMutex
+	orders    map[string]*Order // ClOrdID -> Order
+	links     map[string]string // Replaced or cancel ClOrdID -> next ClOrdID in the chain
+	quotes    map[string]*Quote // QuoteReqID -> Quote
+	persister OrderPersister    // Optional write-through storage
 }
 
 // NewOrderStore creates a new OrderStore.
 func NewOrderStore() *OrderStore {
 	return &OrderStore{
 		orders: make(map[string]*Order),
+		links:  make(map[string]string),
 		quotes: make(map[string]*Quote),
 	}
 }
@@ -195,6 +280,7 @@ func (os *OrderStore) AddOrder(order *Order) {
 		order.CreatedAt = order.UpdatedAt
 	}
 	os.orders[order.ClOrdID] = order
+	os.persistOrder(order)
 }
 
 // GetOrder retrieves an order by ClOrdID.
@@ -202,49 +288,178 @@ func (os *OrderStore) GetOrder(clOrdID string) *Order {
 	os.mu.RLock()
 	defer os.mu.RUnlock()
 	if order, exists := os.orders[clOrdID]; exists {
-		copy := *order
-		return &copy
+		return order.clone()
 	}
 	return nil
 }
 
-// GetOrderByOrderID retrieves an order by exchange OrderID.
+// GetOrderByOrderID retrieves an order by exchange OrderID. An order keeps
+// its OrderID across replaces; the current version is returned.
 func (os *OrderStore) GetOrderByOrderID(orderID string) *Order {
 	os.mu.RLock()
 	defer os.mu.RUnlock()
+	if order := os.orderByOrderID(orderID); order != nil {
+		return order.clone()
+	}
+	return nil
+}
+
+// ResolveOrder retrieves the current version of an order from any ClOrdID in
+// its cancel/replace chain, or from its OrderID.
+func (os *OrderStore) ResolveOrder(id string) *Order {
+	os.mu.RLock()
+	defer os.mu.RUnlock()
+	if order := os.resolve(id); order != nil {
+		return order.clone()
+	}
+	if order := os.orderByOrderID(id); order != nil {
+		return order.clone()
+	}
+	return nil
+}
+
+// GetCurrentOrders returns a copy of every order that has not been replaced,
+// one per logical order. Earlier versions are listed in Amendments.
+func (os *OrderStore) GetCurrentOrders() []*Order {
+	os.mu.RLock()
+	defer os.mu.RUnlock()
+
+	result := make([]*Order, 0, len(os.orders))
 	for _, order := range os.orders {
-		if order.OrderID == orderID {
-			copy := *order
-			return &copy
+		if _, replaced := os.links[order.ClOrdID]; !replaced {
+			result = append(result, order.clone())
 		}
 	}
+	return result
+}
+
+// resolve follows the chain from clOrdID to the last order in it, or returns
+// nil if clOrdID is not tracked. Caller must hold os.mu.
+func (os *OrderStore) resolve(clOrdID string) *Order {
+	// A chain cannot be longer than the number of links; the bound guards
+	// against cycles
+	for range len(os.links) + 1 {
+		next, ok := os.links[clOrdID]
+		if !ok {
+			break
+		}
+		clOrdID = next
+	}
+	return os.orders[clOrdID]
+}
+
+// orderByOrderID returns the order with orderID that has not been replaced.
+// Caller must hold os.mu.
+func (os *OrderStore) orderByOrderID(orderID string) *Order {
+	var match *Order
+	for _, order := range os.orders {
+		if order.OrderID != orderID {
+			continue
+		}
+		if _, replaced := os.links[order.ClOrdID]; !replaced {
+			return order
+		}
+		match = order
+	}
+	if match != nil {
+		return os.resolve(match.ClOrdID)
+	}
 	return nil
 }
 
-// UpdateOrderFromExecReport updates an order based on an execution report.
-func (os *OrderStore) UpdateOrderFromExecReport(er *ExecutionReport) {
+// UpdateOrderFromExecReport applies an execution report to its order and
+// records it in the order's History.
+//
+// Reports are checked against the order lifecycle (see orderTransitions). A
+// report that cannot follow the order's current state, such as Filled → New
+// or a fill older than one already applied, is not applied and a
+// *TransitionError is returned; status reports are applied anyway and only
+// flagged. A report whose ExecID was already applied returns
+// ErrDuplicateExecID.
+//
+// Cancel and replace reports carry the new ClOrdID and the OrigClOrdID of the
+// order they act on. A cancel updates the original order; a replace marks it
+// Replaced and continues it under the new ClOrdID. Reports for untracked
+// orders, e.g. ones placed in another session, create a new order, flag the
+// event and return ErrUntrackedOrder.
+func (os *OrderStore) UpdateOrderFromExecReport(er *ExecutionReport) error {
 	os.mu.Lock()
 	defer os.mu.Unlock()
 
 	order, exists := os.orders[er.ClOrdID]
-	if !exists {
+	var orig *Order
+	if er.OrigClOrdID != "" && er.OrigClOrdID != er.ClOrdID {
+		if orig = os.resolve(er.OrigClOrdID); orig != nil && orig.ClOrdID == er.ClOrdID {
+			orig = nil // Already followed, e.g. a resent replace report
+		}
+	}
+
+	report := *er
+	if er.ExecType == constants.ExecTypeReplaced {
+		report.OrdStatus = replacementStatus(er)
+	}
+	if er.ExecType == constants.ExecTypeReplaced && orig != nil && orig.OrdStatus != constants.OrdStatusReplaced {
+		if orig.hasExec(er.ExecID) {
+			return fmt.Errorf("order %s: %w %s", orig.ClOrdID, ErrDuplicateExecID, er.ExecID)
+		}
+		successor, err := os.replaceOrder(orig, er)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			order, exists = successor, true
+		}
+	} else if !exists && orig != nil {
+		order, exists = orig, true
+		os.links[er.ClOrdID] = orig.ClOrdID
+	}
+
+	unknown := !exists
+	if unknown {
 		// Create new order from execution report
 		order = &Order{
-			ClOrdID:   er.ClOrdID,
-			CreatedAt: time.Now(),
+			ClOrdID:     er.ClOrdID,
+			OrigClOrdID: er.OrigClOrdID,
+			CreatedAt:   time.Now(),
 		}
 		os.orders[er.ClOrdID] = order
+		order.recordEvent(&report, "unknown order, not tracked before this report", false)
+		order.History[len(order.History)-1].Unknown = true
+	} else if order.hasExec(er.ExecID) {
+		return fmt.Errorf("order %s: %w %s", order.ClOrdID, ErrDuplicateExecID, er.ExecID)
+	} else if err := checkTransition(order, &report); err != nil {
+		if !isStatusReport(er) {
+			order.recordEvent(&report, err.Reason, true)
+			os.persistOrder(order)
+			return err
+		}
+		log.Printf("Applying status report despite %v", err)
+		order.recordEvent(&report, "restated: "+err.Reason, false)
+	} else {
+		order.recordEvent(&report, "", false)
 	}
+	er = &report
 
 	order.UpdatedAt = time.Now()
-	order.OrderID = er.OrderID
-	order.Symbol = er.Symbol
-	order.Side = er.Side
-	order.OrdType = er.OrdType
 	order.OrdStatus = er.OrdStatus
 	order.ExecType = er.ExecType
-	order.Account = er.Account
 
+	// Cancel and replace reports may omit fields the order already has
+	if er.OrderID != "" {
+		order.OrderID = er.OrderID
+	}
+	if er.Symbol != "" {
+		order.Symbol = er.Symbol
+	}
+	if er.Side != "" {
+		order.Side = er.Side
+	}
+	if er.OrdType != "" {
+		order.OrdType = er.OrdType
+	}
+	if er.Account != "" {
+		order.Account = er.Account
+	}
 	if er.OrderQty != "" {
 		order.OrderQty = er.OrderQty
 	}
@@ -281,12 +496,65 @@ func (os *OrderStore) UpdateOrderFromExecReport(er *ExecutionReport) {
 	if er.NetAvgPx != "" {
 		order.NetAvgPx = er.NetAvgPx
 	}
+	if len(er.MiscFees) > 0 && !hasFeesForExec(order, er.ExecID) {
+		// Fees are per execution; a resent report must not count them twice
+		for _, fee := range er.MiscFees {
+			fee.ExecID = er.ExecID
+			order.MiscFees = append(order.MiscFees, fee)
+		}
+	}
 	if er.OrdRejReason != "" {
 		order.OrdRejReason = er.OrdRejReason
 	}
 	if er.Text != "" {
 		order.Text = er.Text
 	}
+	os.persistOrder(order)
+	if order.QuoteReqID != "" {
+		os.linkQuoteOrder(order)
+	}
+	if unknown {
+		return fmt.Errorf("order %s: %w", order.ClOrdID, ErrUntrackedOrder)
+	}
+	return nil
+}
+
+// replaceOrder marks orig Replaced by the replace report er and returns the
+// order that continues it under er's ClOrdID. Caller must hold os.mu.
+func (os *OrderStore) replaceOrder(orig *Order, er *ExecutionReport) (*Order, error) {
+	replaced := *er
+	replaced.OrdStatus = constants.OrdStatusReplaced
+	if err := checkTransition(orig, &replaced); err != nil {
+		orig.recordEvent(er, err.Reason, true)
+		os.persistOrder(orig)
+		return nil, err
+	}
+
+	successor := orig.clone()
+	successor.ClOrdID = er.ClOrdID
+	successor.OrigClOrdID = orig.ClOrdID
+	successor.CreatedAt = time.Now()
+	successor.History = nil
+	successor.Amendments = append(successor.Amendments, OrderAmendment{
+		ClOrdID:      orig.ClOrdID,
+		OrderQty:     orig.OrderQty,
+		CashOrderQty: orig.CashOrderQty,
+		Price:        orig.Price,
+		ReplacedAt:   time.Now(),
+	})
+	if current, tracked := os.orders[er.ClOrdID]; tracked {
+		current.Amendments = successor.Amendments
+	} else {
+		os.orders[er.ClOrdID] = successor
+	}
+	os.links[orig.ClOrdID] = er.ClOrdID
+
+	orig.recordEvent(&replaced, "replaced by "+er.ClOrdID, false)
+	orig.OrdStatus = constants.OrdStatusReplaced
+	orig.ExecType = constants.ExecTypeReplaced
+	orig.UpdatedAt = time.Now()
+	os.persistOrder(orig)
+	return successor, nil
 }
 
 // GetAllOrders returns a copy of all orders.
@@ -296,8 +564,7 @@ func (os *OrderStore) GetAllOrders() []*Order {
 
 	result := make([]*Order, 0, len(os.orders))
 	for _, order := range os.orders {
-		copy := *order
-		result = append(result, &copy)
+		result = append(result, order.clone())
 	}
 	return result
 }
@@ -310,8 +577,7 @@ func (os *OrderStore) GetOpenOrders() []*Order {
 	result := make([]*Order, 0)
 	for _, order := range os.orders {
 		if isOpenStatus(order.OrdStatus) {
-			copy := *order
-			result = append(result, &copy)
+			result = append(result, order.clone())
 		}
 	}
 	return result
@@ -322,16 +588,173 @@ func (os *OrderStore) RemoveOrder(clOrdID string) {
 	os.mu.Lock()
 	defer os.mu.Unlock()
 	delete(os.orders, clOrdID)
+	for from, to := range os.links {
+		if from == clOrdID || to == clOrdID {
+			delete(os.links, from)
+		}
+	}
+	if os.persister != nil {
+		if err := os.persister.DeleteOrder(clOrdID); err != nil {
+			log.Printf("Failed to delete order %s from storage: %v", clOrdID, err)
+		}
+	}
 }
 
 // --- Quote Operations ---
 
-// AddQuote adds or updates a quote in the store.
+// AddQuoteRequest tracks a Quote Request (R) about to be sent, so that a quote
+// or reject answering it always finds it. An RFQ that is already tracked is
+// left alone; returns false if it was.
+func (os *OrderStore) AddQuoteRequest(rfq *Quote) bool {
+	os.mu.Lock()
+	defer os.mu.Unlock()
+	if _, exists := os.quotes[rfq.QuoteReqID]; exists {
+		return false
+	}
+	rfq.RequestedAt = time.Now()
+	rfq.UpdatedAt = rfq.RequestedAt
+	rfq.Status = constants.RfqStatusRequested
+	os.quotes[rfq.QuoteReqID] = rfq
+	os.persistQuote(rfq)
+	return true
+}
+
+// RemoveQuoteRequest forgets an RFQ whose Quote Request could not be sent. It
+// is removed from storage too, unless an answer has arrived for it meanwhile.
+func (os *OrderStore) RemoveQuoteRequest(quoteReqID string) {
+	os.mu.Lock()
+	defer os.mu.Unlock()
+	rfq, ok := os.quotes[quoteReqID]
+	if !ok || rfq.Status != constants.RfqStatusRequested {
+		return
+	}
+	delete(os.quotes, quoteReqID)
+	if os.persister != nil {
+		if err := os.persister.DeleteQuote(quoteReqID); err != nil {
+			log.Printf("Failed to delete quote %s from storage: %v", quoteReqID, err)
+		}
+	}
+}
+
+// AddQuote adds or updates a quote in the store and marks its RFQ quoted.
+// What was requested is kept from the RFQ's earlier entry.
 func (os *OrderStore) AddQuote(quote *Quote) {
 	os.mu.Lock()
 	defer os.mu.Unlock()
 	quote.ReceivedAt = time.Now()
+	quote.UpdatedAt = quote.ReceivedAt
+	quote.Status = constants.RfqStatusQuoted
+	if rfq, ok := os.quotes[quote.QuoteReqID]; ok {
+		quote.RequestedAt = rfq.RequestedAt
+		quote.Side = rfq.Side
+		quote.OrderQty = rfq.OrderQty
+	}
 	os.quotes[quote.QuoteReqID] = quote
+	os.persistQuote(quote)
+}
+
+// RejectQuoteRequest marks the RFQ named by a Quote Acknowledgement rejected
+// and returns a copy of it. An RFQ that is not tracked is added.
+func (os *OrderStore) RejectQuoteRequest(ack *QuoteAck) *Quote {
+	os.mu.Lock()
+	defer os.mu.Unlock()
+	rfq, ok := os.quotes[ack.QuoteReqID]
+	if !ok {
+		rfq = &Quote{QuoteReqID: ack.QuoteReqID, Account: ack.Account, Symbol: ack.Symbol}
+		os.quotes[ack.QuoteReqID] = rfq
+	}
+	rfq.Status = constants.RfqStatusRejected
+	rfq.RejectReason = ack.QuoteRejectReason
+	rfq.Text = ack.Text
+	rfq.UpdatedAt = time.Now()
+	os.persistQuote(rfq)
+	rejected := *rfq
+	return &rejected
+}
+
+// AcceptQuote marks the RFQ accepted by the order clOrdID. Execution reports
+// for that order then update the RFQ.
+func (os *OrderStore) AcceptQuote(quoteReqID, clOrdID string) {
+	os.mu.Lock()
+	defer os.mu.Unlock()
+	rfq, ok := os.quotes[quoteReqID]
+	if !ok {
+		return
+	}
+	rfq.Status = constants.RfqStatusAccepted
+	rfq.ClOrdID = clOrdID
+	rfq.UpdatedAt = time.Now()
+	os.persistQuote(rfq)
+}
+
+// ExpireQuote marks the RFQ expired if it is still quoted and its quote is no
+// longer valid at now, or if it is still waiting for a quote quoteRequestTimeout
+// after it was sent, and returns a copy of it if it was.
+func (os *OrderStore) ExpireQuote(quoteReqID string, now time.Time) (*Quote, bool) {
+	os.mu.Lock()
+	defer os.mu.Unlock()
+	rfq, ok := os.quotes[quoteReqID]
+	if !ok {
+		return nil, false
+	}
+	switch rfq.Status {
+	case constants.RfqStatusQuoted:
+		if rfq.ValidUntilTime.IsZero() || now.Before(rfq.ValidUntilTime) {
+			return nil, false
+		}
+	case constants.RfqStatusRequested:
+		if now.Before(rfq.RequestedAt.Add(quoteRequestTimeout)) {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	rfq.Status = constants.RfqStatusExpired
+	rfq.UpdatedAt = now
+	os.persistQuote(rfq)
+	expired := *rfq
+	return &expired, true
+}
+
+// PruneQuote drops a finished RFQ from memory: one that was rejected, expired,
+// or accepted by an order that is no longer open. It stays in storage.
+func (os *OrderStore) PruneQuote(quoteReqID string) bool {
+	os.mu.Lock()
+	defer os.mu.Unlock()
+	rfq, ok := os.quotes[quoteReqID]
+	if !ok || !isFinishedQuote(rfq) {
+		return false
+	}
+	delete(os.quotes, quoteReqID)
+	return true
+}
+
+// isFinishedQuote reports whether nothing more can happen to an RFQ.
+func isFinishedQuote(rfq *Quote) bool {
+	switch rfq.Status {
+	case constants.RfqStatusRejected, constants.RfqStatusExpired:
+		return true
+	case constants.RfqStatusAccepted:
+		return rfq.OrdStatus != "" && !isOpenStatus(rfq.OrdStatus)
+	default:
+		return false
+	}
+}
+
+// linkQuoteOrder copies the state of an order that accepted a quote to its
+// RFQ. Caller must hold os.mu.
+func (os *OrderStore) linkQuoteOrder(order *Order) {
+	rfq, ok := os.quotes[order.QuoteReqID]
+	if !ok {
+		return
+	}
+	rfq.ClOrdID = order.ClOrdID
+	rfq.OrderID = order.OrderID
+	rfq.OrdStatus = order.OrdStatus
+	rfq.CumQty = order.CumQty
+	rfq.AvgPx = order.AvgPx
+	rfq.UpdatedAt = time.Now()
+	os.persistQuote(rfq)
 }
 
 // GetQuote retrieves a quote by QuoteReqID.
@@ -363,6 +786,11 @@ func (os *OrderStore) RemoveQuote(quoteReqID string) {
 	os.mu.Lock()
 	defer os.mu.Unlock()
 	delete(os.quotes, quoteReqID)
+	if os.persister != nil {
+		if err := os.persister.DeleteQuote(quoteReqID); err != nil {
+			log.Printf("Failed to delete quote %s from storage: %v", quoteReqID, err)
+		}
+	}
 }
 
 // GetAllQuotes returns a copy of all quotes.
@@ -380,12 +808,70 @@ func (os *OrderStore) GetAllQuotes() []*Quote {
 
 // --- Helper Functions ---
 
-// isOpenStatus returns true if the order status indicates an open order.
-func isOpenStatus(status string) bool {
-	switch status {
-	case "0", "1", "6", "9", "A", "E": // New, PartiallyFilled, PendingCancel, Suspended, PendingNew, PendingReplace
-		return true
-	default:
+// MiscFeeTotals sums MiscFees per currency, e.g. {"USD": "1.25"}. Amounts are
+// added exactly and keep the largest number of decimals seen.
+func (o *Order) MiscFeeTotals() map[string]string {
+	return sumMiscFees(o.MiscFees)
+}
+
+// sumMiscFees implements MiscFeeTotals for any list of fees.
+func sumMiscFees(fees []MiscFee) map[string]string {
+	if len(fees) == 0 {
+		return nil
+	}
+	sums := make(map[string]decimal.Decimal)
+	for _, fee := range fees {
+		amt, err := decimal.Parse(fee.Amt)
+		if err != nil {
+			continue
+		}
+		if sum, err := sums[fee.Curr].Add(amt); err == nil {
+			sums[fee.Curr] = sum
+		}
+	}
+
+	totals := make(map[string]string, len(sums))
+	for curr, sum := range sums {
+		totals[curr] = sum.String()
+	}
+	return totals
+}
+
+// clone returns a copy of o that shares no mutable state with it.
+func (o *Order) clone() *Order {
+	c := *o
+	c.MiscFees = slices.Clone(o.MiscFees)
+	c.History = slices.Clone(o.History)
+	c.Amendments = slices.Clone(o.Amendments)
+	c.ExecIDs = slices.Clone(o.ExecIDs)
+	return &c
+}
+
+// hasFeesForExec reports whether fees for execID are already recorded.
+// Reports without an ExecID cannot be matched and are never duplicates.
+func hasFeesForExec(order *Order, execID string) bool {
+	if execID == "" {
 		return false
 	}
+	for _, fee := range order.MiscFees {
+		if fee.ExecID == execID {
+			return true
+		}
+	}
+	return false
+}
+
+// openOrdStatuses are the order statuses that can still change.
+var openOrdStatuses = []string{
+	constants.OrdStatusNew,
+	constants.OrdStatusPartiallyFilled,
+	constants.OrdStatusPendingCancel,
+	constants.OrdStatusSuspended,
+	constants.OrdStatusPendingNew,
+	constants.OrdStatusPendingReplace,
+}
+
+// isOpenStatus returns true if the order status indicates an open order.
+func isOpenStatus(status string) bool {
+	return slices.Contains(openOrdStatuses, status)
 }
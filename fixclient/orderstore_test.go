@@ -172,6 +172,74 @@ func TestOrderStore_UpdateOrderFromExecReport_CreatesIfNotExists(t *testing.T) {
 	}
 }
 
+// TestOrderStore_UpdateOrderFromExecReport_AccumulatesMiscFees verifies that
+// fees from each fill are kept per ExecID and that a resent report is not
+// counted twice.
+func TestOrderStore_UpdateOrderFromExecReport_AccumulatesMiscFees(t *testing.T) {
+	store := NewOrderStore()
+
+	fill1 := &ExecutionReport{
+		ClOrdID: "order-1", ExecID: "exec-1", OrdStatus: "1",
+		MiscFees: []MiscFee{{Amt: "0.10", Curr: "USD", Type: "2"}, {Amt: "0.05", Curr: "USD", Type: "4"}},
+	}
+	fill2 := &ExecutionReport{
+		ClOrdID: "order-1", ExecID: "exec-2", OrdStatus: "2",
+		MiscFees: []MiscFee{{Amt: "0.2", Curr: "USD", Type: "2"}, {Amt: "0.0001", Curr: "BTC", Type: "1"}},
+	}
+	store.UpdateOrderFromExecReport(fill1)
+	store.UpdateOrderFromExecReport(fill2)
+	store.UpdateOrderFromExecReport(fill1) // resend
+
+	order := store.GetOrder("order-1")
+	if len(order.MiscFees) != 4 {
+		t.Fatalf("expected 4 fees, got %+v", order.MiscFees)
+	}
+	if order.MiscFees[0].ExecID != "exec-1" || order.MiscFees[2].ExecID != "exec-2" {
+		t.Errorf("expected fees tagged with their ExecID, got %+v", order.MiscFees)
+	}
+
+	totals := order.MiscFeeTotals()
+	if totals["USD"] != "0.35" || totals["BTC"] != "0.0001" {
+		t.Errorf("expected totals USD=0.35 BTC=0.0001, got %v", totals)
+	}
+}
+
+// TestOrderStore_UpdateOrderFromExecReport_MiscFeesWithoutExecID verifies
+// that fees from reports without an ExecID are all kept.
+func TestOrderStore_UpdateOrderFromExecReport_MiscFeesWithoutExecID(t *testing.T) {
+	store := NewOrderStore()
+
+	store.UpdateOrderFromExecReport(&ExecutionReport{
+		ClOrdID: "order-1", OrdStatus: "1", MiscFees: []MiscFee{{Amt: "0.10", Curr: "USD", Type: "2"}},
+	})
+	store.UpdateOrderFromExecReport(&ExecutionReport{
+		ClOrdID: "order-1", OrdStatus: "2", MiscFees: []MiscFee{{Amt: "0.20", Curr: "USD", Type: "2"}},
+	})
+
+	order := store.GetOrder("order-1")
+	if len(order.MiscFees) != 2 {
+		t.Fatalf("expected 2 fees, got %+v", order.MiscFees)
+	}
+	if totals := order.MiscFeeTotals(); totals["USD"] != "0.30" {
+		t.Errorf("expected USD total 0.30, got %v", totals)
+	}
+}
+
+// TestOrderStore_GetOrder_CopiesMiscFees verifies that fees on a returned
+// order are not shared with the store.
+func TestOrderStore_GetOrder_CopiesMiscFees(t *testing.T) {
+	store := NewOrderStore()
+	store.UpdateOrderFromExecReport(&ExecutionReport{
+		ClOrdID: "order-1", ExecID: "exec-1",
+		MiscFees: []MiscFee{{Amt: "1", Curr: "USD", Type: "2"}},
+	})
+
+	store.GetOrder("order-1").MiscFees[0].Amt = "999"
+	if amt := store.GetOrder("order-1").MiscFees[0].Amt; amt != "1" {
+		t.Errorf("expected stored fee to be unchanged, got %s", amt)
+	}
+}
+
 // TestOrderStore_GetOpenOrders verifies filtering of orders by open status.
 func TestOrderStore_GetOpenOrders(t *testing.T) {
 	store := NewOrderStore()
@@ -346,3 +414,42 @@ func TestIsOpenStatus(t *testing.T) {
 		}
 	}
 }
+
+// TestOrderStore_ResolveOrder_FollowsReplaceChain verifies that every
+// ClOrdID of a twice-replaced order, and its OrderID, resolve to the current
+// version, which lists the earlier ones as amendments.
+func TestOrderStore_ResolveOrder_FollowsReplaceChain(t *testing.T) {
+	store := NewOrderStore()
+	store.AddOrder(&Order{ClOrdID: "lmt-1", OrderID: "ord-9", Symbol: "BTC-USD", Side: "1",
+		OrderQty: "1", Price: "49000", OrdStatus: "0"})
+	store.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: "rep-1", OrigClOrdID: "lmt-1", OrderID: "ord-9",
+		ExecID: "e1", ExecType: "5", OrdStatus: "0", OrderQty: "2", Price: "49500"})
+	store.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: "rep-2", OrigClOrdID: "rep-1", OrderID: "ord-9",
+		ExecID: "e2", ExecType: "5", OrdStatus: "0", OrderQty: "2", Price: "49800"})
+
+	for _, id := range []string{"lmt-1", "rep-1", "rep-2", "ord-9"} {
+		if order := store.ResolveOrder(id); order == nil || order.ClOrdID != "rep-2" {
+			t.Errorf("expected %s to resolve to rep-2, got %+v", id, order)
+		}
+	}
+	if order := store.GetOrderByOrderID("ord-9"); order.ClOrdID != "rep-2" {
+		t.Errorf("expected OrderID lookup to return rep-2, got %s", order.ClOrdID)
+	}
+
+	current := store.GetCurrentOrders()
+	if len(current) != 1 {
+		t.Fatalf("expected 1 logical order, got %d", len(current))
+	}
+	amendments := current[0].Amendments
+	if len(amendments) != 2 || amendments[0].ClOrdID != "lmt-1" || amendments[0].Price != "49000" ||
+		amendments[1].ClOrdID != "rep-1" || amendments[1].Price != "49500" {
+		t.Errorf("unexpected amendments %+v", amendments)
+	}
+
+	// A cancel naming the first ClOrdID cancels the current version
+	store.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: "cxl-1", OrigClOrdID: "lmt-1", OrderID: "ord-9",
+		ExecID: "e3", ExecType: "4", OrdStatus: "4"})
+	if order := store.ResolveOrder("cxl-1"); order == nil || order.ClOrdID != "rep-2" || order.OrdStatus != "4" {
+		t.Errorf("expected cxl-1 to resolve to the canceled rep-2, got %+v", order)
+	}
+}
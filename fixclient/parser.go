@@ -23,7 +23,13 @@
 // We use raw string parsing instead of quickfix's structured field access because:
 // 1. quickfix.Message.GetGroup() has significant overhead for repeating groups
 // 2. Direct string search is faster for our specific field extraction pattern
-// 3. We know the exact tags we need (269, 270, 271, 273, 290, 2446)
+// 3. We know the exact tags we need (269, 270, 271, 273, 278, 279, 290, 55, 2446)
+//
+// Entry Boundaries:
+// Entries are located by their MdEntryType (269) tag. In incremental refreshes (X)
+// each entry starts with MDUpdateAction (279), which precedes 269, so the boundary
+// is moved back to include it. Otherwise the action would be attributed to the
+// previous entry's segment.
 //
 // Performance Characteristics:
 // - findEntryBoundaries: O(m) where m = message length, 1 allocation
@@ -37,6 +43,7 @@ import (
 	"time"
 
 	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
 	"prime-fix-md-go/utils"
 
 	"github.com/quickfixgo/quickfix"
@@ -102,6 +109,9 @@ func (a *FixApp) extractTradesImproved(msg *quickfix.Message, symbol, mdReqId st
 // The "269=" tag marks the start of each repeating group entry in FIX market data.
 // We find all positions to define segment boundaries for individual parsing.
 //
+// If the field immediately before "269=" is MDUpdateAction ("279="), the boundary
+// starts at that field instead so each segment carries its own update action.
+//
 // Performance: O(m) where m = message length (two passes: Count + Index loop)
 // Allocations: 1 (pre-sized slice based on strings.Count)
 //
@@ -125,12 +135,26 @@ func (a *FixApp) findEntryBoundaries(rawMsg string) []int {
 		if pos == -1 {
 			break
 		}
-		entryStarts = append(entryStarts, searchFrom+pos)
+		entryStarts = append(entryStarts, entryStartWithUpdateAction(rawMsg, searchFrom+pos))
 		searchFrom += pos + 4 // Skip past "269=" to find next occurrence
 	}
 	return entryStarts
 }
 
+// entryStartWithUpdateAction returns the start of the "279=" field if it directly
+// precedes the "269=" tag at typePos, otherwise typePos unchanged.
+// HOT PATH: Backward scan over a single field, O(field length), no allocations.
+func entryStartWithUpdateAction(rawMsg string, typePos int) int {
+	if typePos < 6 || rawMsg[typePos-1] != '\x01' {
+		return typePos
+	}
+	prevStart := strings.LastIndexByte(rawMsg[:typePos-1], '\x01') + 1
+	if strings.HasPrefix(rawMsg[prevStart:typePos], "279=") {
+		return prevStart
+	}
+	return typePos
+}
+
 // getEntryEndPos returns the end position for an entry segment.
 // HOT PATH: Simple index lookup, O(1), no allocations.
 func (a *FixApp) getEntryEndPos(entryStarts []int, currentIndex, msgLen int) int {
@@ -146,6 +170,9 @@ func (a *FixApp) getEntryEndPos(entryStarts []int, currentIndex, msgLen int) int
 // This is the optimized version that parses all fields in a single pass through
 // the segment, instead of calling extractSingleFieldValue 6 times.
 //
+// Price (270) and Size (271) are also parsed into fixed-point PriceVal and
+// SizeVal here, so consumers never re-parse the strings.
+//
 // Performance: ~50-80ns per entry (3-4x faster than multi-pass)
 // Allocations: 0 (returns struct by value, strings are substrings)
 func (a *FixApp) parseTradeFromSegmentFast(segment, symbol, mdReqId string, isSnapshot bool, seqNum string, entryIndex int, timestamp time.Time) Trade {
@@ -194,12 +221,20 @@ func (a *FixApp) parseTradeFromSegmentFast(segment, symbol, mdReqId string, isSn
 			trade.EntryType = value
 		case "270": // MdEntryPx - usually present
 			trade.Price = value
+			trade.PriceVal, _ = decimal.Parse(value)
 		case "271": // MdEntrySize - usually present
 			trade.Size = value
+			trade.SizeVal, _ = decimal.Parse(value)
 		case "273": // MdEntryTime - usually present
 			trade.Time = value
 		case "290": // MdEntryPositionNo - optional
 			trade.Position = value
+		case "279": // MdUpdateAction - incremental refresh only
+			trade.UpdateAction = value
+		case "278": // MdEntryId - optional
+			trade.EntryID = value
+		case "55": // Symbol - per-entry in multi-symbol incrementals
+			trade.Symbol = value
 		case "2446": // AggressorSide - optional, only for trades
 			trade.Aggressor = getAggressorSideDesc(value)
 		}
@@ -237,9 +272,11 @@ func (a *FixApp) parseTradeFromSegment(segment, symbol, mdReqId string, isSnapsh
 	}
 	if price := extractSingleFieldValue(segment, "270="); price != "" {
 		trade.Price = price
+		trade.PriceVal, _ = decimal.Parse(price)
 	}
 	if size := extractSingleFieldValue(segment, "271="); size != "" {
 		trade.Size = size
+		trade.SizeVal, _ = decimal.Parse(size)
 	}
 	if timeVal := extractSingleFieldValue(segment, "273="); timeVal != "" {
 		trade.Time = timeVal
@@ -176,6 +176,10 @@ func BenchmarkParseTradeFromSegmentFast(b *testing.B) {
 			"OHLCVEntry",
 			"269=4\x01270=49500.00\x01273=20250101-12:00:00\x01",
 		},
+		{
+			"IncrementalEntry",
+			"279=1\x01269=0\x01278=entry-1\x0155=BTC-USD\x01270=49999.00\x01271=2.5000\x01273=20250101-12:00:00\x01290=1\x01",
+		},
 	}
 
 	for _, bc := range benchCases {
@@ -17,7 +17,12 @@
 package fixclient
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
 	"testing"
+
+	"github.com/quickfixgo/quickfix"
 )
 
 // Tests for FIX message parsing behavior.
@@ -50,6 +55,27 @@ func TestExtractTrades_SingleTradeEntry(t *testing.T) {
 	})
 }
 
+// TestExtractTrades_ParsesDecimalPriceAndSize verifies that the parser fills
+// PriceVal and SizeVal exactly, including sizes below float64's precision at
+// BTC prices.
+func TestExtractTrades_ParsesDecimalPriceAndSize(t *testing.T) {
+	app := &FixApp{TradeStore: NewTradeStore(100, "")}
+
+	segment := "269=2\x01270=92233720368.54775807\x01271=0.00000001\x01"
+	trades := parseSegmentToTrades(t, app, segment, "BTC-USD", "req-123", false)
+	if len(trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(trades))
+	}
+
+	trade := trades[0]
+	if got := trade.PriceVal.String(); got != "92233720368.54775807" {
+		t.Errorf("PriceVal: got %s, want 92233720368.54775807", got)
+	}
+	if trade.SizeVal.Units() != 1 || trade.SizeVal.Scale() != 8 {
+		t.Errorf("SizeVal: got %s, want 0.00000001", trade.SizeVal)
+	}
+}
+
 // TestExtractTrades_BidOfferEntries verifies that bid (type=0) and offer (type=1)
 // entries are correctly parsed with position information for order book data.
 func TestExtractTrades_BidOfferEntries(t *testing.T) {
@@ -272,6 +298,67 @@ func TestExtractTrades_SymbolPropagation(t *testing.T) {
 	}
 }
 
+// TestExtractTrades_IncrementalUpdateFields verifies that MDUpdateAction (279),
+// MDEntryID (278) and a per-entry Symbol (55) are captured for incremental entries.
+func TestExtractTrades_IncrementalUpdateFields(t *testing.T) {
+	app := &FixApp{TradeStore: NewTradeStore(100, "")}
+
+	segment := "279=2\x01269=0\x01278=entry-42\x0155=ETH-USD\x01270=3000.00\x01271=0\x01290=3\x01"
+	trades := parseSegmentToTrades(t, app, segment, "BTC-USD", "req-123", false)
+
+	if len(trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(trades))
+	}
+	got := trades[0]
+	if got.UpdateAction != "2" {
+		t.Errorf("UpdateAction: got %q, want %q", got.UpdateAction, "2")
+	}
+	if got.EntryID != "entry-42" {
+		t.Errorf("EntryID: got %q, want %q", got.EntryID, "entry-42")
+	}
+	if got.Symbol != "ETH-USD" {
+		t.Errorf("Symbol: per-entry symbol should override message symbol, got %q", got.Symbol)
+	}
+}
+
+// TestExtractTrades_UpdateActionAttributedToOwnEntry verifies that the 279 tag,
+// which precedes 269 in each incremental entry, is parsed as part of its own
+// entry rather than the previous one.
+func TestExtractTrades_UpdateActionAttributedToOwnEntry(t *testing.T) {
+	app := &FixApp{TradeStore: NewTradeStore(100, "")}
+
+	rawMsg := buildFIXMessage(3, []string{
+		"279=0\x01269=0\x01270=49999.00\x01271=1.0\x01",
+		"279=1\x01269=1\x01270=50001.00\x01271=2.0\x01",
+		"279=2\x01269=0\x01270=49998.00\x01271=0\x01",
+	})
+
+	trades := parseSegmentToTrades(t, app, rawMsg, "BTC-USD", "req-123", false)
+	if len(trades) != 3 {
+		t.Fatalf("expected 3 trades, got %d", len(trades))
+	}
+
+	wantActions := []string{"0", "1", "2"}
+	for i, want := range wantActions {
+		if trades[i].UpdateAction != want {
+			t.Errorf("entry %d: UpdateAction got %q, want %q", i, trades[i].UpdateAction, want)
+		}
+	}
+}
+
+// TestExtractTrades_SnapshotHasNoUpdateAction verifies that snapshot entries,
+// which never carry 279, leave UpdateAction empty.
+func TestExtractTrades_SnapshotHasNoUpdateAction(t *testing.T) {
+	app := &FixApp{TradeStore: NewTradeStore(100, "")}
+
+	segment := "269=0\x01270=49999.00\x01271=1.0\x01290=1\x01"
+	trades := parseSegmentToTrades(t, app, segment, "BTC-USD", "req-123", true)
+
+	if trades[0].UpdateAction != "" {
+		t.Errorf("expected empty UpdateAction for snapshot entry, got %q", trades[0].UpdateAction)
+	}
+}
+
 // --- Test Helpers ---
 
 type expectedTrade struct {
@@ -337,3 +424,81 @@ func buildFIXMessage(numEntries int, entries []string) string {
 	msg += "10=000\x01"
 	return msg
 }
+
+// TestParseMiscFees verifies that each MiscFees entry is split at MiscFeeAmt
+// (137) and that parsing stops at the first field after the group.
+func TestParseMiscFees(t *testing.T) {
+	raw := "8=FIXT.1.1\x019=100\x0135=8\x0111=ord-1\x0117=exec-1\x01136=3\x01" +
+		"137=0.25\x01138=USD\x01139=2\x01" +
+		"137=0.10\x01138=USD\x01139=4\x01" +
+		"137=0.001\x01139=1\x01" +
+		"150=2\x0110=000\x01"
+
+	fees := parseMiscFees(raw)
+	want := []MiscFee{
+		{Amt: "0.25", Curr: "USD", Type: "2"},
+		{Amt: "0.10", Curr: "USD", Type: "4"},
+		{Amt: "0.001", Type: "1"},
+	}
+	if len(fees) != len(want) {
+		t.Fatalf("expected %d fees, got %d: %+v", len(want), len(fees), fees)
+	}
+	for i := range want {
+		if fees[i] != want[i] {
+			t.Errorf("fee %d: expected %+v, got %+v", i, want[i], fees[i])
+		}
+	}
+}
+
+// TestParseMiscFees_NoGroup verifies that messages without the group, or with
+// a malformed one, yield no fees.
+func TestParseMiscFees_NoGroup(t *testing.T) {
+	for name, raw := range map[string]string{
+		"absent":         "8=FIXT.1.1\x0135=8\x0111=ord-1\x0110=000\x01",
+		"empty":          "8=FIXT.1.1\x0135=8\x01136=0\x01150=0\x0110=000\x01",
+		"missing amount": "8=FIXT.1.1\x0135=8\x01136=1\x01138=USD\x01139=2\x0110=000\x01",
+	} {
+		if fees := parseMiscFees(raw); len(fees) != 0 {
+			t.Errorf("%s: expected no fees, got %+v", name, fees)
+		}
+	}
+}
+
+// TestHandleExecutionReport_MiscFees verifies that fees on a parsed execution
+// report reach the order in the OrderStore.
+func TestHandleExecutionReport_MiscFees(t *testing.T) {
+	body := "35=8\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01" +
+		"11=ord-1\x0137=exch-1\x0117=exec-1\x0139=2\x01150=2\x0155=BTC-USD\x0154=1\x01" +
+		"38=1\x0114=1\x01151=0\x0131=50000\x0132=1\x01" +
+		"136=2\x01137=1.50\x01138=USD\x01139=2\x01137=0.25\x01138=USD\x01139=4\x01"
+	msg := parseFixMessage(t, body)
+
+	app := &FixApp{OrderStore: NewOrderStore(), FillStore: NewFillStore(), Positions: NewPositions()}
+	app.handleExecutionReport(msg)
+
+	order := app.OrderStore.GetOrder("ord-1")
+	if order == nil || len(order.MiscFees) != 2 {
+		t.Fatalf("expected 2 fees on ord-1, got %+v", order)
+	}
+	if fee := order.MiscFees[0]; fee.ExecID != "exec-1" || fee.Amt != "1.50" || fee.Type != "2" {
+		t.Errorf("unexpected first fee %+v", fee)
+	}
+}
+
+// parseFixMessage frames body (starting at MsgType) with BeginString,
+// BodyLength and CheckSum and parses it the way the session would.
+func parseFixMessage(t *testing.T, body string) *quickfix.Message {
+	t.Helper()
+	raw := "8=FIXT.1.1\x019=" + strconv.Itoa(len(body)) + "\x01" + body
+	sum := 0
+	for i := 0; i < len(raw); i++ {
+		sum += int(raw[i])
+	}
+	raw += fmt.Sprintf("10=%03d\x01", sum%256)
+
+	msg := quickfix.NewMessage()
+	if err := quickfix.ParseMessage(msg, bytes.NewBufferString(raw)); err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	return msg
+}
@@ -0,0 +1,299 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
+)
+
+// costDigits is how many digits a symbol's divided amounts keep beyond the
+// finest price, quantity or fee reported for it. An int64 at BTC-USD's scale
+// of 10 still holds 9.2e8 in quote currency.
+const costDigits = 2
+
+// Position is the net holding in one symbol built from executions, using
+// average cost. Fees are added to the cost of buys and deducted from the
+// proceeds of sells, so AvgCost and RealizedPnL are net of fees.
+//
+// Quantities, fees and CostBasis are summed exactly, so closing a whole
+// position realizes exactly its proceeds less its cost. Only a partial close,
+// which takes its share of CostBasis, and AvgCost divide; they are rounded to
+// the symbol's scale, costDigits beyond the finest price, quantity or fee seen
+// for it, which never shrinks.
+type Position struct {
+	Symbol      string          `json:"symbol"`
+	NetQty      decimal.Decimal `json:"netQty"`      // Base units, negative when short
+	CostBasis   decimal.Decimal `json:"costBasis"`   // Paid for a long, received for a short
+	AvgCost     decimal.Decimal `json:"avgCost"`     // CostBasis per base unit
+	RealizedPnL decimal.Decimal `json:"realizedPnl"` // Quote currency
+	Fees        decimal.Decimal `json:"fees"`        // Total fees paid, quote currency
+	BoughtQty   decimal.Decimal `json:"boughtQty"`
+	SoldQty     decimal.Decimal `json:"soldQty"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+// Scale returns the number of decimals AvgCost and PnL are kept at.
+func (p Position) Scale() uint8 {
+	return max(p.AvgCost.Scale(), p.RealizedPnL.Scale())
+}
+
+// UnrealizedPnL values the open quantity at mark against CostBasis, rounded
+// to the position's scale.
+func (p Position) UnrealizedPnL(mark decimal.Decimal) (decimal.Decimal, error) {
+	value, err := mark.Mul(p.NetQty, p.Scale())
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	if p.NetQty.Sign() < 0 {
+		return value.Add(p.CostBasis)
+	}
+	return value.Sub(p.CostBasis)
+}
+
+// Positions tracks a Position per symbol.
+type Positions struct {
+	mu        sync.RWMutex
+	positions map[string]*Position
+}
+
+// NewPositions creates an empty Positions.
+func NewPositions() *Positions {
+	return &Positions{positions: make(map[string]*Position)}
+}
+
+// ApplyExecution adds the execution in er to its symbol's position and returns
+// the updated position, or false if er carries no execution. prev is the
+// order as OrderStore held it before er, or nil for a new order: Commission,
+// FilledAmt and NetAvgPx are cumulative per order, so this execution's share
+// is the difference from prev. Callers must apply each ExecID only once.
+//
+// An execution whose amounts do not fit the symbol's scale is logged and
+// leaves the position unchanged.
+func (ps *Positions) ApplyExecution(er *ExecutionReport, prev *Order) (Position, bool) {
+	qty, err := decimal.Parse(er.LastShares)
+	if err != nil || qty.Sign() <= 0 || er.Symbol == "" {
+		return Position{}, false
+	}
+	px := parseDecimalOrZero(er.LastPx)
+
+	c := &calc{}
+	var prevFees, prevFilledAmt decimal.Decimal
+	if prev != nil {
+		prevFees = c.cumulativeFees(prev.Commission, prev.NetAvgPx, prev.AvgPx, prev.CumQty)
+		prevFilledAmt = parseDecimalOrZero(prev.FilledAmt)
+	}
+	fee := c.sub(c.cumulativeFees(er.Commission, er.NetAvgPx, er.AvgPx, er.CumQty), prevFees)
+	if fee.Sign() < 0 {
+		fee = decimal.Decimal{}
+	}
+	notional := c.mul(px, qty, productScale(px, qty))
+	if filledAmt := parseDecimalOrZero(er.FilledAmt); filledAmt.Cmp(prevFilledAmt) > 0 {
+		notional = c.sub(filledAmt, prevFilledAmt)
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	pos := Position{Symbol: er.Symbol}
+	if existing, ok := ps.positions[er.Symbol]; ok {
+		pos = *existing
+	}
+	finest := max(pos.Scale(), px.Scale()+costDigits, qty.Scale()+costDigits, fee.Scale()+costDigits)
+	c.scale = min(finest, decimal.MaxScale)
+
+	pos.Fees = c.add(pos.Fees, fee)
+	if er.Side == constants.SideSell {
+		pos.SoldQty = c.add(pos.SoldQty, qty)
+		pos.trade(c, qty.Neg(), c.sub(notional, fee))
+	} else {
+		pos.BoughtQty = c.add(pos.BoughtQty, qty)
+		pos.trade(c, qty, c.add(notional, fee))
+	}
+	if c.err != nil {
+		log.Printf("Position in %s not updated for execution %s: %v", er.Symbol, er.ExecID, c.err)
+		return Position{}, false
+	}
+	pos.UpdatedAt = time.Now()
+	ps.positions[er.Symbol] = &pos
+	return pos, true
+}
+
+// trade applies a signed quantity that cost, net of fees, paid for a buy or
+// received for a sell. The part that reduces the open position realizes PnL
+// against its share of CostBasis; any remainder opens a position in the
+// other direction at its share of cost.
+func (p *Position) trade(c *calc, qty, cost decimal.Decimal) {
+	net := c.add(p.NetQty, qty)
+	if p.NetQty.IsZero() || p.NetQty.Sign() == qty.Sign() {
+		p.CostBasis = c.add(p.CostBasis, cost)
+	} else {
+		open, traded := p.NetQty.Abs(), qty.Abs()
+		basis, proceeds := p.CostBasis, cost
+		if traded.Cmp(open) < 0 {
+			basis = c.share(p.CostBasis, traded, open)
+		} else if traded.Cmp(open) > 0 {
+			proceeds = c.share(cost, open, traded)
+		}
+		pnl := c.sub(proceeds, basis) // Sold above cost
+		if p.NetQty.Sign() < 0 {
+			pnl = pnl.Neg() // Bought back below what the short received
+		}
+		p.RealizedPnL = c.add(p.RealizedPnL, pnl)
+		p.CostBasis = c.sub(p.CostBasis, basis)
+		if traded.Cmp(open) > 0 {
+			p.CostBasis = c.sub(cost, proceeds) // Flipped through flat
+		}
+	}
+
+	p.NetQty = net
+	if net.IsZero() {
+		p.CostBasis, p.AvgCost = decimal.New(0, c.scale), decimal.New(0, c.scale)
+		return
+	}
+	p.AvgCost = c.div(p.CostBasis, net.Abs())
+}
+
+// Get returns a copy of symbol's position.
+func (ps *Positions) Get(symbol string) (Position, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	if pos, ok := ps.positions[symbol]; ok {
+		return *pos, true
+	}
+	return Position{}, false
+}
+
+// All returns a copy of every position, sorted by symbol.
+func (ps *Positions) All() []Position {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	result := make([]Position, 0, len(ps.positions))
+	for _, pos := range ps.positions {
+		result = append(result, *pos)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Symbol < result[j].Symbol })
+	return result
+}
+
+// Restore replaces the tracked positions, e.g. with snapshots loaded at
+// startup. A position without a CostBasis, as stored snapshots are, gets one
+// from AvgCost.
+func (ps *Positions) Restore(positions []Position) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.positions = make(map[string]*Position, len(positions))
+	for i := range positions {
+		pos := positions[i]
+		if pos.CostBasis.IsZero() {
+			pos.CostBasis, _ = pos.AvgCost.Mul(pos.NetQty.Abs(), pos.Scale())
+		}
+		ps.positions[pos.Symbol] = &pos
+	}
+}
+
+// calc chains decimal arithmetic and keeps the first error, so that a
+// position update reads like the formula it applies. Divisions round to
+// scale; once an operation fails the rest return their first operand.
+type calc struct {
+	scale uint8
+	err   error
+}
+
+func (c *calc) add(a, b decimal.Decimal) decimal.Decimal {
+	return c.keep(a, func() (decimal.Decimal, error) { return a.Add(b) })
+}
+
+func (c *calc) sub(a, b decimal.Decimal) decimal.Decimal {
+	return c.keep(a, func() (decimal.Decimal, error) { return a.Sub(b) })
+}
+
+func (c *calc) mul(a, b decimal.Decimal, scale uint8) decimal.Decimal {
+	return c.keep(a, func() (decimal.Decimal, error) { return a.Mul(b, scale) })
+}
+
+func (c *calc) div(a, b decimal.Decimal) decimal.Decimal {
+	return c.keep(a, func() (decimal.Decimal, error) { return a.Div(b, c.scale) })
+}
+
+// share returns part/whole of amount.
+func (c *calc) share(amount, part, whole decimal.Decimal) decimal.Decimal {
+	return c.div(c.mul(amount, part, c.scale), whole)
+}
+
+func (c *calc) keep(a decimal.Decimal, op func() (decimal.Decimal, error)) decimal.Decimal {
+	if c.err != nil {
+		return a
+	}
+	v, err := op()
+	if err != nil {
+		c.err = err
+		return a
+	}
+	return v
+}
+
+// cumulativeFees returns an order's total fees from Commission or, when
+// Prime does not send it, from the gap between NetAvgPx and AvgPx.
+func (c *calc) cumulativeFees(commission, netAvgPx, avgPx, cumQty string) decimal.Decimal {
+	if commission != "" {
+		return parseDecimalOrZero(commission)
+	}
+	if netAvgPx == "" || avgPx == "" {
+		return decimal.Decimal{}
+	}
+	gap := c.sub(parseDecimalOrZero(netAvgPx), parseDecimalOrZero(avgPx)).Abs()
+	cum := parseDecimalOrZero(cumQty)
+	return c.mul(gap, cum, productScale(gap, cum))
+}
+
+// productScale is the scale at which a × b is exact, up to MaxScale.
+func productScale(a, b decimal.Decimal) uint8 {
+	return min(a.Scale()+b.Scale(), decimal.MaxScale)
+}
+
+// parseDecimalOrZero parses s, treating a missing or malformed value as zero.
+func parseDecimalOrZero(s string) decimal.Decimal {
+	v, _ := decimal.Parse(s)
+	return v
+}
+
+// markPrice returns the price to value a position in symbol at: the mid of
+// the best bid and offer in the live book, or without both the most recent
+// trade. source is "mid" or "last"; ok is false when neither is available.
+// The mid keeps one more decimal than the finer of the two prices.
+func (a *FixApp) markPrice(symbol string) (price decimal.Decimal, source string, ok bool) {
+	if a.OrderBook != nil {
+		if bid, offer, hasBid, hasOffer := a.OrderBook.BestBidOffer(symbol); hasBid && hasOffer {
+			if sum, err := bid.PriceVal.Add(offer.PriceVal); err == nil {
+				if mid, err := sum.Div(decimal.New(2, 0), min(sum.Scale()+1, decimal.MaxScale)); err == nil {
+					return mid, "mid", true
+				}
+			}
+		}
+	}
+	if px, ok := a.TradeStore.LastTradePrice(symbol); ok {
+		return px, "last", true
+	}
+	return decimal.Decimal{}, "", false
+}
@@ -0,0 +1,201 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"path/filepath"
+	"testing"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/database"
+	"prime-fix-md-go/decimal"
+)
+
+// Tests for position and PnL tracking.
+
+func assertAmount(t *testing.T, name string, got decimal.Decimal, want string) {
+	t.Helper()
+	if !got.Equal(decimal.MustParse(want)) {
+		t.Errorf("%s = %s, want %s", name, got, want)
+	}
+}
+
+func unrealizedAt(t *testing.T, pos Position, mark string) decimal.Decimal {
+	t.Helper()
+	pnl, err := pos.UnrealizedPnL(decimal.MustParse(mark))
+	if err != nil {
+		t.Fatalf("UnrealizedPnL(%s): %v", mark, err)
+	}
+	return pnl
+}
+
+// TestPositions_AverageCostWithFees verifies that fees raise the cost of buys
+// and reduce the proceeds of sells, and that cumulative Commission is split
+// between the executions of one order.
+func TestPositions_AverageCostWithFees(t *testing.T) {
+	ps := NewPositions()
+
+	first := &ExecutionReport{ClOrdID: "buy-1", Symbol: "BTC-USD", Side: constants.SideBuy,
+		LastShares: "1", LastPx: "100", CumQty: "1", Commission: "1"}
+	ps.ApplyExecution(first, nil)
+	prev := &Order{ClOrdID: "buy-1", CumQty: "1", Commission: "1"}
+	ps.ApplyExecution(&ExecutionReport{ClOrdID: "buy-1", Symbol: "BTC-USD", Side: constants.SideBuy,
+		LastShares: "1", LastPx: "110", CumQty: "2", Commission: "2.5"}, prev)
+
+	pos, _ := ps.Get("BTC-USD")
+	assertAmount(t, "NetQty", pos.NetQty, "2")
+	assertAmount(t, "AvgCost", pos.AvgCost, "106.25") // (101 + 111.5) / 2
+
+	pos, ok := ps.ApplyExecution(&ExecutionReport{ClOrdID: "sell-1", Symbol: "BTC-USD", Side: constants.SideSell,
+		LastShares: "1.5", LastPx: "120", CumQty: "1.5", Commission: "0.6"}, nil)
+	if !ok {
+		t.Fatal("expected the sell to apply")
+	}
+	assertAmount(t, "NetQty", pos.NetQty, "0.5")
+	assertAmount(t, "AvgCost", pos.AvgCost, "106.25")
+	assertAmount(t, "RealizedPnL", pos.RealizedPnL, "20.025") // (119.6 - 106.25) × 1.5
+	assertAmount(t, "Fees", pos.Fees, "3.1")
+	assertAmount(t, "UnrealizedPnL", unrealizedAt(t, pos, "110"), "1.875")
+	assertAmount(t, "BoughtQty", pos.BoughtQty, "2")
+	assertAmount(t, "SoldQty", pos.SoldQty, "1.5")
+
+	if _, ok := ps.ApplyExecution(&ExecutionReport{Symbol: "BTC-USD", ExecType: constants.ExecTypeNew}, nil); ok {
+		t.Error("expected a report without an execution to be ignored")
+	}
+}
+
+// TestPositions_ShortAndFlip verifies short positions and a buy that closes a
+// short and opens a long at its own price.
+func TestPositions_ShortAndFlip(t *testing.T) {
+	ps := NewPositions()
+	ps.ApplyExecution(&ExecutionReport{Symbol: "ETH-USD", Side: constants.SideSell, LastShares: "1", LastPx: "100"}, nil)
+
+	pos, _ := ps.Get("ETH-USD")
+	assertAmount(t, "NetQty", pos.NetQty, "-1")
+	assertAmount(t, "UnrealizedPnL", unrealizedAt(t, pos, "95"), "5")
+
+	pos, _ = ps.ApplyExecution(&ExecutionReport{Symbol: "ETH-USD", Side: constants.SideBuy, LastShares: "2", LastPx: "90"}, nil)
+	assertAmount(t, "NetQty", pos.NetQty, "1")
+	assertAmount(t, "AvgCost", pos.AvgCost, "90")
+	assertAmount(t, "RealizedPnL", pos.RealizedPnL, "10")
+
+	pos, _ = ps.ApplyExecution(&ExecutionReport{Symbol: "ETH-USD", Side: constants.SideSell, LastShares: "1", LastPx: "95"}, nil)
+	if !pos.NetQty.IsZero() || !pos.AvgCost.IsZero() || !pos.CostBasis.IsZero() {
+		t.Errorf("expected a flat position, got %+v", pos)
+	}
+	assertAmount(t, "RealizedPnL", pos.RealizedPnL, "15")
+}
+
+// TestPositions_FeesFromNetAvgPx verifies that without Commission the fee is
+// derived from NetAvgPx, and FilledAmt is used for the execution's notional.
+func TestPositions_FeesFromNetAvgPx(t *testing.T) {
+	ps := NewPositions()
+	pos, _ := ps.ApplyExecution(&ExecutionReport{Symbol: "SOL-USD", Side: constants.SideBuy, LastShares: "2", LastPx: "100",
+		CumQty: "2", AvgPx: "100.10", NetAvgPx: "100.60", FilledAmt: "200.20"}, nil)
+
+	assertAmount(t, "Fees", pos.Fees, "1")
+	assertAmount(t, "AvgCost", pos.AvgCost, "100.6")
+}
+
+// TestPositions_ExactAcrossFills verifies that a position built from sizes
+// float64 cannot hold exactly closes flat and realizes exactly its proceeds
+// less its cost, even though its average cost had to be rounded.
+func TestPositions_ExactAcrossFills(t *testing.T) {
+	ps := NewPositions()
+	ps.ApplyExecution(&ExecutionReport{Symbol: "BTC-USD", Side: constants.SideBuy, LastShares: "0.1", LastPx: "50000.01"}, nil)
+	pos, _ := ps.ApplyExecution(&ExecutionReport{Symbol: "BTC-USD", Side: constants.SideBuy, LastShares: "0.2",
+		LastPx: "50000.02"}, nil)
+	assertAmount(t, "CostBasis", pos.CostBasis, "15000.005")
+	assertAmount(t, "AvgCost", pos.AvgCost, "50000.0167") // 15000.005 / 0.3 at scale 4
+
+	pos, _ = ps.ApplyExecution(&ExecutionReport{Symbol: "BTC-USD", Side: constants.SideSell, LastShares: "0.00000001",
+		LastPx: "50001.00"}, nil)
+	assertAmount(t, "NetQty", pos.NetQty, "0.29999999")
+	pos, _ = ps.ApplyExecution(&ExecutionReport{Symbol: "BTC-USD", Side: constants.SideSell, LastShares: "0.29999999",
+		LastPx: "50001.00"}, nil)
+	if !pos.NetQty.IsZero() || !pos.CostBasis.IsZero() {
+		t.Fatalf("expected a flat position, got %+v", pos)
+	}
+	assertAmount(t, "RealizedPnL", pos.RealizedPnL, "0.295") // 0.3 × 50001 - 15000.005
+	if pos.Scale() != 10 {
+		t.Errorf("expected BTC-USD amounts at scale 10, got %d", pos.Scale())
+	}
+}
+
+// TestFixApp_MarkPrice verifies that positions are marked to the mid of the
+// best bid and offer in the book, even after deeper levels arrive, and to the
+// last trade without a two-sided book.
+func TestFixApp_MarkPrice(t *testing.T) {
+	app := &FixApp{TradeStore: NewTradeStore(100, ""), OrderBook: NewOrderBook()}
+	if _, _, ok := app.markPrice("BTC-USD"); ok {
+		t.Error("expected no mark price without market data")
+	}
+
+	app.TradeStore.AddTrades("BTC-USD", []Trade{{EntryType: constants.MdEntryTypeTrade, Price: "100.5"}}, false, "md_2")
+	if px, source, ok := app.markPrice("BTC-USD"); !ok || px.String() != "100.5" || source != "last" {
+		t.Errorf("expected last trade 100.5, got %v %q %v", px, source, ok)
+	}
+
+	// Snapshots list the best levels first and the deepest last
+	snapshot := []Trade{
+		{EntryType: constants.MdEntryTypeBid, Price: "99", Size: "1", Position: "1"},
+		{EntryType: constants.MdEntryTypeOffer, Price: "101", Size: "1", Position: "1"},
+		{EntryType: constants.MdEntryTypeBid, Price: "90", Size: "1", Position: "2"},
+		{EntryType: constants.MdEntryTypeOffer, Price: "120", Size: "1", Position: "2"},
+	}
+	app.TradeStore.AddTrades("BTC-USD", snapshot, true, "md_1")
+	app.OrderBook.ApplySnapshot("BTC-USD", snapshot)
+	if px, source, ok := app.markPrice("BTC-USD"); !ok || px.String() != "100.0" || source != "mid" {
+		t.Errorf("expected mid 100, got %v %q %v", px, source, ok)
+	}
+}
+
+// TestHandleExecutionReport_PositionsPersistAcrossRestart verifies that fills
+// update positions once per ExecID and that the snapshots are reloaded by the
+// next FixApp.
+func TestHandleExecutionReport_PositionsPersistAcrossRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "positions.db")
+	db, err := database.NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	app := NewFixApp(&Config{}, db)
+	header := "35=8\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01"
+	fill := header + "11=ord-1\x0137=exch-1\x0117=exec-1\x0139=2\x01150=F\x0155=BTC-USD\x0154=1\x01" +
+		"38=1\x0114=1\x01151=0\x016=50000\x0131=50000\x0132=1\x0112=25\x01"
+	app.handleExecutionReport(parseFixMessage(t, fill))
+	app.handleExecutionReport(parseFixMessage(t, fill))
+
+	pos, ok := app.Positions.Get("BTC-USD")
+	if !ok || pos.NetQty.String() != "1" || !pos.AvgCost.Equal(decimal.New(50025, 0)) || pos.Fees.String() != "25" {
+		t.Fatalf("expected 1 BTC-USD at 50025 including fees, got %+v", pos)
+	}
+	db.Close()
+
+	db, err = database.NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	restored, ok := NewFixApp(&Config{}, db).Positions.Get("BTC-USD")
+	if !ok || restored.NetQty.String() != "1" || restored.AvgCost != pos.AvgCost || restored.Fees.String() != "25" ||
+		restored.BoughtQty.String() != "1" || !restored.CostBasis.Equal(pos.CostBasis) {
+		t.Errorf("expected the position to be restored, got %+v", restored)
+	}
+}
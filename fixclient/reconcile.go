@@ -0,0 +1,433 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"prime-fix-md-go/builder"
+	"prime-fix-md-go/constants"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// reconcileTimeout bounds how long a logon reconciliation waits for status
+// reports before summarizing what it has.
+const reconcileTimeout = 10 * time.Second
+
+// ReconcileModeFromSettings reads the ReconcileOnLogon setting from the
+// [DEFAULT] section of fix.cfg:
+//   - none:   do not query order status on logon (default)
+//   - mass:   one Order Mass Status Request (AF) for the portfolio
+//   - orders: one Order Status Request (H) per open order
+func ReconcileModeFromSettings(settings *quickfix.Settings) (string, error) {
+	global := settings.GlobalSettings()
+	if !global.HasSetting(constants.SettingReconcileOnLogon) {
+		return constants.ReconcileModeNone, nil
+	}
+
+	value, _ := global.Setting(constants.SettingReconcileOnLogon)
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case constants.ReconcileModeNone, constants.ReconcileModeMass, constants.ReconcileModeOrders:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown %s %q (expected %s, %s or %s)", constants.SettingReconcileOnLogon, mode,
+			constants.ReconcileModeNone, constants.ReconcileModeMass, constants.ReconcileModeOrders)
+	}
+}
+
+// reconciliation tracks the status reports requested for the orders that were
+// open when the session logged on. In mass mode, open orders the mass status
+// response leaves out are followed up with per-order status requests.
+type reconciliation struct {
+	mode  string
+	reqId string // MassStatusReqID in mass mode
+
+	mu        sync.Mutex
+	before    map[string]*Order // Open orders at logon, by ClOrdID
+	orderIds  map[string]string // ClOrdID of each open order, by OrderID
+	reported  map[string]bool   // ClOrdIDs with a status report
+	unknown   map[string]string // Reject text, by ClOrdID, for orders Prime did not know
+	followUps map[string]bool   // ClOrdIDs missing from the mass response, once requested
+	timer     *time.Timer
+	done      bool
+}
+
+func newReconciliation(mode string, open []*Order) *reconciliation {
+	r := &reconciliation{
+		mode:     mode,
+		before:   make(map[string]*Order, len(open)),
+		orderIds: make(map[string]string, len(open)),
+		reported: make(map[string]bool),
+		unknown:  make(map[string]string),
+	}
+	if mode == constants.ReconcileModeMass {
+		r.reqId = "recon_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+	for _, order := range open {
+		r.before[order.ClOrdID] = order
+		if order.OrderID != "" {
+			r.orderIds[order.OrderID] = order.ClOrdID
+		}
+	}
+	return r
+}
+
+// matches reports whether er answers one of r's requests.
+func (r *reconciliation) matches(er *ExecutionReport) bool {
+	if er.ExecType != constants.ExecTypeOrderStatus {
+		return false
+	}
+	if r.mode == constants.ReconcileModeMass && er.MassStatusReqID == r.reqId {
+		return true
+	}
+	return r.awaits(r.requested(er))
+}
+
+// awaits reports whether a per-order status request was sent for clOrdID: for
+// every open order in orders mode, and in mass mode for those followed up.
+func (r *reconciliation) awaits(clOrdID string) bool {
+	if clOrdID == "" {
+		return false
+	}
+	if r.mode != constants.ReconcileModeMass {
+		_, ok := r.before[clOrdID]
+		return ok
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.followUps[clOrdID]
+}
+
+// requested returns the ClOrdID of the open order a per-order status report
+// answers. An order replaced while offline is reported under its new
+// ClOrdID, so it is also found by OrderID.
+func (r *reconciliation) requested(er *ExecutionReport) string {
+	if _, ok := r.before[er.ClOrdID]; ok {
+		return er.ClOrdID
+	}
+	if er.OrderID != "" {
+		return r.orderIds[er.OrderID]
+	}
+	return ""
+}
+
+// record notes a status report and returns true once every expected report
+// has arrived. A mass status response is complete at LastRptRequested=Y or
+// after TotNumReports reports; an empty response has TotNumReports=0. Once
+// orders have been followed up, their reports complete it instead.
+func (r *reconciliation) record(er *ExecutionReport) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return false
+	}
+
+	if r.mode != constants.ReconcileModeMass || er.MassStatusReqID != r.reqId {
+		r.reported[r.requested(er)] = true
+		return r.answered()
+	}
+	if er.ClOrdID != "" {
+		r.reported[er.ClOrdID] = true
+	}
+	if r.followUps != nil {
+		return r.answered()
+	}
+	if er.LastRptRequested == "Y" {
+		return true
+	}
+	total, err := strconv.Atoi(er.TotNumReports)
+	return err == nil && len(r.reported) >= total
+}
+
+// answered reports whether every per-order status request has been answered.
+// Caller must hold r.mu.
+func (r *reconciliation) answered() bool {
+	if r.mode != constants.ReconcileModeMass {
+		return len(r.reported) == len(r.before)
+	}
+	for id := range r.followUps {
+		if !r.reported[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// recordUnknown notes that Prime rejected the status request for clOrdID and
+// returns true once every per-order status request has been answered.
+func (r *reconciliation) recordUnknown(clOrdID, text string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return false
+	}
+	r.reported[clOrdID] = true
+	r.unknown[clOrdID] = text
+	return r.answered()
+}
+
+// followUp returns the open orders a complete mass status response left out,
+// e.g. ones filled or cancelled while offline, and from then on waits for
+// their per-order status reports, with a fresh timeout. It returns nil in
+// orders mode and once the orders have been followed up.
+func (r *reconciliation) followUp() []*Order {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done || r.mode != constants.ReconcileModeMass || r.followUps != nil {
+		return nil
+	}
+
+	r.followUps = make(map[string]bool)
+	var missing []*Order
+	for id, order := range r.before {
+		if !r.reported[id] {
+			r.followUps[id] = true
+			missing = append(missing, order)
+		}
+	}
+	if len(missing) > 0 && r.timer != nil {
+		r.timer.Reset(reconcileTimeout)
+	}
+	return missing
+}
+
+// finish marks r done and returns the ClOrdIDs that were reported, or false if
+// it was already finished.
+func (r *reconciliation) finish() (map[string]bool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return nil, false
+	}
+	r.done = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	return r.reported, true
+}
+
+// orderChange is one order whose state differs from what was tracked before
+// the reconnect.
+type orderChange struct {
+	ClOrdID string
+	Changes []string // e.g. "Status: Pending New → Filled"
+}
+
+// startReconciliation requests the status of every open order after a logon,
+// as configured by ReconcileMode. The reports update OrderStore as usual and
+// a summary of what changed while offline is printed once they have all
+// arrived or reconcileTimeout expires; strategies are then resumed. It returns
+// false if there was nothing to reconcile.
+func (a *FixApp) startReconciliation() bool {
+	if a.ReconcileMode == "" || a.ReconcileMode == constants.ReconcileModeNone {
+		return false
+	}
+	open := a.OrderStore.GetOpenOrders()
+	if len(open) == 0 {
+		return false
+	}
+
+	r := newReconciliation(a.ReconcileMode, open)
+	if prev := a.reconcile.Swap(r); prev != nil {
+		prev.finish()
+	}
+	r.mu.Lock()
+	r.timer = time.AfterFunc(reconcileTimeout, func() { a.finishReconciliation(r, true) })
+	r.mu.Unlock()
+
+	if r.mode == constants.ReconcileModeMass {
+		msg := builder.BuildOrderMassStatusRequest(builder.OrderMassStatusParams{
+			MassStatusReqID:   r.reqId,
+			MassStatusReqType: constants.MassStatusReqTypeAllOrders,
+			Account:           a.Config.PortfolioId,
+		}, a.Config.SenderCompId, a.Config.TargetCompId)
+		if err := quickfix.SendToTarget(msg, a.SessionId); err != nil {
+			log.Printf("Error sending order mass status request: %v", err)
+			a.finishReconciliation(r, false)
+			return true
+		}
+		log.Printf("Reconciling %d open order(s) (MassStatusReqID: %s)", len(open), r.reqId)
+		return true
+	}
+
+	a.sendOrderStatusRequests(open)
+	log.Printf("Reconciling %d open order(s) with order status requests", len(open))
+	return true
+}
+
+// sendOrderStatusRequests sends an Order Status Request (H) for each order and
+// returns how many were sent.
+func (a *FixApp) sendOrderStatusRequests(orders []*Order) int {
+	sent := 0
+	for _, order := range orders {
+		msg := builder.BuildOrderStatusRequest(order.OrderID, order.ClOrdID, order.Symbol, order.Side,
+			a.Config.SenderCompId, a.Config.TargetCompId)
+		if err := quickfix.SendToTarget(msg, a.SessionId); err != nil {
+			log.Printf("Error sending order status request for %s: %v", order.ClOrdID, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// handleReconciliationReport applies a status report requested by the
+// current reconciliation. It returns false if er is not part of one.
+func (a *FixApp) handleReconciliationReport(er *ExecutionReport) bool {
+	r := a.reconcile.Load()
+	if r == nil || !r.matches(er) {
+		return false
+	}
+
+	// An empty mass status response carries no order
+	if er.ClOrdID != "" && er.TotNumReports != "0" {
+		if id := r.requested(er); id != "" && id != er.ClOrdID && er.OrigClOrdID == "" {
+			er.OrigClOrdID = id // Continues the order under its new ClOrdID
+		}
+		if err := a.OrderStore.UpdateOrderFromExecReport(er); errors.Is(err, ErrUntrackedOrder) {
+			log.Printf("Tracking order from status report: %v", err)
+		} else if err != nil {
+			log.Printf("Status report not applied to order: %v", err)
+		}
+		a.afterExecutionReport(er)
+	}
+	if r.record(er) {
+		a.finishReconciliation(r, false)
+	}
+	return true
+}
+
+// handleReconciliationReject answers a per-order status request that Prime
+// rejected with a Business Message Reject, so the reconciliation does not
+// wait for a report that will never come.
+func (a *FixApp) handleReconciliationReject(reject *BusinessReject) {
+	r := a.reconcile.Load()
+	if r == nil || reject.RefMsgType != constants.MsgTypeOrderStatusRequest || !r.awaits(reject.BusinessRejectRefID) {
+		return
+	}
+	text := reject.Text
+	if text == "" {
+		text = getBusinessRejectReasonDesc(reject.BusinessRejectReason)
+	}
+	if r.recordUnknown(reject.BusinessRejectRefID, text) {
+		a.finishReconciliation(r, false)
+	}
+}
+
+// finishReconciliation compares the orders tracked at logon with their
+// current state, prints the differences and resumes the strategies. A mass
+// reconciliation first requests the status of each open order the mass
+// response left out, and finishes once those have been answered.
+func (a *FixApp) finishReconciliation(r *reconciliation, timedOut bool) {
+	if !timedOut {
+		if missing := r.followUp(); len(missing) > 0 {
+			if sent := a.sendOrderStatusRequests(missing); sent > 0 {
+				log.Printf("Requesting the status of %d open order(s) missing from the mass status response", sent)
+				return
+			}
+		}
+	}
+
+	reported, ok := r.finish()
+	if !ok {
+		return
+	}
+	a.reconcile.CompareAndSwap(r, nil)
+
+	ids := make([]string, 0, len(r.before)+len(reported))
+	for id := range r.before {
+		ids = append(ids, id)
+	}
+	for id := range reported {
+		if _, ok := r.before[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var changed []orderChange
+	var unreported []string
+	for _, id := range ids {
+		if !reported[id] {
+			unreported = append(unreported, id)
+			continue
+		}
+		if text, ok := r.unknown[id]; ok {
+			changed = append(changed, orderChange{ClOrdID: id, Changes: []string{"Unknown to Prime: " + text}})
+			continue
+		}
+		if diff := diffOrders(r.before[id], a.OrderStore.GetOrder(id)); len(diff) > 0 {
+			changed = append(changed, orderChange{ClOrdID: id, Changes: diff})
+		}
+	}
+	a.displayReconciliation(len(r.before), changed, unreported, timedOut)
+	a.resumeStrategies()
+}
+
+// diffOrders lists the tracked fields that differ between before and after.
+// A nil before is an order we did not know about.
+func diffOrders(before, after *Order) []string {
+	if after == nil {
+		return nil
+	}
+	if before == nil {
+		return []string{"New to this client, status " + getOrdStatusDesc(after.OrdStatus)}
+	}
+
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"Status", getOrdStatusDesc(before.OrdStatus), getOrdStatusDesc(after.OrdStatus)},
+		{"OrderID", before.OrderID, after.OrderID},
+		{"Qty", before.OrderQty, after.OrderQty},
+		{"Price", before.Price, after.Price},
+		{"Filled", zeroIfEmpty(before.CumQty), zeroIfEmpty(after.CumQty)},
+		{"Leaves", before.LeavesQty, after.LeavesQty},
+		{"AvgPx", before.AvgPx, after.AvgPx},
+	}
+
+	var changes []string
+	for _, f := range fields {
+		if f.before != f.after {
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", f.name, orDash(f.before), orDash(f.after)))
+		}
+	}
+	return changes
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func zeroIfEmpty(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
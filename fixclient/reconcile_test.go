@@ -0,0 +1,234 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"prime-fix-md-go/constants"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// TestReconcileModeFromSettings verifies the default and validation of the
+// ReconcileOnLogon setting.
+func TestReconcileModeFromSettings(t *testing.T) {
+	settings := quickfix.NewSettings()
+	if mode, err := ReconcileModeFromSettings(settings); err != nil || mode != constants.ReconcileModeNone {
+		t.Errorf("expected default none, got %q, %v", mode, err)
+	}
+
+	settings.GlobalSettings().Set(constants.SettingReconcileOnLogon, " Mass ")
+	if mode, err := ReconcileModeFromSettings(settings); err != nil || mode != constants.ReconcileModeMass {
+		t.Errorf("expected mass, got %q, %v", mode, err)
+	}
+
+	settings.GlobalSettings().Set(constants.SettingReconcileOnLogon, "all")
+	if _, err := ReconcileModeFromSettings(settings); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+// TestReconciliation_MassStatusUpdatesStore verifies that mass status reports
+// update OrderStore, complete the reconciliation at LastRptRequested=Y and
+// that only the reported orders count as reconciled.
+func TestReconciliation_MassStatusUpdatesStore(t *testing.T) {
+	app := &FixApp{Config: &Config{}, OrderStore: NewOrderStore(), FillStore: NewFillStore(), Strategies: NewStrategies()}
+	app.OrderStore.AddOrder(&Order{ClOrdID: "ord-1", Symbol: "BTC-USD", Side: "1", OrderQty: "1", OrdStatus: constants.OrdStatusPendingNew})
+	app.OrderStore.AddOrder(&Order{ClOrdID: "ord-2", Symbol: "BTC-USD", Side: "1", OrderQty: "1", OrdStatus: constants.OrdStatusPendingCancel})
+
+	r := newReconciliation(constants.ReconcileModeMass, app.OrderStore.GetOpenOrders())
+	app.reconcile.Store(r)
+
+	if app.handleReconciliationReport(&ExecutionReport{ClOrdID: "ord-1", ExecType: constants.ExecTypeNew}) {
+		t.Error("expected a non-status report to be ignored")
+	}
+	if app.handleReconciliationReport(&ExecutionReport{ClOrdID: "ord-1", ExecType: constants.ExecTypeOrderStatus, MassStatusReqID: "other"}) {
+		t.Error("expected a report for another request to be ignored")
+	}
+
+	header := "35=8\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01"
+	app.handleExecutionReport(parseFixMessage(t, header+
+		"11=ord-1\x0137=exch-1\x0117=exec-1\x0139=2\x01150=I\x0155=BTC-USD\x0154=1\x01"+
+		"38=1\x0114=1\x01151=0\x016=50000\x01584="+r.reqId+"\x01911=1\x01912=Y\x01"))
+
+	if app.reconcile.Load() != nil {
+		t.Error("expected reconciliation to complete at LastRptRequested=Y")
+	}
+	order := app.OrderStore.GetOrder("ord-1")
+	if order.OrdStatus != constants.OrdStatusFilled || order.OrderID != "exch-1" || order.CumQty != "1" {
+		t.Errorf("expected ord-1 to be reconciled to Filled, got %+v", order)
+	}
+	if r.reported["ord-2"] {
+		t.Error("expected ord-2 to be unreported")
+	}
+	if len(app.FillStore.GetAllFills()) != 0 {
+		t.Error("expected status reports not to be recorded as fills")
+	}
+}
+
+// TestReconciliation_MassFollowsUpMissingOrders verifies that open orders a
+// complete mass status response leaves out are followed up, and that the
+// reconciliation then completes on their per-order reports or rejects.
+func TestReconciliation_MassFollowsUpMissingOrders(t *testing.T) {
+	open := []*Order{
+		{ClOrdID: "ord-1", OrdStatus: constants.OrdStatusNew},
+		{ClOrdID: "ord-2", OrderID: "exch-2", OrdStatus: constants.OrdStatusNew},
+		{ClOrdID: "ord-3", OrdStatus: constants.OrdStatusPendingNew},
+	}
+	r := newReconciliation(constants.ReconcileModeMass, open)
+
+	if !r.record(&ExecutionReport{ClOrdID: "ord-1", ExecType: constants.ExecTypeOrderStatus, MassStatusReqID: r.reqId,
+		TotNumReports: "1", LastRptRequested: "Y"}) {
+		t.Fatal("expected the mass response to be complete")
+	}
+	followUp := &ExecutionReport{ClOrdID: "ord-2", OrderID: "exch-2", ExecType: constants.ExecTypeOrderStatus,
+		OrdStatus: constants.OrdStatusFilled}
+	if r.matches(followUp) {
+		t.Error("expected a per-order report not to match before the follow-up")
+	}
+
+	missing := r.followUp()
+	ids := make([]string, 0, len(missing))
+	for _, order := range missing {
+		ids = append(ids, order.ClOrdID)
+	}
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "ord-2,ord-3" {
+		t.Fatalf("expected ord-2 and ord-3 to be followed up, got %v", ids)
+	}
+	if r.followUp() != nil {
+		t.Error("expected orders to be followed up only once")
+	}
+
+	if !r.matches(followUp) || r.record(followUp) {
+		t.Error("expected the ord-2 report to match and the reconciliation to wait for ord-3")
+	}
+	if !r.awaits("ord-3") || !r.recordUnknown("ord-3", "Unknown order") {
+		t.Error("expected the reject for ord-3 to complete the reconciliation")
+	}
+}
+
+// TestReconciliation_PerOrderCompletes verifies that a per-order
+// reconciliation completes once every open order has been reported.
+func TestReconciliation_PerOrderCompletes(t *testing.T) {
+	app := &FixApp{Config: &Config{}, OrderStore: NewOrderStore(), FillStore: NewFillStore(), Strategies: NewStrategies()}
+	app.OrderStore.AddOrder(&Order{ClOrdID: "ord-1", OrdStatus: constants.OrdStatusNew})
+	app.OrderStore.AddOrder(&Order{ClOrdID: "ord-2", OrdStatus: constants.OrdStatusNew})
+	app.reconcile.Store(newReconciliation(constants.ReconcileModeOrders, app.OrderStore.GetOpenOrders()))
+
+	app.handleReconciliationReport(&ExecutionReport{ClOrdID: "ord-1", ExecType: constants.ExecTypeOrderStatus, OrdStatus: constants.OrdStatusCanceled})
+	if app.reconcile.Load() == nil {
+		t.Fatal("expected reconciliation to wait for ord-2")
+	}
+	app.handleReconciliationReport(&ExecutionReport{ClOrdID: "ord-2", ExecType: constants.ExecTypeOrderStatus, OrdStatus: constants.OrdStatusNew})
+	if app.reconcile.Load() != nil {
+		t.Error("expected reconciliation to complete")
+	}
+	if got := app.OrderStore.GetOrder("ord-1").OrdStatus; got != constants.OrdStatusCanceled {
+		t.Errorf("expected ord-1 canceled, got %s", got)
+	}
+}
+
+// reportObserver records the execution reports shown to the user.
+type reportObserver struct {
+	NopObserver
+	reports []*ExecutionReport
+}
+
+func (o *reportObserver) OnExecutionReport(er *ExecutionReport) {
+	o.reports = append(o.reports, er)
+}
+
+// TestReconciliation_ReportsReachObserverAndReplies verifies that a status
+// report applied by the reconciliation is shown and answers a waiting request.
+func TestReconciliation_ReportsReachObserverAndReplies(t *testing.T) {
+	observer := &reportObserver{}
+	app := &FixApp{Config: &Config{}, OrderStore: NewOrderStore(), FillStore: NewFillStore(), Strategies: NewStrategies(), Observer: observer}
+	app.OrderStore.AddOrder(&Order{ClOrdID: "ord-1", OrdStatus: constants.OrdStatusPendingNew})
+	app.reconcile.Store(newReconciliation(constants.ReconcileModeOrders, app.OrderStore.GetOpenOrders()))
+	reply := app.replies.add("ord-1")
+
+	app.handleExecutionReport(parseFixMessage(t, "35=8\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01"+
+		"11=ord-1\x0137=exch-1\x0117=exec-1\x0139=0\x01150=I\x0155=BTC-USD\x0154=1\x0138=1\x01151=1\x01"))
+
+	if len(observer.reports) != 1 || observer.reports[0].ClOrdID != "ord-1" {
+		t.Errorf("expected the status report to be shown, got %+v", observer.reports)
+	}
+	select {
+	case err := <-reply:
+		if err != nil {
+			t.Errorf("expected the waiting request to succeed, got %v", err)
+		}
+	default:
+		t.Error("expected the status report to answer the waiting request")
+	}
+	if app.reconcile.Load() != nil {
+		t.Error("expected reconciliation to complete")
+	}
+}
+
+// TestReconciliation_PerOrderCompletesForUnknownOrders verifies that a
+// per-order reconciliation completes when Prime reports an order under a
+// newer ClOrdID or rejects the request for one it does not know.
+func TestReconciliation_PerOrderCompletesForUnknownOrders(t *testing.T) {
+	app := &FixApp{Config: &Config{}, OrderStore: NewOrderStore(), FillStore: NewFillStore(), Strategies: NewStrategies()}
+	app.OrderStore.AddOrder(&Order{ClOrdID: "ord-1", OrderID: "exch-1", OrdStatus: constants.OrdStatusNew})
+	app.OrderStore.AddOrder(&Order{ClOrdID: "ord-2", OrdStatus: constants.OrdStatusPendingNew})
+	r := newReconciliation(constants.ReconcileModeOrders, app.OrderStore.GetOpenOrders())
+	app.reconcile.Store(r)
+
+	// Replaced while offline: reported under its new ClOrdID
+	app.handleReconciliationReport(&ExecutionReport{ClOrdID: "ord-1b", OrderID: "exch-1",
+		ExecType: constants.ExecTypeOrderStatus, OrdStatus: constants.OrdStatusFilled, CumQty: "1"})
+	if app.reconcile.Load() == nil {
+		t.Fatal("expected reconciliation to wait for ord-2")
+	}
+	if got := app.OrderStore.ResolveOrder("ord-1b"); got == nil || got.OrdStatus != constants.OrdStatusFilled {
+		t.Errorf("expected ord-1b to continue ord-1 as Filled, got %+v", got)
+	}
+
+	app.handleBusinessReject(parseFixMessage(t, "35=j\x0149=COIN\x0156=CLIENT\x0134=3\x0152=20250101-12:00:00.000\x01"+
+		"45=2\x01372=H\x01379=ord-2\x01380=0\x0158=Unknown order\x01"))
+	if app.reconcile.Load() != nil {
+		t.Fatal("expected reconciliation to complete on the reject")
+	}
+	if r.unknown["ord-2"] != "Unknown order" {
+		t.Errorf("expected ord-2 to be unknown to Prime, got %v", r.unknown)
+	}
+}
+
+// TestDiffOrders verifies which changes are reported.
+func TestDiffOrders(t *testing.T) {
+	before := &Order{ClOrdID: "ord-1", OrdStatus: constants.OrdStatusPendingNew, OrderQty: "1", Price: "50000"}
+	after := &Order{ClOrdID: "ord-1", OrdStatus: constants.OrdStatusPartiallyFilled, OrderID: "exch-1",
+		OrderQty: "1", Price: "50000", CumQty: "0.4", LeavesQty: "0.6"}
+
+	got := strings.Join(diffOrders(before, after), ", ")
+	want := "Status: Pending New → Partially Filled, OrderID: - → exch-1, Filled: 0 → 0.4, Leaves: - → 0.6"
+	if got != want {
+		t.Errorf("diffOrders() = %q, want %q", got, want)
+	}
+	if diff := diffOrders(after, after); len(diff) != 0 {
+		t.Errorf("expected no changes, got %v", diff)
+	}
+	if diff := diffOrders(&Order{CumQty: ""}, &Order{CumQty: "0"}); len(diff) != 0 {
+		t.Errorf("expected empty and zero CumQty to be equal, got %v", diff)
+	}
+}
@@ -17,13 +17,18 @@
 package fixclient
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"prime-fix-md-go/builder"
 	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
 	"prime-fix-md-go/utils"
 
 	"github.com/chzyer/readline"
@@ -45,6 +50,8 @@ func Repl(app *FixApp) {
 			),
 		),
 		readline.PcItem("unsubscribe", readline.PcItem("BTC-USD"), readline.PcItem("ETH-USD")),
+		readline.PcItem("book", readline.PcItem("BTC-USD"), readline.PcItem("ETH-USD")),
+		readline.PcItem("candles", readline.PcItem("BTC-USD"), readline.PcItem("ETH-USD")),
 
 		// Order entry commands
 		readline.PcItem("order",
@@ -52,6 +59,7 @@ func Repl(app *FixApp) {
 			readline.PcItem("sell", readline.PcItem("BTC-USD"), readline.PcItem("ETH-USD")),
 		),
 		readline.PcItem("cancel"),
+		readline.PcItem("cancelall", readline.PcItem("BTC-USD"), readline.PcItem("ETH-USD"), readline.PcItem("--side")),
 		readline.PcItem("replace"),
 		readline.PcItem("ordstatus"),
 		readline.PcItem("rfq",
@@ -60,7 +68,15 @@ func Repl(app *FixApp) {
 		),
 		readline.PcItem("accept"),
 		readline.PcItem("orders"),
+		readline.PcItem("history"),
+		readline.PcItem("fills"),
+		readline.PcItem("positions"),
 		readline.PcItem("quotes"),
+		readline.PcItem("oco", readline.PcItem("buy"), readline.PcItem("sell")),
+		readline.PcItem("bracket", readline.PcItem("buy"), readline.PcItem("sell")),
+		readline.PcItem("trail", readline.PcItem("buy"), readline.PcItem("sell")),
+		readline.PcItem("strategies"),
+		readline.PcItem("cancelstrategy"),
 
 		// General commands
 		readline.PcItem("status"),
@@ -105,12 +121,18 @@ func Repl(app *FixApp) {
 			app.handleDirectMdRequest(parts)
 		case "unsubscribe":
 			app.handleUnsubscribeRequest(parts)
+		case "book":
+			app.handleBookCommand(parts)
+		case "candles":
+			app.handleCandlesCommand(parts)
 
 		// Order entry commands
 		case "order":
 			app.handleOrderCommand(parts)
 		case "cancel":
 			app.handleCancelCommand(parts)
+		case "cancelall":
+			app.handleCancelAllCommand(parts)
 		case "replace":
 			app.handleReplaceCommand(parts)
 		case "ordstatus":
@@ -121,8 +143,24 @@ func Repl(app *FixApp) {
 			app.handleAcceptQuoteCommand(parts)
 		case "orders":
 			app.handleOrdersCommand()
+		case "history":
+			app.handleHistoryCommand(parts)
+		case "fills":
+			app.handleFillsCommand(parts)
+		case "positions":
+			app.handlePositionsCommand()
 		case "quotes":
 			app.handleQuotesCommand()
+		case "oco":
+			app.handleOcoCommand(parts)
+		case "bracket":
+			app.handleBracketCommand(parts)
+		case "trail":
+			app.handleTrailCommand(parts)
+		case "strategies":
+			app.handleStrategiesCommand()
+		case "cancelstrategy":
+			app.handleCancelStrategyCommand(parts)
 
 		// General commands
 		case "status":
@@ -326,6 +364,12 @@ func (a *FixApp) handleStatusRequest() bool {
 		fmt.Println("(Disconnected)")
 	}
 
+	if a.DbWriter != nil {
+		stats := a.DbWriter.Stats()
+		fmt.Printf("DB writer: queue %d/%d, written %d, dropped %d, spilled %d, errors %d\n",
+			stats.QueueDepth, stats.QueueCapacity, stats.Written, stats.Dropped, stats.Spilled, stats.Errors)
+	}
+
 	subscriptionsBySymbol := a.TradeStore.GetSubscriptionsBySymbol()
 	if len(subscriptionsBySymbol) == 0 {
 		fmt.Println("No active subscriptions")
@@ -344,6 +388,8 @@ Active Subscriptions:
 			status := "Active"
 			if !sub.Active {
 				status = "Inactive"
+			} else if sub.Stale {
+				status = "Stale"
 			}
 
 			lastUpdate := "Never"
@@ -373,6 +419,116 @@ Active Subscriptions:
 	return true
 }
 
+// handleBookCommand displays the live L2 order book for a symbol.
+// Usage: book <symbol> [levels]
+func (a *FixApp) handleBookCommand(parts []string) {
+	if len(parts) < 2 {
+		fmt.Print(`Usage: book <symbol> [levels]
+
+Shows the live order book built from --subscribe --depth market data.
+
+Examples:
+  book BTC-USD              - Top 10 levels per side
+  book ETH-USD 25           - Top 25 levels per side
+  book BTC-USD 0            - Full book
+`)
+		return
+	}
+
+	symbol := strings.ToUpper(parts[1])
+	levels := 10
+	if len(parts) >= 3 {
+		n, err := strconv.Atoi(parts[2])
+		if err != nil || n < 0 {
+			fmt.Println("Error: levels must be a non-negative integer")
+			return
+		}
+		levels = n
+	}
+
+	bids, offers := a.OrderBook.TopLevels(symbol, levels)
+	if len(bids) == 0 && len(offers) == 0 {
+		fmt.Printf("No order book for %s (subscribe with: md %s --subscribe --depth N)\n", symbol, symbol)
+		return
+	}
+
+	a.displayOrderBook(symbol, bids, offers)
+}
+
+// handleCandlesCommand shows the most recent OHLCV bars built from live
+// trades for one symbol and interval.
+func (a *FixApp) handleCandlesCommand(parts []string) {
+	if a.Candles == nil {
+		fmt.Println("Candles are disabled (start with -candles 1s,1m,5m,1h)")
+		return
+	}
+	if len(parts) < 2 {
+		fmt.Print(`Usage: candles <symbol> [interval] [count]
+
+Shows OHLCV bars built from live trades (md <symbol> --subscribe --trades).
+The last row is the bar still open, marked *.
+
+Examples:
+  candles BTC-USD           - Last 10 one-minute bars
+  candles BTC-USD 1s 30     - Last 30 one-second bars
+  candles ETH-USD 1h        - Last 10 one-hour bars
+`)
+		return
+	}
+
+	symbol := strings.ToUpper(parts[1])
+	interval := time.Minute
+	if len(parts) >= 3 {
+		d, err := time.ParseDuration(parts[2])
+		if err != nil || !slices.Contains(a.Candles.Intervals(), d) {
+			names := make([]string, 0, len(a.Candles.Intervals()))
+			for _, d := range a.Candles.Intervals() {
+				names = append(names, candleIntervalName(d))
+			}
+			fmt.Printf("Error: interval must be one of %s\n", strings.Join(names, ", "))
+			return
+		}
+		interval = d
+	}
+	count := 10
+	if len(parts) >= 4 {
+		n, err := strconv.Atoi(parts[3])
+		if err != nil || n <= 0 {
+			fmt.Println("Error: count must be a positive integer")
+			return
+		}
+		count = n
+	}
+
+	candles := a.Candles.Recent(symbol, interval, count)
+	if len(candles) == 0 {
+		fmt.Printf("No %s candles for %s (subscribe with: md %s --subscribe --trades)\n",
+			candleIntervalName(interval), symbol, symbol)
+		return
+	}
+
+	fmt.Printf(`
+%s %s candles:
+┌──────────────────────┬───────────────┬───────────────┬───────────────┬───────────────┬────────────────┬───────────────┬────────┐
+│ Start (UTC)          │ Open          │ High          │ Low           │ Close         │ Volume         │ VWAP          │ Trades │
+├──────────────────────┼───────────────┼───────────────┼───────────────┼───────────────┼────────────────┼───────────────┼────────┤
+`, symbol, candleIntervalName(interval))
+	for _, c := range candles {
+		start := c.Start.UTC().Format("2006-01-02 15:04:05")
+		if !c.Closed {
+			start += " *"
+		}
+		fmt.Printf("│ %-20s │ %13s │ %13s │ %13s │ %13s │ %14s │ %13s │ %6d │\n",
+			start, c.Open, c.High, c.Low, c.Close, c.Volume, c.VWAP, c.Trades)
+	}
+	fmt.Println("└──────────────────────┴───────────────┴───────────────┴───────────────┴───────────────┴────────────────┴───────────────┴────────┘")
+
+	if stats := a.Candles.Stats(); stats.Amended > 0 || stats.LateDropped > 0 {
+		fmt.Printf("Late trades: %d bar(s) amended after close, %d trade(s) too late to place\n",
+			stats.Amended, stats.LateDropped)
+	}
+}
+
 // --- Order Entry Command Handlers ---
 
 // handleOrderCommand processes new order requests.
@@ -467,8 +623,7 @@ Examples:
 		tif = constants.TimeInForceGTC
 	}
 
-	// Generate ClOrdID
-	clOrdID := fmt.Sprintf("ord_%d", time.Now().UnixNano())
+	clOrdID := newClOrdID()
 
 	params := builder.NewOrderParams{
 		ClOrdID:        clOrdID,
@@ -498,33 +653,16 @@ Examples:
 		params.StopPx = stopPx
 	}
 
-	// Build and send message
-	msg := builder.BuildNewOrderSingle(params, a.Config.SenderCompId, a.Config.TargetCompId)
-
-	if err := quickfix.SendToTarget(msg, a.SessionId); err != nil {
-		log.Printf("Error sending order: %v", err)
+	if err := a.submitOrder(params); err != nil {
+		var rejection *RiskRejection
+		if errors.As(err, &rejection) {
+			fmt.Printf("Order rejected by risk check (not sent): %v\n", err)
+		} else {
+			log.Printf("Error sending order: %v", err)
+		}
 		return
 	}
 
-	// Track order locally
-	order := &Order{
-		ClOrdID:        clOrdID,
-		Symbol:         symbol,
-		Side:           sideCode,
-		OrdType:        ordType,
-		OrderQty:       qty,
-		Price:          price,
-		TargetStrategy: strategy,
-		TimeInForce:    tif,
-		OrdStatus:      constants.OrdStatusPendingNew,
-		Account:        a.Config.PortfolioId,
-	}
-	if isCashOrder {
-		order.CashOrderQty = qty
-		order.OrderQty = ""
-	}
-	a.OrderStore.AddOrder(order)
-
 	log.Printf("Order submitted: %s %s %s @ %s (ClOrdID: %s)", side, qty, symbol, price, clOrdID)
 }
 
@@ -543,32 +681,19 @@ Examples:
 
 	identifier := parts[1]
 
-	// Try to find order by ClOrdID first, then by OrderID
-	order := a.OrderStore.GetOrder(identifier)
-	if order == nil {
-		order = a.OrderStore.GetOrderByOrderID(identifier)
-	}
-
+	// Any ClOrdID in the order's cancel/replace chain, or its OrderID
+	order := a.OrderStore.ResolveOrder(identifier)
 	if order == nil {
 		fmt.Printf("Order not found: %s\n", identifier)
 		return
 	}
-
-	newClOrdID := fmt.Sprintf("cxl_%d", time.Now().UnixNano())
-
-	params := builder.CancelOrderParams{
-		ClOrdID:     newClOrdID,
-		OrigClOrdID: order.ClOrdID,
-		OrderID:     order.OrderID,
-		Account:     a.Config.PortfolioId,
-		Symbol:      order.Symbol,
-		Side:        order.Side,
-		OrderQty:    order.OrderQty,
+	if !isOpenStatus(order.OrdStatus) {
+		fmt.Printf("Order %s is %s\n", order.ClOrdID, getOrdStatusDesc(order.OrdStatus))
+		return
 	}
 
-	msg := builder.BuildOrderCancelRequest(params, a.Config.SenderCompId, a.Config.TargetCompId)
-
-	if err := quickfix.SendToTarget(msg, a.SessionId); err != nil {
+	newClOrdID, err := a.sendCancel(order)
+	if err != nil {
 		log.Printf("Error sending cancel: %v", err)
 		return
 	}
@@ -576,6 +701,48 @@ Examples:
 	log.Printf("Cancel request sent for order %s (new ClOrdID: %s)", order.ClOrdID, newClOrdID)
 }
 
+// handleCancelAllCommand cancels every open order, optionally limited to one
+// symbol and/or side.
+// Usage: cancelall [symbol] [--side <buy|sell>]
+func (a *FixApp) handleCancelAllCommand(parts []string) {
+	usage := `Usage: cancelall [symbol] [--side <buy|sell>]
+
+Sends an Order Mass Cancel Request, falling back to one cancel per open
+order if it is rejected. A side without a symbol always sends one cancel per
+open order on that side.
+
+Examples:
+  cancelall                   - Cancel every open order
+  cancelall BTC-USD           - Cancel all open BTC-USD orders
+  cancelall --side sell       - Cancel all open sell orders
+`
+	var symbol, side string
+	for i := 1; i < len(parts); i++ {
+		switch {
+		case parts[i] == "--side" && i+1 < len(parts):
+			i++
+			switch strings.ToLower(parts[i]) {
+			case "buy":
+				side = constants.SideBuy
+			case "sell":
+				side = constants.SideSell
+			default:
+				fmt.Printf("Invalid side: %s (must be 'buy' or 'sell')\n", parts[i])
+				return
+			}
+		case strings.HasPrefix(parts[i], "--") || symbol != "":
+			fmt.Print(usage)
+			return
+		default:
+			symbol = strings.ToUpper(parts[i])
+		}
+	}
+
+	if _, err := a.CancelAll(symbol, side); err != nil {
+		log.Printf("Error sending cancel all: %v", err)
+	}
+}
+
 // handleReplaceCommand processes order cancel/replace requests.
 // Usage: replace <clOrdId> [--qty <qty>] [--price <price>]
 func (a *FixApp) handleReplaceCommand(parts []string) {
@@ -594,12 +761,17 @@ Examples:
 		return
 	}
 
-	origClOrdID := parts[1]
-	order := a.OrderStore.GetOrder(origClOrdID)
+	// Replace the current version even if an earlier ClOrdID was given
+	order := a.OrderStore.ResolveOrder(parts[1])
 	if order == nil {
-		fmt.Printf("Order not found: %s\n", origClOrdID)
+		fmt.Printf("Order not found: %s\n", parts[1])
+		return
+	}
+	if !isOpenStatus(order.OrdStatus) {
+		fmt.Printf("Order %s is %s\n", order.ClOrdID, getOrdStatusDesc(order.OrdStatus))
 		return
 	}
+	origClOrdID := order.ClOrdID
 
 	// Parse flags
 	newQty := order.OrderQty
@@ -620,6 +792,12 @@ Examples:
 		}
 	}
 
+	// A replace can raise the size or move the price, so it is checked too
+	if err := a.RiskChecks.Check(replaceCheckParams(order, newQty, newPrice)); err != nil {
+		fmt.Printf("Replace rejected by risk check (not sent): %v\n", err)
+		return
+	}
+
 	newClOrdID := fmt.Sprintf("rep_%d", time.Now().UnixNano())
 
 	params := builder.ReplaceOrderParams{
@@ -659,10 +837,7 @@ Examples:
 
 	identifier := parts[1]
 
-	order := a.OrderStore.GetOrder(identifier)
-	if order == nil {
-		order = a.OrderStore.GetOrderByOrderID(identifier)
-	}
+	order := a.OrderStore.ResolveOrder(identifier)
 
 	var clOrdID, orderID, symbol, side string
 	if order != nil {
@@ -729,7 +904,19 @@ Examples:
 
 	msg := builder.BuildQuoteRequest(params, a.Config.SenderCompId, a.Config.TargetCompId)
 
+	// Tracked before sending so that a quick quote or reject finds it
+	rfq := &Quote{
+		QuoteReqID: quoteReqID,
+		Account:    a.Config.PortfolioId,
+		Symbol:     symbol,
+		Side:       sideCode,
+		OrderQty:   qty,
+	}
+	a.OrderStore.AddQuoteRequest(rfq)
+	a.scheduleQuoteRequestExpiry(rfq)
+
 	if err := quickfix.SendToTarget(msg, a.SessionId); err != nil {
+		a.OrderStore.RemoveQuoteRequest(quoteReqID)
 		log.Printf("Error sending quote request: %v", err)
 		return
 	}
@@ -763,8 +950,21 @@ Examples:
 		return
 	}
 
+	switch quote.Status {
+	case constants.RfqStatusRequested:
+		fmt.Printf("Error: No quote received yet for %s\n", quote.QuoteReqID)
+		return
+	case constants.RfqStatusRejected:
+		fmt.Printf("Error: Quote request was rejected: %s\n", getQuoteRejectReasonDesc(quote.RejectReason))
+		return
+	case constants.RfqStatusAccepted:
+		fmt.Printf("Error: Quote already accepted (ClOrdID: %s)\n", quote.ClOrdID)
+		return
+	}
+
 	// Check if quote is still valid
-	if !quote.ValidUntilTime.IsZero() && time.Now().After(quote.ValidUntilTime) {
+	if quote.Status == constants.RfqStatusExpired ||
+		(!quote.ValidUntilTime.IsZero() && time.Now().After(quote.ValidUntilTime)) {
 		fmt.Println("Error: Quote has expired")
 		return
 	}
@@ -795,6 +995,21 @@ Examples:
 		Price:    price,
 	}
 
+	// Accepting a quote is a new order, so it goes through the same checks
+	if err := a.RiskChecks.Check(builder.NewOrderParams{
+		ClOrdID:  clOrdID,
+		Account:  quote.Account,
+		Symbol:   quote.Symbol,
+		Side:     side,
+		OrdType:  constants.OrdTypePreviouslyQuoted,
+		OrderQty: qty,
+		Price:    price,
+		QuoteID:  quote.QuoteID,
+	}); err != nil {
+		fmt.Printf("Quote acceptance rejected by risk check (not sent): %v\n", err)
+		return
+	}
+
 	msg := builder.BuildAcceptQuote(params, a.Config.SenderCompId, a.Config.TargetCompId)
 
 	if err := quickfix.SendToTarget(msg, a.SessionId); err != nil {
@@ -804,23 +1019,27 @@ Examples:
 
 	// Track as order
 	order := &Order{
-		ClOrdID:   clOrdID,
-		Symbol:    quote.Symbol,
-		Side:      side,
-		OrdType:   constants.OrdTypePreviouslyQuoted,
-		OrderQty:  qty,
-		Price:     price,
-		OrdStatus: constants.OrdStatusPendingNew,
-		Account:   quote.Account,
+		ClOrdID:    clOrdID,
+		Symbol:     quote.Symbol,
+		Side:       side,
+		OrdType:    constants.OrdTypePreviouslyQuoted,
+		OrderQty:   qty,
+		Price:      price,
+		OrdStatus:  constants.OrdStatusPendingNew,
+		Account:    quote.Account,
+		QuoteReqID: quote.QuoteReqID,
 	}
 	a.OrderStore.AddOrder(order)
+	a.OrderStore.AcceptQuote(quote.QuoteReqID, clOrdID)
 
 	log.Printf("Quote accepted: %s %s %s @ %s (ClOrdID: %s)", getSideDesc(side), qty, quote.Symbol, price, clOrdID)
 }
 
-// handleOrdersCommand lists all tracked orders.
+// handleOrdersCommand lists all tracked orders, one row per logical order.
+// The versions an order replaced are listed below it, oldest first.
 func (a *FixApp) handleOrdersCommand() {
-	orders := a.OrderStore.GetAllOrders()
+	orders := a.OrderStore.GetCurrentOrders()
+	sort.Slice(orders, func(i, j int) bool { return orders[i].CreatedAt.Before(orders[j].CreatedAt) })
 	if len(orders) == 0 {
 		fmt.Println("No orders tracked")
 		return
@@ -828,9 +1047,9 @@ func (a *FixApp) handleOrdersCommand() {
 
 	fmt.Print(`
 Orders:
-┌──────────────────────┬─────────────┬──────┬───────────────┬───────────────┬───────────────┬─────────────┐
-│ ClOrdID              │ Symbol      │ Side │ Qty           │ Price         │ Status        │ Filled      │
-├──────────────────────┼─────────────┼──────┼───────────────┼───────────────┼───────────────┼─────────────┤
+┌──────────────────────┬─────────────┬──────┬───────────────┬───────────────┬───────────────┬─────────────┬──────────────────┐
+│ ClOrdID              │ Symbol      │ Side │ Qty           │ Price         │ Status        │ Filled      │ Fees             │
+├──────────────────────┼─────────────┼──────┼───────────────┼───────────────┼───────────────┼─────────────┼──────────────────┤
 `)
 
 	for _, order := range orders {
@@ -854,7 +1073,12 @@ Orders:
 			filled = "0"
 		}
 
-		fmt.Printf("│ %-20s │ %-11s │ %-4s │ %-13s │ %-13s │ %-13s │ %-11s │\n",
+		fees := formatMiscFeeTotals(order.MiscFeeTotals())
+		if len(fees) > 16 {
+			fees = fees[:13] + "..."
+		}
+
+		fmt.Printf("│ %-20s │ %-11s │ %-4s │ %-13s │ %-13s │ %-13s │ %-11s │ %-16s │\n",
 			clOrdID,
 			order.Symbol,
 			getSideDesc(order.Side),
@@ -862,33 +1086,538 @@ Orders:
 			price,
 			getOrdStatusDesc(order.OrdStatus),
 			filled,
+			fees,
+		)
+
+		for _, amendment := range order.Amendments {
+			prevID := "↳ " + amendment.ClOrdID
+			if len([]rune(prevID)) > 20 {
+				prevID = string([]rune(prevID)[:17]) + "..."
+			}
+			prevQty := amendment.OrderQty
+			if amendment.CashOrderQty != "" {
+				prevQty = "$" + amendment.CashOrderQty
+			}
+			prevPrice := amendment.Price
+			if prevPrice == "" {
+				prevPrice = "MARKET"
+			}
+			fmt.Printf("│ %-20s │ %-11s │ %-4s │ %-13s │ %-13s │ %-13s │ %-11s │ %-16s │\n",
+				prevID, "", "", prevQty, prevPrice, "Replaced", "", "")
+		}
+	}
+
+	fmt.Println("└──────────────────────┴─────────────┴──────┴───────────────┴───────────────┴───────────────┴─────────────┴──────────────────┘")
+}
+
+// handleHistoryCommand lists the execution reports and local changes recorded
+// for an order and the versions it replaced, including reports that were
+// refused as illegal transitions.
+// Usage: history <clOrdId|orderId>
+func (a *FixApp) handleHistoryCommand(parts []string) {
+	if len(parts) < 2 {
+		fmt.Println("Usage: history <clOrdId|orderId>")
+		return
+	}
+
+	order := a.OrderStore.ResolveOrder(parts[1])
+	if order == nil {
+		fmt.Printf("Order not found: %s\n", parts[1])
+		return
+	}
+
+	// Events of the versions it replaced come first
+	var events []OrderEvent
+	for _, amendment := range order.Amendments {
+		if earlier := a.OrderStore.GetOrder(amendment.ClOrdID); earlier != nil {
+			events = append(events, earlier.History...)
+		}
+	}
+	events = append(events, order.History...)
+	if len(events) == 0 {
+		fmt.Printf("No events recorded for %s\n", order.ClOrdID)
+		return
+	}
+
+	fmt.Printf(`
+History: %s (%s %s, %s)
+┌──────────────┬──────────────────────┬────────────────┬──────────────────────────────────────┬─────────────┬────────────────────────────────┐
+│ Time         │ ClOrdID              │ Exec Type      │ Status                               │ CumQty      │ Note                           │
+├──────────────┼──────────────────────┼────────────────┼──────────────────────────────────────┼─────────────┼────────────────────────────────┤
+`, order.ClOrdID, getSideDesc(order.Side), order.Symbol, getOrdStatusDesc(order.OrdStatus))
+
+	for _, event := range events {
+		clOrdID := event.ClOrdID
+		if len(clOrdID) > 20 {
+			clOrdID = clOrdID[:17] + "..."
+		}
+
+		status := getOrdStatusDesc(event.OrdStatus)
+		if event.FromStatus != "" && event.FromStatus != event.OrdStatus {
+			status = getOrdStatusDesc(event.FromStatus) + " → " + status
+		}
+
+		note := event.Note
+		if event.Refused {
+			note = "REFUSED: " + note
+		} else if event.Unknown {
+			note = "UNKNOWN: " + note
+		}
+		if len(note) > 30 {
+			note = note[:27] + "..."
+		}
+
+		fmt.Printf("│ %-12s │ %-20s │ %-14s │ %-36s │ %-11s │ %-30s │\n",
+			event.Time.Format("15:04:05.000"),
+			clOrdID,
+			getExecTypeDesc(event.ExecType),
+			status,
+			event.CumQty,
+			note,
+		)
+	}
+
+	fmt.Println("└──────────────┴──────────────────────┴────────────────┴──────────────────────────────────────┴─────────────┴────────────────────────────────┘")
+}
+
+// handleFillsCommand lists recorded fills, optionally for one order (by
+// ClOrdID) or product, followed by the quantity, notional and VWAP per symbol
+// and side.
+func (a *FixApp) handleFillsCommand(parts []string) {
+	var fills []Fill
+	if len(parts) > 1 {
+		fills = a.FillStore.GetFillsByClOrdID(parts[1])
+		if len(fills) == 0 {
+			fills = a.FillStore.GetFillsBySymbol(strings.ToUpper(parts[1]))
+		}
+	} else {
+		fills = a.FillStore.GetAllFills()
+	}
+	if len(fills) == 0 {
+		fmt.Println("No fills recorded")
+		return
+	}
+
+	fmt.Print(`
+Fills:
+┌───────────────────────┬──────────────────────┬─────────────┬──────┬───────────────┬───────────────┬─────────────┬──────────────────┐
+│ Time                  │ ClOrdID              │ Symbol      │ Side │ Qty           │ Price         │ Commission  │ Fees             │
+├───────────────────────┼──────────────────────┼─────────────┼──────┼───────────────┼───────────────┼─────────────┼──────────────────┤
+`)
+
+	for _, fill := range fills {
+		clOrdID := fill.ClOrdID
+		if len(clOrdID) > 20 {
+			clOrdID = clOrdID[:17] + "..."
+		}
+
+		commission := fill.Commission
+		if commission == "" {
+			commission = "-"
+		}
+
+		fees := formatMiscFeeTotals(sumMiscFees(fill.MiscFees))
+		if len(fees) > 16 {
+			fees = fees[:13] + "..."
+		}
+
+		fmt.Printf("│ %-21s │ %-20s │ %-11s │ %-4s │ %-13s │ %-13s │ %-11s │ %-16s │\n",
+			fill.TransactTime,
+			clOrdID,
+			fill.Symbol,
+			getSideDesc(fill.Side),
+			fill.LastShares,
+			fill.LastPx,
+			commission,
+			fees,
+		)
+	}
+
+	fmt.Println("└───────────────────────┴──────────────────────┴─────────────┴──────┴───────────────┴───────────────┴─────────────┴──────────────────┘")
+
+	for _, total := range SummarizeFills(fills) {
+		fmt.Printf("%s %s: %s in %d fill(s), notional %s, VWAP %s\n",
+			total.Symbol, getSideDesc(total.Side), total.Qty, total.Count, total.Notional, total.VWAP)
+	}
+}
+
+// handlePositionsCommand lists the net position, average cost and PnL per
+// symbol. Unrealized PnL is marked at the last trade, or the mid when no
+// trade has been received.
+func (a *FixApp) handlePositionsCommand() {
+	positions := a.Positions.All()
+	if len(positions) == 0 {
+		fmt.Println("No positions")
+		return
+	}
+
+	fmt.Print(`
+Positions:
+┌─────────────┬───────────────┬───────────────┬──────────────────┬───────────────┬───────────────┬─────────────┐
+│ Symbol      │ Net Qty       │ Avg Cost      │ Mark             │ Unrealized    │ Realized      │ Fees        │
+├─────────────┼───────────────┼───────────────┼──────────────────┼───────────────┼───────────────┼─────────────┤
+`)
+
+	var totalRealized, totalUnrealized decimal.Decimal
+	totals := &calc{}
+	quoteCurrencies := make(map[string]bool)
+	for _, pos := range positions {
+		avgCost, mark, unrealized := "-", "-", "-"
+		if !pos.NetQty.IsZero() {
+			avgCost = formatPrice(pos.AvgCost)
+			if px, source, ok := a.markPrice(pos.Symbol); ok {
+				mark = formatPrice(px) + " (" + source + ")"
+				if pnl, err := pos.UnrealizedPnL(px); err == nil {
+					unrealized = formatAmount(pnl)
+					totalUnrealized = totals.add(totalUnrealized, pnl)
+				}
+			}
+		}
+		totalRealized = totals.add(totalRealized, pos.RealizedPnL)
+		if _, quote, ok := strings.Cut(pos.Symbol, "-"); ok {
+			quoteCurrencies[quote] = true
+		}
+
+		fmt.Printf("│ %-11s │ %-13s │ %-13s │ %-16s │ %-13s │ %-13s │ %-11s │\n",
+			pos.Symbol,
+			pos.NetQty,
+			avgCost,
+			mark,
+			unrealized,
+			formatAmount(pos.RealizedPnL),
+			formatAmount(pos.Fees),
 		)
 	}
 
-	fmt.Println("└──────────────────────┴─────────────┴──────┴───────────────┴───────────────┴───────────────┴─────────────┘")
+	fmt.Println("└─────────────┴───────────────┴───────────────┴──────────────────┴───────────────┴───────────────┴─────────────┘")
+
+	// Totals only make sense in a single quote currency
+	if len(quoteCurrencies) == 1 && totals.err == nil {
+		for quote := range quoteCurrencies {
+			fmt.Printf("Total (%s): Realized %s, Unrealized %s\n", quote, formatAmount(totalRealized), formatAmount(totalUnrealized))
+		}
+	}
+}
+
+// handleOcoCommand starts a one-cancels-other pair.
+// Usage: oco <buy|sell> <symbol> <qty> --limit <price> --stop <price> [--stop-limit <price>]
+func (a *FixApp) handleOcoCommand(parts []string) {
+	usage := `Usage: oco <buy|sell> <symbol> <qty> --limit <price> --stop <price> [--stop-limit <price>]
+
+Sends a limit order and a stop-limit order on the same side; a fill on
+either cancels the other. The stop-limit's limit defaults to the stop price.
+
+Examples:
+  oco sell BTC-USD 0.5 --limit 52000 --stop 48000    - Take profit or stop out a long
+  oco buy ETH-USD 2 --limit 2900 --stop 3200 --stop-limit 3220
+`
+	args, err := parseStrategyArgs(parts, false)
+	if err != nil {
+		fmt.Printf("Error: %v\n\n%s", err, usage)
+		return
+	}
+	limitPx, stopPx := args.flags["--limit"], args.flags["--stop"]
+	if limitPx == "" || stopPx == "" {
+		fmt.Print(usage)
+		return
+	}
+	stopLimitPx := args.flags["--stop-limit"]
+	if stopLimitPx == "" {
+		stopLimitPx = stopPx
+	}
+	if err := checkExitPrices(args.side, limitPx, stopPx, stopLimitPx); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	s := NewOCOStrategy(args.symbol, args.side, args.qty, limitPx, stopPx, stopLimitPx)
+	if err := a.StartStrategy(s); err != nil {
+		fmt.Printf("Strategy not started: %v\n", err)
+	}
+}
+
+// handleBracketCommand starts a bracket order.
+// Usage: bracket <buy|sell> <symbol> <qty> [price] --tp <price> --sl <price> [--sl-limit <price>]
+func (a *FixApp) handleBracketCommand(parts []string) {
+	usage := `Usage: bracket <buy|sell> <symbol> <qty> [price] --tp <price> --sl <price> [--sl-limit <price>]
+
+Sends the entry, a limit order at price or a market order without one. Once
+it has filled, sends a take-profit limit and a stop-loss stop-limit on the
+other side for the filled quantity; a fill on either cancels the other.
+
+Examples:
+  bracket buy BTC-USD 0.1 50000 --tp 52000 --sl 49000
+  bracket sell ETH-USD 2 --tp 2800 --sl 3100 --sl-limit 3120
+`
+	args, err := parseStrategyArgs(parts, true)
+	if err != nil {
+		fmt.Printf("Error: %v\n\n%s", err, usage)
+		return
+	}
+	takeProfitPx, stopLossPx := args.flags["--tp"], args.flags["--sl"]
+	if takeProfitPx == "" || stopLossPx == "" {
+		fmt.Print(usage)
+		return
+	}
+	stopLimitPx := args.flags["--sl-limit"]
+	if stopLimitPx == "" {
+		stopLimitPx = stopLossPx
+	}
+	if err := checkExitPrices(oppositeSide(args.side), takeProfitPx, stopLossPx, stopLimitPx); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	s := NewBracketStrategy(args.symbol, args.side, args.qty, args.price, takeProfitPx, stopLossPx, stopLimitPx)
+	if err := a.StartStrategy(s); err != nil {
+		fmt.Printf("Strategy not started: %v\n", err)
+	}
+}
+
+// handleTrailCommand starts a trailing stop.
+// Usage: trail <buy|sell> <symbol> <qty> --amount <price> | --pct <percent>
+func (a *FixApp) handleTrailCommand(parts []string) {
+	usage := `Usage: trail <buy|sell> <symbol> <qty> --amount <price> | --pct <percent>
+
+Follows live trades and sends a market order once the price retraces the
+trail from its best since the stop started: a sell triggers on a fall from
+the high, a buy on a rise from the low. Needs a trade subscription:
+  md <symbol> --subscribe --trades
+
+Examples:
+  trail sell BTC-USD 0.5 --amount 500    - Sell if BTC falls $500 from its high
+  trail buy ETH-USD 2 --pct 1.5          - Buy if ETH rises 1.5% from its low
+`
+	args, err := parseStrategyArgs(parts, false)
+	if err != nil {
+		fmt.Printf("Error: %v\n\n%s", err, usage)
+		return
+	}
+
+	var trailAmt, trailPct decimal.Decimal
+	switch amount, pct := args.flags["--amount"], args.flags["--pct"]; {
+	case amount != "" && pct == "":
+		trailAmt, err = parsePositive("trail amount", amount)
+	case pct != "" && amount == "":
+		if trailPct, err = parsePositive("trail percent", pct); err == nil && trailPct.Cmp(decimal.New(100, 0)) >= 0 {
+			err = fmt.Errorf("trail percent must be below 100")
+		}
+	default:
+		fmt.Print(usage)
+		return
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	// A trailing stop follows trades, so it starts from the last one if any
+	anchor, ok := a.TradeStore.LastTradePrice(args.symbol)
+	if !ok {
+		anchor, _, ok = a.markPrice(args.symbol)
+	}
+	if !ok {
+		fmt.Printf("No live price for %s; subscribe first with md %s --subscribe --trades\n", args.symbol, args.symbol)
+		return
+	}
+
+	s := NewTrailingStrategy(args.symbol, args.side, args.qty, trailAmt, trailPct, anchor)
+	stop, err := s.StopPrice()
+	if err != nil {
+		fmt.Printf("Error: no stop price from %s: %v\n", formatPrice(anchor), err)
+		return
+	}
+	if err := a.StartStrategy(s); err != nil {
+		fmt.Printf("Strategy not started: %v\n", err)
+		return
+	}
+	fmt.Printf("Trailing from %s, stop at %s\n", formatPrice(anchor), formatPrice(stop))
+}
+
+// handleStrategiesCommand lists strategies with one row per leg.
+func (a *FixApp) handleStrategiesCommand() {
+	strategies := a.Strategies.All()
+	if len(strategies) == 0 {
+		fmt.Println("No strategies")
+		return
+	}
+
+	fmt.Print(`
+Strategies:
+┌───────────────────────────────┬──────────┬──────────┬──────────┬──────┬────────────┬────────────────────────────────┐
+│ ID / Leg                      │ Kind     │ Status   │ Symbol   │ Side │ Qty        │ Price / Order Status           │
+├───────────────────────────────┼──────────┼──────────┼──────────┼──────┼────────────┼────────────────────────────────┤
+`)
+	clip := func(s string) string {
+		if len(s) > 30 {
+			return s[:27] + "..."
+		}
+		return s
+	}
+	for _, s := range strategies {
+		detail := s.Note
+		if s.Kind == constants.StrategyKindTrailing && s.Status == constants.StrategyStatusActive {
+			stop, _ := s.StopPrice()
+			detail = fmt.Sprintf("stop %s (from %s)", formatPrice(stop), formatPrice(s.Anchor))
+		}
+		fmt.Printf("│ %-29s │ %-8s │ %-8s │ %-8s │ %-4s │ %-10s │ %-30s │\n",
+			s.ID, s.Kind, s.Status, s.Symbol, getSideDesc(s.Side), s.OrderQty, clip(detail))
+
+		for _, leg := range s.Legs {
+			status := "not sent"
+			if leg.Sent && leg.OrdStatus == "" {
+				status = getOrdStatusDesc(constants.OrdStatusPendingNew)
+			} else if leg.Sent {
+				status = getOrdStatusDesc(leg.OrdStatus)
+			}
+			fmt.Printf("│   ↳ %-25s │ %-8s │ %-8s │ %-8s │ %-4s │ %-10s │ %-30s │\n",
+				leg.Role, "", "", "", getSideDesc(leg.Side), leg.OrderQty,
+				clip(describeLegPrice(leg)+", "+status))
+		}
+	}
+	fmt.Println("└───────────────────────────────┴──────────┴──────────┴──────────┴──────┴────────────┴────────────────────────────────┘")
+}
+
+// handleCancelStrategyCommand stops a strategy and cancels its working legs.
+// Usage: cancelstrategy <id>
+func (a *FixApp) handleCancelStrategyCommand(parts []string) {
+	if len(parts) < 2 {
+		fmt.Println("Usage: cancelstrategy <id>")
+		return
+	}
+	if err := a.CancelStrategy(parts[1]); err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
+
+// strategyArgs are the arguments shared by the strategy commands.
+type strategyArgs struct {
+	side   string
+	symbol string
+	qty    string
+	price  string            // Optional positional price
+	flags  map[string]string // --name value
 }
 
-// handleQuotesCommand lists all received quotes.
+// parseStrategyArgs reads "<buy|sell> <symbol> <qty> [price]" followed by
+// "--name value" flags, checking that every number is positive.
+func parseStrategyArgs(parts []string, allowPrice bool) (strategyArgs, error) {
+	if len(parts) < 4 {
+		return strategyArgs{}, fmt.Errorf("missing arguments")
+	}
+	args := strategyArgs{symbol: strings.ToUpper(parts[2]), qty: parts[3], flags: make(map[string]string)}
+	switch strings.ToLower(parts[1]) {
+	case "buy":
+		args.side = constants.SideBuy
+	case "sell":
+		args.side = constants.SideSell
+	default:
+		return strategyArgs{}, fmt.Errorf("side must be 'buy' or 'sell'")
+	}
+	if _, err := parsePositive("quantity", args.qty); err != nil {
+		return strategyArgs{}, err
+	}
+
+	for i := 4; i < len(parts); i++ {
+		part := parts[i]
+		switch {
+		case strings.HasPrefix(part, "--") && i+1 < len(parts):
+			i++
+			args.flags[part] = parts[i]
+		case !strings.HasPrefix(part, "--") && allowPrice && args.price == "":
+			args.price = part
+		default:
+			return strategyArgs{}, fmt.Errorf("unexpected argument %q", part)
+		}
+	}
+
+	for name, value := range args.flags {
+		if _, err := parsePositive(strings.TrimPrefix(name, "--"), value); err != nil {
+			return strategyArgs{}, err
+		}
+	}
+	if args.price != "" {
+		if _, err := parsePositive("price", args.price); err != nil {
+			return strategyArgs{}, err
+		}
+	}
+	return args, nil
+}
+
+// checkExitPrices checks that a take-profit limit and a stop on side are on
+// the right sides of each other: a sell takes profit above its stop, a buy
+// below it.
+func checkExitPrices(side, limitPx, stopPx, stopLimitPx string) error {
+	limit, stop, stopLimit := parseDecimalOrZero(limitPx), parseDecimalOrZero(stopPx), parseDecimalOrZero(stopLimitPx)
+	if side == constants.SideSell {
+		if limit.Cmp(stop) <= 0 {
+			return fmt.Errorf("a sell's limit price %s must be above its stop %s", limitPx, stopPx)
+		}
+		if stopLimit.Cmp(stop) > 0 {
+			return fmt.Errorf("a sell stop's limit %s must not be above the stop %s", stopLimitPx, stopPx)
+		}
+		return nil
+	}
+	if limit.Cmp(stop) >= 0 {
+		return fmt.Errorf("a buy's limit price %s must be below its stop %s", limitPx, stopPx)
+	}
+	if stopLimit.Cmp(stop) < 0 {
+		return fmt.Errorf("a buy stop's limit %s must not be below the stop %s", stopLimitPx, stopPx)
+	}
+	return nil
+}
+
+// formatAmount formats a PnL or fee amount with two decimals.
+func formatAmount(v decimal.Decimal) string {
+	return formatRounded(v, 2)
+}
+
+// formatPrice keeps more decimals for prices below 1.
+func formatPrice(v decimal.Decimal) string {
+	if v.Abs().Cmp(decimal.New(1, 0)) < 0 {
+		return formatRounded(v, 8)
+	}
+	return formatAmount(v)
+}
+
+// formatRounded formats v rounded or padded to scale decimals.
+func formatRounded(v decimal.Decimal, scale uint8) string {
+	r, err := v.Round(scale)
+	if err != nil {
+		return v.String()
+	}
+	return r.String()
+}
+
+// handleQuotesCommand lists the tracked RFQs with their status and, while a
+// quote can still be accepted, the time left on it.
 func (a *FixApp) handleQuotesCommand() {
 	quotes := a.OrderStore.GetAllQuotes()
 	if len(quotes) == 0 {
-		fmt.Println("No quotes received")
+		fmt.Println("No quote requests or quotes")
 		return
 	}
+	sort.Slice(quotes, func(i, j int) bool { return rfqTime(quotes[i]).Before(rfqTime(quotes[j])) })
 
 	fmt.Print(`
 Quotes:
-┌──────────────────────┬─────────────┬───────────────┬───────────────┬───────────────┬──────────────┐
-│ QuoteReqID           │ Symbol      │ Bid           │ Offer         │ Valid Until   │ QuoteID      │
-├──────────────────────┼─────────────┼───────────────┼───────────────┼───────────────┼──────────────┤
+┌──────────────────────┬─────────────┬───────────────┬───────────────┬───────────────┬──────────────────────────┬────────────┬──────────────┐
+│ QuoteReqID           │ Symbol      │ Request       │ Bid           │ Offer         │ Status                   │ Expires In │ QuoteID      │
+├──────────────────────┼─────────────┼───────────────┼───────────────┼───────────────┼──────────────────────────┼────────────┼──────────────┤
 `)
 
+	now := time.Now()
 	for _, quote := range quotes {
 		quoteReqID := quote.QuoteReqID
 		if len(quoteReqID) > 20 {
 			quoteReqID = quoteReqID[:17] + "..."
 		}
 
+		request := "-"
+		if quote.OrderQty != "" {
+			request = fmt.Sprintf("%s %s", getSideDesc(quote.Side), quote.OrderQty)
+		}
+
 		bid := "-"
 		if quote.BidPx != "" {
 			bid = fmt.Sprintf("%s@%s", quote.BidSize, quote.BidPx)
@@ -899,12 +1628,14 @@ Quotes:
 			offer = fmt.Sprintf("%s@%s", quote.OfferSize, quote.OfferPx)
 		}
 
-		validUntil := "-"
-		if !quote.ValidUntilTime.IsZero() {
-			if time.Now().After(quote.ValidUntilTime) {
-				validUntil = "EXPIRED"
+		// Counts down only while the quote can still be accepted
+		expiresIn := "-"
+		quoted := quote.Status == constants.RfqStatusQuoted || quote.Status == ""
+		if quoted && !quote.ValidUntilTime.IsZero() {
+			if left := quote.ValidUntilTime.Sub(now); left > 0 {
+				expiresIn = formatCountdown(left)
 			} else {
-				validUntil = quote.ValidUntilTime.Format("15:04:05")
+				expiresIn = "EXPIRED"
 			}
 		}
 
@@ -913,17 +1644,28 @@ Quotes:
 			quoteID = quoteID[:9] + "..."
 		}
 
-		fmt.Printf("│ %-20s │ %-11s │ %-13s │ %-13s │ %-13s │ %-12s │\n",
+		fmt.Printf("│ %-20s │ %-11s │ %-13s │ %-13s │ %-13s │ %-24s │ %-10s │ %-12s │\n",
 			quoteReqID,
 			quote.Symbol,
+			request,
 			bid,
 			offer,
-			validUntil,
+			getRfqStatusDesc(quote),
+			expiresIn,
 			quoteID,
 		)
 	}
 
-	fmt.Println("└──────────────────────┴─────────────┴───────────────┴───────────────┴───────────────┴──────────────┘")
+	fmt.Println("└──────────────────────┴─────────────┴───────────────┴───────────────┴───────────────┴──────────────────────────┴────────────┴──────────────┘")
+}
+
+// rfqTime is when an RFQ was requested, or received for RFQs not sent from
+// this client.
+func rfqTime(quote *Quote) time.Time {
+	if !quote.RequestedAt.IsZero() {
+		return quote.RequestedAt
+	}
+	return quote.ReceivedAt
 }
 
 // --- Order Entry Helper Functions ---
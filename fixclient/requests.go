@@ -17,14 +17,17 @@
 package fixclient
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"prime-fix-md-go/builder"
 	"prime-fix-md-go/constants"
+	"prime-fix-md-go/utils"
 
 	"github.com/quickfixgo/quickfix"
 )
@@ -34,7 +37,7 @@ func (a *FixApp) sendUnsubscribeBySymbol(symbol string) {
 
 	var symbolSubs []*Subscription
 	for _, sub := range subscriptions {
-		if sub.Symbol == symbol {
+		if sub.HasSymbol(symbol) {
 			symbolSubs = append(symbolSubs, sub)
 		}
 	}
@@ -54,37 +57,47 @@ func (a *FixApp) sendUnsubscribeBySymbol(symbol string) {
 	}
 
 	for _, sub := range symbolSubs {
-		msg := builder.BuildMarketDataRequest(
-			sub.MdReqId,
-			[]string{symbol},
-			constants.SubscriptionRequestTypeUnsubscribe,
-			"0",
-			a.Config.SenderCompId,
-			a.Config.TargetCompId,
-			[]string{constants.MdEntryTypeTrade},
-		)
-
-		if err := quickfix.Send(msg); err != nil {
+		// An unsubscribe ends the whole request, including its other symbols
+		symbols := sub.AllSymbols()
+		if _, err := a.unsubscribe(sub.MdReqId); err != nil {
 			log.Printf("Error sending unsubscribe request for reqId %s: %v", sub.MdReqId, err)
-		} else {
-			fmt.Printf("Unsubscribe request sent for %s (reqId: %s)\n", symbol, sub.MdReqId)
-			a.TradeStore.RemoveSubscriptionByReqId(sub.MdReqId)
+			continue
+		}
+		fmt.Printf("Unsubscribe request sent for %s (reqId: %s)\n", strings.Join(symbols, ","), sub.MdReqId)
+
+		// No more updates will arrive, so the books would only go stale
+		for _, s := range symbols {
+			a.OrderBook.Clear(s)
 		}
 	}
 }
 
 func (a *FixApp) sendUnsubscribeByReqId(reqId string) {
-	subscriptions := a.TradeStore.GetSubscriptionStatus()
+	symbol, err := a.unsubscribe(reqId)
+	switch {
+	case errors.Is(err, ErrUnknownSubscription):
+		fmt.Printf("No active subscription found with reqId: %s\n", reqId)
+	case err != nil:
+		log.Printf("Error sending unsubscribe request for reqId %s: %v", reqId, err)
+		fmt.Printf("Failed to send unsubscribe request for reqId: %s\n", reqId)
+	default:
+		fmt.Printf("Unsubscribe request sent for %s (reqId: %s)\n", symbol, reqId)
+	}
+}
 
-	sub, exists := subscriptions[reqId]
+// unsubscribe ends the tracked subscription reqId and returns its symbol.
+// reqId may also be the MdReqId the subscription was first created with.
+// Prime does not acknowledge unsubscribes, so it is removed once sent.
+func (a *FixApp) unsubscribe(reqId string) (string, error) {
+	reqId = a.TradeStore.CurrentMdReqId(reqId)
+	sub, exists := a.TradeStore.GetSubscriptionStatus()[reqId]
 	if !exists {
-		fmt.Printf("No active subscription found with reqId: %s\n", reqId)
-		return
+		return "", ErrUnknownSubscription
 	}
 
 	msg := builder.BuildMarketDataRequest(
 		reqId,
-		[]string{sub.Symbol},
+		sub.AllSymbols(),
 		constants.SubscriptionRequestTypeUnsubscribe,
 		"0",
 		a.Config.SenderCompId,
@@ -93,12 +106,10 @@ func (a *FixApp) sendUnsubscribeByReqId(reqId string) {
 	)
 
 	if err := quickfix.Send(msg); err != nil {
-		log.Printf("Error sending unsubscribe request for reqId %s: %v", reqId, err)
-		fmt.Printf("Failed to send unsubscribe request for reqId: %s\n", reqId)
-	} else {
-		fmt.Printf("Unsubscribe request sent for %s (reqId: %s)\n", sub.Symbol, reqId)
-		a.TradeStore.RemoveSubscriptionByReqId(reqId)
+		return "", err
 	}
+	a.TradeStore.RemoveSubscriptionByReqId(reqId)
+	return sub.Symbol, nil
 }
 
 func (a *FixApp) sendMarketDataRequest(symbols []string, subscriptionType, description string) {
@@ -106,13 +117,28 @@ func (a *FixApp) sendMarketDataRequest(symbols []string, subscriptionType, descr
 }
 
 func (a *FixApp) sendMarketDataRequestWithOptions(symbols []string, subscriptionType, marketDepth string, entryTypes []string, description string) {
-	// Use strconv instead of fmt.Sprintf for simple int formatting (faster)
-	reqId := "md_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	reqId := newMdReqId()
+	if err := a.requestMarketData(reqId, symbols, subscriptionType, marketDepth, entryTypes); err != nil {
+		log.Printf("Error sending market data request: %v", err)
+		fmt.Printf("Failed to send %s request for %v\n", description, symbols)
+	} else {
+		// Use strings.Builder to avoid O(n²) string concatenation
+		entryTypeNames := make([]string, len(entryTypes))
+		for i, et := range entryTypes {
+			entryTypeNames[i] = getMdEntryTypeName(et)
+		}
+		entryTypesStr := strings.Join(entryTypeNames, ", ")
+		fmt.Printf("%s request sent for %v (depth=%s, types=[%s], reqId=%s)\n",
+			description, symbols, marketDepth, entryTypesStr, reqId)
+	}
+}
 
+// requestMarketData sends a Market Data Request (V) under reqId. Live
+// subscriptions are tracked, for resubscribe on reconnect, before the request
+// is sent and dropped again if it cannot be.
+func (a *FixApp) requestMarketData(reqId string, symbols []string, subscriptionType, marketDepth string, entryTypes []string) error {
 	if subscriptionType == constants.SubscriptionRequestTypeSubscribe {
-		for _, symbol := range symbols {
-			a.TradeStore.AddSubscription(symbol, subscriptionType, reqId)
-		}
+		a.TradeStore.AddSubscriptionRequest(symbols, subscriptionType, reqId, marketDepth, entryTypes)
 	}
 
 	for _, symbol := range symbols {
@@ -130,19 +156,108 @@ func (a *FixApp) sendMarketDataRequestWithOptions(symbols []string, subscription
 	)
 
 	if err := quickfix.Send(msg); err != nil {
-		log.Printf("Error sending market data request: %v", err)
-		fmt.Printf("Failed to send %s request for %v\n", description, symbols)
-		for _, symbol := range symbols {
-			a.TradeStore.RemoveSubscription(symbol)
+		a.TradeStore.RemoveSubscriptionByReqId(reqId)
+		return err
+	}
+	return nil
+}
+
+// unsubscribeAll sends an unsubscribe for every tracked subscription, e.g. on
+// daemon shutdown.
+func (a *FixApp) unsubscribeAll() {
+	for reqId := range a.TradeStore.GetSubscriptionStatus() {
+		a.sendUnsubscribeByReqId(reqId)
+	}
+}
+
+// resubscribeAll replays every tracked subscription after a (re)logon.
+// Subscriptions do not survive a session drop, so each original request is
+// resent with a fresh MdReqId; the TradeStore maps the new id back to the
+// original and keeps the subscription stale until data arrives.
+func (a *FixApp) resubscribeAll() {
+	resubscribed := 0
+	for reqId, sub := range a.TradeStore.GetSubscriptionStatus() {
+		if a.resubscribe(reqId, sub) {
+			resubscribed++
 		}
-	} else {
-		// Use strings.Builder to avoid O(n²) string concatenation
-		entryTypeNames := make([]string, len(entryTypes))
-		for i, et := range entryTypes {
-			entryTypeNames[i] = getMdEntryTypeName(et)
+	}
+
+	if resubscribed > 0 {
+		log.Printf("Resubscribed %d market data subscription(s)", resubscribed)
+	}
+}
+
+// resubscribeIfTracked re-sends a subscription whose MarketDataRequest is being
+// gap-filled during a resend. It runs asynchronously because ToApp is called
+// while quickfix is in the middle of processing the ResendRequest.
+func (a *FixApp) resubscribeIfTracked(msg *quickfix.Message) {
+	if msgType, _ := msg.Header.GetString(constants.TagMsgType); msgType != constants.MsgTypeMarketDataRequest {
+		return
+	}
+
+	reqId := utils.GetString(msg, constants.TagMdReqId)
+	sub, exists := a.TradeStore.GetSubscriptionStatus()[reqId]
+	if !exists {
+		return
+	}
+	go a.resubscribe(reqId, sub)
+}
+
+// resubscribe resends sub's original request under a fresh MdReqId.
+// Returns false for snapshot-only entries or if the send fails; failed
+// subscriptions stay stale and are retried on the next logon.
+func (a *FixApp) resubscribe(oldReqId string, sub *Subscription) bool {
+	if sub.SubscriptionType != constants.SubscriptionRequestTypeSubscribe {
+		return false
+	}
+
+	reqId := newMdReqId()
+	if !a.TradeStore.ReplaceSubscriptionReqId(oldReqId, reqId) {
+		return false // unsubscribed in the meantime
+	}
+
+	msg := builder.BuildMarketDataRequest(
+		reqId,
+		sub.Symbols,
+		sub.SubscriptionType,
+		sub.MarketDepth,
+		a.Config.SenderCompId,
+		a.Config.TargetCompId,
+		sub.EntryTypes,
+	)
+
+	if err := quickfix.Send(msg); err != nil {
+		log.Printf("Error resubscribing %v (reqId %s): %v", sub.Symbols, reqId, err)
+		return false
+	}
+
+	for _, symbol := range sub.Symbols {
+		a.createDatabaseSession(symbol, sub.SubscriptionType, sub.MarketDepth, sub.EntryTypes, reqId)
+	}
+	return true
+}
+
+// lastMdReqId holds the last nanosecond timestamp used for an MdReqId so that
+// requests issued in a tight loop (e.g. resubscribeAll) never share an id.
+var lastMdReqId atomic.Int64
+
+// newMdReqId returns a unique MdReqId for a market data request.
+func newMdReqId() string {
+	// Use strconv instead of fmt.Sprintf for simple int formatting (faster)
+	return "md_" + strconv.FormatInt(nextNano(&lastMdReqId), 10)
+}
+
+// nextNano returns the current nanosecond timestamp, or one more than the
+// last value taken from last if the clock has not moved past it.
+func nextNano(last *atomic.Int64) int64 {
+	id := time.Now().UnixNano()
+	for {
+		prev := last.Load()
+		if id <= prev {
+			id = prev + 1
+		}
+		if last.CompareAndSwap(prev, id) {
+			return id
 		}
-		entryTypesStr := strings.Join(entryTypeNames, ", ")
-		fmt.Printf("%s request sent for %v (depth=%s, types=[%s], reqId=%s)\n",
-			description, symbols, marketDepth, entryTypesStr, reqId)
 	}
 }
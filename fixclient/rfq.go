@@ -0,0 +1,128 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"prime-fix-md-go/constants"
+)
+
+// quoteRetention is how long a rejected, expired or filled RFQ stays listed
+// in `quotes` before it is dropped from memory.
+const quoteRetention = time.Minute
+
+// quoteRequestTimeout is how long an RFQ waits for a quote before it is
+// expired.
+const quoteRequestTimeout = time.Minute
+
+// scheduleQuoteRequestExpiry expires rfq if no quote has arrived for it
+// within quoteRequestTimeout.
+func (a *FixApp) scheduleQuoteRequestExpiry(rfq *Quote) {
+	quoteReqID := rfq.QuoteReqID
+	time.AfterFunc(time.Until(rfq.RequestedAt.Add(quoteRequestTimeout)), func() { a.expireQuote(quoteReqID) })
+}
+
+// scheduleQuoteExpiry marks quote's RFQ expired once its ValidUntilTime
+// passes, unless it has been accepted by then. A newer quote for the same
+// RFQ schedules its own expiry; the earlier timer then finds it still valid.
+func (a *FixApp) scheduleQuoteExpiry(quote *Quote) {
+	if quote.ValidUntilTime.IsZero() {
+		return
+	}
+	quoteReqID := quote.QuoteReqID
+	time.AfterFunc(time.Until(quote.ValidUntilTime), func() { a.expireQuote(quoteReqID) })
+}
+
+// expireQuote expires an RFQ whose quote is no longer valid.
+func (a *FixApp) expireQuote(quoteReqID string) {
+	quote, ok := a.OrderStore.ExpireQuote(quoteReqID, time.Now())
+	if !ok {
+		return
+	}
+	if quote.QuoteID == "" {
+		log.Printf("Quote request expired without a quote: %s (QuoteReqID: %s)", quote.Symbol, quote.QuoteReqID)
+	} else {
+		log.Printf("Quote expired: %s %s (QuoteReqID: %s, QuoteID: %s)",
+			quote.Symbol, formatQuotePrices(quote), quote.QuoteReqID, quote.QuoteID)
+	}
+	a.pruneQuoteLater(quoteReqID)
+}
+
+// pruneQuoteLater drops a finished RFQ from memory after quoteRetention.
+func (a *FixApp) pruneQuoteLater(quoteReqID string) {
+	time.AfterFunc(quoteRetention, func() { a.OrderStore.PruneQuote(quoteReqID) })
+}
+
+// pruneAcceptedQuote schedules the RFQ accepted by the order clOrdID for
+// pruning once that order is no longer open.
+func (a *FixApp) pruneAcceptedQuote(clOrdID string) {
+	order := a.OrderStore.ResolveOrder(clOrdID)
+	if order == nil || order.QuoteReqID == "" || isOpenStatus(order.OrdStatus) {
+		return
+	}
+	a.pruneQuoteLater(order.QuoteReqID)
+}
+
+// getRfqStatusDesc describes an RFQ's status; an accepted one includes the
+// status of the order that accepted it, e.g. "Accepted (Filled)".
+func getRfqStatusDesc(quote *Quote) string {
+	switch quote.Status {
+	case constants.RfqStatusRequested:
+		return "Requested"
+	case constants.RfqStatusQuoted:
+		return "Quoted"
+	case constants.RfqStatusRejected:
+		return "Rejected"
+	case constants.RfqStatusExpired:
+		return "Expired"
+	case constants.RfqStatusAccepted:
+		if quote.OrdStatus == "" {
+			return "Accepted"
+		}
+		return fmt.Sprintf("Accepted (%s)", getOrdStatusDesc(quote.OrdStatus))
+	case "":
+		return "Quoted"
+	default:
+		return quote.Status
+	}
+}
+
+// formatQuotePrices describes the sides of a quote, e.g. "bid 0.5@49999".
+func formatQuotePrices(quote *Quote) string {
+	switch {
+	case quote.BidPx != "" && quote.OfferPx != "":
+		return fmt.Sprintf("bid %s@%s / offer %s@%s", quote.BidSize, quote.BidPx, quote.OfferSize, quote.OfferPx)
+	case quote.BidPx != "":
+		return fmt.Sprintf("bid %s@%s", quote.BidSize, quote.BidPx)
+	case quote.OfferPx != "":
+		return fmt.Sprintf("offer %s@%s", quote.OfferSize, quote.OfferPx)
+	default:
+		return "no prices"
+	}
+}
+
+// formatCountdown formats the time left on a quote, e.g. "1m05s" or "9s".
+func formatCountdown(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,203 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"testing"
+	"time"
+
+	"prime-fix-md-go/constants"
+)
+
+// Tests for RFQ lifecycle tracking.
+
+// TestOrderStore_RfqLifecycle verifies an RFQ moving from requested to quoted
+// to accepted, and that the accepting order's execution reports update it.
+func TestOrderStore_RfqLifecycle(t *testing.T) {
+	store := NewOrderStore()
+	store.AddQuoteRequest(&Quote{QuoteReqID: "rfq-1", Symbol: "BTC-USD", Side: constants.SideBuy, OrderQty: "0.5"})
+	if rfq := store.GetQuote("rfq-1"); rfq.Status != constants.RfqStatusRequested || rfq.RequestedAt.IsZero() {
+		t.Fatalf("expected a requested RFQ, got %+v", rfq)
+	}
+
+	store.AddQuote(&Quote{QuoteReqID: "rfq-1", QuoteID: "q-1", Symbol: "BTC-USD", OfferPx: "50001", OfferSize: "0.5",
+		ValidUntilTime: time.Now().Add(time.Minute)})
+	rfq := store.GetQuote("rfq-1")
+	if rfq.Status != constants.RfqStatusQuoted || rfq.Side != constants.SideBuy || rfq.OrderQty != "0.5" || rfq.RequestedAt.IsZero() {
+		t.Fatalf("expected the quote to keep the request, got %+v", rfq)
+	}
+
+	store.AddOrder(&Order{ClOrdID: "acc-1", Symbol: "BTC-USD", OrdStatus: constants.OrdStatusPendingNew, QuoteReqID: "rfq-1"})
+	store.AcceptQuote("rfq-1", "acc-1")
+	store.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: "acc-1", OrderID: "ord-1", ExecID: "e1",
+		ExecType: constants.ExecTypeTrade, OrdStatus: constants.OrdStatusFilled, CumQty: "0.5", AvgPx: "50001"})
+
+	rfq = store.GetQuote("rfq-1")
+	if rfq.Status != constants.RfqStatusAccepted || rfq.ClOrdID != "acc-1" || rfq.OrderID != "ord-1" ||
+		rfq.OrdStatus != constants.OrdStatusFilled || rfq.AvgPx != "50001" {
+		t.Errorf("expected the RFQ to follow its order, got %+v", rfq)
+	}
+	if desc := getRfqStatusDesc(rfq); desc != "Accepted (Filled)" {
+		t.Errorf("unexpected status description %q", desc)
+	}
+	if _, expired := store.ExpireQuote("rfq-1", time.Now().Add(time.Hour)); expired {
+		t.Error("expected an accepted quote not to expire")
+	}
+}
+
+// TestOrderStore_ExpireQuote verifies that only quotes past ValidUntilTime
+// expire and that expired RFQs can then be pruned from memory.
+func TestOrderStore_ExpireQuote(t *testing.T) {
+	store := NewOrderStore()
+	validUntil := time.Now().Add(time.Second)
+	store.AddQuote(&Quote{QuoteReqID: "rfq-1", QuoteID: "q-1", BidPx: "49999", BidSize: "1", ValidUntilTime: validUntil})
+
+	if store.PruneQuote("rfq-1") {
+		t.Error("expected a live quote not to be pruned")
+	}
+	if _, expired := store.ExpireQuote("rfq-1", validUntil.Add(-time.Millisecond)); expired {
+		t.Error("expected the quote not to expire early")
+	}
+	if quote, expired := store.ExpireQuote("rfq-1", validUntil); !expired || quote.Status != constants.RfqStatusExpired {
+		t.Fatalf("expected the quote to expire, got %+v", quote)
+	}
+	if !store.PruneQuote("rfq-1") || store.GetQuote("rfq-1") != nil {
+		t.Error("expected the expired quote to be pruned")
+	}
+}
+
+// TestOrderStore_ExpireQuoteRequest verifies that an RFQ that never gets a
+// quote expires after quoteRequestTimeout and can then be pruned.
+func TestOrderStore_ExpireQuoteRequest(t *testing.T) {
+	store := NewOrderStore()
+	store.AddQuoteRequest(&Quote{QuoteReqID: "rfq-1", Symbol: "BTC-USD", Side: constants.SideBuy, OrderQty: "0.5"})
+	requestedAt := store.GetQuote("rfq-1").RequestedAt
+
+	if _, expired := store.ExpireQuote("rfq-1", requestedAt.Add(quoteRequestTimeout-time.Millisecond)); expired {
+		t.Error("expected the request not to expire early")
+	}
+	if store.PruneQuote("rfq-1") {
+		t.Error("expected a pending request not to be pruned")
+	}
+	if rfq, expired := store.ExpireQuote("rfq-1", requestedAt.Add(quoteRequestTimeout)); !expired || rfq.Status != constants.RfqStatusExpired {
+		t.Fatalf("expected the request to expire, got %+v", rfq)
+	}
+	if !store.PruneQuote("rfq-1") {
+		t.Error("expected the expired request to be pruned")
+	}
+}
+
+// TestOrderStore_QuoteRequestKeepsAnswer verifies that recording an RFQ
+// leaves an answer that is already tracked alone, and that an RFQ whose
+// request failed is only forgotten while it is still unanswered.
+func TestOrderStore_QuoteRequestKeepsAnswer(t *testing.T) {
+	store := NewOrderStore()
+	store.AddQuote(&Quote{QuoteReqID: "rfq-1", QuoteID: "q-1", Symbol: "BTC-USD", OfferPx: "50001", OfferSize: "0.5"})
+
+	if store.AddQuoteRequest(&Quote{QuoteReqID: "rfq-1", Symbol: "BTC-USD", Side: constants.SideBuy, OrderQty: "0.5"}) {
+		t.Error("expected an RFQ that is already tracked not to be added again")
+	}
+	store.RemoveQuoteRequest("rfq-1")
+	if rfq := store.GetQuote("rfq-1"); rfq == nil || rfq.Status != constants.RfqStatusQuoted || rfq.QuoteID != "q-1" {
+		t.Fatalf("expected the quote to be kept, got %+v", rfq)
+	}
+
+	store.AddQuoteRequest(&Quote{QuoteReqID: "rfq-2", Symbol: "BTC-USD", Side: constants.SideSell, OrderQty: "1"})
+	store.RemoveQuoteRequest("rfq-2")
+	if store.GetQuote("rfq-2") != nil {
+		t.Error("expected an unanswered RFQ to be forgotten")
+	}
+}
+
+// TestHandleRfqCommand_SendFailureForgetsRfq verifies that an RFQ is not left
+// behind when its Quote Request cannot be sent.
+func TestHandleRfqCommand_SendFailureForgetsRfq(t *testing.T) {
+	app := NewFixApp(&Config{}, nil)
+	app.handleRfqCommand([]string{"rfq", "buy", "BTC-USD", "1"})
+
+	if quotes := app.OrderStore.GetAllQuotes(); len(quotes) != 0 {
+		t.Errorf("expected no RFQ after a failed send, got %+v", quotes)
+	}
+}
+
+// TestOrderStore_PruneAcceptedQuote verifies that an accepted RFQ is kept
+// while its order is open and can be pruned once the order is done.
+func TestOrderStore_PruneAcceptedQuote(t *testing.T) {
+	store := NewOrderStore()
+	store.AddQuote(&Quote{QuoteReqID: "rfq-1", QuoteID: "q-1", Symbol: "BTC-USD", OfferPx: "50001", OfferSize: "0.5",
+		ValidUntilTime: time.Now().Add(time.Minute)})
+	store.AddOrder(&Order{ClOrdID: "acc-1", Symbol: "BTC-USD", OrdStatus: constants.OrdStatusPendingNew, QuoteReqID: "rfq-1"})
+	store.AcceptQuote("rfq-1", "acc-1")
+
+	store.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: "acc-1", OrderID: "ord-1", ExecID: "e1",
+		ExecType: constants.ExecTypeNew, OrdStatus: constants.OrdStatusNew})
+	if store.PruneQuote("rfq-1") {
+		t.Error("expected an RFQ with an open order not to be pruned")
+	}
+
+	store.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: "acc-1", OrderID: "ord-1", ExecID: "e2",
+		ExecType: constants.ExecTypeTrade, OrdStatus: constants.OrdStatusFilled, CumQty: "0.5", AvgPx: "50001"})
+	if !store.PruneQuote("rfq-1") || store.GetQuote("rfq-1") != nil {
+		t.Error("expected the RFQ of a filled order to be pruned")
+	}
+}
+
+// TestHandleQuote_ExpiresInBackground verifies that a received quote is
+// expired once its ValidUntilTime passes.
+func TestHandleQuote_ExpiresInBackground(t *testing.T) {
+	app := NewFixApp(&Config{}, nil)
+	validUntil := time.Now().UTC().Add(50 * time.Millisecond).Format(constants.FixTimeFormat)
+	app.handleQuote(parseFixMessage(t, "35=S\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01"+
+		"117=q-1\x01131=rfq-1\x0155=BTC-USD\x01132=49999\x01134=1\x0162="+validUntil+"\x01"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for app.OrderStore.GetQuote("rfq-1").Status != constants.RfqStatusExpired {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the quote to expire, got %+v", app.OrderStore.GetQuote("rfq-1"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestHandleQuoteAck_RejectsRfq verifies that a Quote Acknowledgement marks
+// the RFQ it answers rejected with its reason.
+func TestHandleQuoteAck_RejectsRfq(t *testing.T) {
+	app := NewFixApp(&Config{}, nil)
+	app.OrderStore.AddQuoteRequest(&Quote{QuoteReqID: "rfq-1", Symbol: "BTC-USD", Side: constants.SideSell, OrderQty: "2"})
+
+	app.handleQuoteAck(parseFixMessage(t, "35=b\x0149=COIN\x0156=CLIENT\x0134=2\x0152=20250101-12:00:00.000\x01"+
+		"131=rfq-1\x0155=BTC-USD\x01297=5\x01300=99\x0158=No liquidity\x01"))
+
+	rfq := app.OrderStore.GetQuote("rfq-1")
+	if rfq.Status != constants.RfqStatusRejected || rfq.RejectReason != "99" || rfq.Text != "No liquidity" || rfq.OrderQty != "2" {
+		t.Errorf("expected a rejected RFQ that keeps its request, got %+v", rfq)
+	}
+}
+
+// TestFormatCountdown verifies the time-left format in `quotes`.
+func TestFormatCountdown(t *testing.T) {
+	tests := map[time.Duration]string{
+		9*time.Second + 900*time.Millisecond: "9s",
+		65 * time.Second:                     "1m05s",
+		10 * time.Minute:                     "10m00s",
+	}
+	for d, want := range tests {
+		if got := formatCountdown(d); got != want {
+			t.Errorf("formatCountdown(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
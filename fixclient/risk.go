@@ -0,0 +1,357 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"prime-fix-md-go/builder"
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
+)
+
+// RiskCheck is one pre-trade check applied to a new order before it is sent.
+// Check returns a non-nil error describing why the order must not be sent.
+type RiskCheck interface {
+	Name() string
+	Check(params builder.NewOrderParams) error
+}
+
+// RiskChain runs its checks in order and stops at the first rejection.
+type RiskChain []RiskCheck
+
+// RiskRejection is returned by RiskChain.Check when an order fails a check.
+type RiskRejection struct {
+	Check  string
+	Reason error
+}
+
+func (r *RiskRejection) Error() string {
+	return fmt.Sprintf("%s: %v", r.Check, r.Reason)
+}
+
+func (r *RiskRejection) Unwrap() error {
+	return r.Reason
+}
+
+// Check returns a *RiskRejection for the first check params fails, or nil.
+// An empty chain accepts every order.
+func (c RiskChain) Check(params builder.NewOrderParams) error {
+	for _, check := range c {
+		if err := check.Check(params); err != nil {
+			return &RiskRejection{Check: check.Name(), Reason: err}
+		}
+	}
+	return nil
+}
+
+// RiskConfig holds the pre-trade limits loaded from a JSON file. Zero or
+// empty fields disable their check. Limits are read as decimals, from JSON
+// numbers or strings, and compared with orders exactly.
+//
+// Example:
+//
+//	{
+//	  "allowedSymbols": ["BTC-USD", "ETH-USD"],
+//	  "maxNotional": 100000,
+//	  "maxQuantity": {"BTC-USD": 2, "ETH-USD": 50},
+//	  "priceCollarPct": 5,
+//	  "maxOpenOrders": 20
+//	}
+type RiskConfig struct {
+	AllowedSymbols []string                   `json:"allowedSymbols"`
+	MaxNotional    decimal.Decimal            `json:"maxNotional"`    // Quote currency per order
+	MaxQuantity    map[string]decimal.Decimal `json:"maxQuantity"`    // Base units per order, by symbol
+	PriceCollarPct decimal.Decimal            `json:"priceCollarPct"` // Percent through the best bid/offer
+	MaxOpenOrders  int                        `json:"maxOpenOrders"`
+}
+
+// LoadRiskConfig reads and validates a risk limits file.
+func LoadRiskConfig(path string) (*RiskConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk config: %v", err)
+	}
+
+	var cfg RiskConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse risk config: %v", err)
+	}
+
+	if cfg.MaxNotional.Sign() < 0 || cfg.PriceCollarPct.Sign() < 0 || cfg.MaxOpenOrders < 0 {
+		return nil, fmt.Errorf("risk config %s: limits must not be negative", path)
+	}
+	for symbol, max := range cfg.MaxQuantity {
+		if max.Sign() <= 0 {
+			return nil, fmt.Errorf("risk config %s: maxQuantity for %s must be positive", path, symbol)
+		}
+	}
+	return &cfg, nil
+}
+
+// Chain builds the checks enabled by cfg. Checks that need live state read it
+// from orders and book when an order is checked.
+func (cfg *RiskConfig) Chain(orders *OrderStore, book *OrderBook) RiskChain {
+	var chain RiskChain
+	if len(cfg.AllowedSymbols) > 0 {
+		allowed := make(map[string]bool, len(cfg.AllowedSymbols))
+		for _, symbol := range cfg.AllowedSymbols {
+			allowed[symbol] = true
+		}
+		chain = append(chain, &symbolAllowListCheck{allowed: allowed})
+	}
+	if len(cfg.MaxQuantity) > 0 {
+		chain = append(chain, &maxQuantityCheck{limits: cfg.MaxQuantity})
+	}
+	if cfg.MaxNotional.Sign() > 0 {
+		chain = append(chain, &maxNotionalCheck{limit: cfg.MaxNotional, book: book})
+	}
+	if cfg.PriceCollarPct.Sign() > 0 {
+		chain = append(chain, &priceCollarCheck{pct: cfg.PriceCollarPct, book: book})
+	}
+	if cfg.MaxOpenOrders > 0 {
+		chain = append(chain, &maxOpenOrdersCheck{limit: cfg.MaxOpenOrders, orders: orders})
+	}
+	return chain
+}
+
+// symbolAllowListCheck rejects symbols that are not explicitly allowed.
+type symbolAllowListCheck struct {
+	allowed map[string]bool
+}
+
+func (c *symbolAllowListCheck) Name() string { return "symbol allow-list" }
+
+func (c *symbolAllowListCheck) Check(params builder.NewOrderParams) error {
+	if !c.allowed[params.Symbol] {
+		return fmt.Errorf("%s is not an allowed symbol", params.Symbol)
+	}
+	return nil
+}
+
+// maxQuantityCheck limits the base quantity of a single order per symbol.
+// Symbols without a limit and cash orders, which have no base quantity until
+// they execute, are not checked.
+type maxQuantityCheck struct {
+	limits map[string]decimal.Decimal
+}
+
+func (c *maxQuantityCheck) Name() string { return "max quantity" }
+
+func (c *maxQuantityCheck) Check(params builder.NewOrderParams) error {
+	limit, ok := c.limits[params.Symbol]
+	if !ok || params.OrderQty == "" {
+		return nil
+	}
+	qty, err := parsePositive("quantity", params.OrderQty)
+	if err != nil {
+		return err
+	}
+	if qty.Cmp(limit) > 0 {
+		return fmt.Errorf("quantity %s exceeds the %s limit of %s", params.OrderQty, params.Symbol, limit)
+	}
+	return nil
+}
+
+// maxNotionalCheck limits the quote-currency value of a single order. Orders
+// without a limit price are valued at the best opposite price in the live
+// book and are rejected when there is none.
+type maxNotionalCheck struct {
+	limit decimal.Decimal
+	book  *OrderBook
+}
+
+func (c *maxNotionalCheck) Name() string { return "max notional" }
+
+func (c *maxNotionalCheck) Check(params builder.NewOrderParams) error {
+	notional, err := orderNotional(params, c.book)
+	if err != nil {
+		return err
+	}
+	if notional.Cmp(c.limit) > 0 {
+		return fmt.Errorf("notional %s exceeds the limit of %s", notional, c.limit)
+	}
+	return nil
+}
+
+// priceCollarCheck rejects limit prices more than pct percent through the
+// best price on the other side of the live book: above the best offer for a
+// buy, below the best bid for a sell. Orders without a price are not checked,
+// nor are stop orders, whose protective stops are meant to sit away from the
+// market.
+type priceCollarCheck struct {
+	pct  decimal.Decimal
+	book *OrderBook
+}
+
+func (c *priceCollarCheck) Name() string { return "price collar" }
+
+func (c *priceCollarCheck) Check(params builder.NewOrderParams) error {
+	if params.Price == "" || isStopOrder(params) {
+		return nil
+	}
+	price, err := parsePositive("price", params.Price)
+	if err != nil {
+		return err
+	}
+	reference, ok := bestOppositePrice(c.book, params.Symbol, params.Side)
+	if !ok {
+		return fmt.Errorf("no live %s price for %s; subscribe to its book with md %s --subscribe --depth 1",
+			oppositeSideName(params.Side), params.Symbol, params.Symbol)
+	}
+
+	band, err := percentOf(reference, c.pct)
+	if err != nil {
+		return err
+	}
+
+	if params.Side == constants.SideSell {
+		floor, err := reference.Sub(band)
+		if err != nil {
+			return err
+		}
+		if price.Cmp(floor) < 0 {
+			return fmt.Errorf("sell price %s is more than %s%% below the best bid %s",
+				params.Price, c.pct, reference)
+		}
+		return nil
+	}
+	ceiling, err := reference.Add(band)
+	if err != nil {
+		return err
+	}
+	if price.Cmp(ceiling) > 0 {
+		return fmt.Errorf("buy price %s is more than %s%% above the best offer %s",
+			params.Price, c.pct, reference)
+	}
+	return nil
+}
+
+// isStopOrder reports whether params is a stop or stop-limit order.
+func isStopOrder(params builder.NewOrderParams) bool {
+	return params.OrdType == constants.OrdTypeStop || params.OrdType == constants.OrdTypeStopLimit || params.StopPx != ""
+}
+
+// maxOpenOrdersCheck limits how many orders can be open at once.
+type maxOpenOrdersCheck struct {
+	limit  int
+	orders *OrderStore
+}
+
+func (c *maxOpenOrdersCheck) Name() string { return "max open orders" }
+
+func (c *maxOpenOrdersCheck) Check(params builder.NewOrderParams) error {
+	open := c.orders.GetOpenOrders()
+	for _, order := range open {
+		if order.ClOrdID == params.ClOrdID {
+			return nil // A replace, which does not open another order
+		}
+	}
+	if len(open) >= c.limit {
+		return fmt.Errorf("%d orders already open (limit %d)", len(open), c.limit)
+	}
+	return nil
+}
+
+// replaceCheckParams describes a replace of order for the risk checks: the
+// new quantity and price on the order's symbol and side, under the order's
+// own ClOrdID so that it is not counted as another open order.
+func replaceCheckParams(order *Order, qty, price string) builder.NewOrderParams {
+	params := builder.NewOrderParams{
+		Account:        order.Account,
+		ClOrdID:        order.ClOrdID,
+		Symbol:         order.Symbol,
+		Side:           order.Side,
+		OrdType:        order.OrdType,
+		TargetStrategy: order.TargetStrategy,
+		TimeInForce:    order.TimeInForce,
+		OrderQty:       qty,
+		Price:          price,
+		StopPx:         order.StopPx,
+	}
+	if qty == "" {
+		params.CashOrderQty = order.CashOrderQty
+	}
+	return params
+}
+
+// orderNotional values an order in quote currency: the cash quantity for cash
+// orders, otherwise the quantity at the limit price or, without one, at the
+// best opposite price. The product is exact.
+func orderNotional(params builder.NewOrderParams, book *OrderBook) (decimal.Decimal, error) {
+	if params.CashOrderQty != "" {
+		return parsePositive("cash quantity", params.CashOrderQty)
+	}
+	qty, err := parsePositive("quantity", params.OrderQty)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	var price decimal.Decimal
+	if params.Price != "" {
+		if price, err = parsePositive("price", params.Price); err != nil {
+			return decimal.Decimal{}, err
+		}
+	} else {
+		var ok bool
+		if price, ok = bestOppositePrice(book, params.Symbol, params.Side); !ok {
+			return decimal.Decimal{}, fmt.Errorf("no live %s price to value a %s order without a price",
+				oppositeSideName(params.Side), params.Symbol)
+		}
+	}
+	notional, err := decimal.Notional(price, qty, productScale(price, qty))
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("notional of %s at %s: %w", qty, price, err)
+	}
+	return notional, nil
+}
+
+// bestOppositePrice returns the price a side trades against: the best offer
+// for a buy, the best bid for a sell.
+func bestOppositePrice(book *OrderBook, symbol, side string) (decimal.Decimal, bool) {
+	bid, offer, hasBid, hasOffer := book.BestBidOffer(symbol)
+	if side == constants.SideSell {
+		return bid.PriceVal, hasBid
+	}
+	return offer.PriceVal, hasOffer
+}
+
+func oppositeSideName(side string) string {
+	if side == constants.SideSell {
+		return "bid"
+	}
+	return "offer"
+}
+
+func parsePositive(name, value string) (decimal.Decimal, error) {
+	v, err := decimal.Parse(value)
+	if err != nil || v.Sign() <= 0 {
+		return decimal.Decimal{}, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return v, nil
+}
+
+// percentOf returns pct percent of v exactly. Dividing pct by 100 only moves
+// its decimal point.
+func percentOf(v, pct decimal.Decimal) (decimal.Decimal, error) {
+	if pct.Scale()+2 > decimal.MaxScale {
+		return decimal.Decimal{}, fmt.Errorf("percent %s has too many decimals", pct)
+	}
+	fraction := decimal.New(pct.Units(), pct.Scale()+2)
+	return v.Mul(fraction, productScale(v, fraction))
+}
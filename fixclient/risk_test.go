@@ -0,0 +1,191 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prime-fix-md-go/builder"
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
+)
+
+// Tests for the pre-trade risk checks.
+
+func newRiskTestChain(t *testing.T) (RiskChain, *OrderStore) {
+	t.Helper()
+	cfg := &RiskConfig{
+		AllowedSymbols: []string{"BTC-USD", "ETH-USD"},
+		MaxNotional:    decimal.New(100000, 0),
+		MaxQuantity:    map[string]decimal.Decimal{"BTC-USD": decimal.New(2, 0)},
+		PriceCollarPct: decimal.New(5, 0),
+		MaxOpenOrders:  2,
+	}
+	orders := NewOrderStore()
+	book := NewOrderBook()
+	book.ApplySnapshot("BTC-USD", []Trade{
+		{EntryType: constants.MdEntryTypeBid, Price: "50000", Size: "1"},
+		{EntryType: constants.MdEntryTypeOffer, Price: "50010", Size: "1"},
+	})
+	return cfg.Chain(orders, book), orders
+}
+
+func limitOrder(symbol, side, qty, price string) builder.NewOrderParams {
+	return builder.NewOrderParams{Symbol: symbol, Side: side, OrdType: constants.OrdTypeLimit, OrderQty: qty, Price: price}
+}
+
+// TestRiskChain_RejectsWithReason verifies that each configured limit rejects
+// an order that breaches it and names the failing check.
+func TestRiskChain_RejectsWithReason(t *testing.T) {
+	chain, _ := newRiskTestChain(t)
+	tests := []struct {
+		name   string
+		params builder.NewOrderParams
+		check  string
+		reason string
+	}{
+		{"symbol not allowed", limitOrder("SOL-USD", constants.SideBuy, "1", "100"), "symbol allow-list", "SOL-USD is not an allowed symbol"},
+		{"quantity over limit", limitOrder("BTC-USD", constants.SideBuy, "2.5", "50000"), "max quantity", "quantity 2.5 exceeds the BTC-USD limit of 2"},
+		{"notional over limit", limitOrder("BTC-USD", constants.SideBuy, "2", "50005"), "max notional", "notional 100010 exceeds the limit of 100000"},
+		{"cash notional over limit", builder.NewOrderParams{Symbol: "ETH-USD", Side: constants.SideBuy, OrdType: constants.OrdTypeMarket, CashOrderQty: "150000"}, "max notional", "notional 150000"},
+		{"buy through the offer", limitOrder("BTC-USD", constants.SideBuy, "1", "53000"), "price collar", "more than 5% above the best offer 50010"},
+		{"sell through the bid", limitOrder("BTC-USD", constants.SideSell, "1", "47000"), "price collar", "more than 5% below the best bid 50000"},
+		{"no market data", limitOrder("ETH-USD", constants.SideBuy, "1", "3000"), "price collar", "no live offer price for ETH-USD"},
+		{"market order without book", builder.NewOrderParams{Symbol: "ETH-USD", Side: constants.SideSell, OrdType: constants.OrdTypeMarket, OrderQty: "1"}, "max notional", "no live bid price"},
+		{"invalid quantity", limitOrder("BTC-USD", constants.SideBuy, "abc", "50000"), "max quantity", `invalid quantity "abc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := chain.Check(tt.params)
+			var rejection *RiskRejection
+			if !errors.As(err, &rejection) {
+				t.Fatalf("expected a RiskRejection, got %v", err)
+			}
+			if rejection.Check != tt.check || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("got %q, want check %q with reason containing %q", err, tt.check, tt.reason)
+			}
+		})
+	}
+}
+
+// TestRiskChain_AcceptsWithinLimits verifies that orders inside every limit
+// pass, including market orders valued at the live book and stop-limits
+// priced away from the market, and that the open order limit counts only open
+// orders.
+func TestRiskChain_AcceptsWithinLimits(t *testing.T) {
+	chain, orders := newRiskTestChain(t)
+
+	for _, params := range []builder.NewOrderParams{
+		limitOrder("BTC-USD", constants.SideBuy, "1", "52000"),
+		limitOrder("BTC-USD", constants.SideSell, "2", "48000"),
+		{Symbol: "BTC-USD", Side: constants.SideBuy, OrdType: constants.OrdTypeMarket, OrderQty: "1.5"},
+		{Symbol: "ETH-USD", Side: constants.SideBuy, OrdType: constants.OrdTypeMarket, CashOrderQty: "5000"},
+		{Symbol: "BTC-USD", Side: constants.SideSell, OrdType: constants.OrdTypeStopLimit, OrderQty: "1", Price: "40000", StopPx: "40500"},
+	} {
+		if err := chain.Check(params); err != nil {
+			t.Errorf("expected %+v to pass, got %v", params, err)
+		}
+	}
+
+	orders.AddOrder(&Order{ClOrdID: "ord-1", OrdStatus: constants.OrdStatusNew})
+	orders.AddOrder(&Order{ClOrdID: "ord-2", OrdStatus: constants.OrdStatusFilled})
+	if err := chain.Check(limitOrder("BTC-USD", constants.SideBuy, "1", "50000")); err != nil {
+		t.Errorf("expected a filled order not to count as open, got %v", err)
+	}
+	orders.AddOrder(&Order{ClOrdID: "ord-3", OrdStatus: constants.OrdStatusPartiallyFilled})
+	err := chain.Check(limitOrder("BTC-USD", constants.SideBuy, "1", "50000"))
+	if err == nil || !strings.Contains(err.Error(), "max open orders: 2 orders already open (limit 2)") {
+		t.Errorf("expected open order limit rejection, got %v", err)
+	}
+
+	if err := (RiskChain)(nil).Check(limitOrder("ANY", constants.SideBuy, "1e9", "1")); err != nil {
+		t.Errorf("expected an empty chain to accept everything, got %v", err)
+	}
+}
+
+// TestRiskChain_ChecksReplace verifies that a replace is checked with its new
+// quantity and price and does not count as another open order.
+func TestRiskChain_ChecksReplace(t *testing.T) {
+	chain, orders := newRiskTestChain(t)
+	working := &Order{ClOrdID: "ord-1", Symbol: "BTC-USD", Side: constants.SideBuy, OrdType: constants.OrdTypeLimit,
+		OrderQty: "1", Price: "50000", OrdStatus: constants.OrdStatusNew}
+	orders.AddOrder(working)
+	orders.AddOrder(&Order{ClOrdID: "ord-2", OrdStatus: constants.OrdStatusNew})
+
+	if err := chain.Check(replaceCheckParams(working, "1.5", "50005")); err != nil {
+		t.Errorf("expected a replace within limits to pass at the open order limit, got %v", err)
+	}
+	err := chain.Check(replaceCheckParams(working, "3", "50000"))
+	if err == nil || !strings.Contains(err.Error(), "max quantity: quantity 3 exceeds the BTC-USD limit of 2") {
+		t.Errorf("expected the new quantity to be checked, got %v", err)
+	}
+	err = chain.Check(replaceCheckParams(working, "1", "53000"))
+	if err == nil || !strings.Contains(err.Error(), "price collar") {
+		t.Errorf("expected the new price to be checked, got %v", err)
+	}
+}
+
+// TestLoadRiskConfig verifies that a config is parsed, unset limits disable
+// their checks, limits are compared exactly, and negative limits are
+// rejected.
+func TestLoadRiskConfig(t *testing.T) {
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "risk.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		return path
+	}
+
+	cfg, err := LoadRiskConfig(write(`{"maxNotional": 25000, "maxOpenOrders": 5}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chain := cfg.Chain(NewOrderStore(), NewOrderBook())
+	if len(chain) != 2 || chain[0].Name() != "max notional" || chain[1].Name() != "max open orders" {
+		t.Errorf("expected notional and open order checks, got %d checks", len(chain))
+	}
+
+	// 0.1 × 3 is 0.30000000000000004 in float64
+	cfg, err = LoadRiskConfig(write(`{"maxNotional": 0.3, "maxQuantity": {"BTC-USD": "0.1"}, "priceCollarPct": 0.1}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	book := NewOrderBook()
+	book.ApplySnapshot("BTC-USD", []Trade{{EntryType: constants.MdEntryTypeOffer, Price: "3", Size: "1"}})
+	chain = cfg.Chain(NewOrderStore(), book)
+	if err := chain.Check(limitOrder("BTC-USD", constants.SideBuy, "0.1", "3")); err != nil {
+		t.Errorf("expected an order exactly at the limits to pass, got %v", err)
+	}
+	if err := chain.Check(limitOrder("BTC-USD", constants.SideBuy, "0.1", "3.001")); err == nil {
+		t.Error("expected an order just over the limits to be rejected")
+	}
+
+	for _, content := range []string{
+		`{"maxNotional": -1}`,
+		`{"maxQuantity": {"BTC-USD": 0}}`,
+		`{"maxOpenOrders": "ten"}`,
+	} {
+		if _, err := LoadRiskConfig(write(content)); err == nil {
+			t.Errorf("expected error for %s", content)
+		}
+	}
+}
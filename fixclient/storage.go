@@ -17,19 +17,29 @@
 package fixclient
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log"
 	"strconv"
 	"time"
 
 	"prime-fix-md-go/constants"
+	"prime-fix-md-go/database"
 )
 
+// storeTradesToDatabase persists one market data message. With a DbWriter the
+// write is queued and batched off the hot path; otherwise it is written
+// synchronously in its own transaction.
 func (a *FixApp) storeTradesToDatabase(trades []Trade, seqNum string, isSnapshot bool) {
 	if a.Db == nil {
 		return
 	}
 
-	seqNumInt, _ := strconv.Atoi(seqNum)
+	if a.DbWriter != nil {
+		a.DbWriter.Enqueue(trades, seqNum, isSnapshot)
+		return
+	}
 
 	tx, err := a.Db.BeginTransaction()
 	if err != nil {
@@ -38,47 +48,253 @@ func (a *FixApp) storeTradesToDatabase(trades []Trade, seqNum string, isSnapshot
 	}
 	defer tx.Rollback()
 
+	if err = storeTradesInTx(a.Db, tx, trades, seqNum, isSnapshot); err != nil {
+		log.Printf("Database write failed: %v", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit database transaction: %v", err)
+	}
+}
+
+// writeBatchesToDatabase writes several messages in a single transaction.
+// Used by DbWriter; any failure rolls back the whole batch.
+func writeBatchesToDatabase(db *database.MarketDataDb, batches []dbBatch) error {
+	tx, err := db.BeginTransaction()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, b := range batches {
+		if err := storeTradesInTx(db, tx, b.Trades, b.SeqNum, b.IsSnapshot); err != nil {
+			return err
+		}
+		if err := storeCandlesInTx(db, tx, b.Candles); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit database transaction: %v", err)
+	}
+	return nil
+}
+
+// storeTradesInTx writes the entries of one message within tx.
+func storeTradesInTx(db *database.MarketDataDb, tx *sql.Tx, trades []Trade, seqNum string, isSnapshot bool) error {
+	seqNumInt, _ := strconv.Atoi(seqNum)
+
+	var err error
 	for _, trade := range trades {
 		switch trade.EntryType {
 		case constants.MdEntryTypeBid: // "0"
 			posInt, _ := strconv.Atoi(trade.Position)
-			err = a.Db.StoreOrderBookBatch(tx, trade.Symbol, "bid", trade.Price, trade.Size,
+			err = db.StoreOrderBookBatch(tx, trade.Symbol, "bid", trade.Price, trade.Size,
 				posInt, seqNumInt, trade.MdReqId, isSnapshot)
 		case constants.MdEntryTypeOffer: // "1"
 			posInt, _ := strconv.Atoi(trade.Position)
-			err = a.Db.StoreOrderBookBatch(tx, trade.Symbol, "offer", trade.Price, trade.Size,
+			err = db.StoreOrderBookBatch(tx, trade.Symbol, "offer", trade.Price, trade.Size,
 				posInt, seqNumInt, trade.MdReqId, isSnapshot)
 		case constants.MdEntryTypeTrade: // "2"
-			err = a.Db.StoreTradeBatch(tx, trade.Symbol, trade.Price, trade.Size,
+			err = db.StoreTradeBatch(tx, trade.Symbol, trade.Price, trade.Size,
 				trade.Aggressor, trade.Time, seqNumInt, trade.MdReqId, isSnapshot)
 		case constants.MdEntryTypeOpen: // "4"
-			err = a.Db.StoreOhlcvBatch(tx, trade.Symbol, "open", trade.Price, trade.Time,
+			err = db.StoreOhlcvBatch(tx, trade.Symbol, "open", trade.Price, trade.Time,
 				seqNumInt, trade.MdReqId)
 		case constants.MdEntryTypeClose: // "5"
-			err = a.Db.StoreOhlcvBatch(tx, trade.Symbol, "close", trade.Price, trade.Time,
+			err = db.StoreOhlcvBatch(tx, trade.Symbol, "close", trade.Price, trade.Time,
 				seqNumInt, trade.MdReqId)
 		case constants.MdEntryTypeHigh: // "7"
-			err = a.Db.StoreOhlcvBatch(tx, trade.Symbol, "high", trade.Price, trade.Time,
+			err = db.StoreOhlcvBatch(tx, trade.Symbol, "high", trade.Price, trade.Time,
 				seqNumInt, trade.MdReqId)
 		case constants.MdEntryTypeLow: // "8"
-			err = a.Db.StoreOhlcvBatch(tx, trade.Symbol, "low", trade.Price, trade.Time,
+			err = db.StoreOhlcvBatch(tx, trade.Symbol, "low", trade.Price, trade.Time,
 				seqNumInt, trade.MdReqId)
 		case constants.MdEntryTypeVolume: // "B"
-			err = a.Db.StoreOhlcvBatch(tx, trade.Symbol, "volume", trade.Size, trade.Time,
+			err = db.StoreOhlcvBatch(tx, trade.Symbol, "volume", trade.Size, trade.Time,
 				seqNumInt, trade.MdReqId)
 		}
 
 		if err != nil {
-			log.Printf("Failed to store %s data to database: %v", getMdEntryTypeName(trade.EntryType), err)
-			return
+			return fmt.Errorf("failed to store %s data to database: %v", getMdEntryTypeName(trade.EntryType), err)
 		}
 	}
+	return nil
+}
+
+// storeCandlesToDatabase persists closed bars, through the DbWriter when
+// there is one. It is the emit function of the app's CandleAggregator.
+func (a *FixApp) storeCandlesToDatabase(candles []Candle) {
+	if a.Db == nil {
+		return
+	}
+
+	if a.DbWriter != nil {
+		a.DbWriter.EnqueueCandles(candles)
+		return
+	}
+
+	tx, err := a.Db.BeginTransaction()
+	if err != nil {
+		log.Printf("Failed to begin database transaction: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err = storeCandlesInTx(a.Db, tx, candles); err != nil {
+		log.Printf("Database write failed: %v", err)
+		return
+	}
 
 	if err = tx.Commit(); err != nil {
 		log.Printf("Failed to commit database transaction: %v", err)
 	}
 }
 
+// storeCandlesInTx writes candles within tx, replacing any earlier version of
+// the same bar.
+func storeCandlesInTx(db *database.MarketDataDb, tx *sql.Tx, candles []Candle) error {
+	for i := range candles {
+		c := &candles[i]
+		err := db.SaveCandleBatch(tx, database.CandleRecord{
+			Symbol:     c.Symbol,
+			Interval:   candleIntervalName(c.Interval),
+			Start:      c.Start,
+			Open:       c.Open,
+			High:       c.High,
+			Low:        c.Low,
+			Close:      c.Close,
+			Volume:     c.Volume,
+			VWAP:       c.VWAP,
+			TradeCount: c.Trades,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store %s %s candle to database: %v", c.Symbol, candleIntervalName(c.Interval), err)
+		}
+	}
+	return nil
+}
+
+// storeFillToDatabase writes a fill to the fills table. Fills are rare
+// compared to market data, so they are written synchronously rather than
+// through DbWriter. It returns false only if the table already holds the
+// ExecID; a fill that could not be stored is still new.
+func (a *FixApp) storeFillToDatabase(fill Fill) bool {
+	if a.Db == nil {
+		return true
+	}
+
+	record := database.FillRecord{
+		ExecID:       fill.ExecID,
+		ClOrdID:      fill.ClOrdID,
+		OrderID:      fill.OrderID,
+		Symbol:       fill.Symbol,
+		Side:         fill.Side,
+		LastPx:       fill.LastPx,
+		LastShares:   fill.LastShares,
+		Commission:   fill.Commission,
+		TransactTime: fill.TransactTime,
+	}
+	if len(fill.MiscFees) > 0 {
+		fees, err := json.Marshal(fill.MiscFees)
+		if err != nil {
+			log.Printf("Failed to encode fees for fill %s: %v", fill.ExecID, err)
+		} else {
+			record.MiscFees = string(fees)
+		}
+	}
+
+	inserted, err := a.Db.StoreFill(record)
+	if err != nil {
+		log.Printf("Failed to store fill %s: %v", fill.ExecID, err)
+		return true
+	}
+	return inserted
+}
+
+// loadFills returns the fills stored by previous runs, oldest first.
+func loadFills(db *database.MarketDataDb) ([]Fill, error) {
+	records, err := db.GetFills("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	fills := make([]Fill, len(records))
+	for i, r := range records {
+		fills[i] = Fill{
+			ExecID:       r.ExecID,
+			ClOrdID:      r.ClOrdID,
+			OrderID:      r.OrderID,
+			Symbol:       r.Symbol,
+			Side:         r.Side,
+			LastPx:       r.LastPx,
+			LastShares:   r.LastShares,
+			Commission:   r.Commission,
+			TransactTime: r.TransactTime,
+		}
+		if r.MiscFees != "" {
+			if err := json.Unmarshal([]byte(r.MiscFees), &fills[i].MiscFees); err != nil {
+				log.Printf("Ignoring unreadable fees for fill %s: %v", r.ExecID, err)
+			}
+		}
+	}
+	return fills, nil
+}
+
+// storePositionSnapshot appends pos, valued at the current mark price, to the
+// position_snapshots table.
+func (a *FixApp) storePositionSnapshot(pos Position) {
+	if a.Db == nil {
+		return
+	}
+
+	snapshot := database.PositionSnapshot{
+		Symbol:      pos.Symbol,
+		NetQty:      pos.NetQty,
+		AvgCost:     pos.AvgCost,
+		RealizedPnL: pos.RealizedPnL,
+		Fees:        pos.Fees,
+		BoughtQty:   pos.BoughtQty,
+		SoldQty:     pos.SoldQty,
+		SnapshotAt:  pos.UpdatedAt,
+	}
+	if mark, _, ok := a.markPrice(pos.Symbol); ok {
+		snapshot.MarkPrice = &mark
+		if unrealized, err := pos.UnrealizedPnL(mark); err == nil {
+			snapshot.UnrealizedPnL = &unrealized
+		}
+	}
+
+	if err := a.Db.StorePositionSnapshot(snapshot); err != nil {
+		log.Printf("Failed to store %s position snapshot: %v", pos.Symbol, err)
+	}
+}
+
+// loadPositions returns the latest stored snapshot of each position.
+func loadPositions(db *database.MarketDataDb) ([]Position, error) {
+	snapshots, err := db.LoadLatestPositions()
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]Position, len(snapshots))
+	for i, s := range snapshots {
+		positions[i] = Position{
+			Symbol:      s.Symbol,
+			NetQty:      s.NetQty,
+			AvgCost:     s.AvgCost,
+			RealizedPnL: s.RealizedPnL,
+			Fees:        s.Fees,
+			BoughtQty:   s.BoughtQty,
+			SoldQty:     s.SoldQty,
+			UpdatedAt:   s.SnapshotAt,
+		}
+	}
+	return positions, nil
+}
+
 func (a *FixApp) createDatabaseSession(symbol, subscriptionType, marketDepth string, entryTypes []string, reqId string) {
 	if a.Db == nil {
 		return
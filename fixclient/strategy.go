@@ -0,0 +1,656 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/database"
+	"prime-fix-md-go/decimal"
+)
+
+// trailPersistInterval bounds how often a trailing stop's anchor is written
+// to storage as the price moves. Triggers and status changes are written
+// immediately; after a restart the anchor may lag by up to this interval,
+// which only loosens the stop.
+const trailPersistInterval = time.Second
+
+// lastStrategyId holds the last nanosecond timestamp used for a strategy ID.
+var lastStrategyId atomic.Int64
+
+// StrategyLeg is one child order of a Strategy. A leg gets its ClOrdID, the
+// strategy ID plus its role, when it is due to be sent, so a leg is never
+// sent twice under different IDs.
+type StrategyLeg struct {
+	Role       string `json:"role"` // constants.LegRole*
+	ClOrdID    string `json:"clOrdId,omitempty"`
+	Side       string `json:"side"`
+	OrdType    string `json:"ordType"`
+	OrderQty   string `json:"orderQty,omitempty"` // Bracket exits: set from the entry's fill
+	Price      string `json:"price,omitempty"`
+	StopPx     string `json:"stopPx,omitempty"`
+	Sent       bool   `json:"sent,omitempty"`
+	CancelSent bool   `json:"cancelSent,omitempty"`
+	OrdStatus  string `json:"ordStatus,omitempty"` // Last status seen for the order
+	CumQty     string `json:"cumQty,omitempty"`
+}
+
+func (l *StrategyLeg) final() bool {
+	return isFinalStatus(l.OrdStatus)
+}
+
+func (l *StrategyLeg) filled() bool {
+	return parseDecimalOrZero(l.CumQty).Sign() > 0
+}
+
+// working reports whether the leg was sent and may still trade.
+func (l *StrategyLeg) working() bool {
+	return l.Sent && !l.final()
+}
+
+// Strategy is a synthetic order built on the client from plain child orders:
+//
+//   - oco: a take-profit limit and a stop-loss stop-limit on the same side;
+//     a fill on either cancels the other.
+//   - bracket: an entry order; once it has finished with a fill, take-profit
+//     and stop-loss exits on the other side for the filled quantity, as an
+//     OCO pair.
+//   - trailing: a market order on Side sent once the last trade retraces
+//     TrailAmt, or TrailPct percent, from the best price since it started.
+type Strategy struct {
+	ID       string        `json:"id"`
+	Kind     string        `json:"kind"`   // constants.StrategyKind*
+	Status   string        `json:"status"` // constants.StrategyStatus*
+	Symbol   string        `json:"symbol"`
+	Side     string        `json:"side"` // Side of the entry, or of every leg for oco and trailing
+	OrderQty string        `json:"orderQty"`
+	Legs     []StrategyLeg `json:"legs"`
+
+	// Trailing stop
+	TrailAmt decimal.Decimal `json:"trailAmt"` // Quote currency
+	TrailPct decimal.Decimal `json:"trailPct"`
+	Anchor   decimal.Decimal `json:"anchor"` // Highest trade for a sell, lowest for a buy
+
+	Note      string    `json:"note,omitempty"` // Why it finished
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	persistedAt time.Time // Last write to storage
+}
+
+// NewOCOStrategy creates a one-cancels-other pair on side: a limit order at
+// limitPx and a stop-limit triggered at stopPx with limit stopLimitPx.
+func NewOCOStrategy(symbol, side, qty, limitPx, stopPx, stopLimitPx string) *Strategy {
+	return &Strategy{
+		ID:       newStrategyID(constants.StrategyKindOCO),
+		Kind:     constants.StrategyKindOCO,
+		Symbol:   symbol,
+		Side:     side,
+		OrderQty: qty,
+		Legs: []StrategyLeg{
+			{Role: constants.LegRoleTakeProfit, Side: side, OrdType: constants.OrdTypeLimit, OrderQty: qty, Price: limitPx},
+			{Role: constants.LegRoleStopLoss, Side: side, OrdType: constants.OrdTypeStopLimit, OrderQty: qty,
+				Price: stopLimitPx, StopPx: stopPx},
+		},
+	}
+}
+
+// NewBracketStrategy creates an entry on side, a limit order at entryPx or a
+// market order without one, with exits on the other side: a take-profit limit
+// at takeProfitPx and a stop-limit triggered at stopLossPx with limit
+// stopLimitPx.
+func NewBracketStrategy(symbol, side, qty, entryPx, takeProfitPx, stopLossPx, stopLimitPx string) *Strategy {
+	entry := StrategyLeg{Role: constants.LegRoleEntry, Side: side, OrdType: constants.OrdTypeMarket, OrderQty: qty}
+	if entryPx != "" {
+		entry.OrdType = constants.OrdTypeLimit
+		entry.Price = entryPx
+	}
+	exitSide := oppositeSide(side)
+	return &Strategy{
+		ID:       newStrategyID(constants.StrategyKindBracket),
+		Kind:     constants.StrategyKindBracket,
+		Symbol:   symbol,
+		Side:     side,
+		OrderQty: qty,
+		Legs: []StrategyLeg{
+			entry,
+			{Role: constants.LegRoleTakeProfit, Side: exitSide, OrdType: constants.OrdTypeLimit, Price: takeProfitPx},
+			{Role: constants.LegRoleStopLoss, Side: exitSide, OrdType: constants.OrdTypeStopLimit,
+				Price: stopLimitPx, StopPx: stopLossPx},
+		},
+	}
+}
+
+// NewTrailingStrategy creates a trailing stop that sends a market order on
+// side once the price retraces trailAmt, or trailPct percent when trailAmt is
+// zero, from the best price seen, starting at anchor.
+func NewTrailingStrategy(symbol, side, qty string, trailAmt, trailPct, anchor decimal.Decimal) *Strategy {
+	return &Strategy{
+		ID:       newStrategyID(constants.StrategyKindTrailing),
+		Kind:     constants.StrategyKindTrailing,
+		Symbol:   symbol,
+		Side:     side,
+		OrderQty: qty,
+		TrailAmt: trailAmt,
+		TrailPct: trailPct,
+		Anchor:   anchor,
+		Legs: []StrategyLeg{
+			{Role: constants.LegRoleTrigger, Side: side, OrdType: constants.OrdTypeMarket, OrderQty: qty},
+		},
+	}
+}
+
+func newStrategyID(kind string) string {
+	return kind + "_" + strconv.FormatInt(nextNano(&lastStrategyId), 10)
+}
+
+// Leg returns the leg with role, or nil.
+func (s *Strategy) Leg(role string) *StrategyLeg {
+	for i := range s.Legs {
+		if s.Legs[i].Role == role {
+			return &s.Legs[i]
+		}
+	}
+	return nil
+}
+
+// StopPrice is the price at which a trailing stop triggers. A percentage
+// trail is rounded to the anchor's decimals, the increment it trades at.
+func (s *Strategy) StopPrice() (decimal.Decimal, error) {
+	trail := s.TrailAmt
+	if trail.IsZero() {
+		pct, err := percentOf(s.Anchor, s.TrailPct)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		if trail, err = pct.Round(s.Anchor.Scale()); err != nil {
+			return decimal.Decimal{}, err
+		}
+	}
+	if s.Side == constants.SideSell {
+		return s.Anchor.Sub(trail)
+	}
+	return s.Anchor.Add(trail)
+}
+
+func (s *Strategy) clone() *Strategy {
+	c := *s
+	c.Legs = append([]StrategyLeg(nil), s.Legs...)
+	return &c
+}
+
+// evaluate advances the strategy from the current state of its legs. It
+// returns the legs that are now due to be sent, already marked sent, and the
+// ClOrdIDs of working legs that must be cancelled, already marked as such.
+func (s *Strategy) evaluate() (send []StrategyLeg, cancel []string) {
+	if s.Status != constants.StrategyStatusActive {
+		return nil, nil
+	}
+
+	switch s.Kind {
+	case constants.StrategyKindOCO:
+		send = s.sendLegs(constants.LegRoleTakeProfit, constants.LegRoleStopLoss)
+		cancel = s.evaluatePair()
+
+	case constants.StrategyKindBracket:
+		entry := s.Leg(constants.LegRoleEntry)
+		if !entry.Sent {
+			return s.sendLegs(constants.LegRoleEntry), nil
+		}
+		if !entry.final() {
+			return nil, nil // Exits wait until the entry can no longer fill
+		}
+		if !entry.filled() {
+			s.finish(constants.StrategyStatusCanceled, "entry "+getOrdStatusDesc(entry.OrdStatus)+" without a fill")
+			return nil, nil
+		}
+		for _, role := range []string{constants.LegRoleTakeProfit, constants.LegRoleStopLoss} {
+			if leg := s.Leg(role); !leg.Sent {
+				leg.OrderQty = entry.CumQty
+			}
+		}
+		send = s.sendLegs(constants.LegRoleTakeProfit, constants.LegRoleStopLoss)
+		cancel = s.evaluatePair()
+
+	case constants.StrategyKindTrailing:
+		trigger := s.Leg(constants.LegRoleTrigger)
+		if !trigger.final() {
+			return nil, nil // Triggered by observe
+		}
+		if trigger.filled() {
+			s.finish(constants.StrategyStatusDone, "triggered and "+getOrdStatusDesc(trigger.OrdStatus))
+		} else {
+			s.finish(constants.StrategyStatusCanceled, "trigger order "+getOrdStatusDesc(trigger.OrdStatus))
+		}
+	}
+	return send, cancel
+}
+
+// sendLegs assigns ClOrdIDs to the legs with roles that have not been sent
+// and returns them.
+func (s *Strategy) sendLegs(roles ...string) []StrategyLeg {
+	var send []StrategyLeg
+	for _, role := range roles {
+		leg := s.Leg(role)
+		if leg.Sent {
+			continue
+		}
+		leg.ClOrdID = s.ID + "_" + role
+		leg.Sent = true
+		send = append(send, *leg)
+	}
+	return send
+}
+
+// evaluatePair applies one-cancels-other to the take-profit and stop-loss
+// legs: once one fills or finishes, the other is cancelled. The strategy is
+// finished when both are final.
+func (s *Strategy) evaluatePair() []string {
+	takeProfit, stopLoss := s.Leg(constants.LegRoleTakeProfit), s.Leg(constants.LegRoleStopLoss)
+
+	var cancel []string
+	for _, pair := range [][2]*StrategyLeg{{takeProfit, stopLoss}, {stopLoss, takeProfit}} {
+		leg, other := pair[0], pair[1]
+		if (leg.filled() || leg.final()) && other.working() && !other.CancelSent {
+			other.CancelSent = true
+			cancel = append(cancel, other.ClOrdID)
+		}
+	}
+
+	if takeProfit.final() && stopLoss.final() {
+		switch {
+		case takeProfit.filled() && stopLoss.filled():
+			s.finish(constants.StrategyStatusDone, "both legs filled")
+		case takeProfit.filled():
+			s.finish(constants.StrategyStatusDone, "take-profit "+getOrdStatusDesc(takeProfit.OrdStatus))
+		case stopLoss.filled():
+			s.finish(constants.StrategyStatusDone, "stop-loss "+getOrdStatusDesc(stopLoss.OrdStatus))
+		default:
+			s.finish(constants.StrategyStatusCanceled, "no leg filled")
+		}
+	}
+	return cancel
+}
+
+// observe applies a trade at px to an active trailing stop: the anchor follows
+// the price in the favourable direction, and once the price reaches the stop
+// the trigger leg is returned, marked sent. moved reports an anchor change.
+// A stop that cannot be computed never triggers.
+func (s *Strategy) observe(px decimal.Decimal) (send []StrategyLeg, moved bool) {
+	if s.Kind != constants.StrategyKindTrailing || s.Status != constants.StrategyStatusActive {
+		return nil, false
+	}
+	if s.Leg(constants.LegRoleTrigger).Sent {
+		return nil, false
+	}
+
+	if s.Side == constants.SideSell && px.Cmp(s.Anchor) > 0 || s.Side != constants.SideSell && px.Cmp(s.Anchor) < 0 {
+		s.Anchor = px
+		return nil, true
+	}
+	stop, err := s.StopPrice()
+	if err != nil {
+		return nil, false
+	}
+	if s.Side == constants.SideSell && px.Cmp(stop) <= 0 || s.Side != constants.SideSell && px.Cmp(stop) >= 0 {
+		return s.sendLegs(constants.LegRoleTrigger), false
+	}
+	return nil, false
+}
+
+func (s *Strategy) finish(status, note string) {
+	s.Status = status
+	s.Note = note
+}
+
+// strategyActions are the orders to send and cancel after a strategy changed.
+// Strategy is a copy taken when the decision was made.
+type strategyActions struct {
+	Strategy *Strategy
+	Send     []StrategyLeg
+	Cancel   []string // ClOrdIDs
+	Finished bool     // The strategy stopped being active with this change
+}
+
+// StrategyPersister stores strategies so they can resume after a restart.
+// Strategies calls it with its lock held; the argument is a copy.
+type StrategyPersister interface {
+	SaveStrategy(s *Strategy) error
+}
+
+// Strategies tracks the client's synthetic orders and decides, as their child
+// orders change and trades arrive, which orders to send or cancel next.
+// Sending them is left to the caller.
+type Strategies struct {
+	mu         sync.Mutex
+	strategies map[string]*Strategy
+	trailing   atomic.Int32 // Active trailing stops, checked on the market data hot path
+	persister  StrategyPersister
+}
+
+// NewStrategies creates an empty Strategies.
+func NewStrategies() *Strategies {
+	return &Strategies{strategies: make(map[string]*Strategy)}
+}
+
+// SetPersister enables write-through of every strategy change to p.
+func (ss *Strategies) SetPersister(p StrategyPersister) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.persister = p
+}
+
+// Restore adds previously persisted strategies without writing them back.
+func (ss *Strategies) Restore(strategies []*Strategy) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for _, s := range strategies {
+		c := s.clone()
+		c.persistedAt = c.UpdatedAt
+		ss.strategies[s.ID] = c
+	}
+	ss.countTrailing()
+}
+
+// Add starts s and returns the legs to send first.
+func (ss *Strategies) Add(s *Strategy) strategyActions {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	s = s.clone()
+	s.Status = constants.StrategyStatusActive
+	s.CreatedAt = time.Now()
+	ss.strategies[s.ID] = s
+	return ss.advance(s)
+}
+
+// OnOrderUpdate applies the current state of order, a leg of one of the
+// tracked strategies, and returns what to do next. ok is false when order is
+// not a leg of an active strategy. A leg replaced by hand keeps its role
+// under the new ClOrdID.
+func (ss *Strategies) OnOrderUpdate(order *Order) (strategyActions, bool) {
+	if order.StrategyID == "" {
+		return strategyActions{}, false
+	}
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	s := ss.strategies[order.StrategyID]
+	if s == nil {
+		return strategyActions{}, false
+	}
+	leg := s.legForOrder(order)
+	if leg == nil {
+		return strategyActions{}, false
+	}
+	leg.ClOrdID = order.ClOrdID
+	leg.OrdStatus = order.OrdStatus
+	leg.CumQty = order.CumQty
+	return ss.advance(s), true
+}
+
+// legForOrder finds the leg sent as order or as an earlier version of it.
+func (s *Strategy) legForOrder(order *Order) *StrategyLeg {
+	ids := []string{order.ClOrdID}
+	for _, amendment := range order.Amendments {
+		ids = append(ids, amendment.ClOrdID)
+	}
+	for i := range s.Legs {
+		for _, id := range ids {
+			if s.Legs[i].ClOrdID == id {
+				return &s.Legs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// LegNotSent records that the leg with clOrdID could not be sent, e.g. a
+// risk check refused it, and returns what to do next.
+func (ss *Strategies) LegNotSent(id, clOrdID, reason string) strategyActions {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	s := ss.strategies[id]
+	if s == nil {
+		return strategyActions{}
+	}
+	for i := range s.Legs {
+		if s.Legs[i].ClOrdID == clOrdID {
+			s.Legs[i].OrdStatus = constants.OrdStatusRejected
+		}
+	}
+	actions := ss.advance(s)
+	if actions.Finished {
+		s.Note += ": " + reason
+		ss.persist(s)
+		actions.Strategy = s.clone()
+	}
+	return actions
+}
+
+// OnTrade applies a trade in symbol at px to the active trailing stops and
+// returns those that triggered.
+func (ss *Strategies) OnTrade(symbol string, px decimal.Decimal) []strategyActions {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var triggered []strategyActions
+	for _, s := range ss.strategies {
+		if s.Symbol != symbol {
+			continue
+		}
+		send, moved := s.observe(px)
+		if len(send) > 0 {
+			s.UpdatedAt = time.Now()
+			ss.persist(s)
+			triggered = append(triggered, strategyActions{Strategy: s.clone(), Send: send})
+		} else if moved {
+			s.UpdatedAt = time.Now()
+			if s.UpdatedAt.Sub(s.persistedAt) >= trailPersistInterval {
+				ss.persist(s)
+			}
+		}
+	}
+	return triggered
+}
+
+// HasTrailing reports whether any trailing stop is waiting for trades,
+// without taking the lock.
+func (ss *Strategies) HasTrailing() bool {
+	return ss.trailing.Load() > 0
+}
+
+// Cancel stops an active strategy: unsent legs are never sent and the
+// returned working legs must be cancelled.
+func (ss *Strategies) Cancel(id string) (strategyActions, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	s := ss.strategies[id]
+	if s == nil {
+		return strategyActions{}, fmt.Errorf("strategy not found: %s", id)
+	}
+	if s.Status != constants.StrategyStatusActive {
+		return strategyActions{}, fmt.Errorf("strategy %s is already %s", id, s.Status)
+	}
+
+	var cancel []string
+	for i := range s.Legs {
+		if leg := &s.Legs[i]; leg.working() && !leg.CancelSent {
+			leg.CancelSent = true
+			cancel = append(cancel, leg.ClOrdID)
+		}
+	}
+	s.finish(constants.StrategyStatusCanceled, "cancelled by user")
+	s.UpdatedAt = time.Now()
+	ss.persist(s)
+	ss.countTrailing()
+	return strategyActions{Strategy: s.clone(), Cancel: cancel}, nil
+}
+
+// Resume re-evaluates every active strategy, syncing its legs with the
+// orders in orders first. FixApp calls it after logon once the logon
+// reconciliation, if any, has finished, so legs whose orders finished while
+// the client was down are seen in their reconciled state.
+func (ss *Strategies) Resume(orders *OrderStore) []strategyActions {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var result []strategyActions
+	for _, s := range ss.strategies {
+		if s.Status != constants.StrategyStatusActive {
+			continue
+		}
+		for i := range s.Legs {
+			leg := &s.Legs[i]
+			if !leg.Sent {
+				continue
+			}
+			if order := orders.ResolveOrder(leg.ClOrdID); order != nil {
+				leg.ClOrdID = order.ClOrdID
+				leg.OrdStatus = order.OrdStatus
+				leg.CumQty = order.CumQty
+			}
+		}
+		if actions := ss.advance(s); len(actions.Send) > 0 || len(actions.Cancel) > 0 {
+			result = append(result, actions)
+		}
+	}
+	return result
+}
+
+// Get returns a copy of the strategy with id, or nil.
+func (ss *Strategies) Get(id string) *Strategy {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if s, ok := ss.strategies[id]; ok {
+		return s.clone()
+	}
+	return nil
+}
+
+// All returns a copy of every tracked strategy, oldest first.
+func (ss *Strategies) All() []*Strategy {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	result := make([]*Strategy, 0, len(ss.strategies))
+	for _, s := range ss.strategies {
+		result = append(result, s.clone())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result
+}
+
+// advance evaluates s, stores the result and returns the actions it decided.
+// Caller must hold ss.mu.
+func (ss *Strategies) advance(s *Strategy) strategyActions {
+	wasActive := s.Status == constants.StrategyStatusActive
+	send, cancel := s.evaluate()
+	s.UpdatedAt = time.Now()
+	ss.persist(s)
+	ss.countTrailing()
+	return strategyActions{Strategy: s.clone(), Send: send, Cancel: cancel,
+		Finished: wasActive && s.Status != constants.StrategyStatusActive}
+}
+
+// countTrailing refreshes the count of trailing stops waiting for a trigger.
+// Caller must hold ss.mu.
+func (ss *Strategies) countTrailing() {
+	var n int32
+	for _, s := range ss.strategies {
+		if s.Kind == constants.StrategyKindTrailing && s.Status == constants.StrategyStatusActive &&
+			!s.Leg(constants.LegRoleTrigger).Sent {
+			n++
+		}
+	}
+	ss.trailing.Store(n)
+}
+
+// persist writes a copy of s, logging storage errors like persistOrder.
+// Caller must hold ss.mu.
+func (ss *Strategies) persist(s *Strategy) {
+	s.persistedAt = s.UpdatedAt
+	if ss.persister == nil {
+		return
+	}
+	if err := ss.persister.SaveStrategy(s.clone()); err != nil {
+		log.Printf("Failed to persist strategy %s: %v", s.ID, err)
+	}
+}
+
+// dbStrategyPersister stores strategies in the strategies table as JSON.
+type dbStrategyPersister struct {
+	db *database.MarketDataDb
+}
+
+// NewDbStrategyPersister returns a StrategyPersister backed by the
+// strategies table of db.
+func NewDbStrategyPersister(db *database.MarketDataDb) StrategyPersister {
+	return &dbStrategyPersister{db: db}
+}
+
+func (p *dbStrategyPersister) SaveStrategy(s *Strategy) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return p.db.SaveStrategy(database.StrategyRecord{
+		ID:        s.ID,
+		Kind:      s.Kind,
+		Symbol:    s.Symbol,
+		Status:    s.Status,
+		UpdatedAt: s.UpdatedAt,
+		Data:      string(data),
+	})
+}
+
+// loadStrategies reads the active strategies saved in db. Finished ones stay
+// in the database but are not reloaded.
+func loadStrategies(db *database.MarketDataDb) ([]*Strategy, error) {
+	records, err := db.LoadStrategies(constants.StrategyStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load strategies: %v", err)
+	}
+	strategies := make([]*Strategy, 0, len(records))
+	for _, r := range records {
+		s := &Strategy{}
+		if err := json.Unmarshal([]byte(r.Data), s); err != nil {
+			log.Printf("Skipping unreadable stored strategy %s: %v", r.ID, err)
+			continue
+		}
+		strategies = append(strategies, s)
+	}
+	return strategies, nil
+}
+
+// oppositeSide returns the side that closes a position opened on side.
+func oppositeSide(side string) string {
+	if side == constants.SideSell {
+		return constants.SideBuy
+	}
+	return constants.SideSell
+}
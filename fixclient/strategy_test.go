@@ -0,0 +1,265 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/database"
+	"prime-fix-md-go/decimal"
+)
+
+// legUpdate sets the state the client last saw for one of s's legs.
+func legUpdate(s *Strategy, role, ordStatus, cumQty string) {
+	leg := s.Leg(role)
+	leg.OrdStatus = ordStatus
+	leg.CumQty = cumQty
+}
+
+// TestStrategy_OCOFillCancelsOtherLeg verifies both OCO legs are sent at
+// once, a partial fill on one cancels the other exactly once, and the
+// strategy is done when both legs are final.
+func TestStrategy_OCOFillCancelsOtherLeg(t *testing.T) {
+	s := NewOCOStrategy("BTC-USD", constants.SideSell, "1", "52000", "48000", "47900")
+	s.Status = constants.StrategyStatusActive
+
+	send, cancel := s.evaluate()
+	if len(send) != 2 || len(cancel) != 0 {
+		t.Fatalf("Expected both legs sent, got %+v / %v", send, cancel)
+	}
+	if send[1].OrdType != constants.OrdTypeStopLimit || send[1].StopPx != "48000" || send[1].Price != "47900" {
+		t.Errorf("Unexpected stop leg %+v", send[1])
+	}
+	if send[0].ClOrdID == send[1].ClOrdID {
+		t.Errorf("Expected distinct leg ClOrdIDs, got %s", send[0].ClOrdID)
+	}
+
+	legUpdate(s, constants.LegRoleTakeProfit, constants.OrdStatusNew, "0")
+	legUpdate(s, constants.LegRoleStopLoss, constants.OrdStatusNew, "0")
+	if send, cancel := s.evaluate(); len(send) != 0 || len(cancel) != 0 {
+		t.Fatalf("Expected nothing while both rest, got %+v / %v", send, cancel)
+	}
+
+	legUpdate(s, constants.LegRoleTakeProfit, constants.OrdStatusPartiallyFilled, "0.4")
+	if _, cancel := s.evaluate(); !slices.Equal(cancel, []string{s.Leg(constants.LegRoleStopLoss).ClOrdID}) {
+		t.Fatalf("Expected the stop leg cancelled, got %v", cancel)
+	}
+	legUpdate(s, constants.LegRoleTakeProfit, constants.OrdStatusFilled, "1")
+	if _, cancel := s.evaluate(); len(cancel) != 0 {
+		t.Errorf("Expected the cancel to be sent once, got %v", cancel)
+	}
+	if s.Status != constants.StrategyStatusActive {
+		t.Errorf("Expected active until the stop leg is final, got %s", s.Status)
+	}
+
+	legUpdate(s, constants.LegRoleStopLoss, constants.OrdStatusCanceled, "0")
+	s.evaluate()
+	if s.Status != constants.StrategyStatusDone {
+		t.Errorf("Expected done, got %s (%s)", s.Status, s.Note)
+	}
+}
+
+// TestStrategy_BracketSendsExitsForFilledQty verifies that bracket exits wait
+// for the entry to finish, are sent on the other side for the quantity that
+// filled, and that an entry finishing without a fill cancels the bracket.
+func TestStrategy_BracketSendsExitsForFilledQty(t *testing.T) {
+	s := NewBracketStrategy("BTC-USD", constants.SideBuy, "1", "50000", "52000", "49000", "49000")
+	s.Status = constants.StrategyStatusActive
+
+	send, _ := s.evaluate()
+	if len(send) != 1 || send[0].Role != constants.LegRoleEntry || send[0].OrdType != constants.OrdTypeLimit {
+		t.Fatalf("Expected only the limit entry sent, got %+v", send)
+	}
+
+	legUpdate(s, constants.LegRoleEntry, constants.OrdStatusPartiallyFilled, "0.6")
+	if send, _ := s.evaluate(); len(send) != 0 {
+		t.Fatalf("Expected exits to wait for the entry, got %+v", send)
+	}
+
+	legUpdate(s, constants.LegRoleEntry, constants.OrdStatusCanceled, "0.6")
+	send, _ = s.evaluate()
+	if len(send) != 2 {
+		t.Fatalf("Expected both exits sent, got %+v", send)
+	}
+	for _, leg := range send {
+		if leg.Side != constants.SideSell || leg.OrderQty != "0.6" {
+			t.Errorf("Expected a sell exit for 0.6, got %+v", leg)
+		}
+	}
+
+	unfilled := NewBracketStrategy("BTC-USD", constants.SideBuy, "1", "", "52000", "49000", "49000")
+	unfilled.Status = constants.StrategyStatusActive
+	if send, _ := unfilled.evaluate(); send[0].OrdType != constants.OrdTypeMarket {
+		t.Errorf("Expected a market entry without a price, got %+v", send[0])
+	}
+	legUpdate(unfilled, constants.LegRoleEntry, constants.OrdStatusRejected, "0")
+	if send, _ := unfilled.evaluate(); len(send) != 0 || unfilled.Status != constants.StrategyStatusCanceled {
+		t.Errorf("Expected the bracket cancelled without exits, got %+v, status %s", send, unfilled.Status)
+	}
+}
+
+// TestStrategy_TrailingStopFollowsAndTriggers verifies a sell trailing stop
+// follows new highs only, triggers once on the retrace, that a buy trailing
+// stop by percent mirrors it, and that saved trails still load.
+func TestStrategy_TrailingStopFollowsAndTriggers(t *testing.T) {
+	d := decimal.MustParse
+	s := NewTrailingStrategy("BTC-USD", constants.SideSell, "0.5", d("500"), decimal.Decimal{}, d("50000.00"))
+	s.Status = constants.StrategyStatusActive
+
+	for _, px := range []string{"50200.00", "49900.00", "50400.00"} {
+		if send, _ := s.observe(d(px)); len(send) != 0 {
+			t.Fatalf("Unexpected trigger at %v", px)
+		}
+	}
+	if stop, err := s.StopPrice(); s.Anchor.String() != "50400.00" || err != nil || stop.String() != "49900.00" {
+		t.Fatalf("Expected anchor 50400.00 and stop 49900.00, got %s and %s (%v)", s.Anchor, stop, err)
+	}
+	send, _ := s.observe(d("49900.00"))
+	if len(send) != 1 || send[0].OrdType != constants.OrdTypeMarket || send[0].OrderQty != "0.5" {
+		t.Fatalf("Expected a market sell trigger, got %+v", send)
+	}
+	if send, _ := s.observe(d("49000.00")); len(send) != 0 {
+		t.Errorf("Expected a single trigger, got %+v", send)
+	}
+
+	legUpdate(s, constants.LegRoleTrigger, constants.OrdStatusFilled, "0.5")
+	s.evaluate()
+	if s.Status != constants.StrategyStatusDone {
+		t.Errorf("Expected done after the trigger filled, got %s", s.Status)
+	}
+
+	// 1.5% of 2800.01 is 42.00015, rounded to the anchor's cents
+	buy := NewTrailingStrategy("ETH-USD", constants.SideBuy, "2", decimal.Decimal{}, d("1.5"), d("3000.00"))
+	buy.Status = constants.StrategyStatusActive
+	buy.observe(d("2800.01"))
+	if stop, err := buy.StopPrice(); err != nil || stop.String() != "2842.01" {
+		t.Errorf("Expected a 2842.01 stop, got %s (%v)", stop, err)
+	}
+	if send, _ := buy.observe(d("2842.00")); len(send) != 0 {
+		t.Errorf("Expected no trigger below the 2842.01 stop, got %+v", send)
+	}
+	if send, _ := buy.observe(d("2842.01")); len(send) != 1 || send[0].Side != constants.SideBuy {
+		t.Errorf("Expected a buy trigger at 2842.01, got %+v", send)
+	}
+
+	// Strategies saved before the trail became a decimal hold JSON numbers
+	var saved Strategy
+	if err := json.Unmarshal([]byte(`{"side":"2","trailAmt":500,"trailPct":0,"anchor":50400.5}`), &saved); err != nil {
+		t.Fatalf("Failed to decode a saved trailing stop: %v", err)
+	}
+	if stop, err := saved.StopPrice(); err != nil || stop.String() != "49900.5" {
+		t.Errorf("Expected a 49900.5 stop from the saved trail, got %s (%v)", stop, err)
+	}
+}
+
+// TestStrategies_FollowReplacedLegAndCancel verifies that a leg replaced by
+// hand is still followed under its new ClOrdID and that cancelling the
+// strategy cancels its working legs only.
+func TestStrategies_FollowReplacedLegAndCancel(t *testing.T) {
+	ss := NewStrategies()
+	actions := ss.Add(NewOCOStrategy("BTC-USD", constants.SideBuy, "1", "48000", "52000", "52000"))
+	id := actions.Strategy.ID
+	takeProfit, stopLoss := actions.Send[0], actions.Send[1]
+
+	replaced := &Order{ClOrdID: "rep-1", StrategyID: id, OrdStatus: constants.OrdStatusNew, CumQty: "0",
+		Amendments: []OrderAmendment{{ClOrdID: takeProfit.ClOrdID}}}
+	if _, ok := ss.OnOrderUpdate(replaced); !ok {
+		t.Fatal("Expected the replaced leg to be recognised")
+	}
+	if _, ok := ss.OnOrderUpdate(&Order{ClOrdID: stopLoss.ClOrdID, StrategyID: id, OrdStatus: constants.OrdStatusRejected}); !ok {
+		t.Fatal("Expected the stop leg to be recognised")
+	}
+	if s := ss.Get(id); s.Leg(constants.LegRoleTakeProfit).ClOrdID != "rep-1" || !s.Leg(constants.LegRoleTakeProfit).CancelSent {
+		t.Errorf("Expected the rejected stop to cancel rep-1, got %+v", s.Legs)
+	}
+
+	other := ss.Add(NewOCOStrategy("ETH-USD", constants.SideSell, "1", "3200", "2800", "2800"))
+	ss.OnOrderUpdate(&Order{ClOrdID: other.Send[0].ClOrdID, StrategyID: other.Strategy.ID, OrdStatus: constants.OrdStatusFilled, CumQty: "1"})
+	cancelled, err := ss.Cancel(other.Strategy.ID)
+	if err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if len(cancelled.Cancel) != 0 {
+		t.Errorf("Expected the stop leg to have been cancelled already, got %v", cancelled.Cancel)
+	}
+	if _, err := ss.Cancel(other.Strategy.ID); err == nil {
+		t.Error("Expected cancelling a finished strategy to fail")
+	}
+}
+
+// TestStrategies_PersistAcrossRestart verifies that active strategies are
+// reloaded with their legs, finished ones are not, and that after a restart
+// a bracket whose entry filled while the client was down sends its exits.
+func TestStrategies_PersistAcrossRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "strategies.db")
+	db, err := database.NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	app := NewFixApp(&Config{}, db)
+	bracket := app.Strategies.Add(NewBracketStrategy("BTC-USD", constants.SideBuy, "1", "50000", "52000", "49000", "49000"))
+	entry := bracket.Send[0]
+	app.OrderStore.AddOrder(&Order{ClOrdID: entry.ClOrdID, Symbol: "BTC-USD", Side: constants.SideBuy, OrderQty: "1",
+		OrdStatus: constants.OrdStatusPendingNew, StrategyID: bracket.Strategy.ID})
+	app.OrderStore.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: entry.ClOrdID, OrderID: "exch-1",
+		OrdStatus: constants.OrdStatusNew, ExecType: constants.ExecTypeNew, ExecID: "e1", CumQty: "0"})
+	app.Strategies.OnOrderUpdate(app.OrderStore.GetOrder(entry.ClOrdID))
+
+	oco := app.Strategies.Add(NewOCOStrategy("ETH-USD", constants.SideSell, "1", "3200", "2800", "2800"))
+	app.Strategies.Cancel(oco.Strategy.ID)
+
+	// The entry fills; the client stops before it sees the report
+	app.OrderStore.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: entry.ClOrdID, OrderID: "exch-1",
+		OrdStatus: constants.OrdStatusPartiallyFilled, ExecType: constants.ExecTypeTrade, ExecID: "e2", CumQty: "0.25"})
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+
+	db, err = database.NewMarketDataDb(dbPath)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	restarted := NewFixApp(&Config{}, db)
+
+	if restarted.Strategies.Get(oco.Strategy.ID) != nil {
+		t.Error("Expected the cancelled OCO not to be restored")
+	}
+	s := restarted.Strategies.Get(bracket.Strategy.ID)
+	if s == nil {
+		t.Fatal("Expected the bracket to be restored")
+	}
+	if leg := s.Leg(constants.LegRoleEntry); !leg.Sent || leg.OrdStatus != constants.OrdStatusNew {
+		t.Errorf("Unexpected restored entry leg %+v", leg)
+	}
+
+	// Reconciliation reports the entry cancelled after the partial fill
+	restarted.OrderStore.UpdateOrderFromExecReport(&ExecutionReport{ClOrdID: entry.ClOrdID, OrderID: "exch-1",
+		OrdStatus: constants.OrdStatusCanceled, ExecType: constants.ExecTypeOrderStatus, ExecID: "e3", CumQty: "0.25"})
+	resumed := restarted.Strategies.Resume(restarted.OrderStore)
+	if len(resumed) != 1 || len(resumed[0].Send) != 2 || resumed[0].Send[0].OrderQty != "0.25" {
+		t.Fatalf("Expected both exits for 0.25 on resume, got %+v", resumed)
+	}
+	if resumed := restarted.Strategies.Resume(restarted.OrderStore); len(resumed) != 0 {
+		t.Errorf("Expected exits to be sent once, got %+v", resumed)
+	}
+}
@@ -0,0 +1,215 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixclient
+
+import (
+	"fmt"
+	"log"
+
+	"prime-fix-md-go/builder"
+	"prime-fix-md-go/constants"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// StartStrategy runs the pre-trade risk checks on the legs s sends first,
+// then starts it and sends them. Legs sent later, such as bracket exits, are
+// checked when they are sent; one that fails stops the strategy.
+func (a *FixApp) StartStrategy(s *Strategy) error {
+	a.strategyMu.Lock()
+	defer a.strategyMu.Unlock()
+
+	first := s.clone()
+	first.Status = constants.StrategyStatusActive
+	send, _ := first.evaluate()
+	for _, leg := range send {
+		if err := a.RiskChecks.Check(a.strategyOrderParams(first, leg)); err != nil {
+			return fmt.Errorf("%s leg rejected by risk check (not sent): %w", leg.Role, err)
+		}
+	}
+
+	actions := a.Strategies.Add(s)
+	log.Printf("Strategy started: %s %s %s %s (ID: %s)",
+		s.Kind, getSideDesc(s.Side), s.OrderQty, s.Symbol, s.ID)
+	a.runStrategyActions(actions)
+	return nil
+}
+
+// CancelStrategy stops the strategy with id and cancels its working legs.
+func (a *FixApp) CancelStrategy(id string) error {
+	a.strategyMu.Lock()
+	defer a.strategyMu.Unlock()
+
+	actions, err := a.Strategies.Cancel(id)
+	if err != nil {
+		return err
+	}
+	log.Printf("Strategy %s cancelled, cancelling %d working leg(s)", id, len(actions.Cancel))
+	a.runStrategyActions(actions)
+	return nil
+}
+
+// onStrategyOrderUpdate advances the strategy, if any, that the order
+// reported as clOrdID is a leg of.
+func (a *FixApp) onStrategyOrderUpdate(clOrdID string) {
+	order := a.OrderStore.ResolveOrder(clOrdID)
+	if order == nil || order.StrategyID == "" {
+		return
+	}
+
+	a.strategyMu.Lock()
+	defer a.strategyMu.Unlock()
+
+	if actions, ok := a.Strategies.OnOrderUpdate(order); ok {
+		a.runStrategyActions(actions)
+	}
+}
+
+// onStrategyTrades feeds incremental trade entries to the trailing stops.
+func (a *FixApp) onStrategyTrades(trades []Trade) {
+	for i := range trades {
+		trade := &trades[i]
+		if trade.EntryType != constants.MdEntryTypeTrade {
+			continue
+		}
+		px, err := trade.PriceDecimal()
+		if err != nil || px.Sign() <= 0 {
+			continue
+		}
+
+		a.strategyMu.Lock()
+		for _, actions := range a.Strategies.OnTrade(trade.Symbol, px) {
+			stop, _ := actions.Strategy.StopPrice()
+			log.Printf("Trailing stop %s triggered at %s (stop %s)", actions.Strategy.ID, trade.Price, stop)
+			a.runStrategyActions(actions)
+		}
+		a.strategyMu.Unlock()
+	}
+}
+
+// resumeStrategies sends whatever restored strategies are due after logon,
+// e.g. bracket exits for an entry that filled while the client was down.
+func (a *FixApp) resumeStrategies() {
+	a.strategyMu.Lock()
+	defer a.strategyMu.Unlock()
+
+	for _, actions := range a.Strategies.Resume(a.OrderStore) {
+		a.runStrategyActions(actions)
+	}
+}
+
+// runStrategyActions sends and cancels the orders a strategy decided on. A
+// leg that cannot be sent is reported back, which may cancel the others.
+// Caller must hold a.strategyMu.
+func (a *FixApp) runStrategyActions(actions strategyActions) {
+	s := actions.Strategy
+	for _, leg := range actions.Send {
+		if err := a.sendStrategyLeg(s, leg); err != nil {
+			log.Printf("Strategy %s: %s leg not sent: %v", s.ID, leg.Role, err)
+			a.runStrategyActions(a.Strategies.LegNotSent(s.ID, leg.ClOrdID, err.Error()))
+			continue
+		}
+		log.Printf("Strategy %s: %s leg sent: %s %s %s %s (ClOrdID: %s)", s.ID, leg.Role,
+			getSideDesc(leg.Side), leg.OrderQty, s.Symbol, describeLegPrice(leg), leg.ClOrdID)
+	}
+
+	for _, clOrdID := range actions.Cancel {
+		order := a.OrderStore.ResolveOrder(clOrdID)
+		if order == nil || !isOpenStatus(order.OrdStatus) {
+			continue
+		}
+		if cxlID, err := a.sendCancel(order); err != nil {
+			log.Printf("Strategy %s: error cancelling %s: %v", s.ID, order.ClOrdID, err)
+		} else {
+			log.Printf("Strategy %s: cancel sent for %s (ClOrdID: %s)", s.ID, order.ClOrdID, cxlID)
+		}
+	}
+
+	if actions.Finished {
+		log.Printf("Strategy %s %s: %s", s.ID, s.Status, s.Note)
+	}
+}
+
+// sendStrategyLeg checks and sends one leg. The order is tracked before it
+// is sent so that execution reports for it always find it.
+func (a *FixApp) sendStrategyLeg(s *Strategy, leg StrategyLeg) error {
+	params := a.strategyOrderParams(s, leg)
+	if err := a.RiskChecks.Check(params); err != nil {
+		return fmt.Errorf("rejected by risk check: %w", err)
+	}
+
+	a.OrderStore.AddOrder(&Order{
+		ClOrdID:        leg.ClOrdID,
+		Symbol:         s.Symbol,
+		Side:           leg.Side,
+		OrdType:        leg.OrdType,
+		OrderQty:       leg.OrderQty,
+		Price:          leg.Price,
+		StopPx:         leg.StopPx,
+		TargetStrategy: params.TargetStrategy,
+		TimeInForce:    params.TimeInForce,
+		OrdStatus:      constants.OrdStatusPendingNew,
+		Account:        a.Config.PortfolioId,
+		StrategyID:     s.ID,
+	})
+
+	msg := builder.BuildNewOrderSingle(params, a.Config.SenderCompId, a.Config.TargetCompId)
+	if err := quickfix.SendToTarget(msg, a.SessionId); err != nil {
+		a.OrderStore.RemoveOrder(leg.ClOrdID)
+		return err
+	}
+	return nil
+}
+
+// strategyOrderParams builds the New Order Single for a leg. Market legs are
+// IOC; the others rest until filled or cancelled.
+func (a *FixApp) strategyOrderParams(s *Strategy, leg StrategyLeg) builder.NewOrderParams {
+	params := builder.NewOrderParams{
+		ClOrdID:     leg.ClOrdID,
+		Account:     a.Config.PortfolioId,
+		Symbol:      s.Symbol,
+		Side:        leg.Side,
+		OrdType:     leg.OrdType,
+		OrderQty:    leg.OrderQty,
+		Price:       leg.Price,
+		StopPx:      leg.StopPx,
+		TimeInForce: constants.TimeInForceGTC,
+	}
+	switch leg.OrdType {
+	case constants.OrdTypeMarket:
+		params.TargetStrategy = constants.TargetStrategyMarket
+		params.TimeInForce = constants.TimeInForceIOC
+	case constants.OrdTypeStopLimit:
+		params.TargetStrategy = constants.TargetStrategyStopLimit
+	default:
+		params.TargetStrategy = constants.TargetStrategyLimit
+	}
+	return params
+}
+
+// describeLegPrice describes a leg's prices, e.g. "@ 51000" or
+// "stop 48000 limit 47900".
+func describeLegPrice(leg StrategyLeg) string {
+	switch {
+	case leg.StopPx != "":
+		return fmt.Sprintf("stop %s limit %s", leg.StopPx, leg.Price)
+	case leg.Price != "":
+		return "@ " + leg.Price
+	default:
+		return "at market"
+	}
+}
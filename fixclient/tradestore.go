@@ -35,32 +35,64 @@
 // - AddTrades: O(n) where n = trades to add, ~70ns per trade
 // - GetRecentTrades: O(m) where m = trades in buffer, 1 allocation
 // - GetAllTrades: O(m), 1 allocation for copy
+// - LastTradePrice: O(m) worst case, 0 allocations
 package fixclient
 
 import (
 	"log"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/decimal"
 )
 
 // Trade represents a single market data entry from a FIX message.
 // Fields are ordered for optimal memory alignment:
 // - time.Time (24 bytes) first
 // - strings (16 bytes each) next
+// - decimals (16 bytes each) after the strings
 // - bools (1 byte each) last to minimize padding
+//
+// Price and Size keep the original FIX strings; PriceVal and SizeVal are the
+// same values as parsed by the market data parser, for arithmetic.
 type Trade struct {
-	Timestamp  time.Time `json:"timestamp"`
-	Symbol     string    `json:"symbol"`
-	Price      string    `json:"price"`
-	Size       string    `json:"size"`
-	Time       string    `json:"time"`
-	Aggressor  string    `json:"aggressor"`
-	MdReqId    string    `json:"mdReqId"`
-	EntryType  string    `json:"entryType"` // MdEntryType (0=Bid, 1=Offer, 2=Trade, 4=Open, 5=Close, 7=High, 8=Low, B=Volume)
-	Position   string    `json:"position"`  // Position in book (for bids/offers)
-	SeqNum     string    `json:"seqNum"`    // FIX MsgSeqNum for ordering
-	IsSnapshot bool      `json:"isSnapshot"`
-	IsUpdate   bool      `json:"isUpdate"`
+	Timestamp    time.Time       `json:"timestamp"`
+	Symbol       string          `json:"symbol"`
+	Price        string          `json:"price"`
+	Size         string          `json:"size"`
+	Time         string          `json:"time"`
+	Aggressor    string          `json:"aggressor"`
+	MdReqId      string          `json:"mdReqId"`
+	EntryType    string          `json:"entryType"`              // MdEntryType (0=Bid, 1=Offer, 2=Trade, 4=Open, 5=Close, 7=High, 8=Low, B=Volume)
+	Position     string          `json:"position"`               // Position in book (for bids/offers)
+	SeqNum       string          `json:"seqNum"`                 // FIX MsgSeqNum for ordering
+	UpdateAction string          `json:"updateAction,omitempty"` // MdUpdateAction (0=New, 1=Change, 2=Delete), incrementals only
+	EntryID      string          `json:"entryId,omitempty"`      // MdEntryId
+	PriceVal     decimal.Decimal `json:"-"`
+	SizeVal      decimal.Decimal `json:"-"`
+	IsSnapshot   bool            `json:"isSnapshot"`
+	IsUpdate     bool            `json:"isUpdate"`
+}
+
+// PriceDecimal returns the entry price. Trades that did not come through the
+// parser, e.g. those reloaded from the DbWriter spill file, only carry Price,
+// which is parsed instead.
+func (t *Trade) PriceDecimal() (decimal.Decimal, error) {
+	if !t.PriceVal.IsZero() {
+		return t.PriceVal, nil
+	}
+	return decimal.Parse(t.Price)
+}
+
+// SizeDecimal returns the entry size, parsing Size like PriceDecimal.
+func (t *Trade) SizeDecimal() (decimal.Decimal, error) {
+	if !t.SizeVal.IsZero() {
+		return t.SizeVal, nil
+	}
+	return decimal.Parse(t.Size)
 }
 
 // TradeStore provides thread-safe in-memory storage for market data trades.
@@ -88,15 +120,35 @@ type TradeStore struct {
 }
 
 // Subscription tracks an active market data subscription.
+// The original request (symbols, depth, entry types) is kept so the
+// subscription can be replayed after a reconnect.
 // Fields are ordered for optimal memory alignment.
 type Subscription struct {
-	LastUpdate       time.Time // 24 bytes
-	TotalUpdates     int64     // 8 bytes
-	Symbol           string    // 16 bytes
-	SubscriptionType string    // 16 bytes - "0"=snapshot, "1"=subscribe, "2"=unsubscribe
-	MdReqId          string    // 16 bytes
-	Active           bool      // 1 byte
-	SnapshotReceived bool      // 1 byte
+	LastUpdate       time.Time `json:"lastUpdate"`       // 24 bytes
+	Symbols          []string  `json:"symbols"`          // 24 bytes - all symbols in the original request
+	EntryTypes       []string  `json:"entryTypes"`       // 24 bytes - MdEntryTypes in the original request
+	TotalUpdates     int64     `json:"totalUpdates"`     // 8 bytes
+	Symbol           string    `json:"symbol"`           // 16 bytes
+	SubscriptionType string    `json:"subscriptionType"` // 16 bytes - "0"=snapshot, "1"=subscribe, "2"=unsubscribe
+	MdReqId          string    `json:"mdReqId"`          // 16 bytes - current MdReqId (changes on resubscribe)
+	OriginalMdReqId  string    `json:"originalMdReqId"`  // 16 bytes - MdReqId of the first request
+	MarketDepth      string    `json:"marketDepth"`      // 16 bytes
+	Active           bool      `json:"active"`           // 1 byte
+	SnapshotReceived bool      `json:"snapshotReceived"` // 1 byte
+	Stale            bool      `json:"stale"`            // 1 byte - session dropped, awaiting data for the resubscribe
+}
+
+// AllSymbols returns every symbol the subscription's request covers.
+func (s *Subscription) AllSymbols() []string {
+	if len(s.Symbols) == 0 {
+		return []string{s.Symbol}
+	}
+	return s.Symbols
+}
+
+// HasSymbol reports whether the subscription's request covers symbol.
+func (s *Subscription) HasSymbol(symbol string) bool {
+	return slices.Contains(s.AllSymbols(), symbol)
 }
 
 // NewTradeStore creates a new TradeStore with pre-allocated ring buffer.
@@ -138,6 +190,7 @@ func (ts *TradeStore) AddTrades(symbol string, trades []Trade, isSnapshot bool,
 	if sub, exists := ts.subscriptions[mdReqId]; exists {
 		sub.LastUpdate = time.Now()
 		sub.TotalUpdates += int64(len(trades))
+		sub.Stale = false // first data after a resubscribe acknowledges it
 		if isSnapshot {
 			sub.SnapshotReceived = true
 		}
@@ -149,7 +202,10 @@ func (ts *TradeStore) AddTrades(symbol string, trades []Trade, isSnapshot bool,
 	for _, trade := range trades {
 		// HOT PATH: Struct field assignment - all stack operations
 		trade.Timestamp = now
-		trade.Symbol = symbol
+		if trade.Symbol == "" {
+			// Per-entry Symbol (55) from the parser takes precedence
+			trade.Symbol = symbol
+		}
 		trade.MdReqId = mdReqId
 		trade.IsSnapshot = isSnapshot
 		trade.IsUpdate = !isSnapshot
@@ -231,6 +287,29 @@ func (ts *TradeStore) GetRecentTrades(symbol string, limit int) []Trade {
 	return recent
 }
 
+// LastTradePrice returns the price of the most recent trade in symbol, or
+// false when none is held.
+//
+// Performance: O(m), scanning newest to oldest and stopping at the first trade
+func (ts *TradeStore) LastTradePrice(symbol string) (decimal.Decimal, bool) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	for i := 0; i < ts.count; i++ {
+		trade := &ts.trades[(ts.head+ts.count-1-i)%ts.maxSize]
+		if trade.Symbol != symbol || trade.EntryType != constants.MdEntryTypeTrade ||
+			trade.UpdateAction == constants.MdUpdateActionDelete {
+			continue
+		}
+		price, err := trade.PriceDecimal()
+		if err != nil || price.Sign() <= 0 {
+			continue
+		}
+		return price, true
+	}
+	return decimal.Decimal{}, false
+}
+
 // GetAllTrades returns a copy of all trades in the buffer.
 // Trades are returned in chronological order (oldest first).
 //
@@ -258,20 +337,126 @@ func (ts *TradeStore) GetAllTrades() []Trade {
 }
 
 func (ts *TradeStore) AddSubscription(symbol, subscriptionType, mdReqId string) {
+	ts.AddSubscriptionRequest([]string{symbol}, subscriptionType, mdReqId, "0", []string{constants.MdEntryTypeTrade})
+}
+
+// AddSubscriptionRequest records a subscription together with the full
+// request that created it, so it can be replayed on reconnect.
+func (ts *TradeStore) AddSubscriptionRequest(symbols []string, subscriptionType, mdReqId, marketDepth string, entryTypes []string) {
+	if len(symbols) == 0 {
+		return
+	}
+
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
 
 	ts.subscriptions[mdReqId] = &Subscription{
-		Symbol:           symbol,
+		Symbol:           symbols[0],
+		Symbols:          append([]string(nil), symbols...),
 		SubscriptionType: subscriptionType,
 		MdReqId:          mdReqId,
+		OriginalMdReqId:  mdReqId,
+		MarketDepth:      marketDepth,
+		EntryTypes:       append([]string(nil), entryTypes...),
 		Active:           true,
 		LastUpdate:       time.Now(),
 		TotalUpdates:     0,
 		SnapshotReceived: false,
 	}
 
-	log.Printf("Added subscription: %s (type=%s, reqId=%s)", symbol, getSubscriptionTypeDesc(subscriptionType), mdReqId)
+	log.Printf("Added subscription: %s (type=%s, reqId=%s)", strings.Join(symbols, ","), getSubscriptionTypeDesc(subscriptionType), mdReqId)
+}
+
+// MarkSubscriptionsStale flags every subscription as stale. Called when the
+// session drops: Prime stops streaming, but the subscriptions are kept so they
+// can be replayed on the next logon.
+func (ts *TradeStore) MarkSubscriptionsStale() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for _, sub := range ts.subscriptions {
+		sub.Stale = true
+	}
+}
+
+// ReplaceSubscriptionReqId re-keys a subscription under a new MdReqId after it
+// has been resent. The subscription stays stale until data arrives for the new
+// MdReqId; OriginalMdReqId keeps pointing at the first request.
+func (ts *TradeStore) ReplaceSubscriptionReqId(oldReqId, newReqId string) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	sub, exists := ts.subscriptions[oldReqId]
+	if !exists {
+		return false
+	}
+
+	delete(ts.subscriptions, oldReqId)
+	sub.MdReqId = newReqId
+	sub.Stale = true
+	sub.SnapshotReceived = false
+	ts.subscriptions[newReqId] = sub
+
+	log.Printf("Resubscribed: %s (reqId: %s -> %s, original: %s)", strings.Join(sub.Symbols, ","), oldReqId, newReqId, sub.OriginalMdReqId)
+	return true
+}
+
+// OriginalMdReqId maps a current MdReqId back to the MdReqId of the request
+// that first created the subscription. Unknown ids are returned unchanged.
+func (ts *TradeStore) OriginalMdReqId(reqId string) string {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	if sub, exists := ts.subscriptions[reqId]; exists && sub.OriginalMdReqId != "" {
+		return sub.OriginalMdReqId
+	}
+	return reqId
+}
+
+// CurrentMdReqId maps the MdReqId a subscription was created with to the
+// MdReqId it is tracked under now, which changes when it is resent after a
+// reconnect. Current and unknown ids are returned unchanged.
+func (ts *TradeStore) CurrentMdReqId(reqId string) string {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	if _, exists := ts.subscriptions[reqId]; exists {
+		return reqId
+	}
+	for currentId, sub := range ts.subscriptions {
+		if sub.OriginalMdReqId == reqId {
+			return currentId
+		}
+	}
+	return reqId
+}
+
+// SubscriptionHasBook reports whether the request reqId asked for bids or
+// offers, i.e. whether its snapshots carry the symbol's book even when empty.
+func (ts *TradeStore) SubscriptionHasBook(reqId string) bool {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	if sub, exists := ts.subscriptions[reqId]; exists {
+		for _, entryType := range sub.EntryTypes {
+			if entryType == constants.MdEntryTypeBid || entryType == constants.MdEntryTypeOffer {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SubscriptionSymbol returns the symbol subscribed with reqId, or "" if the
+// request is unknown.
+func (ts *TradeStore) SubscriptionSymbol(reqId string) string {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	if sub, exists := ts.subscriptions[reqId]; exists {
+		return sub.Symbol
+	}
+	return ""
 }
 
 func (ts *TradeStore) RemoveSubscription(symbol string) {
@@ -280,7 +465,7 @@ func (ts *TradeStore) RemoveSubscription(symbol string) {
 
 	// Find all subscriptions for this symbol and remove them
 	for reqId, sub := range ts.subscriptions {
-		if sub.Symbol == symbol {
+		if sub.HasSymbol(symbol) {
 			delete(ts.subscriptions, reqId)
 			log.Printf("Removed subscription: %s (reqId: %s, total updates: %d)", symbol, reqId, sub.TotalUpdates)
 		}
@@ -317,7 +502,9 @@ func (ts *TradeStore) GetSubscriptionsBySymbol() map[string][]*Subscription {
 	for _, sub := range ts.subscriptions {
 		// Create copy to avoid race conditions
 		subCopy := *sub
-		result[sub.Symbol] = append(result[sub.Symbol], &subCopy)
+		for _, symbol := range sub.AllSymbols() {
+			result[symbol] = append(result[symbol], &subCopy)
+		}
 	}
 	return result
 }
@@ -245,6 +245,21 @@ func TestTradeStore_FieldsPopulatedOnAdd(t *testing.T) {
 
 // --- Subscription Tests ---
 
+// TestTradeStore_PerEntrySymbolPreserved verifies that entries which already
+// carry a symbol (from a per-entry Symbol tag) keep it instead of the message symbol.
+func TestTradeStore_PerEntrySymbolPreserved(t *testing.T) {
+	store := NewTradeStore(100, "")
+
+	store.AddTrades("BTC-USD", []Trade{{Price: "3000", Symbol: "ETH-USD"}, {Price: "50000"}}, false, "req-1")
+
+	if got := store.GetRecentTrades("ETH-USD", 10); len(got) != 1 {
+		t.Errorf("expected 1 ETH-USD trade, got %d", len(got))
+	}
+	if got := store.GetRecentTrades("BTC-USD", 10); len(got) != 1 {
+		t.Errorf("expected 1 BTC-USD trade, got %d", len(got))
+	}
+}
+
 // TestSubscription_AddAndRemoveByReqId verifies the subscription lifecycle:
 // add, verify active, remove by reqId, verify removed.
 func TestSubscription_AddAndRemoveByReqId(t *testing.T) {
@@ -272,6 +287,112 @@ func TestSubscription_AddAndRemoveByReqId(t *testing.T) {
 	}
 }
 
+// TestSubscription_RequestRetainedForReplay verifies that the full original
+// request is kept on the subscription.
+func TestSubscription_RequestRetainedForReplay(t *testing.T) {
+	store := NewTradeStore(100, "")
+
+	store.AddSubscriptionRequest([]string{"BTC-USD", "ETH-USD"}, "1", "req-1", "10", []string{"0", "1"})
+
+	sub := store.GetSubscriptionStatus()["req-1"]
+	if sub == nil {
+		t.Fatal("expected subscription with reqId 'req-1'")
+	}
+	if len(sub.Symbols) != 2 || sub.MarketDepth != "10" || len(sub.EntryTypes) != 2 {
+		t.Errorf("request not retained: symbols=%v depth=%s types=%v", sub.Symbols, sub.MarketDepth, sub.EntryTypes)
+	}
+	if sub.OriginalMdReqId != "req-1" {
+		t.Errorf("expected original reqId req-1, got %s", sub.OriginalMdReqId)
+	}
+}
+
+// TestSubscription_MatchesEverySymbol verifies that a multi-symbol
+// subscription is found, listed and removed by any of its symbols.
+func TestSubscription_MatchesEverySymbol(t *testing.T) {
+	store := NewTradeStore(100, "")
+	store.AddSubscriptionRequest([]string{"BTC-USD", "ETH-USD"}, "1", "req-1", "10", []string{"0", "1"})
+
+	bySymbol := store.GetSubscriptionsBySymbol()
+	if len(bySymbol["BTC-USD"]) != 1 || len(bySymbol["ETH-USD"]) != 1 {
+		t.Errorf("expected the subscription under both symbols, got %v", bySymbol)
+	}
+
+	store.RemoveSubscription("ETH-USD")
+	if len(store.GetSubscriptionStatus()) != 0 {
+		t.Error("expected removing ETH-USD to remove the BTC-USD,ETH-USD subscription")
+	}
+}
+
+// TestSubscription_StaleUntilResubscribeData verifies the reconnect lifecycle:
+// stale on logout, re-keyed on resubscribe, and fresh again once data arrives
+// for the new MdReqId.
+func TestSubscription_StaleUntilResubscribeData(t *testing.T) {
+	store := NewTradeStore(100, "")
+	store.AddSubscription("BTC-USD", "1", "req-1")
+
+	store.MarkSubscriptionsStale()
+	if !store.GetSubscriptionStatus()["req-1"].Stale {
+		t.Fatal("expected subscription to be stale after logout")
+	}
+
+	if !store.ReplaceSubscriptionReqId("req-1", "req-2") {
+		t.Fatal("expected ReplaceSubscriptionReqId to find req-1")
+	}
+	subs := store.GetSubscriptionStatus()
+	if _, exists := subs["req-1"]; exists {
+		t.Error("old reqId should no longer be tracked")
+	}
+	if !subs["req-2"].Stale {
+		t.Error("subscription should stay stale until data arrives")
+	}
+	if got := store.OriginalMdReqId("req-2"); got != "req-1" {
+		t.Errorf("expected req-2 to map back to req-1, got %s", got)
+	}
+
+	store.AddTrades("BTC-USD", []Trade{{Price: "50000"}}, true, "req-2")
+	if store.GetSubscriptionStatus()["req-2"].Stale {
+		t.Error("subscription should be fresh after data for the new reqId")
+	}
+}
+
+// TestSubscription_OriginalMdReqIdSurvivesRepeatedResubscribes verifies that
+// the mapping always points at the first request, not the previous one.
+func TestSubscription_OriginalMdReqIdSurvivesRepeatedResubscribes(t *testing.T) {
+	store := NewTradeStore(100, "")
+	store.AddSubscription("BTC-USD", "1", "req-1")
+
+	store.ReplaceSubscriptionReqId("req-1", "req-2")
+	store.ReplaceSubscriptionReqId("req-2", "req-3")
+
+	if got := store.OriginalMdReqId("req-3"); got != "req-1" {
+		t.Errorf("expected req-3 to map back to req-1, got %s", got)
+	}
+	if got := store.OriginalMdReqId("unknown"); got != "unknown" {
+		t.Errorf("expected unknown reqId returned unchanged, got %s", got)
+	}
+}
+
+// TestSubscription_CurrentMdReqIdFollowsResubscribes verifies that the id
+// handed out when subscribing still finds the subscription after it has been
+// re-keyed by resubscribes.
+func TestSubscription_CurrentMdReqIdFollowsResubscribes(t *testing.T) {
+	store := NewTradeStore(100, "")
+	store.AddSubscription("BTC-USD", "1", "req-1")
+
+	store.ReplaceSubscriptionReqId("req-1", "req-2")
+	store.ReplaceSubscriptionReqId("req-2", "req-3")
+
+	if got := store.CurrentMdReqId("req-1"); got != "req-3" {
+		t.Errorf("expected req-1 to resolve to req-3, got %s", got)
+	}
+	if got := store.CurrentMdReqId("req-3"); got != "req-3" {
+		t.Errorf("expected current reqId returned unchanged, got %s", got)
+	}
+	if got := store.CurrentMdReqId("unknown"); got != "unknown" {
+		t.Errorf("expected unknown reqId returned unchanged, got %s", got)
+	}
+}
+
 // TestSubscription_RemoveBySymbolRemovesAllMatching verifies that
 // RemoveSubscription removes all subscriptions for a symbol.
 func TestSubscription_RemoveBySymbolRemovesAllMatching(t *testing.T) {
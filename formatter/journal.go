@@ -0,0 +1,234 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"prime-fix-md-go/constants"
+)
+
+// Journal directions.
+const (
+	DirectionIncoming = "IN"
+	DirectionOutgoing = "OUT"
+)
+
+// redactedValue replaces the value of every field in redactedTags.
+const redactedValue = "***"
+
+// redactedTags are never written to a journal: the Logon HMAC signature and
+// the API passphrase.
+var redactedTags = []string{
+	strconv.Itoa(int(constants.TagHmac)),
+	strconv.Itoa(int(constants.TagPassword)),
+}
+
+// Journal is an audit trail of raw FIX messages. Messages are redacted before
+// they reach the journal.
+type Journal interface {
+	Record(timestamp time.Time, direction, sessionId, message string) error
+	Close() error
+}
+
+// RedactMessage returns msg with the values of secret fields replaced by ***.
+// BodyLength and CheckSum are left as received, so the result is for reading,
+// not for replay.
+func RedactMessage(msg []byte) string {
+	if !containsRedactedTag(msg) {
+		return string(msg)
+	}
+
+	var b strings.Builder
+	b.Grow(len(msg))
+	for len(msg) > 0 {
+		end := bytes.IndexByte(msg, '\x01')
+		field := msg
+		if end >= 0 {
+			field = msg[:end+1]
+		}
+		msg = msg[len(field):]
+
+		eq := bytes.IndexByte(field, '=')
+		if eq > 0 && isRedactedTag(field[:eq]) {
+			b.Write(field[:eq+1])
+			b.WriteString(redactedValue)
+			if field[len(field)-1] == '\x01' {
+				b.WriteByte('\x01')
+			}
+			continue
+		}
+		b.Write(field)
+	}
+	return b.String()
+}
+
+func containsRedactedTag(msg []byte) bool {
+	for _, tag := range redactedTags {
+		if bytes.HasPrefix(msg, []byte(tag+"=")) || bytes.Contains(msg, []byte("\x01"+tag+"=")) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRedactedTag(tag []byte) bool {
+	for _, t := range redactedTags {
+		if string(tag) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// FileJournal writes one line per message to a file and rotates it by size:
+// path is renamed to path.1, path.1 to path.2 and so on, keeping maxBackups
+// old files. SOH delimiters are written as '|' so the file reads in a pager.
+type FileJournal struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileJournal opens (or appends to) the journal at path. A maxBytes of 0
+// disables rotation.
+func NewFileJournal(path string, maxBytes int64, maxBackups int) (*FileJournal, error) {
+	j := &FileJournal{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *FileJournal) open() error {
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat journal: %v", err)
+	}
+	j.file = f
+	j.size = info.Size()
+	return nil
+}
+
+// journalTimeFormat is the timestamp layout of FileJournal lines.
+const journalTimeFormat = "2006-01-02T15:04:05.000000Z"
+
+// JournalEntry is one parsed FileJournal line.
+type JournalEntry struct {
+	Timestamp time.Time
+	Direction string
+	SessionId string
+	Message   string // SOH-delimited, as sent or received
+}
+
+// ParseJournalLine parses a line written by FileJournal and restores the SOH
+// delimiters. Field values that themselves contained '|' cannot be recovered.
+func ParseJournalLine(line string) (JournalEntry, error) {
+	parts := strings.SplitN(strings.TrimRight(line, "\r\n"), " ", 4)
+	if len(parts) != 4 {
+		return JournalEntry{}, fmt.Errorf("malformed journal line")
+	}
+
+	timestamp, err := time.Parse(journalTimeFormat, parts[0])
+	if err != nil {
+		return JournalEntry{}, fmt.Errorf("invalid journal timestamp: %v", err)
+	}
+	if parts[1] != DirectionIncoming && parts[1] != DirectionOutgoing {
+		return JournalEntry{}, fmt.Errorf("invalid journal direction %q", parts[1])
+	}
+
+	return JournalEntry{
+		Timestamp: timestamp,
+		Direction: parts[1],
+		SessionId: parts[2],
+		Message:   strings.ReplaceAll(parts[3], "|", "\x01"),
+	}, nil
+}
+
+// Record appends a line: timestamp, direction, session ID, message.
+func (j *FileJournal) Record(timestamp time.Time, direction, sessionId, message string) error {
+	line := timestamp.UTC().Format(journalTimeFormat) + " " + direction + " " + sessionId + " " +
+		strings.ReplaceAll(message, "\x01", "|") + "\n"
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return fmt.Errorf("journal is closed")
+	}
+	if j.maxBytes > 0 && j.size > 0 && j.size+int64(len(line)) > j.maxBytes {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := j.file.WriteString(line)
+	j.size += int64(n)
+	return err
+}
+
+// rotate shifts existing backups up by one and starts a new file.
+func (j *FileJournal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return fmt.Errorf("failed to close journal: %v", err)
+	}
+	j.file = nil
+
+	if j.maxBackups > 0 {
+		_ = os.Remove(j.backupPath(j.maxBackups))
+		for i := j.maxBackups - 1; i >= 1; i-- {
+			_ = os.Rename(j.backupPath(i), j.backupPath(i+1)) // missing backups are expected
+		}
+		if err := os.Rename(j.path, j.backupPath(1)); err != nil {
+			return fmt.Errorf("failed to rotate journal: %v", err)
+		}
+	} else if err := os.Remove(j.path); err != nil {
+		return fmt.Errorf("failed to rotate journal: %v", err)
+	}
+
+	return j.open()
+}
+
+func (j *FileJournal) backupPath(n int) string {
+	return j.path + "." + strconv.Itoa(n)
+}
+
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
@@ -0,0 +1,191 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// Tests for the raw FIX message journal.
+// These tests verify redaction of secrets, file rotation and that TableLog
+// forwards both directions to the configured journal.
+
+type recordedMessage struct {
+	direction string
+	sessionId string
+	message   string
+}
+
+type memoryJournal struct {
+	mu       sync.Mutex
+	messages []recordedMessage
+}
+
+func (j *memoryJournal) Record(_ time.Time, direction, sessionId, message string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.messages = append(j.messages, recordedMessage{direction, sessionId, message})
+	return nil
+}
+
+func (j *memoryJournal) Close() error { return nil }
+
+func TestRedactMessage(t *testing.T) {
+	logon := "8=FIXT.1.1\x019=120\x0135=A\x0134=1\x0196=c2lnbmF0dXJl\x01554=my-passphrase\x019407=access-key\x0110=042\x01"
+
+	redacted := RedactMessage([]byte(logon))
+
+	if strings.Contains(redacted, "c2lnbmF0dXJl") || strings.Contains(redacted, "my-passphrase") {
+		t.Fatalf("Secrets not redacted: %q", redacted)
+	}
+	if !strings.Contains(redacted, "\x0196=***\x01") || !strings.Contains(redacted, "\x01554=***\x01") {
+		t.Errorf("Expected redaction markers, got %q", redacted)
+	}
+	if !strings.Contains(redacted, "\x0135=A\x01") || !strings.Contains(redacted, "\x019407=access-key\x01") {
+		t.Errorf("Non-secret fields should be unchanged, got %q", redacted)
+	}
+}
+
+func TestRedactMessageWithoutSecrets(t *testing.T) {
+	msg := "8=FIXT.1.1\x0135=W\x01196=not-a-signature\x0110=000\x01"
+
+	if got := RedactMessage([]byte(msg)); got != msg {
+		t.Errorf("Expected message unchanged, got %q", got)
+	}
+}
+
+func TestFileJournalRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fix.log")
+	journal, err := NewFileJournal(path, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to create journal: %v", err)
+	}
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC)
+	if err := journal.Record(ts, DirectionIncoming, "FIXT.1.1:COIN->CLIENT", "8=FIXT.1.1\x0135=W\x01"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	want := "2025-01-02T03:04:05.000006Z IN FIXT.1.1:COIN->CLIENT 8=FIXT.1.1|35=W|\n"
+	if string(data) != want {
+		t.Errorf("Expected %q, got %q", want, string(data))
+	}
+
+	if err := journal.Record(ts, DirectionIncoming, "", "35=0\x01"); err == nil {
+		t.Error("Expected error recording to a closed journal")
+	}
+}
+
+func TestFileJournalRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fix.log")
+	journal, err := NewFileJournal(path, 100, 2)
+	if err != nil {
+		t.Fatalf("Failed to create journal: %v", err)
+	}
+	defer journal.Close()
+
+	// Each line is ~70 bytes so every record after the first rotates
+	for i := 0; i < 5; i++ {
+		if err := journal.Record(time.Now(), DirectionOutgoing, "S", strings.Repeat("x", 30)); err != nil {
+			t.Fatalf("Record %d failed: %v", i, err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("Expected %s to exist: %v", filepath.Base(name), err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only 2 backups to be kept, stat err=%v", err)
+	}
+}
+
+func TestTableLogJournalsBothDirections(t *testing.T) {
+	journal := &memoryJournal{}
+	sessionId := quickfix.SessionID{BeginString: "FIXT.1.1", SenderCompID: "CLIENT", TargetCompID: "COIN"}
+
+	log, err := NewTableLogFactoryWithJournal(journal).CreateSessionLog(sessionId)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	log.OnOutgoing([]byte("8=FIXT.1.1\x0135=A\x0196=secret\x01"))
+	log.OnIncoming([]byte("8=FIXT.1.1\x0135=A\x01"))
+	log.OnIncoming(nil)
+
+	if len(journal.messages) != 2 {
+		t.Fatalf("Expected 2 journaled messages, got %d", len(journal.messages))
+	}
+	out := journal.messages[0]
+	if out.direction != DirectionOutgoing || out.sessionId != sessionId.String() {
+		t.Errorf("Unexpected outgoing entry: %+v", out)
+	}
+	if strings.Contains(out.message, "secret") {
+		t.Errorf("Expected HMAC to be redacted before journaling, got %q", out.message)
+	}
+	if journal.messages[1].direction != DirectionIncoming {
+		t.Errorf("Expected incoming entry, got %+v", journal.messages[1])
+	}
+}
+
+func TestParseJournalLineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fix.log")
+	journal, err := NewFileJournal(path, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to create journal: %v", err)
+	}
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC)
+	msg := "8=FIXT.1.1\x019=5\x0135=0\x0110=000\x01"
+	if err := journal.Record(ts, DirectionIncoming, "FIXT.1.1:CLIENT->COIN", msg); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	_ = journal.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	entry, err := ParseJournalLine(string(data))
+	if err != nil {
+		t.Fatalf("ParseJournalLine failed: %v", err)
+	}
+	if !entry.Timestamp.Equal(ts) || entry.Direction != DirectionIncoming ||
+		entry.SessionId != "FIXT.1.1:CLIENT->COIN" || entry.Message != msg {
+		t.Errorf("Round trip mismatch: %+v", entry)
+	}
+
+	for _, bad := range []string{"", "2025-01-02T03:04:05.000006Z IN", "yesterday IN S 35=0|", "2025-01-02T03:04:05.000006Z SIDEWAYS S 35=0|"} {
+		if _, err := ParseJournalLine(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
@@ -18,35 +18,57 @@ package formatter
 
 import (
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/quickfixgo/quickfix"
 )
 
-type TableLogFactory struct{}
+type TableLogFactory struct {
+	journal Journal
+}
 
 func NewTableLogFactory() *TableLogFactory {
 	return &TableLogFactory{}
 }
 
+// NewTableLogFactoryWithJournal returns a factory whose logs also record every
+// inbound and outbound message, redacted, to journal.
+func NewTableLogFactoryWithJournal(journal Journal) *TableLogFactory {
+	return &TableLogFactory{journal: journal}
+}
+
 func (f *TableLogFactory) Create() (quickfix.Log, error) {
-	return &TableLog{}, nil
+	return &TableLog{Journal: f.journal}, nil
 }
 
 func (f *TableLogFactory) CreateSessionLog(sessionId quickfix.SessionID) (quickfix.Log, error) {
-	return &TableLog{SessionId: sessionId}, nil
+	return &TableLog{SessionId: sessionId, Journal: f.journal}, nil
 }
 
 type TableLog struct {
 	SessionId quickfix.SessionID
+	Journal   Journal // Optional raw message audit trail
 }
 
 func (l *TableLog) OnIncoming(msg []byte) {
-	// Raw FIX data logging disabled - data is processed in application layer
+	// Raw FIX data is not displayed - data is processed in application layer
+	l.record(DirectionIncoming, msg)
 }
 
 func (l *TableLog) OnOutgoing(msg []byte) {
-	// Raw FIX data logging disabled - data is processed in application layer
+	// Raw FIX data is not displayed - data is processed in application layer
+	l.record(DirectionOutgoing, msg)
+}
+
+func (l *TableLog) record(direction string, msg []byte) {
+	if l.Journal == nil || len(msg) == 0 {
+		return
+	}
+	if err := l.Journal.Record(time.Now(), direction, l.SessionId.String(), RedactMessage(msg)); err != nil {
+		log.Printf("Failed to journal %s message: %v", direction, err)
+	}
 }
 
 func (l *TableLog) OnEvent(msg string) {
@@ -0,0 +1,657 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gateway serves a FIX session over local HTTP/JSON and WebSocket,
+// for tools that want market data and order entry without speaking FIX.
+//
+//	tool ──HTTP──▶ Server ──▶ fixclient.Client ──FIX──▶ Prime
+//	     ◀──JSON──        ◀── TradeStore, OrderBook, OrderStore
+//	     ◀──WebSocket──── MarketDataBus
+//
+// Reads are served from the in-process stores, so they work without a live
+// session. Every request must carry the server's token, as
+// "Authorization: Bearer <token>" or, for WebSocket clients that cannot set
+// headers, a token query parameter.
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"prime-fix-md-go/builder"
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/fixclient"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// DefaultTimeout bounds how long a request waits for Prime to answer.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultTradeLimit is the number of trades returned when the request
+	// does not give a limit.
+	DefaultTradeLimit = 100
+
+	maxRequestBody     = 1 << 20
+	streamWriteTimeout = 10 * time.Second
+)
+
+// streamEvents are the event kinds a stream can ask for, in the order they
+// are subscribed.
+var streamEvents = []string{
+	fixclient.EventTrade, fixclient.EventBookUpdate, fixclient.EventBBO, fixclient.EventCandle, fixclient.EventReject,
+}
+
+// OrderRequest is the body of POST /api/orders.
+type OrderRequest struct {
+	ClOrdID     string `json:"clOrdId,omitempty"` // Generated when empty
+	Symbol      string `json:"symbol"`
+	Side        string `json:"side"`                  // buy or sell
+	Type        string `json:"type,omitempty"`        // market, limit, stop or stoplimit; limit with a price, else market
+	Qty         string `json:"qty,omitempty"`         // Base units
+	CashQty     string `json:"cashQty,omitempty"`     // Quote units, instead of qty
+	Price       string `json:"price,omitempty"`       // Limit price
+	StopPx      string `json:"stopPx,omitempty"`      // Stop price for stop orders
+	TimeInForce string `json:"timeInForce,omitempty"` // gtc, ioc, fok or gtd; default gtc
+	Strategy    string `json:"strategy,omitempty"`    // Target strategy: L, M, T, V, SL or R
+	PostOnly    bool   `json:"postOnly,omitempty"`
+}
+
+// Book is a symbol's order book as returned by GET /api/books/{symbol}.
+type Book struct {
+	Symbol     string                `json:"symbol"`
+	Bids       []fixclient.BookLevel `json:"bids"`
+	Offers     []fixclient.BookLevel `json:"offers"`
+	LastUpdate time.Time             `json:"lastUpdate"`
+}
+
+// Status is returned by GET /api/status.
+type Status struct {
+	LoggedOn      bool   `json:"loggedOn"`
+	Session       string `json:"session"`
+	Subscriptions int    `json:"subscriptions"`
+	OpenOrders    int    `json:"openOrders"`
+	Streams       int    `json:"streams"`
+}
+
+// StreamEvent is one message on the WebSocket stream. Data is a
+// fixclient.Trade, BookUpdate, BBO, Candle or MarketDataReject, by Type.
+type StreamEvent struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// errorResponse is the body of every error. Order is set when an order was
+// sent, e.g. when Prime rejected it or did not answer in time.
+type errorResponse struct {
+	Error string           `json:"error"`
+	Order *fixclient.Order `json:"order,omitempty"`
+}
+
+// Server is the gateway for one FIX session. It is an http.Handler; Start
+// serves it on an address.
+type Server struct {
+	Client  *fixclient.Client
+	Timeout time.Duration // Bounds waits for Prime; DefaultTimeout when zero
+
+	token      string
+	mux        *http.ServeMux
+	httpServer *http.Server
+
+	mu      sync.Mutex
+	streams map[*stream]struct{}
+	closed  bool
+	wg      sync.WaitGroup // Running streams
+}
+
+// New creates a gateway for client that accepts requests carrying token.
+// The client's FixApp must have a Bus for the stream.
+func New(client *fixclient.Client, token string) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("gateway token must not be empty")
+	}
+	if client.App.Bus == nil {
+		return nil, errors.New("gateway needs a FixApp with a MarketDataBus")
+	}
+
+	s := &Server{
+		Client:  client,
+		Timeout: DefaultTimeout,
+		token:   token,
+		mux:     http.NewServeMux(),
+		streams: make(map[*stream]struct{}),
+	}
+	s.mux.HandleFunc("GET /api/status", s.handleStatus)
+	s.mux.HandleFunc("GET /api/subscriptions", s.handleListSubscriptions)
+	s.mux.HandleFunc("POST /api/subscriptions", s.handleSubscribe)
+	s.mux.HandleFunc("DELETE /api/subscriptions/{mdReqId}", s.handleUnsubscribe)
+	s.mux.HandleFunc("GET /api/books", s.handleListBooks)
+	s.mux.HandleFunc("GET /api/books/{symbol}", s.handleBook)
+	s.mux.HandleFunc("GET /api/trades/{symbol}", s.handleTrades)
+	s.mux.HandleFunc("GET /api/orders", s.handleListOrders)
+	s.mux.HandleFunc("GET /api/orders/{id}", s.handleOrder)
+	s.mux.HandleFunc("POST /api/orders", s.handlePlaceOrder)
+	s.mux.HandleFunc("DELETE /api/orders/{id}", s.handleCancelOrder)
+	s.mux.HandleFunc("GET /api/quotes", s.handleListQuotes)
+	s.mux.HandleFunc("GET /api/quotes/{quoteReqId}", s.handleQuote)
+	s.mux.HandleFunc("GET /api/stream", s.handleStream)
+	return s, nil
+}
+
+// Start listens on addr and serves in the background. It returns the
+// address listened on, which tells the port when addr gives port 0.
+func (s *Server) Start(addr string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("gateway listen: %w", err)
+	}
+	s.httpServer = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Gateway stopped: %v", err)
+		}
+	}()
+	log.Printf("Gateway listening on http://%s", listener.Addr())
+	return listener.Addr(), nil
+}
+
+// Close stops accepting requests, closes the streams and waits for them to
+// unsubscribe from the bus. Requests waiting for Prime are given until their
+// timeout to finish.
+func (s *Server) Close() error {
+	var err error
+	if s.httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+		defer cancel()
+		err = s.httpServer.Shutdown(ctx)
+	}
+
+	s.mu.Lock()
+	s.closed = true
+	for st := range s.streams {
+		st.ws.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// ServeHTTP checks the token and routes the request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="gateway"`)
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"), nil)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		token = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) timeout() time.Duration {
+	if s.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return s.Timeout
+}
+
+// --- Status and market data ---
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	app := s.Client.App
+	s.mu.Lock()
+	streams := len(s.streams)
+	s.mu.Unlock()
+
+	status := Status{
+		LoggedOn:      app.IsLoggedOn(),
+		Subscriptions: len(app.TradeStore.GetSubscriptionStatus()),
+		OpenOrders:    len(app.OrderStore.GetOpenOrders()),
+		Streams:       streams,
+	}
+	if status.LoggedOn {
+		status.Session = app.SessionId.String()
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs := make([]*fixclient.Subscription, 0)
+	for _, sub := range s.Client.App.TradeStore.GetSubscriptionStatus() {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Symbol != subs[j].Symbol {
+			return subs[i].Symbol < subs[j].Symbol
+		}
+		return subs[i].MdReqId < subs[j].MdReqId
+	})
+	writeJSON(w, http.StatusOK, subs)
+}
+
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	var req fixclient.SubscribeRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+	if len(req.Symbols) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("symbols is required"), nil)
+		return
+	}
+	if req.Depth < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid depth %d", req.Depth), nil)
+		return
+	}
+	for i, symbol := range req.Symbols {
+		req.Symbols[i] = strings.ToUpper(symbol)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout())
+	defer cancel()
+	sub, err := s.Client.Subscribe(ctx, req)
+	if err != nil {
+		writeError(w, statusFor(err), err, nil)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+func (s *Server) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if err := s.Client.Unsubscribe(r.PathValue("mdReqId")); err != nil {
+		writeError(w, statusFor(err), err, nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListBooks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Client.App.OrderBook.Symbols())
+}
+
+// handleBook returns a symbol's book, limited to the depth query parameter
+// per side when given.
+func (s *Server) handleBook(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	depth, err := intParam(r, "depth", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	book := s.Client.App.OrderBook
+	lastUpdate := book.LastUpdate(symbol)
+	if lastUpdate.IsZero() {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no book for %s; subscribe to it first", symbol), nil)
+		return
+	}
+	bids, offers := book.TopLevels(symbol, depth)
+	if bids == nil {
+		bids = []fixclient.BookLevel{}
+	}
+	if offers == nil {
+		offers = []fixclient.BookLevel{}
+	}
+	writeJSON(w, http.StatusOK, Book{Symbol: symbol, Bids: bids, Offers: offers, LastUpdate: lastUpdate})
+}
+
+// handleTrades returns the most recent market data entries for a symbol,
+// oldest first, up to the limit query parameter.
+func (s *Server) handleTrades(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", DefaultTradeLimit)
+	if err != nil || limit <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", r.URL.Query().Get("limit")), nil)
+		return
+	}
+	trades := s.Client.App.TradeStore.GetRecentTrades(strings.ToUpper(r.PathValue("symbol")), limit)
+	if trades == nil {
+		trades = []fixclient.Trade{}
+	}
+	writeJSON(w, http.StatusOK, trades)
+}
+
+// --- Orders and quotes ---
+
+// handleListOrders returns the current version of every order, or only the
+// open ones with ?status=open, oldest first.
+func (s *Server) handleListOrders(w http.ResponseWriter, r *http.Request) {
+	var orders []*fixclient.Order
+	switch r.URL.Query().Get("status") {
+	case "":
+		orders = s.Client.App.OrderStore.GetCurrentOrders()
+	case "open":
+		orders = s.Client.App.OrderStore.GetOpenOrders()
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("status must be open or omitted"), nil)
+		return
+	}
+	if orders == nil {
+		orders = []*fixclient.Order{}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].CreatedAt.Before(orders[j].CreatedAt) })
+	writeJSON(w, http.StatusOK, orders)
+}
+
+func (s *Server) handleOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	order := s.Client.App.OrderStore.ResolveOrder(id)
+	if order == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", fixclient.ErrUnknownOrder, id), nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+func (s *Server) handlePlaceOrder(w http.ResponseWriter, r *http.Request) {
+	var req OrderRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+	params, err := req.params()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout())
+	defer cancel()
+	order, err := s.Client.PlaceOrder(ctx, params)
+	if err != nil {
+		writeError(w, statusFor(err), err, order)
+		return
+	}
+	writeJSON(w, http.StatusCreated, order)
+}
+
+func (s *Server) handleCancelOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout())
+	defer cancel()
+	order, err := s.Client.CancelOrder(ctx, r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err, order)
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+func (s *Server) handleListQuotes(w http.ResponseWriter, r *http.Request) {
+	quotes := s.Client.App.OrderStore.GetAllQuotes()
+	if quotes == nil {
+		quotes = []*fixclient.Quote{}
+	}
+	sort.Slice(quotes, func(i, j int) bool { return quotes[i].QuoteReqID < quotes[j].QuoteReqID })
+	writeJSON(w, http.StatusOK, quotes)
+}
+
+func (s *Server) handleQuote(w http.ResponseWriter, r *http.Request) {
+	quoteReqId := r.PathValue("quoteReqId")
+	quote := s.Client.App.OrderStore.GetQuote(quoteReqId)
+	if quote == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such quote request: %s", quoteReqId), nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, quote)
+}
+
+// params validates the request and converts it to New Order Single fields.
+// Defaults the request leaves out are filled in by Client.PlaceOrder.
+func (req OrderRequest) params() (builder.NewOrderParams, error) {
+	params := builder.NewOrderParams{
+		ClOrdID:        req.ClOrdID,
+		Symbol:         strings.ToUpper(req.Symbol),
+		OrderQty:       req.Qty,
+		CashOrderQty:   req.CashQty,
+		Price:          req.Price,
+		StopPx:         req.StopPx,
+		TargetStrategy: strings.ToUpper(req.Strategy),
+	}
+	if params.Symbol == "" {
+		return params, errors.New("symbol is required")
+	}
+	if (req.Qty == "") == (req.CashQty == "") {
+		return params, errors.New("exactly one of qty and cashQty is required")
+	}
+
+	switch strings.ToLower(req.Side) {
+	case "buy":
+		params.Side = constants.SideBuy
+	case "sell":
+		params.Side = constants.SideSell
+	default:
+		return params, fmt.Errorf("side must be buy or sell, got %q", req.Side)
+	}
+
+	switch strings.ToLower(req.Type) {
+	case "":
+	case "market":
+		params.OrdType = constants.OrdTypeMarket
+	case "limit":
+		params.OrdType = constants.OrdTypeLimit
+	case "stop":
+		params.OrdType = constants.OrdTypeStop
+	case "stoplimit":
+		params.OrdType = constants.OrdTypeStopLimit
+	default:
+		return params, fmt.Errorf("type must be market, limit, stop or stoplimit, got %q", req.Type)
+	}
+
+	switch strings.ToLower(req.TimeInForce) {
+	case "":
+	case "gtc":
+		params.TimeInForce = constants.TimeInForceGTC
+	case "ioc":
+		params.TimeInForce = constants.TimeInForceIOC
+	case "fok":
+		params.TimeInForce = constants.TimeInForceFOK
+	case "gtd":
+		params.TimeInForce = constants.TimeInForceGTD
+	default:
+		return params, fmt.Errorf("timeInForce must be gtc, ioc, fok or gtd, got %q", req.TimeInForce)
+	}
+
+	if req.PostOnly {
+		params.ExecInst = constants.ExecInstPostOnly
+	}
+	return params, nil
+}
+
+// --- WebSocket stream ---
+
+// stream is one WebSocket client. Each of its bus subscribers writes from
+// its own goroutine, so writes are serialized by mu.
+type stream struct {
+	ws     *websocket.Conn
+	mu     sync.Mutex
+	failed bool
+}
+
+// handleStream upgrades to a WebSocket that receives live market data as
+// StreamEvents. The symbols and events query parameters, comma-separated,
+// limit what is sent; both default to everything.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	symbols := splitList(strings.ToUpper(r.URL.Query().Get("symbols")))
+	events := splitList(r.URL.Query().Get("events"))
+	if len(events) == 0 {
+		events = streamEvents
+	}
+	for _, event := range events {
+		if !slices.Contains(streamEvents, event) {
+			writeError(w, http.StatusBadRequest,
+				fmt.Errorf("unknown event %q; use %s", event, strings.Join(streamEvents, ", ")), nil)
+			return
+		}
+	}
+
+	// Tools that are not browsers send no Origin, and the token already
+	// authenticates the request, so the Origin check is skipped
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		s.serveStream(ws, r.RemoteAddr, symbols, events)
+	}}
+	server.ServeHTTP(w, r)
+}
+
+// serveStream subscribes the connection to the bus until the client goes
+// away or the server closes.
+func (s *Server) serveStream(ws *websocket.Conn, remoteAddr string, symbols, events []string) {
+	st := &stream{ws: ws}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ws.Close()
+		return
+	}
+	s.streams[st] = struct{}{}
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, st)
+		s.mu.Unlock()
+		s.wg.Done()
+	}()
+
+	bus := s.Client.App.Bus
+	cfg := fixclient.SubscriberConfig{Name: "gateway " + remoteAddr, Symbols: symbols}
+	var subs []*fixclient.Subscriber
+	for _, event := range events {
+		switch event {
+		case fixclient.EventTrade:
+			subs = append(subs, bus.OnTrade(cfg, func(t fixclient.Trade) { st.send(event, t) }))
+		case fixclient.EventBookUpdate:
+			subs = append(subs, bus.OnBookUpdate(cfg, func(u fixclient.BookUpdate) { st.send(event, u) }))
+		case fixclient.EventBBO:
+			subs = append(subs, bus.OnBBO(cfg, func(b fixclient.BBO) { st.send(event, b) }))
+		case fixclient.EventCandle:
+			subs = append(subs, bus.OnCandle(cfg, func(c fixclient.Candle) { st.send(event, c) }))
+		case fixclient.EventReject:
+			subs = append(subs, bus.OnReject(cfg, func(rej fixclient.MarketDataReject) { st.send(event, rej) }))
+		}
+	}
+	log.Printf("Gateway stream opened for %s (events: %s)", remoteAddr, strings.Join(events, ","))
+
+	// Messages from the client are ignored; reading detects when it closes
+	var discard []byte
+	for websocket.Message.Receive(ws, &discard) == nil {
+	}
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	ws.Close()
+	log.Printf("Gateway stream closed for %s", remoteAddr)
+}
+
+// send writes one event. A client that cannot take it within
+// streamWriteTimeout is disconnected; until then the bus queues its events
+// and drops the oldest.
+func (st *stream) send(kind string, data any) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.failed {
+		return
+	}
+	st.ws.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if err := websocket.JSON.Send(st.ws, StreamEvent{Type: kind, Data: data}); err != nil {
+		st.failed = true
+		st.ws.Close() // Ends the read loop, which unsubscribes
+	}
+}
+
+// --- Helpers ---
+
+// statusFor maps a Client error to an HTTP status.
+func statusFor(err error) int {
+	var risk *fixclient.RiskRejection
+	var orderRejected *fixclient.OrderRejectedError
+	var mdRejected *fixclient.MarketDataRejectedError
+	switch {
+	case errors.Is(err, fixclient.ErrNotLoggedOn):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, fixclient.ErrUnknownOrder), errors.Is(err, fixclient.ErrUnknownSubscription):
+		return http.StatusNotFound
+	case errors.Is(err, fixclient.ErrOrderNotOpen):
+		return http.StatusConflict
+	case errors.As(err, &risk), errors.As(err, &orderRejected), errors.As(err, &mdRejected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Gateway: failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error, order *fixclient.Order) {
+	writeJSON(w, status, errorResponse{Error: err.Error(), Order: order})
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,287 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/fixclient"
+
+	"github.com/quickfixgo/quickfix"
+	"golang.org/x/net/websocket"
+)
+
+// Tests for the gateway.
+// These tests serve a Client with no FIX session: market data is delivered
+// straight to the FixApp as a replay would, and orders are added to its
+// OrderStore.
+
+const testToken = "test-token"
+
+// newTestGateway serves a sessionless Client with httptest.
+func newTestGateway(t *testing.T) (*httptest.Server, *fixclient.FixApp) {
+	t.Helper()
+	client := fixclient.NewClient(&fixclient.Config{}, nil)
+	server, err := New(client, testToken)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(func() {
+		server.Close()
+		ts.Close()
+		client.App.Bus.Close()
+	})
+	return ts, client.App
+}
+
+// fixMessage builds a raw FIX message with a correct BodyLength and CheckSum
+// from the fields after BodyLength (MsgType first).
+func fixMessage(fields ...string) string {
+	body := strings.Join(fields, "\x01") + "\x01"
+	msg := "8=FIXT.1.1\x019=" + strconv.Itoa(len(body)) + "\x01" + body
+	sum := 0
+	for i := 0; i < len(msg); i++ {
+		sum += int(msg[i])
+	}
+	return msg + fmt.Sprintf("10=%03d\x01", sum%256)
+}
+
+// deliverSnapshot delivers a Market Data Snapshot (W) for symbol with one
+// bid, one offer and one trade.
+func deliverSnapshot(t *testing.T, app *fixclient.FixApp, seq int, symbol, bid, offer, trade string) {
+	t.Helper()
+	raw := fixMessage("35=W", "49=COIN", "56=CLIENT", "34="+strconv.Itoa(seq), "52=20250101-12:00:00.000",
+		"262=md-1", "55="+symbol, "268=3",
+		"269=0", "270="+bid, "271=1.5", "290=1",
+		"269=1", "270="+offer, "271=2.0", "290=1",
+		"269=2", "270="+trade, "271=0.1")
+	msg := quickfix.NewMessage()
+	if err := quickfix.ParseMessage(msg, bytes.NewBufferString(raw)); err != nil {
+		t.Fatalf("failed to parse snapshot: %v", err)
+	}
+	if rej := app.FromApp(msg, quickfix.SessionID{}); rej != nil {
+		t.Fatalf("snapshot rejected: %v", rej)
+	}
+}
+
+// do sends a request with the test token and decodes a JSON response into v.
+func do(t *testing.T, ts *httptest.Server, method, path, body string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("bad request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if v != nil {
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("%s %s: bad JSON %q: %v", method, path, data, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// TestServer_RequiresToken verifies that requests without the token, in the
+// header or the query, are refused.
+func TestServer_RequiresToken(t *testing.T) {
+	ts, _ := newTestGateway(t)
+
+	tests := []struct {
+		name, header, query string
+		want                int
+	}{
+		{"none", "", "", http.StatusUnauthorized},
+		{"wrong", "Bearer nope", "", http.StatusUnauthorized},
+		{"not bearer", testToken, "", http.StatusUnauthorized},
+		{"header", "Bearer " + testToken, "", http.StatusOK},
+		{"query", "", "?token=" + testToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/status"+tt.query, nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, resp.StatusCode)
+		}
+	}
+}
+
+// TestServer_ReadsFromStores verifies the book, trade, subscription, order
+// and quote endpoints against the in-process stores.
+func TestServer_ReadsFromStores(t *testing.T) {
+	ts, app := newTestGateway(t)
+	app.TradeStore.AddSubscriptionRequest([]string{"BTC-USD"}, constants.SubscriptionRequestTypeSubscribe, "md-1", "0",
+		[]string{constants.MdEntryTypeBid, constants.MdEntryTypeOffer, constants.MdEntryTypeTrade})
+	deliverSnapshot(t, app, 2, "BTC-USD", "49999.00", "50001.00", "50000.00")
+	app.OrderStore.AddOrder(&fixclient.Order{ClOrdID: "ord-1", OrderID: "exch-1", Symbol: "BTC-USD",
+		Side: constants.SideBuy, OrdStatus: constants.OrdStatusNew})
+	app.OrderStore.AddQuoteRequest(&fixclient.Quote{QuoteReqID: "rfq-1", Symbol: "BTC-USD"})
+
+	var book Book
+	if status := do(t, ts, http.MethodGet, "/api/books/btc-usd?depth=1", "", &book); status != http.StatusOK {
+		t.Fatalf("expected the book, got status %d", status)
+	}
+	if len(book.Bids) != 1 || book.Bids[0].Price != "49999.00" || len(book.Offers) != 1 || book.Offers[0].Price != "50001.00" {
+		t.Errorf("unexpected book: %+v", book)
+	}
+	if status := do(t, ts, http.MethodGet, "/api/books/ETH-USD", "", nil); status != http.StatusNotFound {
+		t.Errorf("expected 404 for a symbol without a book, got %d", status)
+	}
+
+	var trades []fixclient.Trade
+	do(t, ts, http.MethodGet, "/api/trades/BTC-USD?limit=1", "", &trades)
+	if len(trades) != 1 || trades[0].EntryType != constants.MdEntryTypeTrade || trades[0].Price != "50000.00" {
+		t.Errorf("expected the last entry to be the trade, got %+v", trades)
+	}
+	if status := do(t, ts, http.MethodGet, "/api/trades/BTC-USD?limit=0", "", nil); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for a zero limit, got %d", status)
+	}
+
+	var subs []fixclient.Subscription
+	do(t, ts, http.MethodGet, "/api/subscriptions", "", &subs)
+	if len(subs) != 1 || subs[0].MdReqId != "md-1" || !subs[0].SnapshotReceived {
+		t.Errorf("expected md-1 with its snapshot, got %+v", subs)
+	}
+
+	var orders []fixclient.Order
+	do(t, ts, http.MethodGet, "/api/orders?status=open", "", &orders)
+	if len(orders) != 1 || orders[0].ClOrdID != "ord-1" {
+		t.Errorf("expected ord-1 open, got %+v", orders)
+	}
+	var order fixclient.Order
+	if status := do(t, ts, http.MethodGet, "/api/orders/exch-1", "", &order); status != http.StatusOK || order.ClOrdID != "ord-1" {
+		t.Errorf("expected exch-1 to resolve to ord-1, got %d %+v", status, order)
+	}
+
+	var quotes []fixclient.Quote
+	do(t, ts, http.MethodGet, "/api/quotes", "", &quotes)
+	if len(quotes) != 1 || quotes[0].QuoteReqID != "rfq-1" {
+		t.Errorf("expected rfq-1, got %+v", quotes)
+	}
+}
+
+// TestServer_OrderEntryWithoutSession verifies that bad orders are refused
+// before anything is sent and that valid ones report the session is down.
+func TestServer_OrderEntryWithoutSession(t *testing.T) {
+	ts, _ := newTestGateway(t)
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"symbol":"BTC-USD","side":"hold","qty":"1"}`, http.StatusBadRequest},
+		{`{"symbol":"BTC-USD","side":"buy"}`, http.StatusBadRequest},
+		{`{"symbol":"BTC-USD","side":"buy","qty":"1","type":"iceberg"}`, http.StatusBadRequest},
+		{`{"symbol":"BTC-USD","side":"buy","qty":"1","colour":"red"}`, http.StatusBadRequest},
+		{`{"symbol":"BTC-USD","side":"buy","qty":"1","price":"50000","strategy":"L"}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		var resp errorResponse
+		if status := do(t, ts, http.MethodPost, "/api/orders", tt.body, &resp); status != tt.want || resp.Error == "" {
+			t.Errorf("%s: expected status %d with an error, got %d %+v", tt.body, tt.want, status, resp)
+		}
+	}
+
+	if status := do(t, ts, http.MethodPost, "/api/subscriptions", `{"symbols":["BTC-USD"]}`, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 subscribing without a session, got %d", status)
+	}
+}
+
+// TestServer_Stream verifies that a stream receives only the events and
+// symbols it asked for, and is closed with the server.
+func TestServer_Stream(t *testing.T) {
+	client := fixclient.NewClient(&fixclient.Config{}, nil)
+	defer client.App.Bus.Close()
+	server, err := New(client, testToken)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/stream?token=" + testToken + "&symbols=BTC-USD&events=trade,bbo"
+	ws, err := websocket.Dial(url, "", ts.URL)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer ws.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(client.App.Bus.Subscribers()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 bus subscribers, got %d", len(client.App.Bus.Subscribers()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// ETH-USD is published first on each topic, so a broken filter shows first
+	deliverSnapshot(t, client.App, 2, "ETH-USD", "2999.00", "3001.00", "3000.00")
+	deliverSnapshot(t, client.App, 3, "BTC-USD", "49999.00", "50001.00", "50000.00")
+
+	seen := make(map[string]bool)
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(seen) < 2 {
+		var event struct {
+			Type string
+			Data struct{ Symbol string }
+		}
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			t.Fatalf("expected trade and bbo events, got %v after %v", err, seen)
+		}
+		if event.Data.Symbol != "BTC-USD" {
+			t.Errorf("expected only BTC-USD, got %s %s", event.Type, event.Data.Symbol)
+		}
+		seen[event.Type] = true
+	}
+	if !seen[fixclient.EventTrade] || !seen[fixclient.EventBBO] {
+		t.Errorf("expected trade and bbo events, got %v", seen)
+	}
+
+	if status := do(t, ts, http.MethodGet, "/api/stream?events=quotes", "", nil); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown event, got %d", status)
+	}
+
+	server.Close()
+	if subs := client.App.Bus.Subscribers(); len(subs) != 0 {
+		t.Errorf("expected the stream to unsubscribe on Close, got %d subscribers", len(subs))
+	}
+	var discard []byte
+	if err := websocket.Message.Receive(ws, &discard); err == nil {
+		t.Error("expected the stream to be closed")
+	}
+}
@@ -17,13 +17,24 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"prime-fix-md-go/builder"
+	"prime-fix-md-go/constants"
 	"prime-fix-md-go/database"
 	"prime-fix-md-go/fixclient"
+	"prime-fix-md-go/mockprime"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/config"
 )
 
 func TestDatabaseIntegration(t *testing.T) {
@@ -307,3 +318,789 @@ func TestEndToEndMarketDataFlow(t *testing.T) {
 		t.Fatalf("Expected %d total trades, got %d", expectedTradeCount, len(allTrades))
 	}
 }
+
+// loopbackAcceptor is a minimal FIX acceptor application that records what the
+// client sends so session recovery can be verified end to end.
+type loopbackAcceptor struct {
+	mu           sync.Mutex
+	logonSeqNums []int
+	appMsgTypes  []string
+	mdRequests   []*quickfix.Message
+	gapFills     int
+}
+
+func (a *loopbackAcceptor) OnCreate(quickfix.SessionID)                       {}
+func (a *loopbackAcceptor) OnLogon(quickfix.SessionID)                        {}
+func (a *loopbackAcceptor) OnLogout(quickfix.SessionID)                       {}
+func (a *loopbackAcceptor) ToAdmin(*quickfix.Message, quickfix.SessionID)     {}
+func (a *loopbackAcceptor) ToApp(*quickfix.Message, quickfix.SessionID) error { return nil }
+func (a *loopbackAcceptor) FromApp(msg *quickfix.Message, _ quickfix.SessionID) quickfix.MessageRejectError {
+	msgType, _ := msg.Header.GetString(constants.TagMsgType)
+	a.mu.Lock()
+	a.appMsgTypes = append(a.appMsgTypes, msgType)
+	if msgType == constants.MsgTypeMarketDataRequest {
+		a.mdRequests = append(a.mdRequests, msg)
+	}
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *loopbackAcceptor) FromAdmin(msg *quickfix.Message, _ quickfix.SessionID) quickfix.MessageRejectError {
+	msgType, _ := msg.Header.GetString(constants.TagMsgType)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch msgType {
+	case constants.MsgTypeLogon:
+		seqNum, _ := msg.Header.GetInt(constants.TagMsgSeqNum)
+		a.logonSeqNums = append(a.logonSeqNums, seqNum)
+	case "4": // SequenceReset
+		a.gapFills++
+	}
+	return nil
+}
+
+func (a *loopbackAcceptor) snapshot() (logons []int, appMsgTypes []string, gapFills int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]int(nil), a.logonSeqNums...), append([]string(nil), a.appMsgTypes...), a.gapFills
+}
+
+func (a *loopbackAcceptor) marketDataRequests() []*quickfix.Message {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]*quickfix.Message(nil), a.mdRequests...)
+}
+
+func newLoopbackSettings(t *testing.T, connectionType string, port int, senderCompId, targetCompId string) *quickfix.Settings {
+	t.Helper()
+	settings := quickfix.NewSettings()
+
+	global := settings.GlobalSettings()
+	global.Set(config.SocketConnectHost, "127.0.0.1")
+	global.Set(config.SocketConnectPort, strconv.Itoa(port))
+	global.Set(config.SocketAcceptPort, strconv.Itoa(port))
+	global.Set(config.StartTime, "00:00:00")
+	global.Set(config.EndTime, "00:00:00")
+	global.Set(config.HeartBtInt, "30")
+	global.Set(config.ReconnectInterval, "1")
+	global.Set(config.ResetOnLogon, "N")
+	global.Set(constants.SettingMessageStore, constants.MessageStoreTypeSqlite)
+
+	session := quickfix.NewSessionSettings()
+	session.Set(config.BeginString, constants.FixBeginString)
+	session.Set(config.DefaultApplVerID, "9")
+	session.Set(config.SenderCompID, senderCompId)
+	session.Set(config.TargetCompID, targetCompId)
+	if _, err := settings.AddSession(session); err != nil {
+		t.Fatalf("Failed to add %s session: %v", connectionType, err)
+	}
+	return settings
+}
+
+func freeLocalPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %s", what)
+}
+
+// TestSessionRecoveryWithLocalAcceptor runs the client against a local acceptor
+// with ResetOnLogon=N and the SQLite message store on both sides. It verifies
+// that a restarted client resumes its sequence numbers, and that when the
+// acceptor requests a resend the stale MarketDataRequest is gap-filled rather
+// than replayed.
+func TestSessionRecoveryWithLocalAcceptor(t *testing.T) {
+	tmpDir := t.TempDir()
+	clientDbPath := filepath.Join(tmpDir, "client.db")
+	port := freeLocalPort(t)
+
+	acceptorDb, err := database.NewMarketDataDb(filepath.Join(tmpDir, "acceptor.db"))
+	if err != nil {
+		t.Fatalf("Failed to create acceptor database: %v", err)
+	}
+	defer acceptorDb.Close()
+
+	acceptorApp := &loopbackAcceptor{}
+	acceptorSettings := newLoopbackSettings(t, "acceptor", port, "COIN", "CLIENT")
+	clientSettings := newLoopbackSettings(t, "initiator", port, "CLIENT", "COIN")
+	clientSessionID := quickfix.SessionID{BeginString: constants.FixBeginString, SenderCompID: "CLIENT", TargetCompID: "COIN"}
+	acceptorSessionID := quickfix.SessionID{BeginString: constants.FixBeginString, SenderCompID: "COIN", TargetCompID: "CLIENT"}
+
+	startAcceptor := func() *quickfix.Acceptor {
+		acceptor, err := quickfix.NewAcceptor(acceptorApp, acceptorDb.NewMessageStoreFactory(), acceptorSettings, quickfix.NewNullLogFactory())
+		if err != nil {
+			t.Fatalf("Failed to create acceptor: %v", err)
+		}
+		if err := acceptor.Start(); err != nil {
+			t.Fatalf("Failed to start acceptor: %v", err)
+		}
+		return acceptor
+	}
+
+	// runClient starts the client with a fresh database handle (as a restart
+	// would), waits for logon, runs fn and stops the initiator.
+	runClient := func(expectedLogons int, fn func()) {
+		db, err := database.NewMarketDataDb(clientDbPath)
+		if err != nil {
+			t.Fatalf("Failed to open client database: %v", err)
+		}
+		defer db.Close()
+
+		app := fixclient.NewFixApp(fixclient.NewConfig("", "", "", "CLIENT", "COIN", ""), db)
+		storeFactory, err := fixclient.NewMessageStoreFactory(clientSettings, db)
+		if err != nil {
+			t.Fatalf("Failed to create message store: %v", err)
+		}
+		initiator, err := quickfix.NewInitiator(app, storeFactory, clientSettings, quickfix.NewNullLogFactory())
+		if err != nil {
+			t.Fatalf("Failed to create initiator: %v", err)
+		}
+		if err := initiator.Start(); err != nil {
+			t.Fatalf("Failed to start initiator: %v", err)
+		}
+		defer initiator.Stop()
+
+		waitFor(t, "client logon", func() bool {
+			logons, _, _ := acceptorApp.snapshot()
+			return len(logons) >= expectedLogons
+		})
+		fn()
+	}
+
+	// First run: logon (1), MarketDataRequest (2), logout (3)
+	acceptor := startAcceptor()
+	runClient(1, func() {
+		msg := builder.BuildMarketDataRequest("recovery-req", []string{"BTC-USD"},
+			constants.SubscriptionRequestTypeSnapshot, "0", "CLIENT", "COIN",
+			[]string{constants.MdEntryTypeTrade})
+		if err := quickfix.SendToTarget(msg, clientSessionID); err != nil {
+			t.Fatalf("Failed to send market data request: %v", err)
+		}
+		waitFor(t, "market data request", func() bool {
+			_, appMsgTypes, _ := acceptorApp.snapshot()
+			return len(appMsgTypes) == 1
+		})
+	})
+	acceptor.Stop()
+
+	// Rewind the acceptor so it believes it missed messages 2-3 and will issue
+	// a ResendRequest when the client logs on again.
+	acceptorStore, err := acceptorDb.NewMessageStoreFactory().Create(acceptorSessionID)
+	if err != nil {
+		t.Fatalf("Failed to open acceptor store: %v", err)
+	}
+	if err := acceptorStore.SetNextTargetMsgSeqNum(2); err != nil {
+		t.Fatalf("Failed to rewind acceptor: %v", err)
+	}
+
+	// Second run: the client must resume at 4 instead of resetting to 1
+	acceptor = startAcceptor()
+	defer acceptor.Stop()
+	runClient(2, func() {
+		waitFor(t, "gap fill", func() bool {
+			_, _, gapFills := acceptorApp.snapshot()
+			return gapFills > 0
+		})
+	})
+
+	logons, appMsgTypes, _ := acceptorApp.snapshot()
+	if logons[1] != 4 {
+		t.Errorf("Expected restarted client to log on with seq 4, got %d", logons[1])
+	}
+	if len(appMsgTypes) != 1 {
+		t.Errorf("Expected stale MarketDataRequest to be gap-filled, acceptor received %v", appMsgTypes)
+	}
+}
+
+// TestResubscribeAfterReconnect verifies that tracked subscriptions are replayed
+// with fresh MdReqIds on every logon, and that the new ids map back to the
+// original request.
+func TestResubscribeAfterReconnect(t *testing.T) {
+	tmpDir := t.TempDir()
+	port := freeLocalPort(t)
+
+	acceptorDb, err := database.NewMarketDataDb(filepath.Join(tmpDir, "acceptor.db"))
+	if err != nil {
+		t.Fatalf("Failed to create acceptor database: %v", err)
+	}
+	defer acceptorDb.Close()
+
+	clientDb, err := database.NewMarketDataDb(filepath.Join(tmpDir, "client.db"))
+	if err != nil {
+		t.Fatalf("Failed to create client database: %v", err)
+	}
+	defer clientDb.Close()
+
+	acceptorApp := &loopbackAcceptor{}
+	acceptorSettings := newLoopbackSettings(t, "acceptor", port, "COIN", "CLIENT")
+	clientSettings := newLoopbackSettings(t, "initiator", port, "CLIENT", "COIN")
+
+	startAcceptor := func() *quickfix.Acceptor {
+		acceptor, err := quickfix.NewAcceptor(acceptorApp, acceptorDb.NewMessageStoreFactory(), acceptorSettings, quickfix.NewNullLogFactory())
+		if err != nil {
+			t.Fatalf("Failed to create acceptor: %v", err)
+		}
+		if err := acceptor.Start(); err != nil {
+			t.Fatalf("Failed to start acceptor: %v", err)
+		}
+		return acceptor
+	}
+
+	// A subscription left over from an earlier session
+	app := fixclient.NewFixApp(fixclient.NewConfig("", "", "", "CLIENT", "COIN", ""), clientDb)
+	app.TradeStore.AddSubscriptionRequest([]string{"BTC-USD"}, constants.SubscriptionRequestTypeSubscribe,
+		"orig-req", "10", []string{constants.MdEntryTypeBid, constants.MdEntryTypeOffer})
+	app.TradeStore.MarkSubscriptionsStale()
+
+	acceptor := startAcceptor()
+	initiator, err := quickfix.NewInitiator(app, clientDb.NewMessageStoreFactory(), clientSettings, quickfix.NewNullLogFactory())
+	if err != nil {
+		t.Fatalf("Failed to create initiator: %v", err)
+	}
+	if err := initiator.Start(); err != nil {
+		t.Fatalf("Failed to start initiator: %v", err)
+	}
+	defer initiator.Stop()
+
+	waitFor(t, "first resubscribe", func() bool { return len(acceptorApp.marketDataRequests()) == 1 })
+
+	// Drop the session from the acceptor side and bring it back
+	acceptor.Stop()
+	acceptor = startAcceptor()
+	defer acceptor.Stop()
+
+	waitFor(t, "second resubscribe", func() bool { return len(acceptorApp.marketDataRequests()) == 2 })
+
+	seen := make(map[string]bool)
+	for _, msg := range acceptorApp.marketDataRequests() {
+		reqId, _ := msg.Body.GetString(constants.TagMdReqId)
+		depth, _ := msg.Body.GetString(constants.TagMarketDepth)
+		subType, _ := msg.Body.GetString(constants.TagSubscriptionRequestType)
+
+		if reqId == "orig-req" || seen[reqId] {
+			t.Errorf("Expected a fresh MdReqId on each resubscribe, got %q", reqId)
+		}
+		seen[reqId] = true
+		if depth != "10" || subType != constants.SubscriptionRequestTypeSubscribe {
+			t.Errorf("Resubscribe did not replay the original request: depth=%s type=%s", depth, subType)
+		}
+		if !strings.Contains(msg.String(), "55=BTC-USD") {
+			t.Errorf("Resubscribe missing original symbol: %s", msg.String())
+		}
+	}
+
+	subs := app.TradeStore.GetSubscriptionStatus()
+	if len(subs) != 1 {
+		t.Fatalf("Expected 1 tracked subscription, got %d", len(subs))
+	}
+	for reqId, sub := range subs {
+		if !seen[reqId] {
+			t.Errorf("Tracked reqId %s was never sent", reqId)
+		}
+		if app.TradeStore.OriginalMdReqId(reqId) != "orig-req" {
+			t.Errorf("Expected %s to map back to orig-req", reqId)
+		}
+		if !sub.Stale {
+			t.Error("Subscription should stay stale until market data arrives")
+		}
+	}
+}
+
+var mockPrimeCredentials = mockprime.Credentials{AccessKey: "test-key", Passphrase: "test-pass", SigningKey: "test-secret"}
+
+// startMockPrimeSession starts a mock Prime acceptor with a scripted BTC-USD
+// market and connects a headless FixApp signing with creds. It waits for
+// logon only when wantLogon is set.
+func startMockPrimeSession(t *testing.T, creds mockprime.Credentials, wantLogon bool) (*mockprime.Server, *fixclient.FixApp) {
+	t.Helper()
+	server := startMockPrime(t)
+	app := fixclient.NewFixApp(fixclient.NewConfig(creds.AccessKey, creds.SigningKey, creds.Passphrase,
+		"CLIENT", "COIN", "portfolio-1"), nil)
+	app.Headless = true
+	connectToMockPrime(t, server, app, wantLogon)
+	return server, app
+}
+
+func startMockPrime(t *testing.T) *mockprime.Server {
+	t.Helper()
+	return startMockPrimeWithConfig(t, mockPrimeConfig())
+}
+
+// mockPrimeConfig returns the mock's default configuration: the test
+// credentials and a scripted BTC-USD book.
+func mockPrimeConfig() mockprime.Config {
+	return mockprime.Config{
+		TargetCompID: "CLIENT",
+		Credentials:  mockPrimeCredentials,
+		Books: map[string]mockprime.Book{
+			"BTC-USD": {
+				Bids:   []mockprime.Level{{Price: "49999.00", Size: "1.0"}, {Price: "49998.00", Size: "2.0"}},
+				Offers: []mockprime.Level{{Price: "50001.00", Size: "1.5"}, {Price: "50002.00", Size: "2.5"}},
+				Updates: []mockprime.Update{
+					{Action: constants.MdUpdateActionChange, EntryType: constants.MdEntryTypeBid, Price: "49999.00", Size: "3.0", Position: 1},
+					{Action: constants.MdUpdateActionNew, EntryType: constants.MdEntryTypeOffer, Price: "50000.50", Size: "0.5", Position: 1},
+				},
+			},
+		},
+	}
+}
+
+func startMockPrimeWithConfig(t *testing.T, cfg mockprime.Config) *mockprime.Server {
+	t.Helper()
+	server, err := mockprime.Start(cfg)
+	if err != nil {
+		t.Fatalf("Failed to start mock Prime: %v", err)
+	}
+	t.Cleanup(server.Stop)
+	return server
+}
+
+// connectToMockPrime starts an initiator for app against server. Stopping the
+// returned initiator disconnects the client; it is also stopped on cleanup.
+func connectToMockPrime(t *testing.T, server *mockprime.Server, app *fixclient.FixApp, wantLogon bool) *quickfix.Initiator {
+	t.Helper()
+	settings := server.InitiatorSettings()
+	storeFactory, err := fixclient.NewMessageStoreFactory(settings, nil)
+	if err != nil {
+		t.Fatalf("Failed to create message store: %v", err)
+	}
+	initiator, err := quickfix.NewInitiator(app, storeFactory, settings, quickfix.NewNullLogFactory())
+	if err != nil {
+		t.Fatalf("Failed to create initiator: %v", err)
+	}
+	if err := initiator.Start(); err != nil {
+		t.Fatalf("Failed to start initiator: %v", err)
+	}
+	t.Cleanup(initiator.Stop)
+
+	if wantLogon {
+		waitFor(t, "client logon", app.IsLoggedOn)
+	}
+	return initiator
+}
+
+func sendToMockPrime(t *testing.T, app *fixclient.FixApp, msg *quickfix.Message) {
+	t.Helper()
+	if err := quickfix.SendToTarget(msg, app.SessionId); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+}
+
+// TestMockPrimeLogon verifies that the client's logon signature is accepted
+// and that bad credentials are rejected and stop the client reconnecting.
+// Subtests run one at a time because quickfix registers session IDs globally.
+func TestMockPrimeLogon(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		server, _ := startMockPrimeSession(t, mockPrimeCredentials, true)
+		if server.Logons() != 1 || server.LogonFailures() != 0 {
+			t.Errorf("Expected 1 logon and no failures, got %d and %d", server.Logons(), server.LogonFailures())
+		}
+	})
+
+	t.Run("bad signature", func(t *testing.T) {
+		badCreds := mockPrimeCredentials
+		badCreds.SigningKey = "wrong-secret"
+		server, app := startMockPrimeSession(t, badCreds, false)
+		waitFor(t, "rejected logon", app.ShouldExit)
+		if server.Logons() != 0 || server.LogonFailures() == 0 || app.IsLoggedOn() {
+			t.Errorf("Expected the logon to be rejected, got %d logons and %d failures", server.Logons(), server.LogonFailures())
+		}
+	})
+}
+
+// TestMockPrimeMarketData verifies that a subscription builds the order book
+// from the snapshot and then applies the streamed incrementals.
+func TestMockPrimeMarketData(t *testing.T) {
+	_, app := startMockPrimeSession(t, mockPrimeCredentials, true)
+
+	sendToMockPrime(t, app, builder.BuildMarketDataRequest("md-1", []string{"BTC-USD"},
+		constants.SubscriptionRequestTypeSubscribe, "10", "CLIENT", "COIN",
+		[]string{constants.MdEntryTypeBid, constants.MdEntryTypeOffer}))
+
+	waitFor(t, "streamed offer", func() bool {
+		_, offer, _, hasOffer := app.OrderBook.BestBidOffer("BTC-USD")
+		return hasOffer && offer.Price == "50000.50"
+	})
+	bid, _, hasBid, _ := app.OrderBook.BestBidOffer("BTC-USD")
+	if !hasBid || bid.Price != "49999.00" || bid.Size != "3.0" {
+		t.Errorf("Expected best bid 3.0@49999.00 after incremental, got %+v", bid)
+	}
+}
+
+// TestMockPrimeOrderEntry verifies a market fill, a limit order that is
+// replaced and cancelled, and an RFQ that is quoted and accepted.
+func TestMockPrimeOrderEntry(t *testing.T) {
+	_, app := startMockPrimeSession(t, mockPrimeCredentials, true)
+	orderStatus := func(clOrdID string) string {
+		if order := app.OrderStore.GetOrder(clOrdID); order != nil {
+			return order.OrdStatus
+		}
+		return ""
+	}
+
+	// Market order fills at the best offer
+	sendToMockPrime(t, app, builder.BuildNewOrderSingle(builder.NewOrderParams{
+		Account: "portfolio-1", ClOrdID: "mkt-1", Symbol: "BTC-USD", Side: constants.SideBuy,
+		OrdType: constants.OrdTypeMarket, TargetStrategy: constants.TargetStrategyMarket,
+		TimeInForce: constants.TimeInForceIOC, OrderQty: "0.1",
+	}, "CLIENT", "COIN"))
+	waitFor(t, "market fill", func() bool { return orderStatus("mkt-1") == constants.OrdStatusFilled })
+	if order := app.OrderStore.GetOrder("mkt-1"); order.AvgPx != "50001.00" || order.CumQty != "0.1" {
+		t.Errorf("Unexpected market fill: %+v", order)
+	}
+
+	// Resting limit order is replaced, then cancelled
+	sendToMockPrime(t, app, builder.BuildNewOrderSingle(builder.NewOrderParams{
+		Account: "portfolio-1", ClOrdID: "lmt-1", Symbol: "BTC-USD", Side: constants.SideBuy,
+		OrdType: constants.OrdTypeLimit, TargetStrategy: constants.TargetStrategyLimit,
+		TimeInForce: constants.TimeInForceGTC, OrderQty: "1", Price: "49000.00",
+	}, "CLIENT", "COIN"))
+	waitFor(t, "limit ack", func() bool { return orderStatus("lmt-1") == constants.OrdStatusNew })
+	orderID := app.OrderStore.GetOrder("lmt-1").OrderID
+
+	sendToMockPrime(t, app, builder.BuildOrderCancelReplaceRequest(builder.ReplaceOrderParams{
+		Account: "portfolio-1", ClOrdID: "rep-1", OrigClOrdID: "lmt-1", OrderID: orderID,
+		Symbol: "BTC-USD", Side: constants.SideBuy, OrdType: constants.OrdTypeLimit,
+		OrderQty: "2", Price: "49500.00",
+	}, "CLIENT", "COIN"))
+	waitFor(t, "replace", func() bool { return app.OrderStore.GetOrder("rep-1") != nil })
+	if order := app.OrderStore.GetOrder("rep-1"); order.OrderID != orderID || order.Price != "49500.00" || order.OrderQty != "2" {
+		t.Errorf("Unexpected replaced order: %+v", order)
+	}
+	if order := app.OrderStore.ResolveOrder("lmt-1"); order == nil || order.ClOrdID != "rep-1" {
+		t.Errorf("Expected lmt-1 to resolve to rep-1, got %+v", order)
+	}
+
+	sendToMockPrime(t, app, builder.BuildOrderCancelRequest(builder.CancelOrderParams{
+		Account: "portfolio-1", ClOrdID: "cxl-1", OrigClOrdID: "rep-1", OrderID: orderID,
+		Symbol: "BTC-USD", Side: constants.SideBuy, OrderQty: "2",
+	}, "CLIENT", "COIN"))
+	waitFor(t, "cancel", func() bool { return orderStatus("rep-1") == constants.OrdStatusCanceled })
+	if status := orderStatus("lmt-1"); status != constants.OrdStatusReplaced {
+		t.Errorf("Expected lmt-1 to be replaced by rep-1, got status %s", status)
+	}
+	if order := app.OrderStore.GetOrder("cxl-1"); order != nil {
+		t.Errorf("Expected the cancel to update rep-1, not track cxl-1: %+v", order)
+	}
+
+	// RFQ is quoted one-sided and the quote is accepted
+	sendToMockPrime(t, app, builder.BuildQuoteRequest(builder.QuoteRequestParams{
+		QuoteReqID: "rfq-1", Account: "portfolio-1", Symbol: "BTC-USD",
+		Side: constants.SideSell, OrderQty: "0.5", Price: "49000.00",
+	}, "CLIENT", "COIN"))
+	waitFor(t, "quote", func() bool { return app.OrderStore.GetQuote("rfq-1") != nil })
+	quote := app.OrderStore.GetQuote("rfq-1")
+	if quote.BidPx != "49999.00" || quote.OfferPx != "" || quote.ValidUntilTime.IsZero() {
+		t.Fatalf("Expected a bid-only quote with an expiry, got %+v", quote)
+	}
+
+	sendToMockPrime(t, app, builder.BuildAcceptQuote(builder.AcceptQuoteParams{
+		Account: "portfolio-1", ClOrdID: "acc-1", Symbol: quote.Symbol, Side: constants.SideSell,
+		QuoteID: quote.QuoteID, OrderQty: quote.BidSize, Price: quote.BidPx,
+	}, "CLIENT", "COIN"))
+	waitFor(t, "quote fill", func() bool { return orderStatus("acc-1") == constants.OrdStatusFilled })
+}
+
+// TestMockPrimeReconcileOnLogon verifies that orders left pending across a
+// disconnect are reconciled after the next logon, first with a mass status
+// request, following up the order it leaves out, and then with per-order
+// status requests.
+func TestMockPrimeReconcileOnLogon(t *testing.T) {
+	server := startMockPrime(t)
+	app := fixclient.NewFixApp(fixclient.NewConfig(mockPrimeCredentials.AccessKey, mockPrimeCredentials.SigningKey,
+		mockPrimeCredentials.Passphrase, "CLIENT", "COIN", "portfolio-1"), nil)
+	app.Headless = true
+	initiator := connectToMockPrime(t, server, app, true)
+	orderStatus := func(clOrdID string) string {
+		if order := app.OrderStore.GetOrder(clOrdID); order != nil {
+			return order.OrdStatus
+		}
+		return ""
+	}
+
+	sendToMockPrime(t, app, builder.BuildNewOrderSingle(builder.NewOrderParams{
+		Account: "portfolio-1", ClOrdID: "lmt-1", Symbol: "BTC-USD", Side: constants.SideBuy,
+		OrdType: constants.OrdTypeLimit, TargetStrategy: constants.TargetStrategyLimit,
+		TimeInForce: constants.TimeInForceGTC, OrderQty: "1", Price: "49000.00",
+	}, "CLIENT", "COIN"))
+	waitFor(t, "limit ack", func() bool { return orderStatus("lmt-1") == constants.OrdStatusNew })
+
+	// reconnect disconnects, rewinds lmt-1 as if its acknowledgement had been
+	// lost, adds an order the mock never received and logs on again
+	reconnect := func(mode string) {
+		t.Helper()
+		initiator.Stop()
+		waitFor(t, "client logout", func() bool { return !app.IsLoggedOn() })
+		app.OrderStore.AddOrder(&fixclient.Order{ClOrdID: "lmt-1", Symbol: "BTC-USD", Side: constants.SideBuy,
+			OrderQty: "1", Price: "49000.00", OrdStatus: constants.OrdStatusPendingNew})
+		app.OrderStore.AddOrder(&fixclient.Order{ClOrdID: "lost-1", Symbol: "BTC-USD", Side: constants.SideBuy,
+			OrderQty: "1", Price: "48000.00", OrdStatus: constants.OrdStatusPendingNew})
+		app.ReconcileMode = mode
+		initiator = connectToMockPrime(t, server, app, true)
+	}
+
+	// Mass status reports only lmt-1; lost-1 is then asked about on its own,
+	// which the mock rejects
+	reconnect(constants.ReconcileModeMass)
+	waitFor(t, "mass status reconciliation", func() bool {
+		return orderStatus("lmt-1") == constants.OrdStatusNew && orderStatus("lost-1") == constants.OrdStatusRejected
+	})
+	if order := app.OrderStore.GetOrder("lmt-1"); order.OrderID == "" {
+		t.Errorf("Expected lmt-1 to regain its OrderID, got %+v", order)
+	}
+	if n := len(server.Received(constants.MsgTypeOrderMassStatusRequest)); n != 1 {
+		t.Errorf("Expected 1 mass status request, got %d", n)
+	}
+	if n := len(server.Received(constants.MsgTypeOrderStatusRequest)); n != 1 {
+		t.Errorf("Expected 1 follow-up order status request, got %d", n)
+	}
+
+	// Per-order status requests resolve both orders
+	reconnect(constants.ReconcileModeOrders)
+	waitFor(t, "order status reconciliation", func() bool {
+		return orderStatus("lmt-1") == constants.OrdStatusNew && orderStatus("lost-1") == constants.OrdStatusRejected
+	})
+	if n := len(server.Received(constants.MsgTypeOrderStatusRequest)); n != 3 {
+		t.Errorf("Expected 3 order status requests, got %d", n)
+	}
+}
+
+// TestMockPrimeCancelAll verifies that the kill switch cancels only the
+// matching orders with an Order Mass Cancel Request, and falls back to one
+// cancel per open order when the mass cancel is rejected or only a side is
+// given.
+// Subtests run one at a time because quickfix registers session IDs globally.
+func TestMockPrimeCancelAll(t *testing.T) {
+	// placeOrders rests two buys and a sell on BTC-USD
+	placeOrders := func(t *testing.T, app *fixclient.FixApp) {
+		t.Helper()
+		for _, o := range []struct{ clOrdID, side, price string }{
+			{"buy-1", constants.SideBuy, "49000.00"},
+			{"buy-2", constants.SideBuy, "48000.00"},
+			{"sell-1", constants.SideSell, "51000.00"},
+		} {
+			sendToMockPrime(t, app, builder.BuildNewOrderSingle(builder.NewOrderParams{
+				Account: "portfolio-1", ClOrdID: o.clOrdID, Symbol: "BTC-USD", Side: o.side,
+				OrdType: constants.OrdTypeLimit, TargetStrategy: constants.TargetStrategyLimit,
+				TimeInForce: constants.TimeInForceGTC, OrderQty: "1", Price: o.price,
+			}, "CLIENT", "COIN"))
+		}
+		waitFor(t, "resting orders", func() bool { return len(app.OrderStore.GetOpenOrders()) == 3 })
+	}
+	newApp := func() *fixclient.FixApp {
+		app := fixclient.NewFixApp(fixclient.NewConfig(mockPrimeCredentials.AccessKey, mockPrimeCredentials.SigningKey,
+			mockPrimeCredentials.Passphrase, "CLIENT", "COIN", "portfolio-1"), nil)
+		app.Headless = true
+		return app
+	}
+
+	t.Run("mass cancel", func(t *testing.T) {
+		server := startMockPrime(t)
+		app := newApp()
+		connectToMockPrime(t, server, app, true)
+		placeOrders(t, app)
+
+		if _, err := app.CancelAll("BTC-USD", constants.SideBuy); err != nil {
+			t.Fatalf("CancelAll failed: %v", err)
+		}
+		waitFor(t, "buys cancelled", func() bool {
+			open := app.OrderStore.GetOpenOrders()
+			return len(open) == 1 && open[0].ClOrdID == "sell-1"
+		})
+		if n := len(server.Received(constants.MsgTypeOrderCancelRequest)); n != 0 {
+			t.Errorf("Expected no per-order cancels, got %d", n)
+		}
+	})
+
+	t.Run("side only", func(t *testing.T) {
+		server := startMockPrime(t)
+		app := newApp()
+		connectToMockPrime(t, server, app, true)
+		placeOrders(t, app)
+
+		if _, err := app.CancelAll("", constants.SideSell); err != nil {
+			t.Fatalf("CancelAll failed: %v", err)
+		}
+		waitFor(t, "sell cancelled", func() bool { return len(app.OrderStore.GetOpenOrders()) == 2 })
+		for _, order := range app.OrderStore.GetOpenOrders() {
+			if order.Side != constants.SideBuy {
+				t.Errorf("Expected only buys to stay open, got %s", order.ClOrdID)
+			}
+		}
+		if n := len(server.Received(constants.MsgTypeOrderMassCancelRequest)); n != 0 {
+			t.Errorf("Expected no mass cancel for a side alone, got %d", n)
+		}
+		if n := len(server.Received(constants.MsgTypeOrderCancelRequest)); n != 1 {
+			t.Errorf("Expected 1 per-order cancel, got %d", n)
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		cfg := mockPrimeConfig()
+		cfg.RejectMassCancel = true
+		server := startMockPrimeWithConfig(t, cfg)
+		app := newApp()
+		connectToMockPrime(t, server, app, true)
+		placeOrders(t, app)
+
+		if _, err := app.CancelAll("", ""); err != nil {
+			t.Fatalf("CancelAll failed: %v", err)
+		}
+		waitFor(t, "all orders cancelled", func() bool { return len(app.OrderStore.GetOpenOrders()) == 0 })
+		if n := len(app.OrderStore.GetAllOrders()); n != 3 {
+			t.Errorf("Expected cancels to update the 3 orders, got %d orders", n)
+		}
+		if n := len(server.Received(constants.MsgTypeOrderCancelRequest)); n != 3 {
+			t.Errorf("Expected 3 per-order cancels, got %d", n)
+		}
+	})
+}
+
+// TestMockPrimeStrategies verifies that an OCO whose limit leg fills cancels
+// its stop leg, and that a bracket sends its exits once the entry fills and
+// cancels them when the bracket is cancelled.
+func TestMockPrimeStrategies(t *testing.T) {
+	newApp := func() *fixclient.FixApp {
+		app := fixclient.NewFixApp(fixclient.NewConfig(mockPrimeCredentials.AccessKey, mockPrimeCredentials.SigningKey,
+			mockPrimeCredentials.Passphrase, "CLIENT", "COIN", "portfolio-1"), nil)
+		app.Headless = true
+		return app
+	}
+	legStatus := func(app *fixclient.FixApp, id, role string) string {
+		leg := app.Strategies.Get(id).Leg(role)
+		if order := app.OrderStore.ResolveOrder(leg.ClOrdID); leg.Sent && order != nil {
+			return order.OrdStatus
+		}
+		return ""
+	}
+
+	t.Run("oco", func(t *testing.T) {
+		server := startMockPrime(t)
+		app := newApp()
+		connectToMockPrime(t, server, app, true)
+
+		// The limit sell crosses the 49999 bid and fills at once
+		s := fixclient.NewOCOStrategy("BTC-USD", constants.SideSell, "0.5", "49500.00", "48000.00", "47900.00")
+		if err := app.StartStrategy(s); err != nil {
+			t.Fatalf("StartStrategy failed: %v", err)
+		}
+		waitFor(t, "OCO done", func() bool {
+			return app.Strategies.Get(s.ID).Status == constants.StrategyStatusDone
+		})
+		if status := legStatus(app, s.ID, constants.LegRoleStopLoss); status != constants.OrdStatusCanceled {
+			t.Errorf("Expected the stop leg cancelled, got status %s", status)
+		}
+		if n := len(server.Received(constants.MsgTypeOrderCancelRequest)); n != 1 {
+			t.Errorf("Expected 1 cancel, got %d", n)
+		}
+	})
+
+	t.Run("bracket", func(t *testing.T) {
+		server := startMockPrime(t)
+		app := newApp()
+		connectToMockPrime(t, server, app, true)
+
+		s := fixclient.NewBracketStrategy("BTC-USD", constants.SideBuy, "0.1", "", "52000.00", "49000.00", "49000.00")
+		if err := app.StartStrategy(s); err != nil {
+			t.Fatalf("StartStrategy failed: %v", err)
+		}
+		waitFor(t, "exits resting", func() bool {
+			return legStatus(app, s.ID, constants.LegRoleTakeProfit) == constants.OrdStatusNew &&
+				legStatus(app, s.ID, constants.LegRoleStopLoss) == constants.OrdStatusNew
+		})
+		if leg := app.Strategies.Get(s.ID).Leg(constants.LegRoleStopLoss); leg.OrderQty != "0.1" || leg.Side != constants.SideSell {
+			t.Errorf("Expected a 0.1 sell stop-loss, got %+v", leg)
+		}
+
+		if err := app.CancelStrategy(s.ID); err != nil {
+			t.Fatalf("CancelStrategy failed: %v", err)
+		}
+		waitFor(t, "exits cancelled", func() bool { return len(app.OrderStore.GetOpenOrders()) == 0 })
+		if status := app.Strategies.Get(s.ID).Status; status != constants.StrategyStatusCanceled {
+			t.Errorf("Expected the bracket cancelled, got %s", status)
+		}
+	})
+}
+
+// TestMockPrimeClient verifies that the embeddable Client's requests wait for
+// Prime's answer and return typed results and errors.
+func TestMockPrimeClient(t *testing.T) {
+	server := startMockPrime(t)
+	client := fixclient.NewClient(fixclient.NewConfig(mockPrimeCredentials.AccessKey, mockPrimeCredentials.SigningKey,
+		mockPrimeCredentials.Passphrase, "CLIENT", "COIN", "portfolio-1"), nil)
+	if err := client.Start(server.InitiatorSettings()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(client.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.WaitForLogon(ctx); err != nil {
+		t.Fatalf("WaitForLogon failed: %v", err)
+	}
+
+	// Market data: a live subscription and an unknown symbol
+	sub, err := client.Subscribe(ctx, fixclient.SubscribeRequest{Symbols: []string{"BTC-USD"}, Depth: 10})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if !sub.Active || sub.MdReqId == "" {
+		t.Errorf("Expected an active subscription, got %+v", sub)
+	}
+	if _, _, hasBid, _ := client.App.OrderBook.BestBidOffer("BTC-USD"); !hasBid {
+		t.Error("Expected the snapshot to be applied before Subscribe returned")
+	}
+	var mdErr *fixclient.MarketDataRejectedError
+	if _, err := client.Subscribe(ctx, fixclient.SubscribeRequest{Symbols: []string{"DOGE-USD"}}); !errors.As(err, &mdErr) {
+		t.Errorf("Expected a MarketDataRejectedError, got %v", err)
+	}
+	if err := client.Unsubscribe(sub.MdReqId); err != nil {
+		t.Errorf("Unsubscribe failed: %v", err)
+	}
+
+	// Orders: an acknowledged limit, an unknown symbol and a cancel
+	order, err := client.PlaceOrder(ctx, builder.NewOrderParams{
+		Symbol: "BTC-USD", Side: constants.SideBuy, TargetStrategy: constants.TargetStrategyLimit,
+		OrderQty: "1", Price: "49000.00",
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	if order.OrdStatus != constants.OrdStatusNew || order.OrderID == "" || order.OrdType != constants.OrdTypeLimit {
+		t.Errorf("Expected an acknowledged limit order, got %+v", order)
+	}
+
+	var orderErr *fixclient.OrderRejectedError
+	rejected, err := client.PlaceOrder(ctx, builder.NewOrderParams{
+		Symbol: "DOGE-USD", Side: constants.SideBuy, TargetStrategy: constants.TargetStrategyMarket, OrderQty: "1",
+	})
+	if !errors.As(err, &orderErr) || rejected == nil || rejected.OrdStatus != constants.OrdStatusRejected {
+		t.Errorf("Expected a rejected order and an OrderRejectedError, got %+v, %v", rejected, err)
+	}
+
+	canceled, err := client.CancelOrder(ctx, order.ClOrdID)
+	if err != nil {
+		t.Fatalf("CancelOrder failed: %v", err)
+	}
+	if canceled.OrdStatus != constants.OrdStatusCanceled {
+		t.Errorf("Expected the order cancelled, got status %s", canceled.OrdStatus)
+	}
+	if _, err := client.CancelOrder(ctx, "nope"); !errors.Is(err, fixclient.ErrUnknownOrder) {
+		t.Errorf("Expected ErrUnknownOrder, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mockprime
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/utils"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// application implements quickfix.Application for the mock. Handlers return
+// their replies rather than sending them so they can be tested without a
+// session; FromApp sends them in order.
+type application struct {
+	cfg Config
+
+	mu            sync.Mutex
+	logons        int
+	logonFailures int
+	received      []*quickfix.Message
+	orders        map[string]*order // By every ClOrdID in the replace chain
+	quotes        map[string]*quote // By QuoteID
+	streams       map[string]chan struct{}
+	streamsWg     sync.WaitGroup
+
+	nextId atomic.Int64
+}
+
+func newApplication(cfg Config) *application {
+	return &application{
+		cfg:     cfg,
+		orders:  make(map[string]*order),
+		quotes:  make(map[string]*quote),
+		streams: make(map[string]chan struct{}),
+	}
+}
+
+func (a *application) OnCreate(quickfix.SessionID) {}
+
+func (a *application) OnLogon(quickfix.SessionID) {
+	a.mu.Lock()
+	a.logons++
+	a.mu.Unlock()
+}
+
+func (a *application) OnLogout(quickfix.SessionID) {
+	a.closeStreams()
+}
+
+func (a *application) ToAdmin(*quickfix.Message, quickfix.SessionID) {}
+
+func (a *application) ToApp(*quickfix.Message, quickfix.SessionID) error { return nil }
+
+// FromAdmin rejects logons whose credentials or signature do not match.
+func (a *application) FromAdmin(msg *quickfix.Message, _ quickfix.SessionID) quickfix.MessageRejectError {
+	if t, _ := msg.Header.GetString(constants.TagMsgType); t != constants.MsgTypeLogon {
+		return nil
+	}
+	if err := a.validateLogon(msg); err != nil {
+		a.mu.Lock()
+		a.logonFailures++
+		a.mu.Unlock()
+		log.Printf("mockprime: rejecting logon: %v", err)
+		return quickfix.RejectLogon{Text: err.Error()}
+	}
+	return nil
+}
+
+// FromApp records msg, routes it to its handler and sends the replies.
+func (a *application) FromApp(msg *quickfix.Message, sessionId quickfix.SessionID) quickfix.MessageRejectError {
+	recorded := quickfix.NewMessage()
+	msg.CopyInto(recorded)
+	a.mu.Lock()
+	a.received = append(a.received, recorded)
+	a.mu.Unlock()
+
+	var replies []*quickfix.Message
+	var st *stream
+	switch t, _ := msg.Header.GetString(constants.TagMsgType); t {
+	case constants.MsgTypeMarketDataRequest:
+		replies, st = a.handleMarketDataRequest(msg)
+	case constants.MsgTypeNewOrderSingle:
+		replies = a.handleNewOrderSingle(msg)
+	case constants.MsgTypeOrderCancelRequest:
+		replies = a.handleOrderCancelRequest(msg)
+	case constants.MsgTypeOrderCancelReplace:
+		replies = a.handleOrderCancelReplace(msg)
+	case constants.MsgTypeOrderStatusRequest:
+		replies = a.handleOrderStatusRequest(msg)
+	case constants.MsgTypeOrderMassStatusRequest:
+		replies = a.handleOrderMassStatusRequest(msg)
+	case constants.MsgTypeOrderMassCancelRequest:
+		replies = a.handleOrderMassCancelRequest(msg)
+	case constants.MsgTypeQuoteRequest:
+		replies = a.handleQuoteRequest(msg)
+	default:
+		return quickfix.UnsupportedMessageType()
+	}
+
+	for _, reply := range replies {
+		if err := quickfix.SendToTarget(reply, sessionId); err != nil {
+			log.Printf("mockprime: failed to send %s: %v", reply.String(), err)
+		}
+	}
+	if st != nil {
+		a.startStream(st, sessionId)
+	}
+	return nil
+}
+
+// validateLogon recomputes the Prime signature over the received SendingTime,
+// MsgSeqNum and TargetCompID and compares it with Tag 96.
+func (a *application) validateLogon(msg *quickfix.Message) error {
+	creds := a.cfg.Credentials
+	accessKey := utils.GetString(msg, constants.TagAccessKey)
+	passphrase := utils.GetString(msg, constants.TagPassword)
+	signature := utils.GetString(msg, constants.TagHmac)
+
+	if accessKey != creds.AccessKey {
+		return fmt.Errorf("unknown access key %q", accessKey)
+	}
+	if passphrase != creds.Passphrase {
+		return fmt.Errorf("invalid passphrase")
+	}
+
+	sendingTime, _ := msg.Header.GetString(constants.TagSendingTime)
+	seqNum, _ := msg.Header.GetString(constants.TagMsgSeqNum)
+	targetCompId, _ := msg.Header.GetString(constants.TagTargetCompId)
+	expected := utils.Sign(sendingTime, constants.MsgTypeLogon, seqNum, accessKey, targetCompId, passphrase, creds.SigningKey)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// newId returns a unique identifier such as "mock-ord-3".
+func (a *application) newId(kind string) string {
+	return fmt.Sprintf("mock-%s-%d", kind, a.nextId.Add(1))
+}
+
+func newMessage(msgType string) *quickfix.Message {
+	msg := quickfix.NewMessage()
+	msg.Header.SetString(constants.TagMsgType, msgType)
+	return msg
+}
+
+// setIfNotEmpty sets tag only when value is non-empty.
+func setIfNotEmpty(msg *quickfix.Message, tag quickfix.Tag, value string) {
+	if value != "" {
+		msg.Body.SetString(tag, value)
+	}
+}
+
+func transactTime() string {
+	return time.Now().UTC().Format(constants.FixTimeFormat)
+}
+
+// fieldValues returns every value of tag in msg, including those inside
+// repeating groups, in wire order.
+func fieldValues(msg *quickfix.Message, tag quickfix.Tag) []string {
+	var values []string
+	prefix := strconv.Itoa(int(tag)) + "="
+	for _, field := range strings.Split(msg.String(), "\x01") {
+		if strings.HasPrefix(field, prefix) {
+			values = append(values, field[len(prefix):])
+		}
+	}
+	return values
+}
@@ -0,0 +1,221 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mockprime
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/utils"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+var (
+	snapshotTemplate = quickfix.GroupTemplate{
+		quickfix.GroupElement(constants.TagMdEntryType),
+		quickfix.GroupElement(constants.TagMdEntryPx),
+		quickfix.GroupElement(constants.TagMdEntrySize),
+		quickfix.GroupElement(constants.TagMdEntryPositionNo),
+	}
+	incrementalTemplate = quickfix.GroupTemplate{
+		quickfix.GroupElement(constants.TagMdUpdateAction),
+		quickfix.GroupElement(constants.TagMdEntryType),
+		quickfix.GroupElement(constants.TagSymbol),
+		quickfix.GroupElement(constants.TagMdEntryPx),
+		quickfix.GroupElement(constants.TagMdEntrySize),
+		quickfix.GroupElement(constants.TagMdEntryPositionNo),
+	}
+)
+
+// stream is a subscription whose scripted updates are sent after its snapshot.
+type stream struct {
+	mdReqId    string
+	symbols    []string
+	entryTypes map[string]bool
+}
+
+// handleMarketDataRequest answers a MarketDataRequest (V) with a snapshot per
+// symbol. For subscriptions it also returns the stream to start once the
+// snapshots are sent. Unknown symbols and duplicate MdReqIds are rejected
+// with a (Y).
+func (a *application) handleMarketDataRequest(msg *quickfix.Message) ([]*quickfix.Message, *stream) {
+	mdReqId := utils.GetString(msg, constants.TagMdReqId)
+	subType := utils.GetString(msg, constants.TagSubscriptionRequestType)
+	depth, _ := strconv.Atoi(utils.GetString(msg, constants.TagMarketDepth))
+
+	if subType == constants.SubscriptionRequestTypeUnsubscribe {
+		a.stopStream(mdReqId)
+		return nil, nil
+	}
+
+	entryTypes := make(map[string]bool)
+	for _, t := range fieldValues(msg, constants.TagMdEntryType) {
+		entryTypes[t] = true
+	}
+	symbols := fieldValues(msg, constants.TagSymbol)
+
+	a.mu.Lock()
+	_, duplicate := a.streams[mdReqId]
+	a.mu.Unlock()
+	if duplicate {
+		return []*quickfix.Message{marketDataReject(mdReqId, constants.MdReqRejReasonDuplicateMdReqId, "Duplicate MdReqID")}, nil
+	}
+
+	var replies []*quickfix.Message
+	var streamed []string
+	for _, symbol := range symbols {
+		book, ok := a.cfg.Books[symbol]
+		if !ok {
+			replies = append(replies, marketDataReject(mdReqId, constants.MdReqRejReasonUnknownSymbol, "Unknown symbol: "+symbol))
+			continue
+		}
+		replies = append(replies, snapshot(mdReqId, symbol, book, entryTypes, depth))
+		streamed = append(streamed, symbol)
+	}
+
+	if subType != constants.SubscriptionRequestTypeSubscribe || len(streamed) == 0 {
+		return replies, nil
+	}
+	return replies, &stream{mdReqId: mdReqId, symbols: streamed, entryTypes: entryTypes}
+}
+
+func marketDataReject(mdReqId, reason, text string) *quickfix.Message {
+	msg := newMessage(constants.MsgTypeMarketDataReject)
+	msg.Body.SetString(constants.TagMdReqId, mdReqId)
+	msg.Body.SetString(constants.TagMdReqRejReason, reason)
+	msg.Body.SetString(constants.TagText, text)
+	return msg
+}
+
+// snapshot builds a Snapshot (W) of book limited to the requested entry types
+// and, for bids and offers, to depth levels (0 = full book).
+func snapshot(mdReqId, symbol string, book Book, entryTypes map[string]bool, depth int) *quickfix.Message {
+	msg := newMessage(constants.MsgTypeMarketDataSnapshot)
+	msg.Body.SetString(constants.TagMdReqId, mdReqId)
+	msg.Body.SetString(constants.TagSymbol, symbol)
+
+	entries := quickfix.NewRepeatingGroup(constants.TagNoMdEntries, snapshotTemplate)
+	addLevels := func(entryType string, levels []Level, positioned bool) {
+		if !entryTypes[entryType] {
+			return
+		}
+		for i, level := range levels {
+			if positioned && depth > 0 && i >= depth {
+				break
+			}
+			entry := entries.Add()
+			entry.SetString(constants.TagMdEntryType, entryType)
+			entry.SetString(constants.TagMdEntryPx, level.Price)
+			entry.SetString(constants.TagMdEntrySize, level.Size)
+			if positioned {
+				entry.SetString(constants.TagMdEntryPositionNo, strconv.Itoa(i+1))
+			}
+		}
+	}
+	addLevels(constants.MdEntryTypeBid, book.Bids, true)
+	addLevels(constants.MdEntryTypeOffer, book.Offers, true)
+	addLevels(constants.MdEntryTypeTrade, book.Trades, false)
+	msg.Body.SetGroup(entries)
+	return msg
+}
+
+// incremental builds an Incremental Refresh (X) carrying a single update.
+func incremental(mdReqId, symbol string, update Update) *quickfix.Message {
+	msg := newMessage(constants.MsgTypeMarketDataIncremental)
+	msg.Body.SetString(constants.TagMdReqId, mdReqId)
+
+	entries := quickfix.NewRepeatingGroup(constants.TagNoMdEntries, incrementalTemplate)
+	entry := entries.Add()
+	entry.SetString(constants.TagMdUpdateAction, update.Action)
+	entry.SetString(constants.TagMdEntryType, update.EntryType)
+	entry.SetString(constants.TagSymbol, symbol)
+	if update.Price != "" {
+		entry.SetString(constants.TagMdEntryPx, update.Price)
+	}
+	if update.Size != "" {
+		entry.SetString(constants.TagMdEntrySize, update.Size)
+	}
+	if update.Position > 0 {
+		entry.SetString(constants.TagMdEntryPositionNo, strconv.Itoa(update.Position))
+	}
+	msg.Body.SetGroup(entries)
+	return msg
+}
+
+// startStream sends each symbol's scripted updates, one per UpdateInterval,
+// until they run out or the stream is stopped. The subscription stays
+// registered after the last update so a duplicate MdReqId is still rejected.
+func (a *application) startStream(st *stream, sessionId quickfix.SessionID) {
+	mdReqId := st.mdReqId
+	stop := make(chan struct{})
+	a.mu.Lock()
+	a.streams[mdReqId] = stop
+	a.streamsWg.Add(1)
+	a.mu.Unlock()
+
+	go func() {
+		defer a.streamsWg.Done()
+		ticker := time.NewTicker(a.cfg.UpdateInterval)
+		defer ticker.Stop()
+
+		for _, symbol := range st.symbols {
+			for _, update := range a.cfg.Books[symbol].Updates {
+				if !st.entryTypes[update.EntryType] {
+					continue
+				}
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+				if err := quickfix.SendToTarget(incremental(mdReqId, symbol, update), sessionId); err != nil {
+					log.Printf("mockprime: stopping stream %s: %v", mdReqId, err)
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (a *application) stopStream(mdReqId string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if stop, ok := a.streams[mdReqId]; ok {
+		close(stop)
+		delete(a.streams, mdReqId)
+	}
+}
+
+// closeStreams signals every stream to stop without waiting. It is safe to
+// call from session callbacks.
+func (a *application) closeStreams() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for mdReqId, stop := range a.streams {
+		close(stop)
+		delete(a.streams, mdReqId)
+	}
+}
+
+// stopStreams stops every stream and waits for their goroutines to exit.
+func (a *application) stopStreams() {
+	a.closeStreams()
+	a.streamsWg.Wait()
+}
@@ -0,0 +1,357 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mockprime
+
+import (
+	"testing"
+	"time"
+
+	"prime-fix-md-go/builder"
+	"prime-fix-md-go/constants"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// Tests for the mock Prime acceptor.
+// Handlers are called directly; the full-session tests against the real
+// client live in the root integration tests.
+
+var testCredentials = Credentials{AccessKey: "key", Passphrase: "pass", SigningKey: "secret"}
+
+func newTestApplication() *application {
+	return newApplication(Config{
+		SenderCompID: "COIN",
+		TargetCompID: "CLIENT",
+		Credentials:  testCredentials,
+		QuoteTTL:     time.Minute,
+		Books: map[string]Book{
+			"BTC-USD": {
+				Bids:   []Level{{"49999", "1"}, {"49998", "2"}, {"49997", "3"}},
+				Offers: []Level{{"50001", "1"}, {"50002", "2"}},
+				Trades: []Level{{"50000", "0.5"}},
+			},
+		},
+	})
+}
+
+func logonMessage(ts, seqNum string, creds Credentials) *quickfix.Message {
+	msg := quickfix.NewMessage()
+	msg.Header.SetString(constants.TagMsgType, constants.MsgTypeLogon)
+	msg.Header.SetString(constants.TagSendingTime, ts)
+	msg.Header.SetString(constants.TagMsgSeqNum, seqNum)
+	msg.Header.SetString(constants.TagTargetCompId, "COIN")
+	builder.BuildLogon(&msg.Body, ts, seqNum, creds.AccessKey, creds.SigningKey, creds.Passphrase, "COIN", "portfolio")
+	return msg
+}
+
+func str(t *testing.T, msg *quickfix.Message, tag quickfix.Tag) string {
+	t.Helper()
+	if tag.IsHeader() {
+		v, _ := msg.Header.GetString(tag)
+		return v
+	}
+	v, _ := msg.Body.GetString(tag)
+	return v
+}
+
+// TestApplication_ValidateLogon verifies that a logon signed by
+// builder.BuildLogon is accepted and that any credential or header mismatch
+// is rejected.
+func TestApplication_ValidateLogon(t *testing.T) {
+	app := newTestApplication()
+	ts := "20250101-12:00:00.000"
+
+	if err := app.validateLogon(logonMessage(ts, "1", testCredentials)); err != nil {
+		t.Fatalf("expected valid logon, got %v", err)
+	}
+
+	badSecret := testCredentials
+	badSecret.SigningKey = "wrong"
+	badPass := testCredentials
+	badPass.Passphrase = "wrong"
+	badKey := testCredentials
+	badKey.AccessKey = "wrong"
+
+	tampered := logonMessage(ts, "1", testCredentials)
+	tampered.Header.SetString(constants.TagMsgSeqNum, "2")
+
+	for name, msg := range map[string]*quickfix.Message{
+		"signing key": logonMessage(ts, "1", badSecret),
+		"passphrase":  logonMessage(ts, "1", badPass),
+		"access key":  logonMessage(ts, "1", badKey),
+		"seq num":     tampered,
+	} {
+		if err := app.validateLogon(msg); err == nil {
+			t.Errorf("expected logon with bad %s to be rejected", name)
+		}
+		if rej := app.FromAdmin(msg, quickfix.SessionID{}); rej == nil {
+			t.Errorf("expected FromAdmin to reject logon with bad %s", name)
+		}
+	}
+	if app.logonFailures != 4 {
+		t.Errorf("expected 4 logon failures, got %d", app.logonFailures)
+	}
+}
+
+// TestApplication_MarketDataSnapshot verifies depth and entry type filtering,
+// unknown symbol rejection and that subscriptions return a stream.
+func TestApplication_MarketDataSnapshot(t *testing.T) {
+	app := newTestApplication()
+
+	req := builder.BuildMarketDataRequest("req-1", []string{"BTC-USD", "DOGE-USD"},
+		constants.SubscriptionRequestTypeSubscribe, "2", "CLIENT", "COIN",
+		[]string{constants.MdEntryTypeBid, constants.MdEntryTypeOffer})
+	replies, st := app.handleMarketDataRequest(req)
+
+	if len(replies) != 2 {
+		t.Fatalf("expected snapshot and reject, got %d replies", len(replies))
+	}
+	snap := replies[0]
+	if str(t, snap, constants.TagMsgType) != constants.MsgTypeMarketDataSnapshot || str(t, snap, constants.TagSymbol) != "BTC-USD" {
+		t.Errorf("unexpected snapshot: %s", snap.String())
+	}
+	if n := str(t, snap, constants.TagNoMdEntries); n != "4" {
+		t.Errorf("expected 2 bids and 2 offers at depth 2, got %s entries", n)
+	}
+	if got := fieldValues(snap, constants.TagMdEntryType); len(got) != 4 || got[0] != constants.MdEntryTypeBid || got[3] != constants.MdEntryTypeOffer {
+		t.Errorf("unexpected entry types %v", got)
+	}
+
+	reject := replies[1]
+	if str(t, reject, constants.TagMsgType) != constants.MsgTypeMarketDataReject || str(t, reject, constants.TagMdReqRejReason) != constants.MdReqRejReasonUnknownSymbol {
+		t.Errorf("expected unknown symbol reject, got %s", reject.String())
+	}
+
+	if st == nil || st.mdReqId != "req-1" || len(st.symbols) != 1 || st.symbols[0] != "BTC-USD" {
+		t.Fatalf("expected a stream for BTC-USD, got %+v", st)
+	}
+	app.streams[st.mdReqId] = make(chan struct{})
+	replies, _ = app.handleMarketDataRequest(req)
+	if len(replies) != 1 || str(t, replies[0], constants.TagMdReqRejReason) != constants.MdReqRejReasonDuplicateMdReqId {
+		t.Errorf("expected duplicate MdReqId reject, got %v", replies)
+	}
+}
+
+// TestApplication_OrderLifecycle verifies resting, replacing and cancelling
+// a limit order, and that a cancel after the order is done is rejected.
+func TestApplication_OrderLifecycle(t *testing.T) {
+	app := newTestApplication()
+
+	replies := app.handleNewOrderSingle(builder.BuildNewOrderSingle(builder.NewOrderParams{
+		Account: "portfolio", ClOrdID: "ord-1", Symbol: "BTC-USD", Side: constants.SideBuy,
+		OrdType: constants.OrdTypeLimit, TargetStrategy: constants.TargetStrategyLimit,
+		TimeInForce: constants.TimeInForceGTC, OrderQty: "1", Price: "49000",
+	}, "CLIENT", "COIN"))
+	if len(replies) != 1 || str(t, replies[0], constants.TagExecType) != constants.ExecTypeNew {
+		t.Fatalf("expected a resting order, got %v", replies)
+	}
+	orderID := str(t, replies[0], constants.TagOrderID)
+
+	replies = app.handleOrderCancelReplace(builder.BuildOrderCancelReplaceRequest(builder.ReplaceOrderParams{
+		ClOrdID: "rep-1", OrigClOrdID: "ord-1", OrderID: orderID, Symbol: "BTC-USD",
+		Side: constants.SideBuy, OrdType: constants.OrdTypeLimit, OrderQty: "2", Price: "49500",
+	}, "CLIENT", "COIN"))
+	replaced := replies[0]
+	if str(t, replaced, constants.TagExecType) != constants.ExecTypeReplaced ||
+		str(t, replaced, constants.TagOrigClOrdID) != "ord-1" ||
+		str(t, replaced, constants.TagPrice) != "49500" || str(t, replaced, constants.TagOrderQty) != "2" {
+		t.Errorf("unexpected replace report: %s", replaced.String())
+	}
+
+	cancel := builder.BuildOrderCancelRequest(builder.CancelOrderParams{
+		ClOrdID: "cxl-1", OrigClOrdID: "rep-1", OrderID: orderID, Symbol: "BTC-USD", Side: constants.SideBuy,
+	}, "CLIENT", "COIN")
+	replies = app.handleOrderCancelRequest(cancel)
+	if str(t, replies[0], constants.TagExecType) != constants.ExecTypeCanceled || str(t, replies[0], constants.TagLeavesQty) != "0" {
+		t.Errorf("unexpected cancel report: %s", replies[0].String())
+	}
+
+	replies = app.handleOrderCancelRequest(cancel)
+	if str(t, replies[0], constants.TagMsgType) != constants.MsgTypeOrderCancelReject ||
+		str(t, replies[0], constants.TagCxlRejReason) != constants.CxlRejReasonTooLate {
+		t.Errorf("expected too-late cancel reject, got %s", replies[0].String())
+	}
+
+	replies = app.handleOrderStatusRequest(builder.BuildOrderStatusRequest(orderID, "", "", "", "CLIENT", "COIN"))
+	if str(t, replies[0], constants.TagExecType) != constants.ExecTypeOrderStatus ||
+		str(t, replies[0], constants.TagOrdStatus) != constants.OrdStatusCanceled {
+		t.Errorf("unexpected status report: %s", replies[0].String())
+	}
+}
+
+// TestApplication_OrderFillsAndRejects verifies that market and marketable
+// limit orders fill at the top of the book and invalid orders are rejected.
+func TestApplication_OrderFillsAndRejects(t *testing.T) {
+	app := newTestApplication()
+	newOrder := func(clOrdID, symbol, side, ordType, price string) []*quickfix.Message {
+		return app.handleNewOrderSingle(builder.BuildNewOrderSingle(builder.NewOrderParams{
+			ClOrdID: clOrdID, Symbol: symbol, Side: side, OrdType: ordType,
+			TimeInForce: constants.TimeInForceIOC, OrderQty: "0.5", Price: price,
+		}, "CLIENT", "COIN"))
+	}
+
+	replies := newOrder("mkt-1", "BTC-USD", constants.SideSell, constants.OrdTypeMarket, "")
+	if len(replies) != 2 || str(t, replies[1], constants.TagExecType) != constants.ExecTypeFilled ||
+		str(t, replies[1], constants.TagLastPx) != "49999" || str(t, replies[1], constants.TagCumQty) != "0.5" {
+		t.Errorf("expected market sell to fill at best bid, got %v", replies)
+	}
+
+	replies = newOrder("lmt-1", "BTC-USD", constants.SideBuy, constants.OrdTypeLimit, "50005")
+	if len(replies) != 2 || str(t, replies[1], constants.TagAvgPx) != "50001" {
+		t.Errorf("expected marketable limit buy to fill at best offer, got %v", replies)
+	}
+
+	for name, replies := range map[string][]*quickfix.Message{
+		"unknown symbol": newOrder("bad-1", "DOGE-USD", constants.SideBuy, constants.OrdTypeMarket, ""),
+		"duplicate":      newOrder("mkt-1", "BTC-USD", constants.SideBuy, constants.OrdTypeMarket, ""),
+		"bad quote":      newOrder("bad-2", "BTC-USD", constants.SideBuy, constants.OrdTypePreviouslyQuoted, "50001"),
+	} {
+		if len(replies) != 1 || str(t, replies[0], constants.TagExecType) != constants.ExecTypeRejected {
+			t.Errorf("expected %s order to be rejected, got %v", name, replies)
+		}
+	}
+}
+
+// TestApplication_QuoteAndAccept verifies that an RFQ yields a one-sided
+// quote that can be accepted once, and that an RFQ outside the limit price
+// is rejected.
+func TestApplication_QuoteAndAccept(t *testing.T) {
+	app := newTestApplication()
+
+	replies := app.handleQuoteRequest(builder.BuildQuoteRequest(builder.QuoteRequestParams{
+		QuoteReqID: "rfq-1", Account: "portfolio", Symbol: "BTC-USD",
+		Side: constants.SideSell, OrderQty: "1", Price: "49000",
+	}, "CLIENT", "COIN"))
+	q := replies[0]
+	if str(t, q, constants.TagMsgType) != constants.MsgTypeQuote || str(t, q, constants.TagBidPx) != "49999" || str(t, q, constants.TagOfferPx) != "" {
+		t.Fatalf("expected a bid-only quote, got %s", q.String())
+	}
+	if _, err := time.Parse(constants.FixTimeFormat, str(t, q, constants.TagValidUntilTime)); err != nil {
+		t.Errorf("ValidUntilTime not in FixTimeFormat: %v", err)
+	}
+
+	accept := builder.BuildAcceptQuote(builder.AcceptQuoteParams{
+		Account: "portfolio", ClOrdID: "acc-1", Symbol: "BTC-USD", Side: constants.SideSell,
+		QuoteID: str(t, q, constants.TagQuoteID), OrderQty: "1", Price: "49999",
+	}, "CLIENT", "COIN")
+	replies = app.handleNewOrderSingle(accept)
+	if len(replies) != 2 || str(t, replies[1], constants.TagLastPx) != "49999" {
+		t.Errorf("expected quote acceptance to fill at the quoted price, got %v", replies)
+	}
+
+	accept.Body.SetString(constants.TagClOrdID, "acc-2")
+	replies = app.handleNewOrderSingle(accept)
+	if str(t, replies[0], constants.TagExecType) != constants.ExecTypeRejected {
+		t.Errorf("expected a used quote to be rejected, got %s", replies[0].String())
+	}
+
+	replies = app.handleQuoteRequest(builder.BuildQuoteRequest(builder.QuoteRequestParams{
+		QuoteReqID: "rfq-2", Symbol: "BTC-USD", Side: constants.SideBuy, OrderQty: "1", Price: "50000",
+	}, "CLIENT", "COIN"))
+	if str(t, replies[0], constants.TagMsgType) != constants.MsgTypeQuoteAcknowledgement ||
+		str(t, replies[0], constants.TagQuoteRejectReason) != constants.QuoteRejectReasonInvalidPrice {
+		t.Errorf("expected quote outside limit to be rejected, got %s", replies[0].String())
+	}
+}
+
+// TestApplication_OrderMassStatus verifies that a mass status request reports
+// each open order once, marks the last report, and answers with a single
+// empty report when nothing is open.
+func TestApplication_OrderMassStatus(t *testing.T) {
+	app := newTestApplication()
+	massStatus := builder.BuildOrderMassStatusRequest(builder.OrderMassStatusParams{
+		MassStatusReqID: "mass-1", MassStatusReqType: constants.MassStatusReqTypeAllOrders, Account: "portfolio",
+	}, "CLIENT", "COIN")
+
+	replies := app.handleOrderMassStatusRequest(massStatus)
+	if len(replies) != 1 || str(t, replies[0], constants.TagTotNumReports) != "0" ||
+		str(t, replies[0], constants.TagLastRptRequested) != "Y" {
+		t.Fatalf("expected a single empty report, got %v", replies)
+	}
+
+	for _, clOrdID := range []string{"ord-1", "ord-2"} {
+		app.handleNewOrderSingle(builder.BuildNewOrderSingle(builder.NewOrderParams{
+			Account: "portfolio", ClOrdID: clOrdID, Symbol: "BTC-USD", Side: constants.SideBuy,
+			OrdType: constants.OrdTypeLimit, TargetStrategy: constants.TargetStrategyLimit,
+			TimeInForce: constants.TimeInForceGTC, OrderQty: "1", Price: "49000",
+		}, "CLIENT", "COIN"))
+	}
+	app.handleOrderCancelReplace(builder.BuildOrderCancelReplaceRequest(builder.ReplaceOrderParams{
+		ClOrdID: "rep-1", OrigClOrdID: "ord-1", Symbol: "BTC-USD", Side: constants.SideBuy,
+		OrdType: constants.OrdTypeLimit, OrderQty: "1", Price: "49100",
+	}, "CLIENT", "COIN"))
+	app.handleOrderCancelRequest(builder.BuildOrderCancelRequest(builder.CancelOrderParams{
+		ClOrdID: "cxl-1", OrigClOrdID: "ord-2", Symbol: "BTC-USD", Side: constants.SideBuy,
+	}, "CLIENT", "COIN"))
+
+	replies = app.handleOrderMassStatusRequest(massStatus)
+	if len(replies) != 1 {
+		t.Fatalf("expected 1 open order reported, got %d", len(replies))
+	}
+	report := replies[0]
+	if str(t, report, constants.TagClOrdID) != "rep-1" || str(t, report, constants.TagExecType) != constants.ExecTypeOrderStatus ||
+		str(t, report, constants.TagMassStatusReqID) != "mass-1" || str(t, report, constants.TagTotNumReports) != "1" ||
+		str(t, report, constants.TagLastRptRequested) != "Y" {
+		t.Errorf("unexpected mass status report: %s", report.String())
+	}
+}
+
+// TestApplication_OrderMassCancel verifies that a mass cancel cancels only the
+// matching open orders, reports how many were affected, and is rejected when
+// RejectMassCancel is set.
+func TestApplication_OrderMassCancel(t *testing.T) {
+	app := newTestApplication()
+	for _, o := range []struct{ clOrdID, side string }{
+		{"ord-1", constants.SideBuy}, {"ord-2", constants.SideBuy}, {"ord-3", constants.SideSell},
+	} {
+		app.handleNewOrderSingle(builder.BuildNewOrderSingle(builder.NewOrderParams{
+			Account: "portfolio", ClOrdID: o.clOrdID, Symbol: "BTC-USD", Side: o.side,
+			OrdType: constants.OrdTypeLimit, TargetStrategy: constants.TargetStrategyLimit,
+			TimeInForce: constants.TimeInForceGTC, OrderQty: "1", Price: "45000",
+		}, "CLIENT", "COIN"))
+	}
+
+	massCancel := builder.BuildOrderMassCancelRequest(builder.MassCancelParams{
+		ClOrdID: "mcxl-1", Account: "portfolio", MassCancelRequestType: constants.MassCancelRequestTypeSecurity,
+		Symbol: "BTC-USD", Side: constants.SideBuy,
+	}, "CLIENT", "COIN")
+	replies := app.handleOrderMassCancelRequest(massCancel)
+	if len(replies) != 3 {
+		t.Fatalf("expected a report and 2 cancels, got %d replies", len(replies))
+	}
+	report := replies[0]
+	if str(t, report, constants.TagMsgType) != constants.MsgTypeOrderMassCancelReport ||
+		str(t, report, constants.TagClOrdID) != "mcxl-1" ||
+		str(t, report, constants.TagMassCancelResponse) != constants.MassCancelResponseSecurity ||
+		str(t, report, constants.TagTotalAffectedOrders) != "2" {
+		t.Errorf("unexpected mass cancel report: %s", report.String())
+	}
+	for _, er := range replies[1:] {
+		if str(t, er, constants.TagExecType) != constants.ExecTypeCanceled || str(t, er, constants.TagSide) != constants.SideBuy {
+			t.Errorf("unexpected cancel report: %s", er.String())
+		}
+	}
+
+	app.cfg.RejectMassCancel = true
+	replies = app.handleOrderMassCancelRequest(builder.BuildOrderMassCancelRequest(builder.MassCancelParams{
+		ClOrdID: "mcxl-2", Account: "portfolio", MassCancelRequestType: constants.MassCancelRequestTypeAllOrders,
+	}, "CLIENT", "COIN"))
+	if len(replies) != 1 || str(t, replies[0], constants.TagMassCancelResponse) != constants.MassCancelResponseRejected ||
+		str(t, replies[0], constants.TagMassCancelRejectReason) != constants.MassCancelRejectReasonNotSupported {
+		t.Errorf("expected a rejected mass cancel report, got %v", replies)
+	}
+}
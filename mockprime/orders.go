@@ -0,0 +1,519 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mockprime
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/utils"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// order is the mock's view of a client order.
+type order struct {
+	clOrdID   string // Latest ClOrdID in the replace chain
+	orderID   string
+	account   string
+	symbol    string
+	side      string
+	ordType   string
+	orderQty  string
+	price     string
+	cumQty    string
+	avgPx     string
+	ordStatus string
+}
+
+// quote is an outstanding RFQ response.
+type quote struct {
+	quoteID    string
+	symbol     string
+	side       string
+	price      string
+	qty        string
+	validUntil time.Time
+}
+
+func (o *order) isTerminal() bool {
+	switch o.ordStatus {
+	case constants.OrdStatusFilled, constants.OrdStatusCanceled, constants.OrdStatusRejected:
+		return true
+	}
+	return false
+}
+
+// handleNewOrderSingle acknowledges a New Order Single (D) and fills it at the
+// top of the book when it is a market order, a marketable limit order or a
+// valid quote acceptance. Stop and stop-limit orders rest until cancelled:
+// the mock's books never trade, so nothing triggers them.
+func (a *application) handleNewOrderSingle(msg *quickfix.Message) []*quickfix.Message {
+	o := &order{
+		clOrdID:  utils.GetString(msg, constants.TagClOrdID),
+		account:  utils.GetString(msg, constants.TagAccount),
+		symbol:   utils.GetString(msg, constants.TagSymbol),
+		side:     utils.GetString(msg, constants.TagSide),
+		ordType:  utils.GetString(msg, constants.TagOrdType),
+		orderQty: utils.GetString(msg, constants.TagOrderQty),
+		price:    utils.GetString(msg, constants.TagPrice),
+	}
+	cashQty := utils.GetString(msg, constants.TagCashOrderQty)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if o.clOrdID == "" || (o.side != constants.SideBuy && o.side != constants.SideSell) ||
+		(o.orderQty == "" && cashQty == "") {
+		return []*quickfix.Message{orderReject(o, constants.OrdRejReasonOther, "Missing or invalid required field")}
+	}
+	if _, exists := a.orders[o.clOrdID]; exists {
+		return []*quickfix.Message{orderReject(o, constants.OrdRejReasonDuplicateOrder, "Duplicate ClOrdID")}
+	}
+	book, ok := a.cfg.Books[o.symbol]
+	if !ok {
+		return []*quickfix.Message{orderReject(o, constants.OrdRejReasonUnknownSymbol, "Unknown symbol: "+o.symbol)}
+	}
+
+	var fillPx string
+	switch o.ordType {
+	case constants.OrdTypePreviouslyQuoted:
+		q := a.quotes[utils.GetString(msg, constants.TagQuoteID)]
+		if q == nil || time.Now().After(q.validUntil) || q.symbol != o.symbol || q.side != o.side {
+			return []*quickfix.Message{orderReject(o, constants.OrdRejReasonOther, "Quote not found or expired")}
+		}
+		delete(a.quotes, q.quoteID)
+		fillPx = q.price
+	case constants.OrdTypeMarket:
+		level, ok := bestOpposite(book, o.side)
+		if !ok {
+			return []*quickfix.Message{orderReject(o, constants.OrdRejReasonOther, "No liquidity")}
+		}
+		fillPx = level.Price
+	case constants.OrdTypeStop, constants.OrdTypeStopLimit:
+	default:
+		if level, ok := bestOpposite(book, o.side); ok && crosses(o.side, o.price, level.Price) {
+			fillPx = level.Price
+		}
+	}
+
+	if o.orderQty == "" {
+		o.orderQty = baseQty(cashQty, firstNonEmpty(fillPx, o.price))
+	}
+	o.orderID = a.newId("ord")
+	o.cumQty = "0"
+	o.ordStatus = constants.OrdStatusNew
+	a.orders[o.clOrdID] = o
+
+	replies := []*quickfix.Message{a.executionReport(o, constants.ExecTypeNew, "")}
+	if fillPx != "" {
+		o.cumQty = o.orderQty
+		o.avgPx = fillPx
+		o.ordStatus = constants.OrdStatusFilled
+		fill := a.executionReport(o, constants.ExecTypeFilled, "")
+		fill.Body.SetString(constants.TagLastPx, fillPx)
+		fill.Body.SetString(constants.TagLastShares, o.orderQty)
+		replies = append(replies, fill)
+	}
+	return replies
+}
+
+// handleOrderCancelRequest cancels an open order or answers with an Order
+// Cancel Reject (9).
+func (a *application) handleOrderCancelRequest(msg *quickfix.Message) []*quickfix.Message {
+	clOrdID := utils.GetString(msg, constants.TagClOrdID)
+	origClOrdID := utils.GetString(msg, constants.TagOrigClOrdID)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	o := a.findOrder(origClOrdID, utils.GetString(msg, constants.TagOrderID))
+	if reject := cancelReject(o, clOrdID, origClOrdID, constants.CxlRejResponseToCancel); reject != nil {
+		return []*quickfix.Message{reject}
+	}
+
+	o.ordStatus = constants.OrdStatusCanceled
+	a.orders[clOrdID] = o
+	o.clOrdID = clOrdID
+	return []*quickfix.Message{a.executionReport(o, constants.ExecTypeCanceled, origClOrdID)}
+}
+
+// handleOrderCancelReplace applies a new price and quantity to an open order
+// or answers with an Order Cancel Reject (9).
+func (a *application) handleOrderCancelReplace(msg *quickfix.Message) []*quickfix.Message {
+	clOrdID := utils.GetString(msg, constants.TagClOrdID)
+	origClOrdID := utils.GetString(msg, constants.TagOrigClOrdID)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	o := a.findOrder(origClOrdID, utils.GetString(msg, constants.TagOrderID))
+	if reject := cancelReject(o, clOrdID, origClOrdID, constants.CxlRejResponseToReplace); reject != nil {
+		return []*quickfix.Message{reject}
+	}
+
+	if qty := utils.GetString(msg, constants.TagOrderQty); qty != "" {
+		o.orderQty = qty
+	}
+	if price := utils.GetString(msg, constants.TagPrice); price != "" {
+		o.price = price
+	}
+	a.orders[clOrdID] = o
+	o.clOrdID = clOrdID
+	return []*quickfix.Message{a.executionReport(o, constants.ExecTypeReplaced, origClOrdID)}
+}
+
+// handleOrderStatusRequest reports the current state of an order with
+// ExecType=I, or OrdStatus=Rejected when the order is unknown.
+func (a *application) handleOrderStatusRequest(msg *quickfix.Message) []*quickfix.Message {
+	clOrdID := utils.GetString(msg, constants.TagClOrdID)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	o := a.findOrder(clOrdID, utils.GetString(msg, constants.TagOrderID))
+	if o == nil {
+		unknown := &order{
+			clOrdID:   clOrdID,
+			orderID:   utils.GetString(msg, constants.TagOrderID),
+			symbol:    utils.GetString(msg, constants.TagSymbol),
+			side:      utils.GetString(msg, constants.TagSide),
+			ordStatus: constants.OrdStatusRejected,
+		}
+		report := a.executionReport(unknown, constants.ExecTypeOrderStatus, "")
+		report.Body.SetString(constants.TagText, "Unknown order")
+		return []*quickfix.Message{report}
+	}
+	return []*quickfix.Message{a.executionReport(o, constants.ExecTypeOrderStatus, "")}
+}
+
+// handleOrderMassStatusRequest reports every open order in the request's
+// account (and symbol, for MassStatusReqType=1) with ExecType=I. Reports carry
+// the MassStatusReqID and TotNumReports, and the last sets LastRptRequested.
+// With no open orders a single report with TotNumReports=0 is sent.
+func (a *application) handleOrderMassStatusRequest(msg *quickfix.Message) []*quickfix.Message {
+	reqId := utils.GetString(msg, constants.TagMassStatusReqID)
+	account := utils.GetString(msg, constants.TagAccount)
+	symbol := ""
+	if utils.GetString(msg, constants.TagMassStatusReqType) == constants.MassStatusReqTypeSecurity {
+		symbol = utils.GetString(msg, constants.TagSymbol)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// orders holds each order under every ClOrdID of its replace chain
+	seen := make(map[*order]bool)
+	var open []*order
+	for _, o := range a.orders {
+		if seen[o] || o.isTerminal() || (account != "" && o.account != account) || (symbol != "" && o.symbol != symbol) {
+			continue
+		}
+		seen[o] = true
+		open = append(open, o)
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].orderID < open[j].orderID })
+
+	if len(open) == 0 {
+		msg := newMessage(constants.MsgTypeExecutionReport)
+		msg.Body.SetString(constants.TagOrderID, "NONE")
+		msg.Body.SetString(constants.TagExecID, a.newId("exec"))
+		msg.Body.SetString(constants.TagOrdStatus, constants.OrdStatusRejected)
+		msg.Body.SetString(constants.TagExecType, constants.ExecTypeOrderStatus)
+		msg.Body.SetString(constants.TagMassStatusReqID, reqId)
+		msg.Body.SetString(constants.TagTotNumReports, "0")
+		msg.Body.SetString(constants.TagLastRptRequested, "Y")
+		msg.Body.SetString(constants.TagText, "No open orders")
+		return []*quickfix.Message{msg}
+	}
+
+	replies := make([]*quickfix.Message, len(open))
+	for i, o := range open {
+		report := a.executionReport(o, constants.ExecTypeOrderStatus, "")
+		report.Body.SetString(constants.TagMassStatusReqID, reqId)
+		report.Body.SetString(constants.TagTotNumReports, strconv.Itoa(len(open)))
+		if i == len(open)-1 {
+			report.Body.SetString(constants.TagLastRptRequested, "Y")
+		} else {
+			report.Body.SetString(constants.TagLastRptRequested, "N")
+		}
+		replies[i] = report
+	}
+	return replies
+}
+
+// handleOrderMassCancelRequest cancels every open order in the request's
+// account, limited to Symbol for MassCancelRequestType=1 and to Side when
+// given. Each cancelled order gets an Execution Report (8) after the Order
+// Mass Cancel Report (r) carrying TotalAffectedOrders.
+func (a *application) handleOrderMassCancelRequest(msg *quickfix.Message) []*quickfix.Message {
+	clOrdID := utils.GetString(msg, constants.TagClOrdID)
+	reqType := utils.GetString(msg, constants.TagMassCancelRequestType)
+	account := utils.GetString(msg, constants.TagAccount)
+	symbol := utils.GetString(msg, constants.TagSymbol)
+	side := utils.GetString(msg, constants.TagSide)
+
+	report := newMessage(constants.MsgTypeOrderMassCancelReport)
+	report.Body.SetString(constants.TagClOrdID, clOrdID)
+	report.Body.SetString(constants.TagOrderID, a.newId("mcxl"))
+	report.Body.SetString(constants.TagMassCancelRequestType, reqType)
+	setIfNotEmpty(report, constants.TagSymbol, symbol)
+	setIfNotEmpty(report, constants.TagSide, side)
+
+	reject := func(reason, text string) []*quickfix.Message {
+		report.Body.SetString(constants.TagMassCancelResponse, constants.MassCancelResponseRejected)
+		report.Body.SetString(constants.TagMassCancelRejectReason, reason)
+		report.Body.SetString(constants.TagText, text)
+		return []*quickfix.Message{report}
+	}
+	switch {
+	case a.cfg.RejectMassCancel:
+		return reject(constants.MassCancelRejectReasonNotSupported, "Mass cancel not supported")
+	case reqType == constants.MassCancelRequestTypeSecurity && symbol == "":
+		return reject(constants.MassCancelRejectReasonUnknownSecurity, "Symbol is required")
+	case reqType != constants.MassCancelRequestTypeSecurity && reqType != constants.MassCancelRequestTypeAllOrders:
+		return reject(constants.MassCancelRejectReasonOther, "Unsupported MassCancelRequestType: "+reqType)
+	case reqType == constants.MassCancelRequestTypeAllOrders:
+		symbol = ""
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	seen := make(map[*order]bool)
+	var cancelled []*order
+	for _, o := range a.orders {
+		if seen[o] || o.isTerminal() || (account != "" && o.account != account) ||
+			(symbol != "" && o.symbol != symbol) || (side != "" && o.side != side) {
+			continue
+		}
+		seen[o] = true
+		cancelled = append(cancelled, o)
+	}
+	sort.Slice(cancelled, func(i, j int) bool { return cancelled[i].orderID < cancelled[j].orderID })
+
+	report.Body.SetString(constants.TagMassCancelResponse, reqType)
+	report.Body.SetString(constants.TagTotalAffectedOrders, strconv.Itoa(len(cancelled)))
+	replies := []*quickfix.Message{report}
+	for _, o := range cancelled {
+		o.ordStatus = constants.OrdStatusCanceled
+		replies = append(replies, a.executionReport(o, constants.ExecTypeCanceled, ""))
+	}
+	return replies
+}
+
+// handleQuoteRequest answers a Quote Request (R) with a one-sided Quote (S)
+// priced at the top of the book: an offer for a buy, a bid for a sell. A quote
+// worse than the request's limit price is rejected with a Quote Ack (b).
+func (a *application) handleQuoteRequest(msg *quickfix.Message) []*quickfix.Message {
+	quoteReqID := utils.GetString(msg, constants.TagQuoteReqID)
+	symbol := utils.GetString(msg, constants.TagSymbol)
+	side := utils.GetString(msg, constants.TagSide)
+	qty := utils.GetString(msg, constants.TagOrderQty)
+	limit := utils.GetString(msg, constants.TagPrice)
+
+	book, ok := a.cfg.Books[symbol]
+	if !ok {
+		return []*quickfix.Message{quoteReject(quoteReqID, symbol, constants.QuoteRejectReasonUnknownSymbol, "Unknown symbol: "+symbol)}
+	}
+	level, ok := bestOpposite(book, side)
+	if !ok {
+		return []*quickfix.Message{quoteReject(quoteReqID, symbol, constants.QuoteRejectReasonOther, "No liquidity")}
+	}
+	if limit != "" && !crosses(side, limit, level.Price) {
+		return []*quickfix.Message{quoteReject(quoteReqID, symbol, constants.QuoteRejectReasonInvalidPrice, "Quote price outside limit")}
+	}
+
+	q := &quote{
+		quoteID:    a.newId("quote"),
+		symbol:     symbol,
+		side:       side,
+		price:      level.Price,
+		qty:        qty,
+		validUntil: time.Now().Add(a.cfg.QuoteTTL),
+	}
+	a.mu.Lock()
+	a.quotes[q.quoteID] = q
+	a.mu.Unlock()
+
+	reply := newMessage(constants.MsgTypeQuote)
+	reply.Body.SetString(constants.TagQuoteReqID, quoteReqID)
+	reply.Body.SetString(constants.TagQuoteID, q.quoteID)
+	setIfNotEmpty(reply, constants.TagAccount, utils.GetString(msg, constants.TagAccount))
+	reply.Body.SetString(constants.TagSymbol, symbol)
+	if side == constants.SideSell {
+		reply.Body.SetString(constants.TagBidPx, q.price)
+		reply.Body.SetString(constants.TagBidSize, qty)
+	} else {
+		reply.Body.SetString(constants.TagOfferPx, q.price)
+		reply.Body.SetString(constants.TagOfferSize, qty)
+	}
+	reply.Body.SetString(constants.TagValidUntilTime, q.validUntil.UTC().Format(constants.FixTimeFormat))
+	return []*quickfix.Message{reply}
+}
+
+// findOrder looks an order up by ClOrdID, falling back to OrderID.
+// The caller must hold a.mu.
+func (a *application) findOrder(clOrdID, orderID string) *order {
+	if o, ok := a.orders[clOrdID]; ok {
+		return o
+	}
+	if orderID == "" {
+		return nil
+	}
+	for _, o := range a.orders {
+		if o.orderID == orderID {
+			return o
+		}
+	}
+	return nil
+}
+
+// executionReport builds an Execution Report (8) for o's current state.
+// origClOrdID is set for cancel and replace responses.
+func (a *application) executionReport(o *order, execType, origClOrdID string) *quickfix.Message {
+	msg := newMessage(constants.MsgTypeExecutionReport)
+	msg.Body.SetString(constants.TagOrderID, firstNonEmpty(o.orderID, "NONE"))
+	msg.Body.SetString(constants.TagClOrdID, o.clOrdID)
+	setIfNotEmpty(msg, constants.TagOrigClOrdID, origClOrdID)
+	msg.Body.SetString(constants.TagExecID, a.newId("exec"))
+	setIfNotEmpty(msg, constants.TagAccount, o.account)
+	msg.Body.SetString(constants.TagSymbol, o.symbol)
+	msg.Body.SetString(constants.TagSide, o.side)
+	setIfNotEmpty(msg, constants.TagOrdType, o.ordType)
+	setIfNotEmpty(msg, constants.TagOrderQty, o.orderQty)
+	setIfNotEmpty(msg, constants.TagPrice, o.price)
+	msg.Body.SetString(constants.TagOrdStatus, o.ordStatus)
+	msg.Body.SetString(constants.TagExecType, execType)
+	msg.Body.SetString(constants.TagCumQty, firstNonEmpty(o.cumQty, "0"))
+	msg.Body.SetString(constants.TagLeavesQty, leavesQty(o))
+	setIfNotEmpty(msg, constants.TagAvgPx, o.avgPx)
+	msg.Body.SetString(constants.TagTransactTime, transactTime())
+	return msg
+}
+
+// orderReject builds a rejected Execution Report for an order that was never
+// accepted.
+func orderReject(o *order, reason, text string) *quickfix.Message {
+	msg := newMessage(constants.MsgTypeExecutionReport)
+	msg.Body.SetString(constants.TagOrderID, "NONE")
+	msg.Body.SetString(constants.TagClOrdID, o.clOrdID)
+	msg.Body.SetString(constants.TagExecID, "NONE")
+	setIfNotEmpty(msg, constants.TagAccount, o.account)
+	setIfNotEmpty(msg, constants.TagSymbol, o.symbol)
+	setIfNotEmpty(msg, constants.TagSide, o.side)
+	setIfNotEmpty(msg, constants.TagOrdType, o.ordType)
+	setIfNotEmpty(msg, constants.TagOrderQty, o.orderQty)
+	msg.Body.SetString(constants.TagOrdStatus, constants.OrdStatusRejected)
+	msg.Body.SetString(constants.TagExecType, constants.ExecTypeRejected)
+	msg.Body.SetString(constants.TagCumQty, "0")
+	msg.Body.SetString(constants.TagLeavesQty, "0")
+	msg.Body.SetString(constants.TagOrdRejReason, reason)
+	msg.Body.SetString(constants.TagText, text)
+	msg.Body.SetString(constants.TagTransactTime, transactTime())
+	return msg
+}
+
+// cancelReject returns an Order Cancel Reject (9) when o cannot be cancelled
+// or replaced, or nil when it can.
+func cancelReject(o *order, clOrdID, origClOrdID, responseTo string) *quickfix.Message {
+	var reason, text, orderID, status string
+	switch {
+	case o == nil:
+		reason, text, orderID, status = constants.CxlRejReasonUnknownOrder, "Unknown order", "NONE", constants.OrdStatusRejected
+	case o.isTerminal():
+		reason, text, orderID, status = constants.CxlRejReasonTooLate, "Order is already done", o.orderID, o.ordStatus
+	default:
+		return nil
+	}
+
+	msg := newMessage(constants.MsgTypeOrderCancelReject)
+	msg.Body.SetString(constants.TagOrderID, orderID)
+	msg.Body.SetString(constants.TagClOrdID, clOrdID)
+	msg.Body.SetString(constants.TagOrigClOrdID, origClOrdID)
+	msg.Body.SetString(constants.TagOrdStatus, status)
+	msg.Body.SetString(constants.TagCxlRejResponseTo, responseTo)
+	msg.Body.SetString(constants.TagCxlRejReason, reason)
+	msg.Body.SetString(constants.TagText, text)
+	return msg
+}
+
+func quoteReject(quoteReqID, symbol, reason, text string) *quickfix.Message {
+	msg := newMessage(constants.MsgTypeQuoteAcknowledgement)
+	msg.Body.SetString(constants.TagQuoteReqID, quoteReqID)
+	msg.Body.SetString(constants.TagSymbol, symbol)
+	msg.Body.SetString(constants.TagQuoteAckStatus, constants.QuoteAckStatusRejected)
+	msg.Body.SetString(constants.TagQuoteRejectReason, reason)
+	msg.Body.SetString(constants.TagText, text)
+	return msg
+}
+
+// bestOpposite returns the level a side trades against: the best offer for a
+// buy, the best bid for a sell.
+func bestOpposite(book Book, side string) (Level, bool) {
+	levels := book.Offers
+	if side == constants.SideSell {
+		levels = book.Bids
+	}
+	if len(levels) == 0 {
+		return Level{}, false
+	}
+	return levels[0], true
+}
+
+// crosses reports whether a limit price on side reaches the opposite price.
+func crosses(side, limit, opposite string) bool {
+	l, err1 := strconv.ParseFloat(limit, 64)
+	o, err2 := strconv.ParseFloat(opposite, 64)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	if side == constants.SideSell {
+		return l <= o
+	}
+	return l >= o
+}
+
+// baseQty converts a quote-currency quantity into base units at price.
+func baseQty(cashQty, price string) string {
+	c, err1 := strconv.ParseFloat(cashQty, 64)
+	p, err2 := strconv.ParseFloat(price, 64)
+	if err1 != nil || err2 != nil || p == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(c/p, 'f', -1, 64)
+}
+
+func leavesQty(o *order) string {
+	if o.isTerminal() {
+		return "0"
+	}
+	qty, _ := strconv.ParseFloat(o.orderQty, 64)
+	cum, _ := strconv.ParseFloat(o.cumQty, 64)
+	return strconv.FormatFloat(qty-cum, 'f', -1, 64)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,232 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mockprime is a local stand-in for the Coinbase Prime FIX gateway,
+// used to run full-session tests of the client on localhost.
+//
+// The mock accepts plain TCP FIXT.1.1 sessions and:
+//   - verifies the Logon signature (Tag 96) the way Prime does, rejecting the
+//     logon on a bad access key, passphrase or HMAC
+//   - answers MarketDataRequests (V) from scripted books: a Snapshot (W) per
+//     symbol, then for subscriptions one Incremental (X) per scripted update
+//   - answers NewOrderSingle (D), OrderCancelRequest (F), OrderCancelReplace
+//     (G), OrderStatusRequest (H) and OrderMassStatusRequest (AF) with
+//     ExecutionReports (8) or OrderCancelRejects (9)
+//   - answers OrderMassCancelRequests (q) with an OrderMassCancelReport (r)
+//     followed by an ExecutionReport per cancelled order
+//   - answers QuoteRequests (R) with one-sided Quotes (S) that can be
+//     accepted with a previously quoted NewOrderSingle
+//
+// There is no matching engine. Market orders, marketable limit orders and
+// accepted quotes fill in full at the top of the scripted book; everything
+// else rests until cancelled.
+package mockprime
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"prime-fix-md-go/constants"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/config"
+)
+
+const (
+	defaultSenderCompId   = "COIN"
+	defaultUpdateInterval = 10 * time.Millisecond
+	defaultQuoteTTL       = 5 * time.Second
+)
+
+// Credentials are the API credentials the mock accepts at logon.
+type Credentials struct {
+	AccessKey  string // Tag 9407
+	Passphrase string // Tag 554
+	SigningKey string // HMAC secret for Tag 96
+}
+
+// Level is a price level or trade in a scripted book.
+type Level struct {
+	Price string
+	Size  string
+}
+
+// Update is one scripted incremental refresh entry.
+type Update struct {
+	Action    string // MdUpdateAction (279)
+	EntryType string // MdEntryType (269)
+	Price     string
+	Size      string
+	Position  int // MdEntryPositionNo (290), 1-based; 0 omits the tag
+}
+
+// Book is the scripted market for one symbol. Bids and Offers are best first.
+type Book struct {
+	Bids    []Level
+	Offers  []Level
+	Trades  []Level
+	Updates []Update // Streamed to subscribers one per UpdateInterval
+}
+
+// Config configures a mock Prime acceptor.
+type Config struct {
+	Port           int    // 0 picks a free local port
+	SenderCompID   string // Mock's CompID (default COIN)
+	TargetCompID   string // Client's SenderCompID (required)
+	Credentials    Credentials
+	Books          map[string]Book // Keyed by symbol, e.g. BTC-USD
+	UpdateInterval time.Duration   // Gap between streamed incrementals (default 10ms)
+	QuoteTTL       time.Duration   // Quote ValidUntilTime offset (default 5s)
+
+	// RejectMassCancel answers every Order Mass Cancel Request (q) with
+	// MassCancelResponse=0 so clients can exercise their fallback.
+	RejectMassCancel bool
+}
+
+// Server is a running mock Prime acceptor.
+type Server struct {
+	cfg      Config
+	acceptor *quickfix.Acceptor
+	app      *application
+}
+
+// Start starts an acceptor listening on 127.0.0.1:cfg.Port.
+func Start(cfg Config) (*Server, error) {
+	if cfg.TargetCompID == "" {
+		return nil, fmt.Errorf("mockprime: TargetCompID is required")
+	}
+	if cfg.SenderCompID == "" {
+		cfg.SenderCompID = defaultSenderCompId
+	}
+	if cfg.UpdateInterval <= 0 {
+		cfg.UpdateInterval = defaultUpdateInterval
+	}
+	if cfg.QuoteTTL <= 0 {
+		cfg.QuoteTTL = defaultQuoteTTL
+	}
+	if cfg.Port == 0 {
+		port, err := freePort()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Port = port
+	}
+
+	app := newApplication(cfg)
+	acceptor, err := quickfix.NewAcceptor(app, quickfix.NewMemoryStoreFactory(), cfg.acceptorSettings(), quickfix.NewNullLogFactory())
+	if err != nil {
+		return nil, fmt.Errorf("mockprime: failed to create acceptor: %v", err)
+	}
+	if err := acceptor.Start(); err != nil {
+		return nil, fmt.Errorf("mockprime: failed to start acceptor: %v", err)
+	}
+	return &Server{cfg: cfg, acceptor: acceptor, app: app}, nil
+}
+
+// Stop ends all market data streams and stops the acceptor.
+func (s *Server) Stop() {
+	s.app.stopStreams()
+	s.acceptor.Stop()
+}
+
+// Port returns the port the acceptor listens on.
+func (s *Server) Port() int {
+	return s.cfg.Port
+}
+
+// InitiatorSettings returns client settings that connect to this server
+// without TLS, with an in-memory message store and ResetOnLogon=Y.
+func (s *Server) InitiatorSettings() *quickfix.Settings {
+	settings := quickfix.NewSettings()
+
+	global := settings.GlobalSettings()
+	global.Set(config.SocketConnectHost, "127.0.0.1")
+	global.Set(config.SocketConnectPort, strconv.Itoa(s.cfg.Port))
+	global.Set(config.StartTime, "00:00:00")
+	global.Set(config.EndTime, "00:00:00")
+	global.Set(config.HeartBtInt, constants.HeartBtInterval)
+	global.Set(config.ReconnectInterval, "1")
+	global.Set(config.ResetOnLogon, "Y")
+	global.Set(constants.SettingMessageStore, constants.MessageStoreTypeMemory)
+
+	session := quickfix.NewSessionSettings()
+	session.Set(config.BeginString, constants.FixBeginString)
+	session.Set(config.DefaultApplVerID, "9")
+	session.Set(config.SenderCompID, s.cfg.TargetCompID)
+	session.Set(config.TargetCompID, s.cfg.SenderCompID)
+	// The session ID is built from the settings above, so this cannot fail
+	_, _ = settings.AddSession(session)
+	return settings
+}
+
+// Logons returns the number of accepted logons.
+func (s *Server) Logons() int {
+	s.app.mu.Lock()
+	defer s.app.mu.Unlock()
+	return s.app.logons
+}
+
+// LogonFailures returns the number of logons rejected for bad credentials.
+func (s *Server) LogonFailures() int {
+	s.app.mu.Lock()
+	defer s.app.mu.Unlock()
+	return s.app.logonFailures
+}
+
+// Received returns copies of the application messages of msgType received so
+// far, oldest first.
+func (s *Server) Received(msgType string) []*quickfix.Message {
+	s.app.mu.Lock()
+	defer s.app.mu.Unlock()
+	var msgs []*quickfix.Message
+	for _, msg := range s.app.received {
+		if t, _ := msg.Header.GetString(constants.TagMsgType); t == msgType {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+func (cfg Config) acceptorSettings() *quickfix.Settings {
+	settings := quickfix.NewSettings()
+
+	global := settings.GlobalSettings()
+	global.Set(config.SocketAcceptHost, "127.0.0.1")
+	global.Set(config.SocketAcceptPort, strconv.Itoa(cfg.Port))
+	global.Set(config.StartTime, "00:00:00")
+	global.Set(config.EndTime, "00:00:00")
+	global.Set(config.HeartBtInt, constants.HeartBtInterval)
+	global.Set(config.ResetOnLogon, "Y")
+
+	session := quickfix.NewSessionSettings()
+	session.Set(config.BeginString, constants.FixBeginString)
+	session.Set(config.DefaultApplVerID, "9")
+	session.Set(config.SenderCompID, cfg.SenderCompID)
+	session.Set(config.TargetCompID, cfg.TargetCompID)
+	_, _ = settings.AddSession(session)
+	return settings
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("mockprime: failed to find free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
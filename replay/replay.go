@@ -0,0 +1,214 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package replay feeds a recorded FIX journal back through a quickfix
+// Application without a network connection.
+//
+// Incoming messages are delivered the way the quickfix session would deliver
+// them: admin messages to FromAdmin, everything else to FromApp. Outgoing
+// messages are skipped; the app sees only what the counterparty sent. All
+// downstream processing (parser, TradeStore, OrderBook, OrderStore, DbWriter,
+// display) therefore runs exactly as it did live.
+//
+// Timing:
+//
+//	speed = 0   deliver as fast as possible
+//	speed = 1   original inter-message gaps
+//	speed = 10  ten times faster than recorded
+//
+// Message due times are computed from the first message, so slow processing
+// of one message does not push every later message back.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"prime-fix-md-go/constants"
+	"prime-fix-md-go/formatter"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// maxLineSize bounds a single journal line; large snapshots can exceed the
+// bufio.Scanner default of 64KB.
+const maxLineSize = 16 * 1024 * 1024
+
+// sqliteHeader starts every SQLite database file.
+const sqliteHeader = "SQLite format 3\x00"
+
+// adminMsgTypes are session-level messages quickfix routes to FromAdmin.
+var adminMsgTypes = map[string]bool{
+	constants.MsgTypeHeartbeat:     true,
+	constants.MsgTypeTestRequest:   true,
+	constants.MsgTypeResendRequest: true,
+	constants.MsgTypeReject:        true,
+	constants.MsgTypeSequenceReset: true,
+	constants.MsgTypeLogout:        true,
+	constants.MsgTypeLogon:         true,
+}
+
+// Stats summarizes a replay.
+type Stats struct {
+	Delivered int // Incoming messages passed to the app
+	Skipped   int // Outgoing messages
+	Malformed int // Lines or messages that could not be parsed
+	Rejected  int // Messages the app rejected (non-nil MessageRejectError)
+}
+
+// Replayer delivers journal entries to App.
+type Replayer struct {
+	App   quickfix.Application
+	Speed float64 // 0 = as fast as possible, 1 = original timing, >1 = accelerated
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New returns a Replayer for app at the given speed.
+func New(app quickfix.Application, speed float64) *Replayer {
+	return &Replayer{
+		App:   app,
+		Speed: speed,
+		now:   time.Now,
+		sleep: sleepContext,
+	}
+}
+
+// RunFile replays the journal at path. See Run.
+func (r *Replayer) RunFile(ctx context.Context, path string) (Stats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to open journal: %v", err)
+	}
+	defer f.Close()
+
+	// A SQLite journal (Journal=sqlite) is a database, not journal lines
+	header := make([]byte, len(sqliteHeader))
+	if n, _ := io.ReadFull(f, header); n == len(header) && string(header) == sqliteHeader {
+		return Stats{}, fmt.Errorf("%s is a SQLite database; only file journals (Journal=file) can be replayed", path)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return Stats{}, fmt.Errorf("failed to read journal: %v", err)
+	}
+	return r.Run(ctx, f)
+}
+
+// Run replays every line of a FileJournal stream. Malformed lines are logged
+// and counted, not fatal. It returns early with ctx.Err() if ctx is cancelled.
+func (r *Replayer) Run(ctx context.Context, journal io.Reader) (Stats, error) {
+	if r.Speed < 0 {
+		return Stats{}, fmt.Errorf("invalid replay speed %v", r.Speed)
+	}
+
+	var stats Stats
+	var firstRecorded, start time.Time
+
+	scanner := bufio.NewScanner(journal)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		entry, err := formatter.ParseJournalLine(line)
+		if err != nil {
+			log.Printf("Replay: skipping line %d: %v", lineNum, err)
+			stats.Malformed++
+			continue
+		}
+		if entry.Direction != formatter.DirectionIncoming {
+			stats.Skipped++
+			continue
+		}
+
+		if r.Speed > 0 {
+			if firstRecorded.IsZero() {
+				firstRecorded, start = entry.Timestamp, r.now()
+			}
+			offset := time.Duration(float64(entry.Timestamp.Sub(firstRecorded)) / r.Speed)
+			if wait := start.Add(offset).Sub(r.now()); wait > 0 {
+				if err := r.sleep(ctx, wait); err != nil {
+					return stats, err
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		msg := quickfix.NewMessage()
+		if err := quickfix.ParseMessage(msg, bytes.NewBufferString(entry.Message)); err != nil {
+			log.Printf("Replay: skipping line %d: %v", lineNum, err)
+			stats.Malformed++
+			continue
+		}
+
+		if r.deliver(msg, parseSessionId(entry.SessionId)) != nil {
+			stats.Rejected++
+		}
+		stats.Delivered++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("failed to read journal: %v", err)
+	}
+	return stats, nil
+}
+
+func (r *Replayer) deliver(msg *quickfix.Message, sessionId quickfix.SessionID) quickfix.MessageRejectError {
+	msgType, _ := msg.Header.GetString(constants.TagMsgType)
+	if adminMsgTypes[msgType] {
+		return r.App.FromAdmin(msg, sessionId)
+	}
+	return r.App.FromApp(msg, sessionId)
+}
+
+// parseSessionId reverses quickfix.SessionID.String() for the common
+// "BeginString:SenderCompID->TargetCompID" form. Unrecognised values yield a
+// zero SessionID.
+func parseSessionId(s string) quickfix.SessionID {
+	beginString, rest, ok := strings.Cut(s, ":")
+	if !ok {
+		return quickfix.SessionID{}
+	}
+	sender, target, ok := strings.Cut(rest, "->")
+	if !ok {
+		return quickfix.SessionID{}
+	}
+	return quickfix.SessionID{BeginString: beginString, SenderCompID: sender, TargetCompID: target}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
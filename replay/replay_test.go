@@ -0,0 +1,214 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"prime-fix-md-go/fixclient"
+	"prime-fix-md-go/formatter"
+)
+
+// Tests for journal replay.
+// These tests record messages with formatter.FileJournal, replay them into a
+// real FixApp and check that the stores end up as they would have live.
+
+const testSession = "FIXT.1.1:CLIENT->COIN"
+
+// fixMessage builds a raw FIX message with a correct BodyLength and CheckSum
+// from the body fields (MsgType first).
+func fixMessage(fields ...string) string {
+	body := strings.Join(fields, "\x01") + "\x01"
+	msg := "8=FIXT.1.1\x019=" + strconv.Itoa(len(body)) + "\x01" + body
+	sum := 0
+	for i := 0; i < len(msg); i++ {
+		sum += int(msg[i])
+	}
+	return msg + fmt.Sprintf("10=%03d\x01", sum%256)
+}
+
+type journalLine struct {
+	offset    time.Duration
+	direction string
+	message   string
+}
+
+// writeJournal records lines with formatter.FileJournal and returns its path.
+func writeJournal(t *testing.T, lines []journalLine) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.log")
+	journal, err := formatter.NewFileJournal(path, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create journal: %v", err)
+	}
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, l := range lines {
+		if err := journal.Record(base.Add(l.offset), l.direction, testSession, l.message); err != nil {
+			t.Fatalf("failed to record: %v", err)
+		}
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+	return path
+}
+
+func sessionHeader(seq int) []string {
+	return []string{"49=COIN", "56=CLIENT", "34=" + strconv.Itoa(seq), "52=20250101-12:00:00.000"}
+}
+
+func withHeader(msgType string, seq int, body ...string) string {
+	fields := append([]string{"35=" + msgType}, sessionHeader(seq)...)
+	return fixMessage(append(fields, body...)...)
+}
+
+// TestReplay_DeliversIncomingMessages verifies that market data and execution
+// reports reach the stores, that outgoing messages are skipped and that admin
+// messages are routed to FromAdmin without error.
+func TestReplay_DeliversIncomingMessages(t *testing.T) {
+	path := writeJournal(t, []journalLine{
+		{0, formatter.DirectionOutgoing, withHeader("V", 2, "262=req-1", "263=1", "264=0")},
+		{0, formatter.DirectionIncoming, withHeader("A", 1, "98=0", "108=30")},
+		{time.Millisecond, formatter.DirectionIncoming, withHeader("W", 2, "262=req-1", "55=BTC-USD", "268=2",
+			"269=0", "270=50000.00", "271=1.5", "290=1",
+			"269=1", "270=50001.00", "271=2.0", "290=1")},
+		{2 * time.Millisecond, formatter.DirectionIncoming, withHeader("X", 3, "262=req-1", "268=1",
+			"279=1", "269=0", "55=BTC-USD", "270=50000.00", "271=3.0", "290=1")},
+		{3 * time.Millisecond, formatter.DirectionIncoming, withHeader("8", 4, "11=ord-1", "37=exch-1", "17=exec-1",
+			"39=0", "150=0", "55=BTC-USD", "54=1", "40=2", "38=1", "14=0", "151=1")},
+	})
+
+	app := fixclient.NewFixApp(&fixclient.Config{}, nil)
+	app.Headless = true
+
+	stats, err := New(app, 0).RunFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Delivered != 4 || stats.Skipped != 1 || stats.Malformed != 0 || stats.Rejected != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	bid, offer, hasBid, hasOffer := app.OrderBook.BestBidOffer("BTC-USD")
+	if !hasBid || !hasOffer {
+		t.Fatal("expected both sides of the book after replay")
+	}
+	if bid.Price != "50000.00" || bid.Size != "3.0" {
+		t.Errorf("expected incremental to update best bid to 3.0@50000.00, got %s@%s", bid.Size, bid.Price)
+	}
+	if offer.Price != "50001.00" {
+		t.Errorf("expected best offer 50001.00, got %s", offer.Price)
+	}
+
+	if trades := app.TradeStore.GetRecentTrades("BTC-USD", 10); len(trades) != 3 {
+		t.Errorf("expected 3 entries in the trade store, got %d", len(trades))
+	}
+	if order := app.OrderStore.GetOrder("ord-1"); order == nil || order.OrderID != "exch-1" {
+		t.Errorf("expected order ord-1 from execution report, got %+v", order)
+	}
+}
+
+// TestReplay_MalformedLines verifies that bad lines are counted and skipped
+// without aborting the replay.
+func TestReplay_MalformedLines(t *testing.T) {
+	journal := "not a journal line\n" +
+		"2025-01-01T12:00:00.000000Z IN " + testSession + " 8=FIXT.1.1|9=99|35=W|10=000|\n" +
+		"\n"
+	app := fixclient.NewFixApp(&fixclient.Config{}, nil)
+	app.Headless = true
+
+	stats, err := New(app, 0).Run(context.Background(), strings.NewReader(journal))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Malformed != 2 || stats.Delivered != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestReplay_Timing verifies that gaps are scaled by speed and measured from
+// the first message rather than accumulated.
+func TestReplay_Timing(t *testing.T) {
+	path := writeJournal(t, []journalLine{
+		{0, formatter.DirectionIncoming, withHeader("0", 1)},
+		{time.Second, formatter.DirectionIncoming, withHeader("0", 2)},
+		{3 * time.Second, formatter.DirectionIncoming, withHeader("0", 3)},
+	})
+
+	clock := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+
+	r := New(fixclient.NewFixApp(&fixclient.Config{}, nil), 2)
+	r.now = func() time.Time { return clock }
+	r.sleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		clock = clock.Add(d + 100*time.Millisecond) // processing overruns each sleep
+		return nil
+	}
+
+	if _, err := r.RunFile(context.Background(), path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []time.Duration{500 * time.Millisecond, 900 * time.Millisecond}
+	if len(sleeps) != len(want) || sleeps[0] != want[0] || sleeps[1] != want[1] {
+		t.Errorf("expected sleeps %v, got %v", want, sleeps)
+	}
+}
+
+// TestReplay_Cancel verifies that cancelling the context stops a timed replay.
+func TestReplay_Cancel(t *testing.T) {
+	path := writeJournal(t, []journalLine{
+		{0, formatter.DirectionIncoming, withHeader("0", 1)},
+		{time.Hour, formatter.DirectionIncoming, withHeader("0", 2)},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	stats, err := New(fixclient.NewFixApp(&fixclient.Config{}, nil), 1).RunFile(ctx, path)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if stats.Delivered != 1 {
+		t.Errorf("expected 1 message before cancellation, got %d", stats.Delivered)
+	}
+}
+
+// TestReplay_RejectsSqliteJournal verifies that a SQLite journal database is
+// reported as such instead of being read as malformed lines.
+func TestReplay_RejectsSqliteJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.db")
+	if err := os.WriteFile(path, []byte(sqliteHeader+"\x10\x00\x02\x02"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	stats, err := New(fixclient.NewFixApp(&fixclient.Config{}, nil), 0).RunFile(context.Background(), path)
+	if err == nil || !strings.Contains(err.Error(), "SQLite") {
+		t.Errorf("expected a SQLite journal error, got %v", err)
+	}
+	if stats != (Stats{}) {
+		t.Errorf("expected nothing replayed, got %+v", stats)
+	}
+}